@@ -0,0 +1,557 @@
+// Package component decodes and encodes the data file of the interface
+// archive, which holds the components of every interface:
+//
+//	G2 count, one more than the highest component id
+//	each component, prefixed by 0xFFFF and its interface's id when
+//	    that differs from the previous component's
+//
+// An interface is a tree of components rooted at a layer whose id is
+// the interface's. A layer lists its children with their positions;
+// every component records the root of the interface it belongs to.
+package component
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// DataName is the name of the data file in the interface archive.
+const DataName = "data"
+
+// Component types.
+const (
+	TypeLayer   = 0
+	TypeUnused  = 1
+	TypeInv     = 2
+	TypeRect    = 3
+	TypeText    = 4
+	TypeGraphic = 5
+	TypeModel   = 6
+	TypeInvText = 7
+)
+
+// Button types, what clicking a component does.
+const (
+	ButtonNone     = 0
+	ButtonOk       = 1
+	ButtonTarget   = 2 // selects a spell or similar to use on something
+	ButtonClose    = 3
+	ButtonToggle   = 4 // flips a varp between 0 and 1
+	ButtonSelect   = 5 // sets a varp to a value
+	ButtonContinue = 6
+)
+
+// Fonts of text components, from the title archive.
+const (
+	FontPlain11 = 0
+	FontPlain12 = 1
+	FontBold12  = 2
+	FontQuill8  = 3
+
+	fontCount = 4
+)
+
+// InvSlots is the number of inventory slots that may have a
+// background sprite.
+const InvSlots = 20
+
+// A Child is a component of a layer and its position in the layer.
+type Child struct {
+	ID int
+	X  int16
+	Y  int16
+}
+
+// An InvSlot is the background of an inventory slot.
+type InvSlot struct {
+	X      int16
+	Y      int16
+	Sprite string // "name,index" in the media archive, or empty
+}
+
+// A Comparator compares the result of the script with the same index
+// against Operand. If any comparison fails the component is shown
+// inactive.
+type Comparator struct {
+	Op      uint8
+	Operand uint16
+}
+
+// A Component is one component of an interface. Fields only apply to
+// the types noted.
+type Component struct {
+	ID         int
+	Layer      int // id of the interface's root, -1 for none
+	Type       uint8
+	ButtonType uint8
+	ClientCode int
+	Width      int
+	Height     int
+	// OverLayer is the component shown while the mouse is over this
+	// one, -1 for none.
+	OverLayer   int
+	Comparators []Comparator
+	Scripts     [][]uint16 // see [ParseScript]
+
+	// Layer.
+	Scroll   int // height of the scrollable area, 0 if not scrollable
+	Hide     bool
+	Children []Child
+
+	// Unused. Read by the client but never drawn.
+	Unused1 int
+	Unused2 bool
+
+	// Inv and InvText.
+	Draggable    bool
+	Interactable bool
+	Usable       bool
+	MarginX      int
+	MarginY      int
+	Slots        [InvSlots]*InvSlot // nil for no background; Inv only
+	IOps         [5]string
+
+	// Rect.
+	Fill bool
+
+	// Text, InvText and Unused.
+	Center   bool
+	Font     uint8
+	Shadowed bool
+
+	// Text.
+	Text       string
+	ActiveText string
+
+	// Colours as 24-bit RGB. Colour applies to Unused, Rect, Text and
+	// InvText, the others to Rect and Text.
+	Colour       uint32
+	ActiveColour uint32
+	OverColour   uint32
+
+	// Graphic.
+	Graphic       string // "name,index" in the media archive, or empty
+	ActiveGraphic string
+
+	// Model.
+	Model       int // -1 for none
+	ActiveModel int
+	Anim        int
+	ActiveAnim  int
+	Zoom        int
+	Xan         int
+	Yan         int
+
+	// Target buttons and Inv.
+	ActionVerb   string
+	Action       string
+	ActionTarget uint16 // what the action may be used on, as flags
+
+	// Ok, Toggle, Select and Continue buttons. The client shows a
+	// default for the button type if empty.
+	Option string
+}
+
+// New returns a component of type typ with the client's defaults.
+func New(id int, layer int, typ uint8) *Component {
+	return &Component{
+		ID:          id,
+		Layer:       layer,
+		Type:        typ,
+		OverLayer:   -1,
+		Model:       -1,
+		ActiveModel: -1,
+		Anim:        -1,
+		ActiveAnim:  -1,
+	}
+}
+
+// A Set holds the components of every interface.
+type Set struct {
+	// Count is the number of component ids. It is raised on encoding
+	// to cover every component.
+	Count      int
+	components []*Component // in the order they are stored
+	byID       map[int]*Component
+}
+
+// NewSet returns an empty set.
+func NewSet() *Set {
+	return &Set{byID: make(map[int]*Component)}
+}
+
+// Read decodes the data file of the interface archive.
+func Read(jf *io.Jagfile) (*Set, error) {
+	p, err := jf.Read(DataName)
+	if err != nil {
+		return nil, fmt.Errorf("component: %w", err)
+	}
+	return Decode(p.Buf)
+}
+
+// Write queues s to be written to the interface archive.
+func Write(jf *io.Jagfile, s *Set) {
+	jf.Write(DataName, packet.NewPacket(s.Encode()))
+}
+
+// Add adds c, replacing any component with its id. New components are
+// stored after the others.
+func (s *Set) Add(c *Component) {
+	if old, ok := s.byID[c.ID]; ok {
+		s.components[slices.Index(s.components, old)] = c
+	} else {
+		s.components = append(s.components, c)
+	}
+	s.byID[c.ID] = c
+}
+
+// Get returns the component id.
+func (s *Set) Get(id int) (*Component, bool) {
+	c, ok := s.byID[id]
+	return c, ok
+}
+
+// Components returns every component in the order they are stored.
+func (s *Set) Components() []*Component {
+	return s.components
+}
+
+// Interfaces returns the root of every interface, the layers that are
+// their own interface.
+func (s *Set) Interfaces() []*Component {
+	var roots []*Component
+	for _, c := range s.components {
+		if c.Layer == c.ID {
+			roots = append(roots, c)
+		}
+	}
+	return roots
+}
+
+// Children returns the components listed by the layer c, skipping any
+// that are missing.
+func (s *Set) Children(c *Component) []*Component {
+	var children []*Component
+	for _, child := range c.Children {
+		if cc, ok := s.byID[child.ID]; ok {
+			children = append(children, cc)
+		}
+	}
+	return children
+}
+
+// Parent returns the layer listing c as a child.
+func (s *Set) Parent(c *Component) (*Component, bool) {
+	for _, l := range s.components {
+		if l.Type == TypeLayer && slices.ContainsFunc(l.Children, func(child Child) bool { return child.ID == c.ID }) {
+			return l, true
+		}
+	}
+	return nil, false
+}
+
+// Decode decodes the data file of the interface archive.
+func Decode(src []byte) (*Set, error) {
+	s, err := decodeSet(packet.NewPacket(src))
+	if err != nil {
+		return nil, fmt.Errorf("component: %w", err)
+	}
+	return s, nil
+}
+
+func decodeSet(p *packet.Packet) (s *Set, err error) {
+	defer packet.Recover(&err)
+
+	s = NewSet()
+	s.Count = int(p.G2())
+	layer := -1
+	for p.Len() > 0 {
+		id := int(p.G2())
+		if id == 0xFFFF {
+			layer = int(p.G2())
+			id = int(p.G2())
+		}
+		if _, ok := s.byID[id]; ok {
+			return nil, fmt.Errorf("%d appears twice", id)
+		}
+		c := New(id, layer, 0)
+		if err := c.decode(p); err != nil {
+			return nil, fmt.Errorf("%d: %w", id, err)
+		}
+		s.Add(c)
+	}
+	return s, nil
+}
+
+var errUnknownType = errors.New("unknown type")
+
+func (c *Component) decode(p *packet.Packet) error {
+	c.Type = p.G1()
+	c.ButtonType = p.G1()
+	c.ClientCode = int(p.G2())
+	c.Width = int(p.G2())
+	c.Height = int(p.G2())
+	c.OverLayer = gRef(p)
+
+	if n := p.G1(); n > 0 {
+		c.Comparators = make([]Comparator, n)
+		for i := range c.Comparators {
+			c.Comparators[i] = Comparator{Op: p.G1(), Operand: p.G2()}
+		}
+	}
+	if n := p.G1(); n > 0 {
+		c.Scripts = make([][]uint16, n)
+		for i := range c.Scripts {
+			c.Scripts[i] = make([]uint16, p.G2())
+			for j := range c.Scripts[i] {
+				c.Scripts[i][j] = p.G2()
+			}
+		}
+	}
+
+	switch c.Type {
+	case TypeLayer:
+		c.Scroll = int(p.G2())
+		c.Hide = p.GBool()
+		if n := p.G1(); n > 0 {
+			c.Children = make([]Child, n)
+			for i := range c.Children {
+				c.Children[i] = Child{ID: int(p.G2()), X: p.G2S(), Y: p.G2S()}
+			}
+		}
+	case TypeUnused:
+		c.Unused1 = int(p.G2())
+		c.Unused2 = p.GBool()
+	case TypeInv:
+		c.Draggable = p.GBool()
+		c.Interactable = p.GBool()
+		c.Usable = p.GBool()
+		c.MarginX = int(p.G1())
+		c.MarginY = int(p.G1())
+		for i := range c.Slots {
+			if p.GBool() {
+				c.Slots[i] = &InvSlot{X: p.G2S(), Y: p.G2S(), Sprite: p.GJStrLF()}
+			}
+		}
+		for i := range c.IOps {
+			c.IOps[i] = p.GJStrLF()
+		}
+	case TypeRect:
+		c.Fill = p.GBool()
+	case TypeText, TypeGraphic, TypeModel, TypeInvText:
+	default:
+		return errUnknownType
+	}
+
+	if c.Type == TypeText || c.Type == TypeUnused {
+		c.Center = p.GBool()
+		c.Font = p.G1()
+		c.Shadowed = p.GBool()
+	}
+	if c.Type == TypeText {
+		c.Text = p.GJStrLF()
+		c.ActiveText = p.GJStrLF()
+	}
+	if c.Type == TypeUnused || c.Type == TypeRect || c.Type == TypeText {
+		c.Colour = p.G4()
+	}
+	if c.Type == TypeRect || c.Type == TypeText {
+		c.ActiveColour = p.G4()
+		c.OverColour = p.G4()
+	}
+	if c.Type == TypeGraphic {
+		c.Graphic = p.GJStrLF()
+		c.ActiveGraphic = p.GJStrLF()
+	}
+	if c.Type == TypeModel {
+		c.Model = gRef(p)
+		c.ActiveModel = gRef(p)
+		c.Anim = gRef(p)
+		c.ActiveAnim = gRef(p)
+		c.Zoom = int(p.G2())
+		c.Xan = int(p.G2())
+		c.Yan = int(p.G2())
+	}
+	if c.Type == TypeInvText {
+		c.Center = p.GBool()
+		c.Font = p.G1()
+		c.Shadowed = p.GBool()
+		c.Colour = p.G4()
+		c.MarginX = int(p.G2S())
+		c.MarginY = int(p.G2S())
+		c.Interactable = p.GBool()
+		for i := range c.IOps {
+			c.IOps[i] = p.GJStrLF()
+		}
+	}
+	if c.ButtonType == ButtonTarget || c.Type == TypeInv {
+		c.ActionVerb = p.GJStrLF()
+		c.Action = p.GJStrLF()
+		c.ActionTarget = p.G2()
+	}
+	if c.hasOption() {
+		c.Option = p.GJStrLF()
+	}
+	return nil
+}
+
+func (c *Component) hasOption() bool {
+	switch c.ButtonType {
+	case ButtonOk, ButtonToggle, ButtonSelect, ButtonContinue:
+		return true
+	}
+	return false
+}
+
+// Encode encodes the data file of the interface archive.
+func (s *Set) Encode() []byte {
+	p := packet.NewPacket(nil)
+	count := s.Count
+	for _, c := range s.components {
+		count = max(count, c.ID+1)
+	}
+	p.P2(uint16(count))
+
+	layer := -1
+	for _, c := range s.components {
+		if c.Layer != layer {
+			layer = c.Layer
+			p.P2(0xFFFF)
+			p.P2(uint16(layer))
+		}
+		p.P2(uint16(c.ID))
+		c.encode(p)
+	}
+	return p.Buf
+}
+
+func (c *Component) encode(p *packet.Packet) {
+	p.P1(c.Type)
+	p.P1(c.ButtonType)
+	p.P2(uint16(c.ClientCode))
+	p.P2(uint16(c.Width))
+	p.P2(uint16(c.Height))
+	pRef(p, c.OverLayer)
+
+	p.P1(uint8(len(c.Comparators)))
+	for _, cmp := range c.Comparators {
+		p.P1(cmp.Op)
+		p.P2(cmp.Operand)
+	}
+	p.P1(uint8(len(c.Scripts)))
+	for _, script := range c.Scripts {
+		p.P2(uint16(len(script)))
+		for _, v := range script {
+			p.P2(v)
+		}
+	}
+
+	switch c.Type {
+	case TypeLayer:
+		p.P2(uint16(c.Scroll))
+		p.PBool(c.Hide)
+		p.P1(uint8(len(c.Children)))
+		for _, child := range c.Children {
+			p.P2(uint16(child.ID))
+			p.P2(uint16(child.X))
+			p.P2(uint16(child.Y))
+		}
+	case TypeUnused:
+		p.P2(uint16(c.Unused1))
+		p.PBool(c.Unused2)
+	case TypeInv:
+		p.PBool(c.Draggable)
+		p.PBool(c.Interactable)
+		p.PBool(c.Usable)
+		p.P1(uint8(c.MarginX))
+		p.P1(uint8(c.MarginY))
+		for _, slot := range c.Slots {
+			if slot == nil {
+				p.P1(0)
+				continue
+			}
+			p.P1(1)
+			p.P2(uint16(slot.X))
+			p.P2(uint16(slot.Y))
+			p.PJStrLF(slot.Sprite)
+		}
+		for _, op := range c.IOps {
+			p.PJStrLF(op)
+		}
+	case TypeRect:
+		p.PBool(c.Fill)
+	}
+
+	if c.Type == TypeText || c.Type == TypeUnused {
+		p.PBool(c.Center)
+		p.P1(c.Font)
+		p.PBool(c.Shadowed)
+	}
+	if c.Type == TypeText {
+		p.PJStrLF(c.Text)
+		p.PJStrLF(c.ActiveText)
+	}
+	if c.Type == TypeUnused || c.Type == TypeRect || c.Type == TypeText {
+		p.P4(c.Colour)
+	}
+	if c.Type == TypeRect || c.Type == TypeText {
+		p.P4(c.ActiveColour)
+		p.P4(c.OverColour)
+	}
+	if c.Type == TypeGraphic {
+		p.PJStrLF(c.Graphic)
+		p.PJStrLF(c.ActiveGraphic)
+	}
+	if c.Type == TypeModel {
+		pRef(p, c.Model)
+		pRef(p, c.ActiveModel)
+		pRef(p, c.Anim)
+		pRef(p, c.ActiveAnim)
+		p.P2(uint16(c.Zoom))
+		p.P2(uint16(c.Xan))
+		p.P2(uint16(c.Yan))
+	}
+	if c.Type == TypeInvText {
+		p.PBool(c.Center)
+		p.P1(c.Font)
+		p.PBool(c.Shadowed)
+		p.P4(c.Colour)
+		p.P2(uint16(c.MarginX))
+		p.P2(uint16(c.MarginY))
+		p.PBool(c.Interactable)
+		for _, op := range c.IOps {
+			p.PJStrLF(op)
+		}
+	}
+	if c.ButtonType == ButtonTarget || c.Type == TypeInv {
+		p.PJStrLF(c.ActionVerb)
+		p.PJStrLF(c.Action)
+		p.P2(c.ActionTarget)
+	}
+	if c.hasOption() {
+		p.PJStrLF(c.Option)
+	}
+}
+
+// gRef gets an id stored as 0 for -1, or as its high byte plus one
+// followed by its low byte.
+func gRef(p *packet.Packet) int {
+	hi := int(p.G1())
+	if hi == 0 {
+		return -1
+	}
+	return (hi-1)<<8 | int(p.G1())
+}
+
+func pRef(p *packet.Packet, id int) {
+	if id == -1 {
+		p.P1(0)
+		return
+	}
+	p.P1(uint8(id>>8 + 1))
+	p.P1(uint8(id))
+}
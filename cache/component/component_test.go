@@ -0,0 +1,314 @@
+package component
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/internal/projectpath"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+func TestSet_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		components []*Component
+	}{
+		{"layer", []*Component{
+			{ID: 100, Layer: 100, Type: TypeLayer, Width: 512, Height: 334, OverLayer: -1,
+				Children: []Child{{101, 10, 20}, {102, -5, 0}}, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+			{ID: 101, Layer: 100, Type: TypeRect, OverLayer: -1, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+			{ID: 102, Layer: 100, Type: TypeRect, OverLayer: -1, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+			{ID: 200, Layer: 200, Type: TypeLayer, Scroll: 600, Hide: true, OverLayer: -1,
+				Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"unused", []*Component{
+			{ID: 7, Layer: 5, Type: TypeUnused, OverLayer: -1, Unused1: 3, Unused2: true,
+				Scripts: [][]uint16{{OpInvCount, 101, 995, OpVarpBit, 1, 5, OpReturn}}, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"inv", []*Component{
+			{ID: 101, Layer: 100, Type: TypeInv, Width: 4, Height: 7, OverLayer: -1,
+				Draggable: true, Interactable: true, MarginX: 10, MarginY: 4,
+				Slots: [InvSlots]*InvSlot{0: {X: 1, Y: -1, Sprite: "backbase1,0"}, 19: {}}, IOps: [5]string{"Wield", "", "", "", "Drop"},
+				ActionVerb: "Use", Action: "with", ActionTarget: 0x10, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"rect", []*Component{
+			{ID: 105, Layer: 100, Type: TypeRect, ButtonType: ButtonToggle, OverLayer: -1,
+				Comparators: []Comparator{{Op: 3, Operand: 1}}, Scripts: [][]uint16{{OpVarp, 2, OpReturn}},
+				Fill: true, Colour: 0x5A5245, ActiveColour: 0x383023,
+				Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"text", []*Component{
+			{ID: 102, Layer: 100, Type: TypeText, ButtonType: ButtonContinue, OverLayer: -1,
+				Comparators: []Comparator{{Op: 3, Operand: 10}}, Scripts: [][]uint16{{OpWeight, OpReturn}},
+				Center: true, Font: FontBold12, Shadowed: true, Text: "Weight: %1",
+				Colour: 0xFFFF00, OverColour: 0xFFFFFF, Option: "Click here to continue",
+				Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"graphic", []*Component{
+			{ID: 103, Layer: 100, Type: TypeGraphic, ButtonType: ButtonTarget, OverLayer: 104,
+				Graphic: "magicon,0", ActiveGraphic: "magicoff,0",
+				ActionVerb: "Cast", Action: "Wind Strike", ActionTarget: 2,
+				Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+			{ID: 104, Layer: 100, Type: TypeRect, OverLayer: -1, Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+		{"model", []*Component{
+			{ID: 104, Layer: 100, Type: TypeModel, OverLayer: -1,
+				Model: 300, ActiveModel: 0x1FF, Anim: 0, ActiveAnim: -1, Zoom: 800, Xan: 150, Yan: 2047},
+		}},
+		{"inv text", []*Component{
+			{ID: 106, Layer: 100, Type: TypeInvText, Width: 8, Height: 5, OverLayer: -1,
+				MarginX: -2, MarginY: 300, IOps: [5]string{"Buy"}, Font: FontPlain11, Colour: 0xFF9040,
+				Model: -1, ActiveModel: -1, Anim: -1, ActiveAnim: -1},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := NewSet()
+			for _, c := range tt.components {
+				want.Add(c)
+			}
+			data := want.Encode()
+
+			got, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if count := tt.components[len(tt.components)-1].ID + 1; got.Count != count {
+				t.Errorf("Count = %d, want %d", got.Count, count)
+			}
+			if !reflect.DeepEqual(got.Components(), tt.components) {
+				t.Errorf("Decode() = %+v, want %+v", got.Components(), tt.components)
+			}
+			if !bytes.Equal(got.Encode(), data) {
+				t.Error("Encode() differs after decoding")
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	// a rect and a text component of interface 5
+	data := []byte{
+		0x00, 0x0A, 0xFF, 0xFF, 0x00, 0x05,
+		0x00, 0x06, 3, 0, 0x00, 0x00, 0x00, 0x10, 0x00, 0x08, 0x00, 0, 0,
+		1, 0x00, 0x11, 0x22, 0x33, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x07, 4, 1, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0x03, 0, 0,
+		1, 1, 0, 'H', 'i', '\n', '\n', 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x03, 'O', 'k', '\n',
+	}
+	s, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	rect, ok := s.Get(6)
+	if !ok || rect.Layer != 5 || rect.Type != TypeRect || !rect.Fill || rect.Colour != 0x112233 || rect.Width != 16 || rect.Height != 8 {
+		t.Errorf("rect = %+v", rect)
+	}
+	text, ok := s.Get(7)
+	if !ok || text.Layer != 5 || text.ButtonType != ButtonOk || text.OverLayer != 0x103 || !text.Center || text.Font != FontPlain12 ||
+		text.Text != "Hi" || text.OverColour != 3 || text.Option != "Ok" {
+		t.Errorf("text = %+v", text)
+	}
+	if !bytes.Equal(s.Encode(), data) {
+		t.Errorf("Encode() = % x, want % x", s.Encode(), data)
+	}
+}
+
+func TestDecode_error(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"truncated", []byte{0x00, 0x01, 0x00, 0x00, 3}, "component: "},
+		{"unknown type", []byte{0x00, 0x01, 0x00, 0x00, 8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, "component: 0: unknown type"},
+		{"repeated id", repeated(), "component: 0 appears twice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Decode() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// repeated returns a data file holding component 0 twice.
+func repeated() []byte {
+	s := NewSet()
+	s.Add(New(0, -1, TypeRect))
+	data := s.Encode()
+	return append(data, data[2:]...)
+}
+
+func TestSet_tree(t *testing.T) {
+	s := NewSet()
+	s.Add(New(100, 100, TypeLayer))
+	s.Add(New(101, 100, TypeRect))
+	s.Add(New(102, 100, TypeText))
+	s.Add(New(200, 200, TypeLayer))
+	get(s, 100).Children = []Child{{ID: 101}, {ID: 102}}
+
+	var roots []int
+	for _, c := range s.Interfaces() {
+		roots = append(roots, c.ID)
+	}
+	if !reflect.DeepEqual(roots, []int{100, 200}) {
+		t.Errorf("Interfaces() = %v, want [100 200]", roots)
+	}
+
+	root := get(s, 100)
+	if n := len(s.Children(root)); n != 2 {
+		t.Errorf("Children() has %d components, want 2", n)
+	}
+	if parent, ok := s.Parent(get(s, 102)); !ok || parent != root {
+		t.Errorf("Parent(102) = %v, %v, want 100", parent, ok)
+	}
+	if _, ok := s.Parent(root); ok {
+		t.Error("Parent(100) found a parent for a root")
+	}
+
+	// replacing a component keeps its place
+	s.Add(New(102, 100, TypeRect))
+	if c := s.Components()[2]; c.ID != 102 || c.Type != TypeRect {
+		t.Errorf("replaced component = %+v", c)
+	}
+}
+
+func TestParseScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    []uint16
+		want    []Instr
+		wantErr bool
+	}{
+		{"return", []uint16{0}, []Instr{{Op: OpReturn, Operands: []uint16{}}}, false},
+		{"operands", []uint16{OpInvCount, 3214, 995, OpCombatLevel, OpReturn}, []Instr{
+			{Op: OpInvCount, Operands: []uint16{3214, 995}},
+			{Op: OpCombatLevel, Operands: []uint16{}},
+			{Op: OpReturn, Operands: []uint16{}},
+		}, false},
+		{"unknown opcode", []uint16{14, 0}, nil, true},
+		{"missing operand", []uint16{OpVarpBit, 1}, nil, true},
+		{"no return", []uint16{OpVarp, 1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseScript(tt.code)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseScript() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSet_Validate(t *testing.T) {
+	refs := Refs{Sprites: map[string]int{"backbase1": 2, "magicon": 1, "magicoff": 1}, Varps: 3}
+	tests := []struct {
+		name       string
+		components []*Component
+		want       string
+	}{
+		{"valid", []*Component{
+			{ID: 100, Layer: 100, Type: TypeLayer, OverLayer: -1, Children: []Child{{ID: 101}, {ID: 102}, {ID: 103}}},
+			{ID: 101, Layer: 100, Type: TypeInv, OverLayer: -1, Slots: [InvSlots]*InvSlot{0: {Sprite: "backbase1,1"}}},
+			{ID: 102, Layer: 100, Type: TypeText, OverLayer: 103, Font: FontQuill8,
+				Comparators: []Comparator{{Op: 3, Operand: 1}}, Scripts: [][]uint16{{OpInvCount, 101, 995, OpReturn}}},
+			{ID: 103, Layer: 100, Type: TypeGraphic, ButtonType: ButtonToggle, OverLayer: -1,
+				Graphic: "magicon,0", ActiveGraphic: "magicoff,0", Scripts: [][]uint16{{OpVarp, 2, OpReturn}}},
+		}, ""},
+		{"missing child", []*Component{
+			{ID: 200, Layer: 200, Type: TypeLayer, OverLayer: -1, Children: []Child{{ID: 300}}},
+		}, "component 200: child 300 does not exist"},
+		{"child of another interface", []*Component{
+			{ID: 100, Layer: 100, Type: TypeLayer, OverLayer: -1},
+			{ID: 101, Layer: 100, Type: TypeRect, OverLayer: -1},
+			{ID: 200, Layer: 200, Type: TypeLayer, OverLayer: -1, Children: []Child{{ID: 101}}},
+		}, "component 200: child 101 belongs to interface 100"},
+		{"missing over layer", []*Component{
+			{ID: 103, Layer: 100, Type: TypeGraphic, OverLayer: 5},
+		}, "component 103: over layer 5 does not exist"},
+		{"font", []*Component{
+			{ID: 102, Layer: 100, Type: TypeText, OverLayer: -1, Font: 4},
+		}, "component 102: font 4 does not exist"},
+		{"sprite index", []*Component{
+			{ID: 101, Layer: 100, Type: TypeInv, OverLayer: -1, Slots: [InvSlots]*InvSlot{0: {Sprite: "backbase1,2"}}},
+		}, `component 101: sprite "backbase1,2" does not exist`},
+		{"sprite name", []*Component{
+			{ID: 103, Layer: 100, Type: TypeGraphic, OverLayer: -1, ActiveGraphic: "magic,0"},
+		}, `component 103: sprite "magic,0" does not exist`},
+		{"malformed sprite", []*Component{
+			{ID: 103, Layer: 100, Type: TypeGraphic, OverLayer: -1, Graphic: "magicon"},
+		}, `component 103: malformed sprite "magicon"`},
+		{"varp", []*Component{
+			{ID: 105, Layer: 100, Type: TypeRect, OverLayer: -1, Scripts: [][]uint16{{OpVarp, 3, OpReturn}}},
+		}, "component 105: script 0: varp 3 does not exist"},
+		{"inventory", []*Component{
+			{ID: 102, Layer: 100, Type: TypeText, OverLayer: -1},
+			{ID: 107, Layer: 100, Type: TypeUnused, OverLayer: -1, Scripts: [][]uint16{{OpInvCount, 102, 995, OpReturn}}},
+		}, "component 107: script 0: inventory 102 does not exist"},
+		{"bad script", []*Component{
+			{ID: 102, Layer: 100, Type: TypeText, OverLayer: -1, Scripts: [][]uint16{{OpWeight}}},
+		}, "component 102: script 0: script does not return"},
+		{"comparators", []*Component{
+			{ID: 102, Layer: 100, Type: TypeText, OverLayer: -1, Comparators: []Comparator{{Op: 3, Operand: 10}}},
+		}, "component 102: 1 comparators for 0 scripts"},
+		{"toggle", []*Component{
+			{ID: 105, Layer: 100, Type: TypeRect, ButtonType: ButtonToggle, OverLayer: -1, Scripts: [][]uint16{{OpWeight, OpReturn}}},
+		}, "component 105: button does not read a varp in script 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSet()
+			for _, c := range tt.components {
+				s.Add(c)
+			}
+			err := s.Validate(refs)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func get(s *Set, id int) *Component {
+	c, _ := s.Get(id)
+	return c
+}
+
+// TestInterfaceArchive decodes the packed interface archive and checks
+// it encodes back to the same bytes.
+func TestInterfaceArchive(t *testing.T) {
+	path := filepath.Join(projectpath.Root, "data", "pack", "client", "interface")
+	jf, err := io.LoadJagfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := jf.Read(DataName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Read(jf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(s.Interfaces()) == 0 {
+		t.Error("Read() found no interfaces")
+	}
+	if !bytes.Equal(s.Encode(), want.Buf) {
+		t.Error("data differs after decoding and encoding")
+	}
+}
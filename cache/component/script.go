@@ -0,0 +1,73 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Script opcodes. Each pushes a value that is added to the script's
+// result, then the script continues with the next opcode.
+const (
+	OpReturn         = 0
+	OpStatLevel      = 1  // stat
+	OpStatBaseLevel  = 2  // stat
+	OpStatXP         = 3  // stat
+	OpInvCount       = 4  // inv component, obj
+	OpVarp           = 5  // varp
+	OpStatXPForLevel = 6  // stat; the xp needed for the next level
+	OpVarpScaled     = 7  // varp; the value * 100 / 46875
+	OpCombatLevel    = 8  //
+	OpTotalLevel     = 9  //
+	OpInvContains    = 10 // inv component, obj; 999999999 if held
+	OpRunEnergy      = 11 //
+	OpWeight         = 12 //
+	OpVarpBit        = 13 // varp, bit
+)
+
+// operands are the number of operands of each opcode.
+var operands = [...]int{
+	OpReturn:         0,
+	OpStatLevel:      1,
+	OpStatBaseLevel:  1,
+	OpStatXP:         1,
+	OpInvCount:       2,
+	OpVarp:           1,
+	OpStatXPForLevel: 1,
+	OpVarpScaled:     1,
+	OpCombatLevel:    0,
+	OpTotalLevel:     0,
+	OpInvContains:    2,
+	OpRunEnergy:      0,
+	OpWeight:         0,
+	OpVarpBit:        2,
+}
+
+// An Instr is one opcode of a script and its operands.
+type Instr struct {
+	Op       uint16
+	Operands []uint16
+}
+
+var errNoReturn = errors.New("script does not return")
+
+// ParseScript splits a component script into its instructions, up to
+// and including the OpReturn that ends it.
+func ParseScript(code []uint16) ([]Instr, error) {
+	var instrs []Instr
+	for pc := 0; pc < len(code); {
+		op := code[pc]
+		if int(op) >= len(operands) {
+			return nil, fmt.Errorf("unknown script opcode %d at %d", op, pc)
+		}
+		n := operands[op]
+		if pc+1+n > len(code) {
+			return nil, fmt.Errorf("script opcode %d at %d needs %d operands", op, pc, n)
+		}
+		instrs = append(instrs, Instr{Op: op, Operands: code[pc+1 : pc+1+n]})
+		if op == OpReturn {
+			return instrs, nil
+		}
+		pc += 1 + n
+	}
+	return nil, errNoReturn
+}
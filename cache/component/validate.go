@@ -0,0 +1,129 @@
+package component
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Refs are what components refer to outside the interface archive.
+type Refs struct {
+	// Sprites holds the number of images of each sprite in the media
+	// archive.
+	Sprites map[string]int
+	Varps   int // number of varps
+}
+
+// Validate checks every component refers only to things that exist:
+// children, over layers and script inventories in s, and sprites,
+// fonts and varps. It returns every problem found.
+func (s *Set) Validate(refs Refs) error {
+	var errs []error
+	for _, c := range s.components {
+		for _, err := range s.check(c, refs) {
+			errs = append(errs, fmt.Errorf("component %d: %w", c.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Set) check(c *Component, refs Refs) []error {
+	var errs []error
+
+	for _, child := range c.Children {
+		cc, ok := s.byID[child.ID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("child %d does not exist", child.ID))
+		} else if cc.Layer != c.Layer {
+			errs = append(errs, fmt.Errorf("child %d belongs to interface %d", child.ID, cc.Layer))
+		}
+	}
+	if c.OverLayer != -1 {
+		if _, ok := s.byID[c.OverLayer]; !ok {
+			errs = append(errs, fmt.Errorf("over layer %d does not exist", c.OverLayer))
+		}
+	}
+
+	switch c.Type {
+	case TypeUnused, TypeText, TypeInvText:
+		if c.Font >= fontCount {
+			errs = append(errs, fmt.Errorf("font %d does not exist", c.Font))
+		}
+	}
+
+	sprites := []string{c.Graphic, c.ActiveGraphic}
+	for _, slot := range c.Slots {
+		if slot != nil {
+			sprites = append(sprites, slot.Sprite)
+		}
+	}
+	for _, sprite := range sprites {
+		if err := checkSprite(sprite, refs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(c.Comparators) > len(c.Scripts) {
+		errs = append(errs, fmt.Errorf("%d comparators for %d scripts", len(c.Comparators), len(c.Scripts)))
+	}
+	for i, code := range c.Scripts {
+		instrs, err := ParseScript(code)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("script %d: %w", i, err))
+			continue
+		}
+		for _, in := range instrs {
+			if err := s.checkInstr(in, refs); err != nil {
+				errs = append(errs, fmt.Errorf("script %d: %w", i, err))
+			}
+		}
+	}
+
+	// toggle and select buttons set the varp their first script reads
+	if c.ButtonType == ButtonToggle || c.ButtonType == ButtonSelect {
+		if len(c.Scripts) == 0 || len(c.Scripts[0]) < 2 || c.Scripts[0][0] != OpVarp {
+			errs = append(errs, errors.New("button does not read a varp in script 0"))
+		}
+		if c.ButtonType == ButtonSelect && len(c.Comparators) == 0 {
+			errs = append(errs, errors.New("select button has no comparator"))
+		}
+	}
+	return errs
+}
+
+func (s *Set) checkInstr(in Instr, refs Refs) error {
+	switch in.Op {
+	case OpVarp, OpVarpScaled, OpVarpBit:
+		if int(in.Operands[0]) >= refs.Varps {
+			return fmt.Errorf("varp %d does not exist", in.Operands[0])
+		}
+		if in.Op == OpVarpBit && in.Operands[1] > 31 {
+			return fmt.Errorf("bit %d out of range", in.Operands[1])
+		}
+	case OpInvCount, OpInvContains:
+		inv, ok := s.byID[int(in.Operands[0])]
+		if !ok || (inv.Type != TypeInv && inv.Type != TypeInvText) {
+			return fmt.Errorf("inventory %d does not exist", in.Operands[0])
+		}
+	}
+	return nil
+}
+
+// checkSprite checks a sprite, "name,index", is in the media archive.
+// An empty sprite is none.
+func checkSprite(sprite string, refs Refs) error {
+	if sprite == "" {
+		return nil
+	}
+	comma := strings.LastIndexByte(sprite, ',')
+	name, index := sprite[:max(comma, 0)], sprite[comma+1:]
+	i, err := strconv.Atoi(index)
+	if comma == -1 || err != nil || i < 0 {
+		return fmt.Errorf("malformed sprite %q", sprite)
+	}
+	if n, ok := refs.Sprites[name]; !ok || i >= n {
+		return fmt.Errorf("sprite %q does not exist", sprite)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package config decodes and encodes the config types in the config
+// archive. Each type is stored as a pair of files:
+//
+//	<name>.dat   G2 count, then each config as opcodes ending in 0
+//	<name>.idx   G2 count, then the G2 size of each config
+//
+// Decoding records the order a config's opcodes were read in, so an
+// unmodified config encodes back to the same bytes. Values set after
+// decoding are encoded after the recorded opcodes.
+//
+// Opcodes from [FirstServerOp] hold properties only the server uses,
+// such as obj weights and npc stats. The server's copy of the config
+// archive keeps them; they are stripped when writing the client's.
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Names of the config types in the config archive.
+const (
+	FloName      = "flo"
+	IdkName      = "idk"
+	LocName      = "loc"
+	NpcName      = "npc"
+	ObjName      = "obj"
+	SeqName      = "seq"
+	SpotAnimName = "spotanim"
+	VarpName     = "varp"
+)
+
+// FirstServerOp is the first opcode of server-only properties.
+const FirstServerOp = 200
+
+var errUnknownOpcode = errors.New("unknown opcode")
+
+// opOrder records the opcodes of a decoded config.
+type opOrder struct {
+	order []uint8
+}
+
+func (o *opOrder) recorded() *opOrder { return o }
+
+// A config is one entry of a config type.
+type config interface {
+	// decodeOp gets the value of op. It may panic with a
+	// *packet.ReadError, which decode recovers.
+	decodeOp(p *packet.Packet, op uint8) error
+	encodeOp(p *packet.Packet, op uint8)
+	// ops returns the opcodes needed to encode the non-default values,
+	// in the order the original tools wrote them.
+	ops() []uint8
+	// isFlag reports whether op has no value; its presence is the value.
+	isFlag(op uint8) bool
+	recorded() *opOrder
+}
+
+// decode gets the opcodes of c up to and including the terminating 0.
+func decode(p *packet.Packet, c config) (err error) {
+	defer packet.Recover(&err)
+
+	o := c.recorded()
+	o.order = o.order[:0]
+	for {
+		op := p.G1()
+		if op == 0 {
+			return nil
+		}
+		if err := c.decodeOp(p, op); err != nil {
+			return fmt.Errorf("opcode %d: %w", op, err)
+		}
+		o.order = append(o.order, op)
+	}
+}
+
+// encode puts the opcodes of c in the order given by encodeOrder.
+func encode(p *packet.Packet, c config, server bool) {
+	for _, op := range encodeOrder(c, server) {
+		p.P1(op)
+		c.encodeOp(p, op)
+	}
+	p.P1(0)
+}
+
+// encodeOrder returns the opcodes to encode c with: those recorded when
+// it was decoded, skipping flags that have since been cleared, then any
+// others its values need. Server-only opcodes are left out unless server
+// is set.
+func encodeOrder(c config, server bool) []uint8 {
+	need := c.ops()
+	var needed, written [256]bool
+	for _, op := range need {
+		needed[op] = true
+	}
+
+	for op := FirstServerOp; op < len(written) && !server; op++ {
+		written[op] = true
+	}
+
+	var order []uint8
+	for _, op := range c.recorded().order {
+		if written[op] || (c.isFlag(op) && !needed[op]) {
+			continue
+		}
+		order = append(order, op)
+		written[op] = true
+	}
+	for _, op := range need {
+		if !written[op] {
+			order = append(order, op)
+		}
+	}
+	return order
+}
+
+// readAll decodes every config of the named type in jf.
+func readAll[T config](jf *io.Jagfile, name string, newConfig func() T) ([]T, error) {
+	count, sizes, offsets, _, err := jf.Deconstruct(name)
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", name, err)
+	}
+	dat, err := jf.Read(name + ".dat")
+	if err != nil {
+		return nil, fmt.Errorf("config: %s: %w", name, err)
+	}
+
+	configs := make([]T, count)
+	for i := range configs {
+		p := packet.NewPacket(dat.Buf[offsets[i] : offsets[i]+sizes[i]])
+		configs[i] = newConfig()
+		if err := decode(p, configs[i]); err != nil {
+			return nil, fmt.Errorf("config: %s %d: %w", name, i, err)
+		}
+		if p.Len() != 0 {
+			return nil, fmt.Errorf("config: %s %d: %d bytes after the end", name, i, p.Len())
+		}
+	}
+	return configs, nil
+}
+
+// writeAll queues the named type's .dat and .idx files, holding
+// configs, to be written to jf. Server-only opcodes are written if
+// server is set.
+func writeAll[T config](jf *io.Jagfile, name string, configs []T, server bool) {
+	dat := packet.NewPacket(nil)
+	idx := packet.NewPacket(nil)
+	dat.P2(uint16(len(configs)))
+	idx.P2(uint16(len(configs)))
+	for _, c := range configs {
+		start := len(dat.Buf)
+		encode(dat, c, server)
+		idx.P2(uint16(len(dat.Buf) - start))
+	}
+	jf.Write(name+".dat", dat)
+	jf.Write(name+".idx", idx)
+}
+
+// A Recol replaces a model colour.
+type Recol struct {
+	From uint16
+	To   uint16
+}
+
+func gRecols(p *packet.Packet) []Recol {
+	recols := make([]Recol, p.G1())
+	for i := range recols {
+		recols[i] = Recol{From: p.G2(), To: p.G2()}
+	}
+	return recols
+}
+
+func pRecols(p *packet.Packet, recols []Recol) {
+	p.P1(uint8(len(recols)))
+	for _, r := range recols {
+		p.P2(r.From)
+		p.P2(r.To)
+	}
+}
+
+func gModels(p *packet.Packet) []uint16 {
+	models := make([]uint16, p.G1())
+	for i := range models {
+		models[i] = p.G2()
+	}
+	return models
+}
+
+func pModels(p *packet.Packet, models []uint16) {
+	p.P1(uint8(len(models)))
+	for _, m := range models {
+		p.P2(m)
+	}
+}
+
+// appendIf appends op to ops if cond is true.
+func appendIf(ops []uint8, op uint8, cond bool) []uint8 {
+	if cond {
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// gID gets a G2 id where 0xFFFF means none, -1.
+func gID(p *packet.Packet) int {
+	id := int(p.G2())
+	if id == 0xFFFF {
+		return -1
+	}
+	return id
+}
+
+func pID(p *packet.Packet, id int) {
+	p.P2(uint16(id))
+}
@@ -0,0 +1,413 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/internal/projectpath"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// str is a newline terminated string as stored in configs.
+func str(s string) []byte {
+	return append([]byte(s), '\n')
+}
+
+func cat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+// configTests are a config of each type, named by its type first.
+var configTests = []struct {
+	name  string
+	new   func() config
+	data  []byte
+	check func(t *testing.T, c config)
+}{
+	{
+		name: "obj",
+		new:  func() config { return NewObjType() },
+		data: cat([]byte{1, 0x0B, 0xB8}, []byte{2}, str("Bronze sword"), []byte{3}, str("A razor sharp sword."),
+			[]byte{4, 0x04, 0xB0, 7, 0xFF, 0xFE, 11, 12, 0, 0, 0, 26, 16, 23, 0x01, 0xF4, 0xFA},
+			[]byte{33}, str("Take"), []byte{35}, str("Wield"), []byte{40, 1, 0x1F, 0xFF, 0x0A, 0x0A},
+			[]byte{97, 0, 5, 100, 0x03, 0xE5, 0, 2, 0}),
+		check: func(t *testing.T, c config) {
+			obj := c.(*ObjType)
+			want := &ObjType{
+				opOrder: obj.opOrder,
+				Model:   3000, Name: "Bronze sword", Desc: "A razor sharp sword.",
+				Zoom2D: 1200, Xof2D: -2, Stackable: true, Cost: 26, Members: true,
+				ManWear: 500, ManWearOffsetY: -6, Recol: []Recol{{From: 0x1FFF, To: 0x0A0A}},
+				ManWear2: -1, ManWear3: -1, WomanWear: -1, WomanWear2: -1, WomanWear3: -1,
+				ManHead: -1, ManHead2: -1, WomanHead: -1, WomanHead2: -1,
+				Code10: -1, CertLink: 5, CertTemplate: -1, RespawnRate: 100,
+			}
+			want.Ops[3] = "Take"
+			want.IOps[0] = "Wield"
+			want.CountObjs[0] = CountObj{Obj: 997, Count: 2}
+			if !reflect.DeepEqual(obj, want) {
+				t.Errorf("decoded %+v, want %+v", obj, want)
+			}
+		},
+	},
+	{
+		name: "loc",
+		new:  func() config { return NewLocType() },
+		data: cat([]byte{1, 2, 0x04, 0xFC, 10, 0x04, 0xFD, 22}, []byte{2}, str("Tree"),
+			[]byte{14, 2, 15, 3, 17, 19, 1, 24, 0xFF, 0xFF, 29, 0xF6}, []byte{30}, str("Chop down"),
+			[]byte{60, 0, 7, 64, 70, 0xFF, 0x80, 0}),
+		check: func(t *testing.T, c config) {
+			loc := c.(*LocType)
+			if !reflect.DeepEqual(loc.Models, []uint16{1276, 1277}) || !reflect.DeepEqual(loc.Shapes, []uint8{10, 22}) {
+				t.Errorf("models %v shapes %v", loc.Models, loc.Shapes)
+			}
+			if loc.Name != "Tree" || loc.Width != 2 || loc.Length != 3 || loc.BlockWalk || !loc.BlockRange ||
+				loc.Active != 1 || loc.Anim != -1 || loc.Ambient != -10 || loc.Ops[0] != "Chop down" ||
+				loc.MapFunction != 7 || loc.Shadow || loc.XOff != -128 {
+				t.Errorf("decoded %+v", loc)
+			}
+		},
+	},
+	{
+		name: "loc single shape",
+		new:  func() config { return NewLocType() },
+		data: []byte{5, 1, 0x00, 0x10, 24, 0x01, 0x00, 0},
+		check: func(t *testing.T, c config) {
+			loc := c.(*LocType)
+			if !reflect.DeepEqual(loc.Models, []uint16{16}) || loc.Shapes != nil || loc.Anim != 256 {
+				t.Errorf("decoded %+v", loc)
+			}
+		},
+	},
+	{
+		name: "npc",
+		new:  func() config { return NewNpcType() },
+		data: cat([]byte{1, 2, 0x00, 0x01, 0x00, 0x02}, []byte{2}, str("Man"), []byte{12, 1, 13, 0x03, 0x32},
+			[]byte{17, 0x03, 0x33, 0x03, 0x34, 0x03, 0x35, 0x03, 0x36}, []byte{32}, str("Pickpocket"),
+			[]byte{60, 1, 0x00, 0x09, 93, 95, 0x00, 0x02, 0}),
+		check: func(t *testing.T, c config) {
+			npc := c.(*NpcType)
+			if npc.Name != "Man" || npc.ReadyAnim != 818 || npc.WalkAnim != 819 || npc.WalkAnimLeft != 822 ||
+				npc.Ops[2] != "Pickpocket" || !reflect.DeepEqual(npc.Heads, []uint16{9}) || npc.Minimap || npc.VisLevel != 2 {
+				t.Errorf("decoded %+v", npc)
+			}
+		},
+	},
+	{
+		name: "npc server",
+		new:  func() config { return NewNpcType() },
+		data: cat([]byte{2}, str("Goblin"), []byte{200, 0, 1, 0, 1, 0, 1, 0, 5, 0, 1, 0, 1, 201, 3, 203, 0, 4,
+			249, 2, 0, 0, 7, 0, 0, 0, 0, 9, 0, 0, 8, 1}, str("goblin"), []byte{0}),
+		check: func(t *testing.T, c config) {
+			npc := c.(*NpcType)
+			if npc.HitPoints() != 5 || npc.Level(StatAttack) != 1 || !npc.Hunts() || npc.HuntRange != 3 || npc.RespawnRate != 100 || npc.Category != 4 {
+				t.Errorf("decoded %+v", npc)
+			}
+			if v, ok := npc.Params.Int(7); !ok || v != 9 {
+				t.Errorf("Params.Int(7) = %v, %v, want 9, true", v, ok)
+			}
+			if v, ok := npc.Params.String(8); !ok || v != "goblin" {
+				t.Errorf("Params.String(8) = %q, %v, want goblin, true", v, ok)
+			}
+		},
+	},
+	{
+		name: "seq",
+		new:  func() config { return NewSeqType() },
+		data: []byte{1, 2, 0x00, 0x0A, 0xFF, 0xFF, 0x00, 0x04, 0x00, 0x0B, 0xFF, 0xFF, 0x00, 0x00,
+			2, 0x00, 0x01, 3, 2, 5, 6, 4, 5, 10, 6, 0x00, 0x00, 8, 1, 0},
+		check: func(t *testing.T, c config) {
+			seq := c.(*SeqType)
+			want := []SeqFrame{{Frame: 10, IFrame: 0xFFFF, Delay: 4}, {Frame: 11, IFrame: 0xFFFF}}
+			if !reflect.DeepEqual(seq.Frames, want) || seq.ReplayOff != 1 || !reflect.DeepEqual(seq.WalkMerge, []uint8{5, 6}) ||
+				!seq.Stretches || seq.Priority != 10 || seq.RightHand != 0 || seq.LeftHand != -1 || seq.ReplayCount != 1 {
+				t.Errorf("decoded %+v", seq)
+			}
+		},
+	},
+	{
+		name: "idk",
+		new:  func() config { return NewIdkType() },
+		data: []byte{1, 3, 2, 1, 0x01, 0x00, 3, 40, 0x12, 0x34, 50, 0x56, 0x78, 60, 0x00, 0x2A, 0},
+		check: func(t *testing.T, c config) {
+			idk := c.(*IdkType)
+			if idk.Type != 3 || !reflect.DeepEqual(idk.Models, []uint16{256}) || !idk.Disable ||
+				idk.RecolSrc[0] != 0x1234 || idk.RecolDst[0] != 0x5678 || idk.Heads[0] != 42 || idk.Heads[1] != -1 {
+				t.Errorf("decoded %+v", idk)
+			}
+		},
+	},
+	{
+		name: "flo",
+		new:  func() config { return NewFloType() },
+		data: cat([]byte{1, 0x35, 0x6A, 0x1F, 2, 1, 3, 5, 6}, str("water"), []byte{0}),
+		check: func(t *testing.T, c config) {
+			flo := c.(*FloType)
+			if flo.RGB != 0x356A1F || flo.Texture != 1 || !flo.Overlay || flo.Occlude || flo.Name != "water" {
+				t.Errorf("decoded %+v", flo)
+			}
+		},
+	},
+	{
+		name: "spotanim",
+		new:  func() config { return NewSpotAnimType() },
+		data: []byte{1, 0x00, 0x64, 2, 0x00, 0x65, 3, 4, 0x00, 0x40, 6, 0x00, 0x5A, 7, 20, 8, 30, 41, 0x00, 0x01, 51, 0x00, 0x02, 0},
+		check: func(t *testing.T, c config) {
+			spot := c.(*SpotAnimType)
+			if spot.Model != 100 || spot.Anim != 101 || !spot.DisposeAlpha || spot.ResizeH != 64 || spot.ResizeV != 128 ||
+				spot.Orientation != 90 || spot.Ambient != 20 || spot.Contrast != 30 || spot.RecolSrc[1] != 1 || spot.RecolDst[1] != 2 {
+				t.Errorf("decoded %+v", spot)
+			}
+		},
+	},
+	{
+		name: "varp",
+		new:  func() config { return NewVarpType() },
+		data: cat([]byte{1, 2, 2, 3, 3, 4, 5, 0x00, 0x06, 6, 7, 0, 0, 1, 0, 8, 10}, str("code"), []byte{0}),
+		check: func(t *testing.T, c config) {
+			varp := c.(*VarpType)
+			want := &VarpType{opOrder: varp.opOrder, Code1: 2, Code2: 3, Code3: true, ClientCode: 6, Code6: true, Code7: 256, Code8: true, Code10: "code"}
+			if !reflect.DeepEqual(varp, want) {
+				t.Errorf("decoded %+v, want %+v", varp, want)
+			}
+		},
+	},
+}
+
+func TestConfig_RoundTrip(t *testing.T) {
+	for _, tt := range configTests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.new()
+			p := packet.NewPacket(tt.data)
+			if err := decode(p, c); err != nil {
+				t.Fatalf("decode() error = %v", err)
+			}
+			if p.Len() != 0 {
+				t.Errorf("decode() left %d bytes", p.Len())
+			}
+			tt.check(t, c)
+
+			out := packet.NewPacket(nil)
+			encode(out, c, true)
+			if !bytes.Equal(out.Buf, tt.data) {
+				t.Errorf("encode() = % x, want % x", out.Buf, tt.data)
+			}
+		})
+	}
+}
+
+func TestConfig_encodeOrder(t *testing.T) {
+	// members before the name, as a hand-written config might have it
+	data := cat([]byte{16, 11, 2}, str("Coins"), []byte{0})
+	obj := NewObjType()
+	if err := obj.Decode(packet.NewPacket(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	obj.Stackable = false
+	obj.Cost = 3
+	p := packet.NewPacket(nil)
+	obj.Encode(p)
+
+	want := cat([]byte{16, 2}, str("Coins"), []byte{12, 0, 0, 0, 3, 0})
+	if !bytes.Equal(p.Buf, want) {
+		t.Errorf("Encode() = % x, want % x", p.Buf, want)
+	}
+
+	// a new config is encoded in canonical order
+	p = packet.NewPacket(nil)
+	NewVarpType().Encode(p)
+	if !bytes.Equal(p.Buf, []byte{0}) {
+		t.Errorf("Encode() default = % x, want 00", p.Buf)
+	}
+}
+
+func TestConfig_decodeError(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"unknown opcode", []byte{150, 0}, errUnknownOpcode},
+		{"truncated value", []byte{1, 0x0B}, nil},
+		{"unterminated", []byte{11}, nil},
+		{"unterminated string", []byte{2, 'a'}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewObjType().Decode(packet.NewPacket(tt.data))
+			if err == nil {
+				t.Fatal("Decode() error = nil, want error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decode() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocType_IsActive(t *testing.T) {
+	tests := []struct {
+		name string
+		loc  func(*LocType)
+		want bool
+	}{
+		{"no models", func(l *LocType) {}, false},
+		{"centrepiece", func(l *LocType) { l.Models = []uint16{1} }, true},
+		{"wall", func(l *LocType) { l.Models, l.Shapes = []uint16{1}, []uint8{0} }, false},
+		{"wall with option", func(l *LocType) { l.Models, l.Shapes, l.Ops[0] = []uint16{1}, []uint8{0}, "Open" }, true},
+		{"set inactive", func(l *LocType) { l.Models, l.Active = []uint16{1}, 0 }, false},
+		{"set active", func(l *LocType) { l.Active = 1 }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := NewLocType()
+			tt.loc(loc)
+			if got := loc.IsActive(); got != tt.want {
+				t.Errorf("IsActive() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadWriteTypes(t *testing.T) {
+	obj := NewObjType()
+	obj.Name = "Coins"
+	obj.Stackable = true
+	objs := []*ObjType{NewObjType(), obj}
+
+	jf := &io.Jagfile{}
+	WriteObjTypes(jf, objs)
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadObjTypes(jf)
+	if err != nil {
+		t.Fatalf("ReadObjTypes() error = %v", err)
+	}
+	if len(got) != 2 || got[1].Name != "Coins" || !got[1].Stackable || got[0].Name != "" {
+		t.Errorf("ReadObjTypes() = %+v", got)
+	}
+
+	if _, err := ReadLocTypes(jf); err == nil {
+		t.Error("ReadLocTypes() error = nil without loc.dat")
+	}
+}
+
+func TestConfig_serverOps(t *testing.T) {
+	obj := NewObjType()
+	obj.Name = "Bronze sword"
+	obj.Weight = 1814
+	obj.Tradeable = true
+	obj.Params.SetInt(1, 4)
+
+	jf := &io.Jagfile{}
+	WriteObjTypes(jf, []*ObjType{obj})
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	dat, err := jf.Read(ObjName + ".dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cat([]byte{0, 1, 2}, str("Bronze sword"), []byte{0})
+	if !bytes.Equal(dat.Buf, want) {
+		t.Errorf("WriteObjTypes() dat = % x, want % x", dat.Buf, want)
+	}
+
+	jf = &io.Jagfile{}
+	WriteServerObjTypes(jf, []*ObjType{obj})
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadObjTypes(jf)
+	if err != nil {
+		t.Fatalf("ReadObjTypes() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Weight != 1814 || !got[0].Tradeable || !reflect.DeepEqual(got[0].Params, obj.Params) {
+		t.Errorf("ReadObjTypes() = %+v", got)
+	}
+}
+
+func TestParams(t *testing.T) {
+	var ps Params
+	ps.SetInt(1, -5)
+	ps.SetString(2, "two")
+	ps.SetString(1, "one")
+
+	if len(ps) != 2 {
+		t.Fatalf("len = %d, want 2", len(ps))
+	}
+	if s, ok := ps.String(1); !ok || s != "one" {
+		t.Errorf("String(1) = %q, %v, want one, true", s, ok)
+	}
+	if _, ok := ps.Int(1); ok {
+		t.Error("Int(1) found a string param")
+	}
+	if _, ok := ps.Int(3); ok {
+		t.Error("Int(3) found a missing param")
+	}
+}
+
+// TestConfigArchive decodes every config type in the packed config
+// archive and checks each encodes back to the same bytes.
+func TestConfigArchive(t *testing.T) {
+	path := filepath.Join(projectpath.Root, "data", "pack", "client", "config")
+	jf, err := io.LoadJagfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		write func(in, out *io.Jagfile) error
+	}{
+		{FloName, roundTrip(ReadFloTypes, WriteFloTypes)},
+		{IdkName, roundTrip(ReadIdkTypes, WriteIdkTypes)},
+		{LocName, roundTrip(ReadLocTypes, WriteLocTypes)},
+		{NpcName, roundTrip(ReadNpcTypes, WriteNpcTypes)},
+		{ObjName, roundTrip(ReadObjTypes, WriteObjTypes)},
+		{SeqName, roundTrip(ReadSeqTypes, WriteSeqTypes)},
+		{SpotAnimName, roundTrip(ReadSpotAnimTypes, WriteSpotAnimTypes)},
+		{VarpName, roundTrip(ReadVarpTypes, WriteVarpTypes)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &io.Jagfile{}
+			if err := tt.write(jf, out); err != nil {
+				t.Fatal(err)
+			}
+			if err := out.ApplyQueue(); err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range []string{tt.name + ".dat", tt.name + ".idx"} {
+				want, err := jf.Read(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := out.Read(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got.Buf, want.Buf) {
+					t.Errorf("%s differs after decoding and encoding", file)
+				}
+			}
+		})
+	}
+}
+
+func roundTrip[T any](read func(*io.Jagfile) ([]T, error), write func(*io.Jagfile, []T)) func(in, out *io.Jagfile) error {
+	return func(in, out *io.Jagfile) error {
+		configs, err := read(in)
+		if err != nil {
+			return err
+		}
+		write(out, configs)
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A FloType is a floor config, used for both underlays and overlays.
+type FloType struct {
+	opOrder
+
+	RGB     uint32 // 24-bit colour
+	Texture int    // -1 for none
+	Overlay bool
+	Occlude bool
+	Name    string
+}
+
+// NewFloType returns a floor config with the client's defaults.
+func NewFloType() *FloType {
+	return &FloType{Texture: -1, Occlude: true}
+}
+
+// ReadFloTypes decodes every floor config in the config archive.
+func ReadFloTypes(jf *io.Jagfile) ([]*FloType, error) {
+	return readAll(jf, FloName, NewFloType)
+}
+
+// WriteFloTypes queues flos to be written to the config archive.
+func WriteFloTypes(jf *io.Jagfile, flos []*FloType) {
+	writeAll(jf, FloName, flos, false)
+}
+
+// Decode gets the config from p.
+func (t *FloType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p.
+func (t *FloType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *FloType) decodeOp(p *packet.Packet, op uint8) error {
+	switch op {
+	case 1:
+		t.RGB = p.G3()
+	case 2:
+		t.Texture = int(p.G1())
+	case 3:
+		t.Overlay = true
+	case 5:
+		t.Occlude = false
+	case 6:
+		t.Name = p.GJStrLF()
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *FloType) encodeOp(p *packet.Packet, op uint8) {
+	switch op {
+	case 1:
+		p.P3(t.RGB)
+	case 2:
+		p.P1(uint8(t.Texture))
+	case 6:
+		p.PJStrLF(t.Name)
+	}
+}
+
+func (t *FloType) isFlag(op uint8) bool {
+	return op == 3 || op == 5
+}
+
+func (t *FloType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, t.RGB != 0)
+	ops = appendIf(ops, 2, t.Texture != -1)
+	ops = appendIf(ops, 3, t.Overlay)
+	ops = appendIf(ops, 5, !t.Occlude)
+	ops = appendIf(ops, 6, t.Name != "")
+	return ops
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// An IdkType is an identity kit config: a body part players can choose
+// when designing their character.
+type IdkType struct {
+	opOrder
+
+	// Type is the body part, 0-6 for men and 7-13 for women, or -1.
+	Type    int
+	Models  []uint16
+	Disable bool // not offered in the design interface
+	// RecolSrc and RecolDst are the colours replaced and their
+	// replacements. A 0 source is unused.
+	RecolSrc [10]uint16
+	RecolDst [10]uint16
+	Heads    [10]int // chat head models, -1 for none
+}
+
+// NewIdkType returns an identity kit config with the client's defaults.
+func NewIdkType() *IdkType {
+	t := &IdkType{Type: -1}
+	for i := range t.Heads {
+		t.Heads[i] = -1
+	}
+	return t
+}
+
+// ReadIdkTypes decodes every identity kit config in the config archive.
+func ReadIdkTypes(jf *io.Jagfile) ([]*IdkType, error) {
+	return readAll(jf, IdkName, NewIdkType)
+}
+
+// WriteIdkTypes queues idks to be written to the config archive.
+func WriteIdkTypes(jf *io.Jagfile, idks []*IdkType) {
+	writeAll(jf, IdkName, idks, false)
+}
+
+// Decode gets the config from p.
+func (t *IdkType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p.
+func (t *IdkType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *IdkType) decodeOp(p *packet.Packet, op uint8) error {
+	switch {
+	case op == 1:
+		t.Type = int(p.G1())
+	case op == 2:
+		t.Models = gModels(p)
+	case op == 3:
+		t.Disable = true
+	case op >= 40 && op < 50:
+		t.RecolSrc[op-40] = p.G2()
+	case op >= 50 && op < 60:
+		t.RecolDst[op-50] = p.G2()
+	case op >= 60 && op < 70:
+		t.Heads[op-60] = int(p.G2())
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *IdkType) encodeOp(p *packet.Packet, op uint8) {
+	switch {
+	case op == 1:
+		p.P1(uint8(t.Type))
+	case op == 2:
+		pModels(p, t.Models)
+	case op >= 40 && op < 50:
+		p.P2(t.RecolSrc[op-40])
+	case op >= 50 && op < 60:
+		p.P2(t.RecolDst[op-50])
+	case op >= 60 && op < 70:
+		p.P2(uint16(t.Heads[op-60]))
+	}
+}
+
+func (t *IdkType) isFlag(op uint8) bool {
+	return op == 3
+}
+
+func (t *IdkType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, t.Type != -1)
+	ops = appendIf(ops, 2, len(t.Models) != 0)
+	ops = appendIf(ops, 3, t.Disable)
+	for i, c := range t.RecolSrc {
+		ops = appendIf(ops, uint8(40+i), c != 0)
+	}
+	for i, c := range t.RecolDst {
+		ops = appendIf(ops, uint8(50+i), c != 0)
+	}
+	for i, h := range t.Heads {
+		ops = appendIf(ops, uint8(60+i), h != -1)
+	}
+	return ops
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A LocType is a loc config.
+type LocType struct {
+	opOrder
+
+	Models []uint16
+	// Shapes are the loc shapes of Models. They are nil if the loc has
+	// a single shape, 10.
+	Shapes []uint8
+	Name   string
+	Desc   string
+	Recol  []Recol
+
+	Width      int
+	Length     int
+	BlockWalk  bool
+	BlockRange bool
+	// Active is 1 if the loc can be interacted with and 0 if not. If
+	// -1, the client decides: see [LocType.IsActive].
+	Active int
+
+	HillSkew      bool
+	ShareLight    bool
+	Occlude       bool
+	Anim          int
+	HasAlpha      bool
+	WallOff       int
+	Ambient       int8
+	Contrast      int8
+	Ops           [5]string
+	MapFunction   int
+	Mirror        bool
+	Shadow        bool
+	ResizeX       int
+	ResizeY       int
+	ResizeZ       int
+	MapScene      int
+	ForceApproach uint8
+	XOff          int16
+	YOff          int16
+	ZOff          int16
+	ForceDecor    bool
+
+	// Server-only properties.
+	Category int // groups locs handled alike by scripts, -1 for none
+	Params   Params
+}
+
+// NewLocType returns a loc config with the client's defaults.
+func NewLocType() *LocType {
+	return &LocType{
+		Width:       1,
+		Length:      1,
+		BlockWalk:   true,
+		BlockRange:  true,
+		Active:      -1,
+		Anim:        -1,
+		WallOff:     16,
+		MapFunction: -1,
+		Shadow:      true,
+		ResizeX:     128,
+		ResizeY:     128,
+		ResizeZ:     128,
+		MapScene:    -1,
+		Category:    -1,
+	}
+}
+
+// ReadLocTypes decodes every loc config in the config archive.
+func ReadLocTypes(jf *io.Jagfile) ([]*LocType, error) {
+	return readAll(jf, LocName, NewLocType)
+}
+
+// WriteLocTypes queues locs to be written to the config archive.
+func WriteLocTypes(jf *io.Jagfile, locs []*LocType) {
+	writeAll(jf, LocName, locs, false)
+}
+
+// WriteServerLocTypes is like [WriteLocTypes] but keeps server-only
+// properties.
+func WriteServerLocTypes(jf *io.Jagfile, locs []*LocType) {
+	writeAll(jf, LocName, locs, true)
+}
+
+// IsActive reports whether the loc can be interacted with. Unless set,
+// a loc is active if it has options or is a single centrepiece model.
+func (t *LocType) IsActive() bool {
+	if t.Active != -1 {
+		return t.Active == 1
+	}
+	for _, op := range t.Ops {
+		if op != "" {
+			return true
+		}
+	}
+	return len(t.Models) > 0 && (t.Shapes == nil || t.Shapes[0] == 10)
+}
+
+// Decode gets the config from p.
+func (t *LocType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p, including any server-only properties.
+func (t *LocType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *LocType) decodeOp(p *packet.Packet, op uint8) error {
+	switch {
+	case op == 1:
+		n := int(p.G1())
+		t.Models = make([]uint16, n)
+		t.Shapes = make([]uint8, n)
+		for i := range n {
+			t.Models[i] = p.G2()
+			t.Shapes[i] = p.G1()
+		}
+	case op == 2:
+		t.Name = p.GJStrLF()
+	case op == 3:
+		t.Desc = p.GJStrLF()
+	case op == 5:
+		t.Models = gModels(p)
+		t.Shapes = nil
+	case op == 14:
+		t.Width = int(p.G1())
+	case op == 15:
+		t.Length = int(p.G1())
+	case op == 17:
+		t.BlockWalk = false
+	case op == 18:
+		t.BlockRange = false
+	case op == 19:
+		t.Active = int(p.G1())
+	case op == 21:
+		t.HillSkew = true
+	case op == 22:
+		t.ShareLight = true
+	case op == 23:
+		t.Occlude = true
+	case op == 24:
+		t.Anim = gID(p)
+	case op == 25:
+		t.HasAlpha = true
+	case op == 28:
+		t.WallOff = int(p.G1())
+	case op == 29:
+		t.Ambient = p.G1B()
+	case op == 39:
+		t.Contrast = p.G1B()
+	case op >= 30 && op < 35:
+		t.Ops[op-30] = p.GJStrLF()
+	case op == 40:
+		t.Recol = gRecols(p)
+	case op == 60:
+		t.MapFunction = int(p.G2())
+	case op == 62:
+		t.Mirror = true
+	case op == 64:
+		t.Shadow = false
+	case op == 65:
+		t.ResizeX = int(p.G2())
+	case op == 66:
+		t.ResizeY = int(p.G2())
+	case op == 67:
+		t.ResizeZ = int(p.G2())
+	case op == 68:
+		t.MapScene = int(p.G2())
+	case op == 69:
+		t.ForceApproach = p.G1()
+	case op == 70:
+		t.XOff = p.G2S()
+	case op == 71:
+		t.YOff = p.G2S()
+	case op == 72:
+		t.ZOff = p.G2S()
+	case op == 73:
+		t.ForceDecor = true
+	case op == 200:
+		t.Category = gID(p)
+	case op == ParamOp:
+		t.Params = gParams(p)
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *LocType) encodeOp(p *packet.Packet, op uint8) {
+	switch {
+	case op == 1:
+		p.P1(uint8(len(t.Models)))
+		for i, m := range t.Models {
+			p.P2(m)
+			p.P1(t.Shapes[i])
+		}
+	case op == 2:
+		p.PJStrLF(t.Name)
+	case op == 3:
+		p.PJStrLF(t.Desc)
+	case op == 5:
+		pModels(p, t.Models)
+	case op == 14:
+		p.P1(uint8(t.Width))
+	case op == 15:
+		p.P1(uint8(t.Length))
+	case op == 19:
+		p.P1(uint8(t.Active))
+	case op == 24:
+		pID(p, t.Anim)
+	case op == 28:
+		p.P1(uint8(t.WallOff))
+	case op == 29:
+		p.P1(uint8(t.Ambient))
+	case op == 39:
+		p.P1(uint8(t.Contrast))
+	case op >= 30 && op < 35:
+		p.PJStrLF(t.Ops[op-30])
+	case op == 40:
+		pRecols(p, t.Recol)
+	case op == 60:
+		p.P2(uint16(t.MapFunction))
+	case op == 65:
+		p.P2(uint16(t.ResizeX))
+	case op == 66:
+		p.P2(uint16(t.ResizeY))
+	case op == 67:
+		p.P2(uint16(t.ResizeZ))
+	case op == 68:
+		p.P2(uint16(t.MapScene))
+	case op == 69:
+		p.P1(t.ForceApproach)
+	case op == 70:
+		p.P2(uint16(t.XOff))
+	case op == 71:
+		p.P2(uint16(t.YOff))
+	case op == 72:
+		p.P2(uint16(t.ZOff))
+	case op == 200:
+		pID(p, t.Category)
+	case op == ParamOp:
+		pParams(p, t.Params)
+	}
+}
+
+func (t *LocType) isFlag(op uint8) bool {
+	switch op {
+	case 17, 18, 21, 22, 23, 25, 62, 64, 73:
+		return true
+	}
+	return false
+}
+
+func (t *LocType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, len(t.Models) != 0 && t.Shapes != nil)
+	ops = appendIf(ops, 2, t.Name != "")
+	ops = appendIf(ops, 3, t.Desc != "")
+	ops = appendIf(ops, 5, len(t.Models) != 0 && t.Shapes == nil)
+	ops = appendIf(ops, 14, t.Width != 1)
+	ops = appendIf(ops, 15, t.Length != 1)
+	ops = appendIf(ops, 17, !t.BlockWalk)
+	ops = appendIf(ops, 18, !t.BlockRange)
+	ops = appendIf(ops, 19, t.Active != -1)
+	ops = appendIf(ops, 21, t.HillSkew)
+	ops = appendIf(ops, 22, t.ShareLight)
+	ops = appendIf(ops, 23, t.Occlude)
+	ops = appendIf(ops, 24, t.Anim != -1)
+	ops = appendIf(ops, 25, t.HasAlpha)
+	ops = appendIf(ops, 28, t.WallOff != 16)
+	ops = appendIf(ops, 29, t.Ambient != 0)
+	for i, s := range t.Ops {
+		ops = appendIf(ops, uint8(30+i), s != "")
+	}
+	ops = appendIf(ops, 39, t.Contrast != 0)
+	ops = appendIf(ops, 40, len(t.Recol) != 0)
+	ops = appendIf(ops, 60, t.MapFunction != -1)
+	ops = appendIf(ops, 62, t.Mirror)
+	ops = appendIf(ops, 64, !t.Shadow)
+	ops = appendIf(ops, 65, t.ResizeX != 128)
+	ops = appendIf(ops, 66, t.ResizeY != 128)
+	ops = appendIf(ops, 67, t.ResizeZ != 128)
+	ops = appendIf(ops, 68, t.MapScene != -1)
+	ops = appendIf(ops, 69, t.ForceApproach != 0)
+	ops = appendIf(ops, 70, t.XOff != 0)
+	ops = appendIf(ops, 71, t.YOff != 0)
+	ops = appendIf(ops, 72, t.ZOff != 0)
+	ops = appendIf(ops, 73, t.ForceDecor)
+	ops = appendIf(ops, 200, t.Category != -1)
+	ops = appendIf(ops, ParamOp, len(t.Params) != 0)
+	return ops
+}
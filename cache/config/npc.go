@@ -0,0 +1,252 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Indexes of [NpcType.Stats].
+const (
+	StatAttack = iota
+	StatDefence
+	StatStrength
+	StatHitpoints
+	StatRanged
+	StatMagic
+)
+
+// An NpcType is an npc config.
+type NpcType struct {
+	opOrder
+
+	Models []uint16
+	Name   string
+	Desc   string
+	Size   int8
+	Heads  []uint16 // chat head models
+	Recol  []Recol
+
+	ReadyAnim     int
+	WalkAnim      int
+	WalkAnimBack  int
+	WalkAnimRight int
+	WalkAnimLeft  int
+	DisposeAlpha  bool
+
+	Ops      [5]string
+	Code90   int
+	Code91   int
+	Code92   int
+	Minimap  bool // shown on the minimap
+	VisLevel int  // combat level, -1 for none
+	ResizeH  int
+	ResizeV  int
+
+	// Server-only properties.
+	Stats       [6]int // combat levels, indexed by StatAttack and so on
+	HuntRange   int    // tiles within which the npc seeks out players
+	RespawnRate int    // ticks before the npc respawns after dying
+	Category    int    // groups npcs handled alike by scripts, -1 for none
+	Params      Params
+}
+
+// NewNpcType returns an npc config with the client's defaults.
+func NewNpcType() *NpcType {
+	return &NpcType{
+		Size:          1,
+		ReadyAnim:     -1,
+		WalkAnim:      -1,
+		WalkAnimBack:  -1,
+		WalkAnimRight: -1,
+		WalkAnimLeft:  -1,
+		Code90:        -1,
+		Code91:        -1,
+		Code92:        -1,
+		Minimap:       true,
+		VisLevel:      -1,
+		ResizeH:       128,
+		ResizeV:       128,
+		Stats:         [6]int{1, 1, 1, 1, 1, 1},
+		RespawnRate:   100,
+		Category:      -1,
+	}
+}
+
+// ReadNpcTypes decodes every npc config in the config archive.
+func ReadNpcTypes(jf *io.Jagfile) ([]*NpcType, error) {
+	return readAll(jf, NpcName, NewNpcType)
+}
+
+// WriteNpcTypes queues npcs to be written to the config archive.
+func WriteNpcTypes(jf *io.Jagfile, npcs []*NpcType) {
+	writeAll(jf, NpcName, npcs, false)
+}
+
+// WriteServerNpcTypes is like [WriteNpcTypes] but keeps server-only
+// properties.
+func WriteServerNpcTypes(jf *io.Jagfile, npcs []*NpcType) {
+	writeAll(jf, NpcName, npcs, true)
+}
+
+// Level returns the npc's level in stat, one of StatAttack and so on.
+func (t *NpcType) Level(stat int) int { return t.Stats[stat] }
+
+// HitPoints returns the hitpoints the npc spawns with.
+func (t *NpcType) HitPoints() int { return t.Level(StatHitpoints) }
+
+// Hunts reports whether the npc seeks out players within its
+// HuntRange.
+func (t *NpcType) Hunts() bool { return t.HuntRange > 0 }
+
+// Decode gets the config from p.
+func (t *NpcType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p, including any server-only properties.
+func (t *NpcType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *NpcType) decodeOp(p *packet.Packet, op uint8) error {
+	switch {
+	case op == 1:
+		t.Models = gModels(p)
+	case op == 2:
+		t.Name = p.GJStrLF()
+	case op == 3:
+		t.Desc = p.GJStrLF()
+	case op == 12:
+		t.Size = p.G1B()
+	case op == 13:
+		t.ReadyAnim = int(p.G2())
+	case op == 14:
+		t.WalkAnim = int(p.G2())
+	case op == 16:
+		t.DisposeAlpha = true
+	case op == 17:
+		t.WalkAnim = int(p.G2())
+		t.WalkAnimBack = int(p.G2())
+		t.WalkAnimRight = int(p.G2())
+		t.WalkAnimLeft = int(p.G2())
+	case op >= 30 && op < 35:
+		t.Ops[op-30] = p.GJStrLF()
+	case op == 40:
+		t.Recol = gRecols(p)
+	case op == 60:
+		t.Heads = gModels(p)
+	case op == 90:
+		t.Code90 = int(p.G2())
+	case op == 91:
+		t.Code91 = int(p.G2())
+	case op == 92:
+		t.Code92 = int(p.G2())
+	case op == 93:
+		t.Minimap = false
+	case op == 95:
+		t.VisLevel = int(p.G2())
+	case op == 97:
+		t.ResizeH = int(p.G2())
+	case op == 98:
+		t.ResizeV = int(p.G2())
+	case op == 200:
+		for i := range t.Stats {
+			t.Stats[i] = int(p.G2())
+		}
+	case op == 201:
+		t.HuntRange = int(p.G1())
+	case op == 202:
+		t.RespawnRate = int(p.G2())
+	case op == 203:
+		t.Category = gID(p)
+	case op == ParamOp:
+		t.Params = gParams(p)
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *NpcType) encodeOp(p *packet.Packet, op uint8) {
+	switch {
+	case op == 1:
+		pModels(p, t.Models)
+	case op == 2:
+		p.PJStrLF(t.Name)
+	case op == 3:
+		p.PJStrLF(t.Desc)
+	case op == 12:
+		p.P1(uint8(t.Size))
+	case op == 13:
+		p.P2(uint16(t.ReadyAnim))
+	case op == 14:
+		p.P2(uint16(t.WalkAnim))
+	case op == 17:
+		p.P2(uint16(t.WalkAnim))
+		p.P2(uint16(t.WalkAnimBack))
+		p.P2(uint16(t.WalkAnimRight))
+		p.P2(uint16(t.WalkAnimLeft))
+	case op >= 30 && op < 35:
+		p.PJStrLF(t.Ops[op-30])
+	case op == 40:
+		pRecols(p, t.Recol)
+	case op == 60:
+		pModels(p, t.Heads)
+	case op == 90:
+		p.P2(uint16(t.Code90))
+	case op == 91:
+		p.P2(uint16(t.Code91))
+	case op == 92:
+		p.P2(uint16(t.Code92))
+	case op == 95:
+		p.P2(uint16(t.VisLevel))
+	case op == 97:
+		p.P2(uint16(t.ResizeH))
+	case op == 98:
+		p.P2(uint16(t.ResizeV))
+	case op == 200:
+		for _, s := range t.Stats {
+			p.P2(uint16(s))
+		}
+	case op == 201:
+		p.P1(uint8(t.HuntRange))
+	case op == 202:
+		p.P2(uint16(t.RespawnRate))
+	case op == 203:
+		pID(p, t.Category)
+	case op == ParamOp:
+		pParams(p, t.Params)
+	}
+}
+
+func (t *NpcType) isFlag(op uint8) bool {
+	return op == 16 || op == 93
+}
+
+func (t *NpcType) ops() []uint8 {
+	walk4 := t.WalkAnimBack != -1 || t.WalkAnimRight != -1 || t.WalkAnimLeft != -1
+
+	var ops []uint8
+	ops = appendIf(ops, 1, len(t.Models) != 0)
+	ops = appendIf(ops, 2, t.Name != "")
+	ops = appendIf(ops, 3, t.Desc != "")
+	ops = appendIf(ops, 12, t.Size != 1)
+	ops = appendIf(ops, 13, t.ReadyAnim != -1)
+	ops = appendIf(ops, 14, t.WalkAnim != -1 && !walk4)
+	ops = appendIf(ops, 16, t.DisposeAlpha)
+	ops = appendIf(ops, 17, walk4)
+	for i, s := range t.Ops {
+		ops = appendIf(ops, uint8(30+i), s != "")
+	}
+	ops = appendIf(ops, 40, len(t.Recol) != 0)
+	ops = appendIf(ops, 60, len(t.Heads) != 0)
+	ops = appendIf(ops, 90, t.Code90 != -1)
+	ops = appendIf(ops, 91, t.Code91 != -1)
+	ops = appendIf(ops, 92, t.Code92 != -1)
+	ops = appendIf(ops, 93, !t.Minimap)
+	ops = appendIf(ops, 95, t.VisLevel != -1)
+	ops = appendIf(ops, 97, t.ResizeH != 128)
+	ops = appendIf(ops, 98, t.ResizeV != 128)
+	ops = appendIf(ops, 200, t.Stats != [6]int{1, 1, 1, 1, 1, 1})
+	ops = appendIf(ops, 201, t.HuntRange != 0)
+	ops = appendIf(ops, 202, t.RespawnRate != 100)
+	ops = appendIf(ops, 203, t.Category != -1)
+	ops = appendIf(ops, ParamOp, len(t.Params) != 0)
+	return ops
+}
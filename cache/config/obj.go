@@ -0,0 +1,300 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A CountObj is the obj shown for a stack of at least Count.
+type CountObj struct {
+	Obj   uint16
+	Count uint16
+}
+
+// An ObjType is an obj config.
+type ObjType struct {
+	opOrder
+
+	Model int
+	Name  string
+	Desc  string
+	Recol []Recol
+
+	Zoom2D int
+	Xan2D  int
+	Yan2D  int
+	Zan2D  int
+	Xof2D  int16
+	Yof2D  int16
+	Code9  bool
+	Code10 int
+
+	Stackable bool
+	Cost      int32
+	Members   bool
+	Ops       [5]string // ground options
+	IOps      [5]string // inventory options
+
+	ManWear          int
+	ManWear2         int
+	ManWear3         int
+	ManWearOffsetY   int8
+	WomanWear        int
+	WomanWear2       int
+	WomanWear3       int
+	WomanWearOffsetY int8
+	ManHead          int
+	ManHead2         int
+	WomanHead        int
+	WomanHead2       int
+
+	CountObjs    [10]CountObj
+	CertLink     int
+	CertTemplate int
+
+	// Server-only properties.
+	Weight      int32 // in grams, negative for weight reducing gear
+	Tradeable   bool
+	RespawnRate int // ticks before a ground spawn reappears
+	Params      Params
+}
+
+// NewObjType returns an obj config with the client's defaults.
+func NewObjType() *ObjType {
+	return &ObjType{
+		Model:        -1,
+		Zoom2D:       2000,
+		Code10:       -1,
+		Cost:         1,
+		ManWear:      -1,
+		ManWear2:     -1,
+		ManWear3:     -1,
+		WomanWear:    -1,
+		WomanWear2:   -1,
+		WomanWear3:   -1,
+		ManHead:      -1,
+		ManHead2:     -1,
+		WomanHead:    -1,
+		WomanHead2:   -1,
+		CertLink:     -1,
+		CertTemplate: -1,
+		RespawnRate:  100,
+	}
+}
+
+// ReadObjTypes decodes every obj config in the config archive.
+func ReadObjTypes(jf *io.Jagfile) ([]*ObjType, error) {
+	return readAll(jf, ObjName, NewObjType)
+}
+
+// WriteObjTypes queues objs to be written to the config archive.
+func WriteObjTypes(jf *io.Jagfile, objs []*ObjType) {
+	writeAll(jf, ObjName, objs, false)
+}
+
+// WriteServerObjTypes is like [WriteObjTypes] but keeps server-only
+// properties.
+func WriteServerObjTypes(jf *io.Jagfile, objs []*ObjType) {
+	writeAll(jf, ObjName, objs, true)
+}
+
+// Decode gets the config from p.
+func (t *ObjType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p, including any server-only properties.
+func (t *ObjType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *ObjType) decodeOp(p *packet.Packet, op uint8) error {
+	switch {
+	case op == 1:
+		t.Model = int(p.G2())
+	case op == 2:
+		t.Name = p.GJStrLF()
+	case op == 3:
+		t.Desc = p.GJStrLF()
+	case op == 4:
+		t.Zoom2D = int(p.G2())
+	case op == 5:
+		t.Xan2D = int(p.G2())
+	case op == 6:
+		t.Yan2D = int(p.G2())
+	case op == 7:
+		t.Xof2D = p.G2S()
+	case op == 8:
+		t.Yof2D = p.G2S()
+	case op == 9:
+		t.Code9 = true
+	case op == 10:
+		t.Code10 = int(p.G2())
+	case op == 11:
+		t.Stackable = true
+	case op == 12:
+		t.Cost = int32(p.G4())
+	case op == 16:
+		t.Members = true
+	case op == 23:
+		t.ManWear = int(p.G2())
+		t.ManWearOffsetY = p.G1B()
+	case op == 24:
+		t.ManWear2 = int(p.G2())
+	case op == 25:
+		t.WomanWear = int(p.G2())
+		t.WomanWearOffsetY = p.G1B()
+	case op == 26:
+		t.WomanWear2 = int(p.G2())
+	case op >= 30 && op < 35:
+		t.Ops[op-30] = p.GJStrLF()
+	case op >= 35 && op < 40:
+		t.IOps[op-35] = p.GJStrLF()
+	case op == 40:
+		t.Recol = gRecols(p)
+	case op == 78:
+		t.ManWear3 = int(p.G2())
+	case op == 79:
+		t.WomanWear3 = int(p.G2())
+	case op == 90:
+		t.ManHead = int(p.G2())
+	case op == 91:
+		t.WomanHead = int(p.G2())
+	case op == 92:
+		t.ManHead2 = int(p.G2())
+	case op == 93:
+		t.WomanHead2 = int(p.G2())
+	case op == 95:
+		t.Zan2D = int(p.G2())
+	case op == 97:
+		t.CertLink = int(p.G2())
+	case op == 98:
+		t.CertTemplate = int(p.G2())
+	case op >= 100 && op < 110:
+		t.CountObjs[op-100] = CountObj{Obj: p.G2(), Count: p.G2()}
+	case op == 200:
+		t.Weight = int32(p.G4())
+	case op == 201:
+		t.Tradeable = true
+	case op == 202:
+		t.RespawnRate = int(p.G2())
+	case op == ParamOp:
+		t.Params = gParams(p)
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *ObjType) encodeOp(p *packet.Packet, op uint8) {
+	switch {
+	case op == 1:
+		p.P2(uint16(t.Model))
+	case op == 2:
+		p.PJStrLF(t.Name)
+	case op == 3:
+		p.PJStrLF(t.Desc)
+	case op == 4:
+		p.P2(uint16(t.Zoom2D))
+	case op == 5:
+		p.P2(uint16(t.Xan2D))
+	case op == 6:
+		p.P2(uint16(t.Yan2D))
+	case op == 7:
+		p.P2(uint16(t.Xof2D))
+	case op == 8:
+		p.P2(uint16(t.Yof2D))
+	case op == 10:
+		p.P2(uint16(t.Code10))
+	case op == 12:
+		p.P4(uint32(t.Cost))
+	case op == 23:
+		p.P2(uint16(t.ManWear))
+		p.P1(uint8(t.ManWearOffsetY))
+	case op == 24:
+		p.P2(uint16(t.ManWear2))
+	case op == 25:
+		p.P2(uint16(t.WomanWear))
+		p.P1(uint8(t.WomanWearOffsetY))
+	case op == 26:
+		p.P2(uint16(t.WomanWear2))
+	case op >= 30 && op < 35:
+		p.PJStrLF(t.Ops[op-30])
+	case op >= 35 && op < 40:
+		p.PJStrLF(t.IOps[op-35])
+	case op == 40:
+		pRecols(p, t.Recol)
+	case op == 78:
+		p.P2(uint16(t.ManWear3))
+	case op == 79:
+		p.P2(uint16(t.WomanWear3))
+	case op == 90:
+		p.P2(uint16(t.ManHead))
+	case op == 91:
+		p.P2(uint16(t.WomanHead))
+	case op == 92:
+		p.P2(uint16(t.ManHead2))
+	case op == 93:
+		p.P2(uint16(t.WomanHead2))
+	case op == 95:
+		p.P2(uint16(t.Zan2D))
+	case op == 97:
+		p.P2(uint16(t.CertLink))
+	case op == 98:
+		p.P2(uint16(t.CertTemplate))
+	case op >= 100 && op < 110:
+		p.P2(t.CountObjs[op-100].Obj)
+		p.P2(t.CountObjs[op-100].Count)
+	case op == 200:
+		p.P4(uint32(t.Weight))
+	case op == 202:
+		p.P2(uint16(t.RespawnRate))
+	case op == ParamOp:
+		pParams(p, t.Params)
+	}
+}
+
+func (t *ObjType) isFlag(op uint8) bool {
+	return op == 9 || op == 11 || op == 16 || op == 201
+}
+
+func (t *ObjType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, t.Model != -1)
+	ops = appendIf(ops, 2, t.Name != "")
+	ops = appendIf(ops, 3, t.Desc != "")
+	ops = appendIf(ops, 4, t.Zoom2D != 2000)
+	ops = appendIf(ops, 5, t.Xan2D != 0)
+	ops = appendIf(ops, 6, t.Yan2D != 0)
+	ops = appendIf(ops, 7, t.Xof2D != 0)
+	ops = appendIf(ops, 8, t.Yof2D != 0)
+	ops = appendIf(ops, 9, t.Code9)
+	ops = appendIf(ops, 10, t.Code10 != -1)
+	ops = appendIf(ops, 11, t.Stackable)
+	ops = appendIf(ops, 12, t.Cost != 1)
+	ops = appendIf(ops, 16, t.Members)
+	ops = appendIf(ops, 23, t.ManWear != -1 || t.ManWearOffsetY != 0)
+	ops = appendIf(ops, 24, t.ManWear2 != -1)
+	ops = appendIf(ops, 25, t.WomanWear != -1 || t.WomanWearOffsetY != 0)
+	ops = appendIf(ops, 26, t.WomanWear2 != -1)
+	for i, s := range t.Ops {
+		ops = appendIf(ops, uint8(30+i), s != "")
+	}
+	for i, s := range t.IOps {
+		ops = appendIf(ops, uint8(35+i), s != "")
+	}
+	ops = appendIf(ops, 40, len(t.Recol) != 0)
+	ops = appendIf(ops, 78, t.ManWear3 != -1)
+	ops = appendIf(ops, 79, t.WomanWear3 != -1)
+	ops = appendIf(ops, 90, t.ManHead != -1)
+	ops = appendIf(ops, 91, t.WomanHead != -1)
+	ops = appendIf(ops, 92, t.ManHead2 != -1)
+	ops = appendIf(ops, 93, t.WomanHead2 != -1)
+	ops = appendIf(ops, 95, t.Zan2D != 0)
+	ops = appendIf(ops, 97, t.CertLink != -1)
+	ops = appendIf(ops, 98, t.CertTemplate != -1)
+	for i, c := range t.CountObjs {
+		ops = appendIf(ops, uint8(100+i), c != CountObj{})
+	}
+	ops = appendIf(ops, 200, t.Weight != 0)
+	ops = appendIf(ops, 201, t.Tradeable)
+	ops = appendIf(ops, 202, t.RespawnRate != 100)
+	ops = appendIf(ops, ParamOp, len(t.Params) != 0)
+	return ops
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// ParamOp is the server-only opcode holding a config's params.
+const ParamOp = 249
+
+// A Param is a server-only value attached to a config, looked up by key
+// from game logic.
+type Param struct {
+	Key      int
+	IsString bool
+	Int      int32
+	Str      string
+}
+
+// Params are the params of a config, in the order they were defined.
+type Params []Param
+
+// Int returns the integer param key.
+func (ps Params) Int(key int) (int32, bool) {
+	for _, p := range ps {
+		if p.Key == key && !p.IsString {
+			return p.Int, true
+		}
+	}
+	return 0, false
+}
+
+// String returns the string param key.
+func (ps Params) String(key int) (string, bool) {
+	for _, p := range ps {
+		if p.Key == key && p.IsString {
+			return p.Str, true
+		}
+	}
+	return "", false
+}
+
+// SetInt sets param key to v, replacing any existing value.
+func (ps *Params) SetInt(key int, v int32) {
+	ps.set(Param{Key: key, Int: v})
+}
+
+// SetString sets param key to s, replacing any existing value.
+func (ps *Params) SetString(key int, s string) {
+	ps.set(Param{Key: key, IsString: true, Str: s})
+}
+
+func (ps *Params) set(param Param) {
+	if i := ps.index(param.Key); i != -1 {
+		(*ps)[i] = param
+		return
+	}
+	*ps = append(*ps, param)
+}
+
+// index returns the index of param key, or -1.
+func (ps Params) index(key int) int {
+	for i, p := range ps {
+		if p.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func gParams(p *packet.Packet) Params {
+	ps := make(Params, p.G1())
+	for i := range ps {
+		ps[i].Key = int(p.G3())
+		ps[i].IsString = p.G1() == 1
+		if ps[i].IsString {
+			ps[i].Str = p.GJStrLF()
+		} else {
+			ps[i].Int = int32(p.G4())
+		}
+	}
+	return ps
+}
+
+func pParams(p *packet.Packet, ps Params) {
+	p.P1(uint8(len(ps)))
+	for _, param := range ps {
+		p.P3(uint32(param.Key))
+		if param.IsString {
+			p.P1(1)
+			p.PJStrLF(param.Str)
+		} else {
+			p.P1(0)
+			p.P4(uint32(param.Int))
+		}
+	}
+}
+
+// paramProp is the param key, repeated once per param: "key,value",
+// where a quoted value is a string.
+func paramProp[T config](field func(T) *Params) prop[T] {
+	return prop[T]{
+		key: "param",
+		op:  ParamOp,
+		parse: func(t T, value string, syms *Symbols) error {
+			k, v, ok := strings.Cut(value, ",")
+			if !ok {
+				return fmt.Errorf("%q needs a key and value", value)
+			}
+			key, err := parseRef(k, "param", syms)
+			if err != nil || key == -1 {
+				return cmpErr(err, "param cannot be none")
+			}
+			ps := field(t)
+			if ps.index(key) != -1 {
+				return fmt.Errorf("param %s is already set", strings.TrimSpace(k))
+			}
+			if len(*ps) == 0xFF {
+				return fmt.Errorf("more than %d params", 0xFF)
+			}
+
+			v = strings.TrimSpace(v)
+			if strings.HasPrefix(v, `"`) {
+				s, err := strconv.Unquote(v)
+				if err != nil {
+					return fmt.Errorf("malformed string %s", v)
+				}
+				ps.SetString(key, s)
+				return nil
+			}
+			n, err := parseNum[int32](v, -1<<31, 1<<31-1)
+			if err != nil {
+				return err
+			}
+			ps.SetInt(key, n)
+			return nil
+		},
+		format: func(t T, syms *Symbols) []kv {
+			var lines []kv
+			for _, p := range *field(t) {
+				value := strconv.Itoa(int(p.Int))
+				if p.IsString {
+					value = strconv.Quote(p.Str)
+				}
+				lines = append(lines, kv{"param", formatRef(p.Key, "param", syms) + "," + value})
+			}
+			return lines
+		},
+	}
+}
@@ -0,0 +1,432 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The source keys of each config type. Ids of other configs and models
+// are written as symbols where known.
+
+var objSchema = &schema[*ObjType]{
+	name: ObjName,
+	new:  NewObjType,
+	props: slices.Concat(
+		[]prop[*ObjType]{
+			refProp("model", 1, "model", func(t *ObjType) *int { return &t.Model }),
+			strProp("name", 2, func(t *ObjType) *string { return &t.Name }),
+			strProp("desc", 3, func(t *ObjType) *string { return &t.Desc }),
+			numProp("2dzoom", 4, 0, 0xFFFF, func(t *ObjType) *int { return &t.Zoom2D }),
+			numProp("2dxan", 5, 0, 0xFFFF, func(t *ObjType) *int { return &t.Xan2D }),
+			numProp("2dyan", 6, 0, 0xFFFF, func(t *ObjType) *int { return &t.Yan2D }),
+			numProp("2dxof", 7, -0x8000, 0x7FFF, func(t *ObjType) *int16 { return &t.Xof2D }),
+			numProp("2dyof", 8, -0x8000, 0x7FFF, func(t *ObjType) *int16 { return &t.Yof2D }),
+			boolProp("code9", 9, func(t *ObjType) *bool { return &t.Code9 }),
+			refProp("code10", 10, "seq", func(t *ObjType) *int { return &t.Code10 }),
+			boolProp("stackable", 11, func(t *ObjType) *bool { return &t.Stackable }),
+			numProp("cost", 12, -1<<31, 1<<31-1, func(t *ObjType) *int32 { return &t.Cost }),
+			boolProp("members", 16, func(t *ObjType) *bool { return &t.Members }),
+			wearProp("manwear", 23, func(t *ObjType) (*int, *int8) { return &t.ManWear, &t.ManWearOffsetY }),
+			refProp("manwear2", 24, "model", func(t *ObjType) *int { return &t.ManWear2 }),
+			wearProp("womanwear", 25, func(t *ObjType) (*int, *int8) { return &t.WomanWear, &t.WomanWearOffsetY }),
+			refProp("womanwear2", 26, "model", func(t *ObjType) *int { return &t.WomanWear2 }),
+		},
+		opProps("op", 30, func(t *ObjType) *[5]string { return &t.Ops }),
+		opProps("iop", 35, func(t *ObjType) *[5]string { return &t.IOps }),
+		recolProps(40, func(t *ObjType) *[]Recol { return &t.Recol }),
+		[]prop[*ObjType]{
+			refProp("manwear3", 78, "model", func(t *ObjType) *int { return &t.ManWear3 }),
+			refProp("womanwear3", 79, "model", func(t *ObjType) *int { return &t.WomanWear3 }),
+			refProp("manhead", 90, "model", func(t *ObjType) *int { return &t.ManHead }),
+			refProp("womanhead", 91, "model", func(t *ObjType) *int { return &t.WomanHead }),
+			refProp("manhead2", 92, "model", func(t *ObjType) *int { return &t.ManHead2 }),
+			refProp("womanhead2", 93, "model", func(t *ObjType) *int { return &t.WomanHead2 }),
+			numProp("2dzan", 95, 0, 0xFFFF, func(t *ObjType) *int { return &t.Zan2D }),
+			refProp("certlink", 97, ObjName, func(t *ObjType) *int { return &t.CertLink }),
+			refProp("certtemplate", 98, ObjName, func(t *ObjType) *int { return &t.CertTemplate }),
+		},
+		countProps(),
+		[]prop[*ObjType]{
+			numProp("weight", 200, -1<<31, 1<<31-1, func(t *ObjType) *int32 { return &t.Weight }),
+			boolProp("tradeable", 201, func(t *ObjType) *bool { return &t.Tradeable }),
+			numProp("respawnrate", 202, 0, 0xFFFF, func(t *ObjType) *int { return &t.RespawnRate }),
+			paramProp(func(t *ObjType) *Params { return &t.Params }),
+		},
+	),
+}
+
+// wearProp is a worn model and its vertical offset: "model,offset".
+func wearProp(key string, op uint8, field func(*ObjType) (*int, *int8)) prop[*ObjType] {
+	return prop[*ObjType]{
+		key: key,
+		op:  op,
+		parse: func(t *ObjType, value string, syms *Symbols) error {
+			parts, err := splitValues(value, 2)
+			if err != nil {
+				return err
+			}
+			model, offset := field(t)
+			if *model, err = parseRef(parts[0], "model", syms); err != nil {
+				return err
+			}
+			*offset, err = parseNum[int8](parts[1], -0x80, 0x7F)
+			return err
+		},
+		format: func(t *ObjType, syms *Symbols) []kv {
+			model, offset := field(t)
+			return []kv{{key, formatRef(*model, "model", syms) + "," + strconv.Itoa(int(*offset))}}
+		},
+	}
+}
+
+// countProps are the count1 to count10 keys: "obj,count".
+func countProps() []prop[*ObjType] {
+	var props []prop[*ObjType]
+	for i := range 10 {
+		key := fmt.Sprintf("count%d", i+1)
+		props = append(props, prop[*ObjType]{
+			key: key,
+			op:  uint8(100 + i),
+			parse: func(t *ObjType, value string, syms *Symbols) error {
+				parts, err := splitValues(value, 2)
+				if err != nil {
+					return err
+				}
+				obj, err := parseRef(parts[0], ObjName, syms)
+				if err != nil || obj == -1 {
+					return cmpErr(err, "obj cannot be none")
+				}
+				count, err := parseNum[uint16](parts[1], 0, 0xFFFF)
+				t.CountObjs[i] = CountObj{Obj: uint16(obj), Count: count}
+				return err
+			},
+			format: func(t *ObjType, syms *Symbols) []kv {
+				c := t.CountObjs[i]
+				return []kv{{key, formatRef(int(c.Obj), ObjName, syms) + "," + strconv.Itoa(int(c.Count))}}
+			},
+		})
+	}
+	return props
+}
+
+var locSchema = &schema[*LocType]{
+	name: LocName,
+	new:  NewLocType,
+	props: slices.Concat(
+		[]prop[*LocType]{
+			locModelProp(),
+			strProp("name", 2, func(t *LocType) *string { return &t.Name }),
+			strProp("desc", 3, func(t *LocType) *string { return &t.Desc }),
+			numProp("width", 14, 0, 0xFF, func(t *LocType) *int { return &t.Width }),
+			numProp("length", 15, 0, 0xFF, func(t *LocType) *int { return &t.Length }),
+			boolProp("blockwalk", 17, func(t *LocType) *bool { return &t.BlockWalk }),
+			boolProp("blockrange", 18, func(t *LocType) *bool { return &t.BlockRange }),
+			numProp("active", 19, 0, 0xFF, func(t *LocType) *int { return &t.Active }),
+			boolProp("hillskew", 21, func(t *LocType) *bool { return &t.HillSkew }),
+			boolProp("sharelight", 22, func(t *LocType) *bool { return &t.ShareLight }),
+			boolProp("occlude", 23, func(t *LocType) *bool { return &t.Occlude }),
+			refProp("anim", 24, SeqName, func(t *LocType) *int { return &t.Anim }),
+			boolProp("hasalpha", 25, func(t *LocType) *bool { return &t.HasAlpha }),
+			numProp("walloff", 28, 0, 0xFF, func(t *LocType) *int { return &t.WallOff }),
+			numProp("ambient", 29, -0x80, 0x7F, func(t *LocType) *int8 { return &t.Ambient }),
+			numProp("contrast", 39, -0x80, 0x7F, func(t *LocType) *int8 { return &t.Contrast }),
+		},
+		opProps("op", 30, func(t *LocType) *[5]string { return &t.Ops }),
+		recolProps(40, func(t *LocType) *[]Recol { return &t.Recol }),
+		[]prop[*LocType]{
+			numProp("mapfunction", 60, 0, 0xFFFF, func(t *LocType) *int { return &t.MapFunction }),
+			boolProp("mirror", 62, func(t *LocType) *bool { return &t.Mirror }),
+			boolProp("shadow", 64, func(t *LocType) *bool { return &t.Shadow }),
+			numProp("resizex", 65, 0, 0xFFFF, func(t *LocType) *int { return &t.ResizeX }),
+			numProp("resizey", 66, 0, 0xFFFF, func(t *LocType) *int { return &t.ResizeY }),
+			numProp("resizez", 67, 0, 0xFFFF, func(t *LocType) *int { return &t.ResizeZ }),
+			numProp("mapscene", 68, 0, 0xFFFF, func(t *LocType) *int { return &t.MapScene }),
+			numProp("forceapproach", 69, 0, 0xFF, func(t *LocType) *uint8 { return &t.ForceApproach }),
+			numProp("xoff", 70, -0x8000, 0x7FFF, func(t *LocType) *int16 { return &t.XOff }),
+			numProp("yoff", 71, -0x8000, 0x7FFF, func(t *LocType) *int16 { return &t.YOff }),
+			numProp("zoff", 72, -0x8000, 0x7FFF, func(t *LocType) *int16 { return &t.ZOff }),
+			boolProp("forcedecor", 73, func(t *LocType) *bool { return &t.ForceDecor }),
+			refProp("category", 200, "category", func(t *LocType) *int { return &t.Category }),
+			paramProp(func(t *LocType) *Params { return &t.Params }),
+		},
+	),
+}
+
+// locModelProp is a key repeated once per model, "model" or
+// "model,shape". Either every model of a loc has a shape or none do.
+func locModelProp() prop[*LocType] {
+	return prop[*LocType]{
+		key: "model",
+		op:  1,
+		alt: 5,
+		parse: func(t *LocType, value string, syms *Symbols) error {
+			modelValue, shapeValue, shaped := strings.Cut(value, ",")
+			if len(t.Models) != 0 && shaped != (t.Shapes != nil) {
+				return fmt.Errorf("%q: every model needs a shape or none do", value)
+			}
+			model, err := parseRef(modelValue, "model", syms)
+			if err != nil || model == -1 {
+				return cmpErr(err, "model cannot be none")
+			}
+			if shaped {
+				shape, err := parseNum[uint8](shapeValue, 0, 22)
+				if err != nil {
+					return err
+				}
+				t.Shapes = append(t.Shapes, shape)
+			}
+			t.Models = append(t.Models, uint16(model))
+			return nil
+		},
+		format: func(t *LocType, syms *Symbols) []kv {
+			var lines []kv
+			for i, m := range t.Models {
+				value := formatRef(int(m), "model", syms)
+				if t.Shapes != nil {
+					value += "," + strconv.Itoa(int(t.Shapes[i]))
+				}
+				lines = append(lines, kv{"model", value})
+			}
+			return lines
+		},
+	}
+}
+
+var npcSchema = &schema[*NpcType]{
+	name: NpcName,
+	new:  NewNpcType,
+	props: slices.Concat(
+		[]prop[*NpcType]{
+			modelListProp("model", 1, func(t *NpcType) *[]uint16 { return &t.Models }),
+			strProp("name", 2, func(t *NpcType) *string { return &t.Name }),
+			strProp("desc", 3, func(t *NpcType) *string { return &t.Desc }),
+			numProp("size", 12, -0x80, 0x7F, func(t *NpcType) *int8 { return &t.Size }),
+			refProp("readyanim", 13, SeqName, func(t *NpcType) *int { return &t.ReadyAnim }),
+			walkAnimProp(),
+			boolProp("disposealpha", 16, func(t *NpcType) *bool { return &t.DisposeAlpha }),
+		},
+		opProps("op", 30, func(t *NpcType) *[5]string { return &t.Ops }),
+		recolProps(40, func(t *NpcType) *[]Recol { return &t.Recol }),
+		[]prop[*NpcType]{
+			modelListProp("head", 60, func(t *NpcType) *[]uint16 { return &t.Heads }),
+			numProp("code90", 90, 0, 0xFFFF, func(t *NpcType) *int { return &t.Code90 }),
+			numProp("code91", 91, 0, 0xFFFF, func(t *NpcType) *int { return &t.Code91 }),
+			numProp("code92", 92, 0, 0xFFFF, func(t *NpcType) *int { return &t.Code92 }),
+			boolProp("minimap", 93, func(t *NpcType) *bool { return &t.Minimap }),
+			numProp("vislevel", 95, 0, 0xFFFF, func(t *NpcType) *int { return &t.VisLevel }),
+			numProp("resizeh", 97, 0, 0xFFFF, func(t *NpcType) *int { return &t.ResizeH }),
+			numProp("resizev", 98, 0, 0xFFFF, func(t *NpcType) *int { return &t.ResizeV }),
+		},
+		statProps(),
+		[]prop[*NpcType]{
+			numProp("huntrange", 201, 0, 0xFF, func(t *NpcType) *int { return &t.HuntRange }),
+			numProp("respawnrate", 202, 0, 0xFFFF, func(t *NpcType) *int { return &t.RespawnRate }),
+			refProp("category", 203, "category", func(t *NpcType) *int { return &t.Category }),
+			paramProp(func(t *NpcType) *Params { return &t.Params }),
+		},
+	),
+}
+
+// statProps are the attack to magic keys, all under a single opcode.
+func statProps() []prop[*NpcType] {
+	keys := []string{"attack", "defence", "strength", "hitpoints", "ranged", "magic"}
+	var props []prop[*NpcType]
+	for i, key := range keys {
+		p := numProp(key, 200, 0, 0xFFFF, func(t *NpcType) *int { return &t.Stats[i] })
+		p.format = nil
+		if i == 0 {
+			p.format = func(t *NpcType, _ *Symbols) []kv {
+				lines := make([]kv, len(keys))
+				for j, key := range keys {
+					lines[j] = kv{key, strconv.Itoa(t.Stats[j])}
+				}
+				return lines
+			}
+		}
+		props = append(props, p)
+	}
+	return props
+}
+
+// walkAnimProp is "walk" or "walk,back,right,left".
+func walkAnimProp() prop[*NpcType] {
+	fields := func(t *NpcType) []*int {
+		return []*int{&t.WalkAnim, &t.WalkAnimBack, &t.WalkAnimRight, &t.WalkAnimLeft}
+	}
+	return prop[*NpcType]{
+		key: "walkanim",
+		op:  14,
+		alt: 17,
+		parse: func(t *NpcType, value string, syms *Symbols) error {
+			parts := strings.Split(value, ",")
+			if len(parts) != 1 && len(parts) != 4 {
+				return fmt.Errorf("%q needs 1 or 4 values", value)
+			}
+			for i, part := range parts {
+				seq, err := parseRef(part, SeqName, syms)
+				if err != nil {
+					return err
+				}
+				*fields(t)[i] = seq
+			}
+			return nil
+		},
+		format: func(t *NpcType, syms *Symbols) []kv {
+			n := 1
+			if t.WalkAnimBack != -1 || t.WalkAnimRight != -1 || t.WalkAnimLeft != -1 {
+				n = 4
+			}
+			values := make([]string, n)
+			for i, f := range fields(t)[:n] {
+				values[i] = formatRef(*f, SeqName, syms)
+			}
+			return []kv{{"walkanim", strings.Join(values, ",")}}
+		},
+	}
+}
+
+var seqSchema = &schema[*SeqType]{
+	name: SeqName,
+	new:  NewSeqType,
+	props: []prop[*SeqType]{
+		{
+			key: "frame",
+			op:  1,
+			parse: func(t *SeqType, value string, _ *Symbols) error {
+				parts, err := splitValues(value, 3)
+				if err != nil {
+					return err
+				}
+				var f [3]uint16
+				for i, part := range parts {
+					if f[i], err = parseNum[uint16](part, 0, 0xFFFF); err != nil {
+						return err
+					}
+				}
+				t.Frames = append(t.Frames, SeqFrame{Frame: f[0], IFrame: f[1], Delay: f[2]})
+				return nil
+			},
+			format: func(t *SeqType, _ *Symbols) []kv {
+				var lines []kv
+				for _, f := range t.Frames {
+					lines = append(lines, kv{"frame", fmt.Sprintf("%d,%d,%d", f.Frame, f.IFrame, f.Delay)})
+				}
+				return lines
+			},
+		},
+		numProp("replayoff", 2, 0, 0xFFFF, func(t *SeqType) *int { return &t.ReplayOff }),
+		{
+			key: "walkmerge",
+			op:  3,
+			parse: func(t *SeqType, value string, _ *Symbols) error {
+				t.WalkMerge = nil
+				for _, part := range strings.Split(value, ",") {
+					label, err := parseNum[uint8](part, 0, 0xFF)
+					if err != nil {
+						return err
+					}
+					t.WalkMerge = append(t.WalkMerge, label)
+				}
+				return nil
+			},
+			format: func(t *SeqType, _ *Symbols) []kv {
+				labels := make([]string, len(t.WalkMerge))
+				for i, label := range t.WalkMerge {
+					labels[i] = strconv.Itoa(int(label))
+				}
+				return []kv{{"walkmerge", strings.Join(labels, ",")}}
+			},
+		},
+		boolProp("stretches", 4, func(t *SeqType) *bool { return &t.Stretches }),
+		numProp("priority", 5, 0, 0xFF, func(t *SeqType) *uint8 { return &t.Priority }),
+		refProp("righthand", 6, ObjName, func(t *SeqType) *int { return &t.RightHand }),
+		refProp("lefthand", 7, ObjName, func(t *SeqType) *int { return &t.LeftHand }),
+		numProp("replaycount", 8, 0, 0xFF, func(t *SeqType) *uint8 { return &t.ReplayCount }),
+	},
+}
+
+var idkSchema = &schema[*IdkType]{
+	name: IdkName,
+	new:  NewIdkType,
+	props: slices.Concat(
+		[]prop[*IdkType]{
+			numProp("type", 1, 0, 0xFF, func(t *IdkType) *int { return &t.Type }),
+			modelListProp("model", 2, func(t *IdkType) *[]uint16 { return &t.Models }),
+			boolProp("disable", 3, func(t *IdkType) *bool { return &t.Disable }),
+		},
+		recolArrayProps(40, 50,
+			func(t *IdkType) *[10]uint16 { return &t.RecolSrc },
+			func(t *IdkType) *[10]uint16 { return &t.RecolDst }),
+		headProps(),
+	),
+}
+
+// headProps are the head1 to head10 keys of an identity kit.
+func headProps() []prop[*IdkType] {
+	var props []prop[*IdkType]
+	for i := range 10 {
+		props = append(props, refProp(fmt.Sprintf("head%d", i+1), uint8(60+i), "model",
+			func(t *IdkType) *int { return &t.Heads[i] }))
+	}
+	return props
+}
+
+var floSchema = &schema[*FloType]{
+	name: FloName,
+	new:  NewFloType,
+	props: []prop[*FloType]{
+		{
+			key: "colour",
+			op:  1,
+			parse: func(t *FloType, value string, _ *Symbols) (err error) {
+				t.RGB, err = parseNum[uint32](value, 0, 0xFFFFFF)
+				return err
+			},
+			format: func(t *FloType, _ *Symbols) []kv {
+				return []kv{{"colour", fmt.Sprintf("0x%06X", t.RGB)}}
+			},
+		},
+		numProp("texture", 2, 0, 0xFF, func(t *FloType) *int { return &t.Texture }),
+		boolProp("overlay", 3, func(t *FloType) *bool { return &t.Overlay }),
+		boolProp("occlude", 5, func(t *FloType) *bool { return &t.Occlude }),
+		strProp("name", 6, func(t *FloType) *string { return &t.Name }),
+	},
+}
+
+var spotAnimSchema = &schema[*SpotAnimType]{
+	name: SpotAnimName,
+	new:  NewSpotAnimType,
+	props: slices.Concat(
+		[]prop[*SpotAnimType]{
+			refProp("model", 1, "model", func(t *SpotAnimType) *int { return &t.Model }),
+			refProp("anim", 2, SeqName, func(t *SpotAnimType) *int { return &t.Anim }),
+			boolProp("disposealpha", 3, func(t *SpotAnimType) *bool { return &t.DisposeAlpha }),
+			numProp("resizeh", 4, 0, 0xFFFF, func(t *SpotAnimType) *int { return &t.ResizeH }),
+			numProp("resizev", 5, 0, 0xFFFF, func(t *SpotAnimType) *int { return &t.ResizeV }),
+			numProp("orientation", 6, 0, 0xFFFF, func(t *SpotAnimType) *int { return &t.Orientation }),
+			numProp("ambient", 7, 0, 0xFF, func(t *SpotAnimType) *uint8 { return &t.Ambient }),
+			numProp("contrast", 8, 0, 0xFF, func(t *SpotAnimType) *uint8 { return &t.Contrast }),
+		},
+		recolArrayProps(40, 50,
+			func(t *SpotAnimType) *[10]uint16 { return &t.RecolSrc },
+			func(t *SpotAnimType) *[10]uint16 { return &t.RecolDst }),
+	),
+}
+
+var varpSchema = &schema[*VarpType]{
+	name: VarpName,
+	new:  NewVarpType,
+	props: []prop[*VarpType]{
+		numProp("code1", 1, 0, 0xFF, func(t *VarpType) *uint8 { return &t.Code1 }),
+		numProp("code2", 2, 0, 0xFF, func(t *VarpType) *uint8 { return &t.Code2 }),
+		boolProp("code3", 3, func(t *VarpType) *bool { return &t.Code3 }),
+		boolProp("code4", 4, func(t *VarpType) *bool { return &t.Code4 }),
+		numProp("clientcode", 5, 0, 0xFFFF, func(t *VarpType) *uint16 { return &t.ClientCode }),
+		boolProp("code6", 6, func(t *VarpType) *bool { return &t.Code6 }),
+		numProp("code7", 7, 0, 0xFFFFFFFF, func(t *VarpType) *uint32 { return &t.Code7 }),
+		boolProp("code8", 8, func(t *VarpType) *bool { return &t.Code8 }),
+		strProp("code10", 10, func(t *VarpType) *string { return &t.Code10 }),
+	},
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A SeqFrame is one frame of an animation.
+type SeqFrame struct {
+	Frame  uint16
+	IFrame uint16 // interface frame, 0xFFFF for none
+	Delay  uint16 // in client ticks, 0 to use the frame's own
+}
+
+// A SeqType is an animation config.
+type SeqType struct {
+	opOrder
+
+	Frames      []SeqFrame
+	ReplayOff   int
+	WalkMerge   []uint8 // labels the walk animation still plays on
+	Stretches   bool
+	Priority    uint8
+	RightHand   int // obj model replacing the right hand, 0 to hide it
+	LeftHand    int
+	ReplayCount uint8
+}
+
+// NewSeqType returns an animation config with the client's defaults.
+func NewSeqType() *SeqType {
+	return &SeqType{
+		ReplayOff:   -1,
+		Priority:    5,
+		RightHand:   -1,
+		LeftHand:    -1,
+		ReplayCount: 99,
+	}
+}
+
+// ReadSeqTypes decodes every animation config in the config archive.
+func ReadSeqTypes(jf *io.Jagfile) ([]*SeqType, error) {
+	return readAll(jf, SeqName, NewSeqType)
+}
+
+// WriteSeqTypes queues seqs to be written to the config archive.
+func WriteSeqTypes(jf *io.Jagfile, seqs []*SeqType) {
+	writeAll(jf, SeqName, seqs, false)
+}
+
+// Decode gets the config from p.
+func (t *SeqType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p.
+func (t *SeqType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *SeqType) decodeOp(p *packet.Packet, op uint8) error {
+	switch op {
+	case 1:
+		t.Frames = make([]SeqFrame, p.G1())
+		for i := range t.Frames {
+			t.Frames[i] = SeqFrame{Frame: p.G2(), IFrame: p.G2(), Delay: p.G2()}
+		}
+	case 2:
+		t.ReplayOff = int(p.G2())
+	case 3:
+		t.WalkMerge = make([]uint8, p.G1())
+		for i := range t.WalkMerge {
+			t.WalkMerge[i] = p.G1()
+		}
+	case 4:
+		t.Stretches = true
+	case 5:
+		t.Priority = p.G1()
+	case 6:
+		t.RightHand = int(p.G2())
+	case 7:
+		t.LeftHand = int(p.G2())
+	case 8:
+		t.ReplayCount = p.G1()
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *SeqType) encodeOp(p *packet.Packet, op uint8) {
+	switch op {
+	case 1:
+		p.P1(uint8(len(t.Frames)))
+		for _, f := range t.Frames {
+			p.P2(f.Frame)
+			p.P2(f.IFrame)
+			p.P2(f.Delay)
+		}
+	case 2:
+		p.P2(uint16(t.ReplayOff))
+	case 3:
+		p.P1(uint8(len(t.WalkMerge)))
+		for _, label := range t.WalkMerge {
+			p.P1(label)
+		}
+	case 5:
+		p.P1(t.Priority)
+	case 6:
+		p.P2(uint16(t.RightHand))
+	case 7:
+		p.P2(uint16(t.LeftHand))
+	case 8:
+		p.P1(t.ReplayCount)
+	}
+}
+
+func (t *SeqType) isFlag(op uint8) bool {
+	return op == 4
+}
+
+func (t *SeqType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, len(t.Frames) != 0)
+	ops = appendIf(ops, 2, t.ReplayOff != -1)
+	ops = appendIf(ops, 3, len(t.WalkMerge) != 0)
+	ops = appendIf(ops, 4, t.Stretches)
+	ops = appendIf(ops, 5, t.Priority != 5)
+	ops = appendIf(ops, 6, t.RightHand != -1)
+	ops = appendIf(ops, 7, t.LeftHand != -1)
+	ops = appendIf(ops, 8, t.ReplayCount != 99)
+	return ops
+}
@@ -0,0 +1,616 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Source files hold configs as blocks of key=value lines, one block per
+// config, headed by the config's symbol:
+//
+//	// comment
+//	[bronze_sword]
+//	name=Bronze sword
+//	model=2604
+//	op3=Take
+//
+// A source file's extension is its config type, such as .obj, and ids
+// come from the type's symbol file. Keys are compiled in the order they
+// are written, so a decompiled archive compiles back to the same bytes.
+// Ids of other configs and models may be given by symbol or number.
+
+// DecompiledName is the name of the source files written by
+// [Decompile], followed by the config type's extension.
+const DecompiledName = "all"
+
+// A kv is a line of a source file.
+type kv struct {
+	key   string
+	value string
+}
+
+// A prop is a key of a config type's source.
+type prop[T config] struct {
+	key string
+	op  uint8
+	// alt is another opcode the key may compile to; the config's values
+	// decide which.
+	alt   uint8
+	parse func(t T, value string, syms *Symbols) error
+	// format returns the lines for op. It is nil if another prop with
+	// the same opcode writes them.
+	format func(t T, syms *Symbols) []kv
+}
+
+// A schema describes the source of a config type.
+type schema[T config] struct {
+	name  string
+	new   func() T
+	props []prop[T]
+}
+
+// A sourceType is a schema of any config type.
+type sourceType interface {
+	typeName() string
+	compile(paths []string, syms *Symbols, jf *io.Jagfile, server bool) error
+	compilePrebuilt(src string, jf *io.Jagfile, server bool) error
+	decompile(jf *io.Jagfile, syms *Symbols) ([]byte, error)
+}
+
+// sourceTypes are the config types with a source format, in the order
+// they are compiled.
+var sourceTypes = []sourceType{
+	floSchema,
+	idkSchema,
+	locSchema,
+	npcSchema,
+	objSchema,
+	seqSchema,
+	spotAnimSchema,
+	varpSchema,
+}
+
+// Compile compiles the config sources in src, and the symbol files
+// beside them, into jf. Sources may be in subdirectories. A type with
+// no sources is taken from a prebuilt <type>.dat and <type>.idx in src
+// if present. Server-only properties are left out, including those of
+// prebuilt files. It has the signature of a pack.Compiler for the
+// config archive.
+func Compile(src string, jf *io.Jagfile) error {
+	return compile(src, jf, false)
+}
+
+// CompileServer is like [Compile] but keeps server-only properties, for
+// the server's copy of the config archive. A prebuilt type has only the
+// server-only properties its files hold.
+func CompileServer(src string, jf *io.Jagfile) error {
+	return compile(src, jf, true)
+}
+
+func compile(src string, jf *io.Jagfile, server bool) error {
+	syms, err := LoadSymbols(src)
+	if err != nil {
+		return err
+	}
+	sources, err := findSources(src)
+	if err != nil {
+		return err
+	}
+
+	for _, st := range sourceTypes {
+		paths := sources[st.typeName()]
+		if len(paths) == 0 {
+			if err := st.compilePrebuilt(src, jf, server); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := st.compile(paths, syms, jf, server); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decompile writes every config type in jf to dst as a source file
+// named [DecompiledName] and updates the symbol files in dst, naming
+// new ids after their type, such as obj_12.
+func Decompile(jf *io.Jagfile, dst string) error {
+	syms, err := LoadSymbols(dst)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	for _, st := range sourceTypes {
+		if _, err := jf.Read(st.typeName() + ".dat"); err != nil {
+			continue
+		}
+		data, err := st.decompile(jf, syms)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dst, DecompiledName+"."+st.typeName()), data, 0644); err != nil {
+			return err
+		}
+	}
+	return syms.Save(dst)
+}
+
+// findSources returns the source files under src by config type.
+func findSources(src string) (map[string][]string, error) {
+	sources := make(map[string][]string)
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			ext := strings.TrimPrefix(filepath.Ext(path), ".")
+			sources[ext] = append(sources[ext], path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return sources, err
+}
+
+func (s *schema[T]) typeName() string { return s.name }
+
+func (s *schema[T]) byKey(key string) *prop[T] {
+	for i := range s.props {
+		if s.props[i].key == key {
+			return &s.props[i]
+		}
+	}
+	return nil
+}
+
+func (s *schema[T]) byOp(op uint8) *prop[T] {
+	for i := range s.props {
+		p := &s.props[i]
+		if (p.op == op || (p.alt != 0 && p.alt == op)) && p.format != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// A block is a config being parsed.
+type block[T config] struct {
+	config T
+	used   []*prop[T]
+}
+
+func (s *schema[T]) compile(paths []string, syms *Symbols, jf *io.Jagfile, server bool) error {
+	count := syms.Count(s.name)
+	configs := make([]T, count)
+	defined := make([]string, count)
+
+	slices.Sort(paths)
+	for _, path := range paths {
+		if err := s.compileFile(path, syms, configs, defined); err != nil {
+			return err
+		}
+	}
+
+	for id, where := range defined {
+		if where == "" {
+			name, _ := syms.Name(s.name, id)
+			return fmt.Errorf("config: %s %d (%s) has no source", s.name, id, name)
+		}
+	}
+	writeAll(jf, s.name, configs, server)
+	return nil
+}
+
+// compilePrebuilt writes the configs in src/<type>.dat and
+// src/<type>.idx into jf if both exist. They are decoded and encoded
+// again so that server-only properties are only kept if server is set.
+func (s *schema[T]) compilePrebuilt(src string, jf *io.Jagfile, server bool) error {
+	prebuilt := &io.Jagfile{}
+	for _, file := range []string{s.name + ".dat", s.name + ".idx"} {
+		p, err := packet.Load(filepath.Join(src, file), false)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		prebuilt.Write(file, p)
+	}
+	if err := prebuilt.ApplyQueue(); err != nil {
+		return fmt.Errorf("config: prebuilt %s: %w", s.name, err)
+	}
+
+	configs, err := readAll(prebuilt, s.name, s.new)
+	if err != nil {
+		return err
+	}
+	writeAll(jf, s.name, configs, server)
+	return nil
+}
+
+func (s *schema[T]) compileFile(path string, syms *Symbols, configs []T, defined []string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var b *block[T]
+	sc := bufio.NewScanner(f)
+	line := 0
+	fail := func(err error) error {
+		return &SourceError{File: path, Line: line, Err: err}
+	}
+	for sc.Scan() {
+		line++
+		text := strings.TrimRight(sc.Text(), "\r")
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+
+		if name, ok := strings.CutPrefix(trimmed, "["); ok {
+			name, ok = strings.CutSuffix(name, "]")
+			if !ok {
+				return fail(fmt.Errorf("malformed header %q", trimmed))
+			}
+			if b != nil {
+				b.finish()
+			}
+			id, ok := syms.ID(s.name, name)
+			if !ok {
+				return fail(fmt.Errorf("%s %q is not in %s%s", s.name, name, s.name, SymbolExt))
+			}
+			if defined[id] != "" {
+				return fail(fmt.Errorf("%s %q is already defined at %s", s.name, name, defined[id]))
+			}
+			defined[id] = fmt.Sprintf("%s:%d", path, line)
+			b = &block[T]{config: s.new()}
+			configs[id] = b.config
+			continue
+		}
+
+		key, value, ok := strings.Cut(text, "=")
+		key = strings.TrimSpace(key)
+		if !ok {
+			return fail(fmt.Errorf("malformed line %q", trimmed))
+		}
+		if b == nil {
+			return fail(fmt.Errorf("%s before the first config", key))
+		}
+		p := s.byKey(key)
+		if p == nil {
+			return fail(fmt.Errorf("unknown %s key %q", s.name, key))
+		}
+		if err := p.parse(b.config, value, syms); err != nil {
+			return fail(fmt.Errorf("%s: %w", key, err))
+		}
+		if !slices.Contains(b.used, p) {
+			b.used = append(b.used, p)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if b != nil {
+		b.finish()
+	}
+	return nil
+}
+
+// finish records the opcodes of the keys used, in order, so they are
+// encoded in that order.
+func (b *block[T]) finish() {
+	c := b.config
+	var needed [256]bool
+	for _, op := range c.ops() {
+		needed[op] = true
+	}
+
+	o := c.recorded()
+	o.order = o.order[:0]
+	for _, p := range b.used {
+		op := p.op
+		if p.alt != 0 && needed[p.alt] {
+			op = p.alt
+		}
+		if (c.isFlag(op) && !needed[op]) || slices.Contains(o.order, op) {
+			continue
+		}
+		o.order = append(o.order, op)
+	}
+}
+
+func (s *schema[T]) decompile(jf *io.Jagfile, syms *Symbols) ([]byte, error) {
+	configs, err := readAll(jf, s.name, s.new)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for id, c := range configs {
+		name, ok := syms.Name(s.name, id)
+		if !ok {
+			name = fmt.Sprintf("%s_%d", s.name, id)
+			if err := syms.Add(s.name, id, name); err != nil {
+				return nil, fmt.Errorf("config: %w", err)
+			}
+		}
+		if id > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "[%s]\n", name)
+		for _, op := range encodeOrder(c, true) {
+			p := s.byOp(op)
+			if p == nil {
+				return nil, fmt.Errorf("config: %s %d: no key for opcode %d", s.name, id, op)
+			}
+			for _, l := range p.format(c, syms) {
+				fmt.Fprintf(&buf, "%s=%s\n", l.key, l.value)
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Value parsing and formatting shared by the schemas.
+
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~uint8 | ~uint16 | ~uint32
+}
+
+func parseNum[N integer](value string, lo, hi int64) (N, error) {
+	v, err := strconv.ParseInt(strings.TrimSpace(value), 0, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	if v < lo || v > hi {
+		return 0, fmt.Errorf("%d out of range %d to %d", v, lo, hi)
+	}
+	return N(v), nil
+}
+
+// configKinds are the kinds of reference whose symbol file lists every
+// config in the archive. The symbol files of other kinds, such as model,
+// only name some ids.
+var configKinds = []string{FloName, IdkName, LocName, NpcName, ObjName, SeqName, SpotAnimName, VarpName}
+
+// parseRef parses an id of kind given by symbol or number, or "none"
+// for -1. A number must be below 0xFFFF, which encodes none, and if kind
+// is a config type with symbols, below their count.
+func parseRef(value string, kind string, syms *Symbols) (int, error) {
+	value = strings.TrimSpace(value)
+	if value == "none" {
+		return -1, nil
+	}
+	if id, ok := syms.ID(kind, value); ok {
+		return id, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("unknown %s %q", kind, value)
+	}
+	if id < 0 || id >= 0xFFFF {
+		return 0, fmt.Errorf("%s %d out of range", kind, id)
+	}
+	if n := syms.Count(kind); n > 0 && id >= n && slices.Contains(configKinds, kind) {
+		return 0, fmt.Errorf("%s %d does not exist, there are %d", kind, id, n)
+	}
+	return id, nil
+}
+
+func formatRef(id int, kind string, syms *Symbols) string {
+	if id == -1 {
+		return "none"
+	}
+	if name, ok := syms.Name(kind, id); ok {
+		return name
+	}
+	return strconv.Itoa(id)
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.TrimSpace(value) {
+	case "yes", "true":
+		return true, nil
+	case "no", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("%q is not yes or no", value)
+}
+
+func formatBool(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+// splitValues splits a comma separated value into exactly n parts.
+func splitValues(value string, n int) ([]string, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != n {
+		return nil, fmt.Errorf("%q needs %d values", value, n)
+	}
+	return parts, nil
+}
+
+// Prop constructors.
+
+func numProp[T config, N integer](key string, op uint8, lo, hi int64, field func(T) *N) prop[T] {
+	return prop[T]{
+		key: key,
+		op:  op,
+		parse: func(t T, value string, _ *Symbols) (err error) {
+			*field(t), err = parseNum[N](value, lo, hi)
+			return err
+		},
+		format: func(t T, _ *Symbols) []kv {
+			return []kv{{key, strconv.FormatInt(int64(*field(t)), 10)}}
+		},
+	}
+}
+
+func refProp[T config](key string, op uint8, kind string, field func(T) *int) prop[T] {
+	return prop[T]{
+		key: key,
+		op:  op,
+		parse: func(t T, value string, syms *Symbols) (err error) {
+			*field(t), err = parseRef(value, kind, syms)
+			return err
+		},
+		format: func(t T, syms *Symbols) []kv {
+			return []kv{{key, formatRef(*field(t), kind, syms)}}
+		},
+	}
+}
+
+func strProp[T config](key string, op uint8, field func(T) *string) prop[T] {
+	return prop[T]{
+		key: key,
+		op:  op,
+		parse: func(t T, value string, _ *Symbols) error {
+			if value == "" {
+				return errors.New("empty string")
+			}
+			*field(t) = value
+			return nil
+		},
+		format: func(t T, _ *Symbols) []kv {
+			return []kv{{key, *field(t)}}
+		},
+	}
+}
+
+// boolProp is a key for a flag opcode; the config's value is written.
+func boolProp[T config](key string, op uint8, field func(T) *bool) prop[T] {
+	return prop[T]{
+		key: key,
+		op:  op,
+		parse: func(t T, value string, _ *Symbols) (err error) {
+			*field(t), err = parseBool(value)
+			return err
+		},
+		format: func(t T, _ *Symbols) []kv {
+			return []kv{{key, formatBool(*field(t))}}
+		},
+	}
+}
+
+// recolProps are the recol1s/recol1d to recol10s/recol10d keys for a
+// list of recols under a single opcode.
+func recolProps[T config](op uint8, field func(T) *[]Recol) []prop[T] {
+	var props []prop[T]
+	for i := range 10 {
+		for _, dst := range []bool{false, true} {
+			key := fmt.Sprintf("recol%ds", i+1)
+			if dst {
+				key = fmt.Sprintf("recol%dd", i+1)
+			}
+			p := prop[T]{
+				key: key,
+				op:  op,
+				parse: func(t T, value string, _ *Symbols) error {
+					c, err := parseNum[uint16](value, 0, 0xFFFF)
+					if err != nil {
+						return err
+					}
+					recols := field(t)
+					if len(*recols) < i {
+						return fmt.Errorf("recol%d is missing", len(*recols)+1)
+					}
+					if len(*recols) == i {
+						*recols = append(*recols, Recol{})
+					}
+					if dst {
+						(*recols)[i].To = c
+					} else {
+						(*recols)[i].From = c
+					}
+					return nil
+				},
+			}
+			if i == 0 && !dst {
+				p.format = func(t T, _ *Symbols) []kv {
+					var lines []kv
+					for j, r := range *field(t) {
+						lines = append(lines,
+							kv{fmt.Sprintf("recol%ds", j+1), strconv.Itoa(int(r.From))},
+							kv{fmt.Sprintf("recol%dd", j+1), strconv.Itoa(int(r.To))})
+					}
+					return lines
+				}
+			}
+			props = append(props, p)
+		}
+	}
+	return props
+}
+
+// recolArrayProps are the recol1s/recol1d to recol10s/recol10d keys for
+// recols with an opcode each, from srcOp and dstOp.
+func recolArrayProps[T config](srcOp, dstOp uint8, src, dst func(T) *[10]uint16) []prop[T] {
+	var props []prop[T]
+	for i := range 10 {
+		props = append(props,
+			numProp(fmt.Sprintf("recol%ds", i+1), srcOp+uint8(i), 0, 0xFFFF, func(t T) *uint16 { return &src(t)[i] }),
+			numProp(fmt.Sprintf("recol%dd", i+1), dstOp+uint8(i), 0, 0xFFFF, func(t T) *uint16 { return &dst(t)[i] }))
+	}
+	return props
+}
+
+// opProps are the op1 to op5 keys from opcode first.
+func opProps[T config](prefix string, first uint8, field func(T) *[5]string) []prop[T] {
+	var props []prop[T]
+	for i := range 5 {
+		props = append(props, strProp(fmt.Sprintf("%s%d", prefix, i+1), first+uint8(i), func(t T) *string { return &field(t)[i] }))
+	}
+	return props
+}
+
+// modelListProp is a key repeated once per model.
+func modelListProp[T config](key string, op uint8, field func(T) *[]uint16) prop[T] {
+	return prop[T]{
+		key: key,
+		op:  op,
+		parse: func(t T, value string, syms *Symbols) error {
+			id, err := parseRef(value, "model", syms)
+			if err != nil || id == -1 {
+				return cmpErr(err, "model cannot be none")
+			}
+			*field(t) = append(*field(t), uint16(id))
+			return nil
+		},
+		format: func(t T, syms *Symbols) []kv {
+			var lines []kv
+			for _, m := range *field(t) {
+				lines = append(lines, kv{key, formatRef(int(m), "model", syms)})
+			}
+			return lines
+		},
+	}
+}
+
+// cmpErr returns err, or a new error with text if err is nil.
+func cmpErr(err error, text string) error {
+	if err != nil {
+		return err
+	}
+	return errors.New(text)
+}
@@ -0,0 +1,453 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/internal/projectpath"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// checkSameConfigs checks every config file in want is in got with the
+// same bytes.
+func checkSameConfigs(t *testing.T, got, want *io.Jagfile) {
+	t.Helper()
+
+	for _, st := range sourceTypes {
+		for _, file := range []string{st.typeName() + ".dat", st.typeName() + ".idx"} {
+			w, err := want.Read(file)
+			if err != nil {
+				continue
+			}
+			g, err := got.Read(file)
+			if err != nil {
+				t.Errorf("%s: %v", file, err)
+				continue
+			}
+			if !bytes.Equal(g.Buf, w.Buf) {
+				t.Errorf("%s = % x, want % x", file, g.Buf, w.Buf)
+			}
+		}
+	}
+}
+
+func compileDir(t *testing.T, dir string) *io.Jagfile {
+	t.Helper()
+	return compileDirWith(t, dir, Compile)
+}
+
+func compileDirWith(t *testing.T, dir string, compile func(string, *io.Jagfile) error) *io.Jagfile {
+	t.Helper()
+
+	jf := &io.Jagfile{}
+	if err := compile(dir, jf); err != nil {
+		t.Fatalf("compile error = %v", err)
+	}
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	return jf
+}
+
+func TestDecompile_RoundTrip(t *testing.T) {
+	// each config of configTests under its type, followed by default
+	// configs up to the seqs and objs the test configs refer to
+	counts := map[string]int{SeqName: 823, ObjName: 998}
+	configs := make(map[string][][]byte)
+	for _, tt := range configTests {
+		name := strings.Fields(tt.name)[0]
+		configs[name] = append(configs[name], tt.data)
+	}
+	want := &io.Jagfile{}
+	for name, list := range configs {
+		for len(list) < max(counts[name], len(configs[name])+1) {
+			list = append(list, []byte{0})
+		}
+		dat := packet.NewPacket(nil)
+		idx := packet.NewPacket(nil)
+		dat.P2(uint16(len(list)))
+		idx.P2(uint16(len(list)))
+		for _, data := range list {
+			dat.PData(data, len(data))
+			idx.P2(uint16(len(data)))
+		}
+		want.Write(name+".dat", dat)
+		want.Write(name+".idx", idx)
+	}
+	if err := want.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	if err := Decompile(want, dir); err != nil {
+		t.Fatalf("Decompile() error = %v", err)
+	}
+	checkSameConfigs(t, compileDirWith(t, dir, CompileServer), want)
+
+	src, err := os.ReadFile(filepath.Join(dir, DecompiledName+"."+NpcName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"attack=1", "hitpoints=5", "magic=1", "huntrange=3", "category=4", "param=7,9", `param=8,"goblin"`} {
+		if !bytes.Contains(src, []byte(line+"\n")) {
+			t.Errorf("decompiled npc source lacks %q:\n%s", line, src)
+		}
+	}
+
+	src, err = os.ReadFile(filepath.Join(dir, DecompiledName+"."+ObjName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"[obj_0]", "name=Bronze sword", "2dxof=-2", "stackable=yes", "manwear=500,-6", "op4=Take",
+		"recol1s=8191", "recol1d=2570", "count1=997,2", "[obj_1]"} {
+		if !bytes.Contains(src, []byte(line+"\n")) {
+			t.Errorf("decompiled obj source lacks %q:\n%s", line, src)
+		}
+	}
+
+	// decompiling again keeps the names chosen by hand
+	if err := os.WriteFile(filepath.Join(dir, LocName+SymbolExt), []byte("0=tree\n1=oak\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Decompile(want, dir); err != nil {
+		t.Fatalf("Decompile() again error = %v", err)
+	}
+	src, err = os.ReadFile(filepath.Join(dir, DecompiledName+"."+LocName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(src, []byte("[tree]\nmodel=1276,10\n")) {
+		t.Errorf("decompiled loc source:\n%s", src)
+	}
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"obj.pack":   "0=coins\n1=cert_coins\n// a comment\n2=template\n",
+		"seq.pack":   "0=idle\n",
+		"model.pack": "2400=model_coins\n",
+		"items/coins.obj": `// money
+[coins]
+name=Coins
+stackable=yes
+model=model_coins
+members=no
+certlink=cert_coins
+
+[cert_coins]
+certtemplate=template
+certlink=coins
+`,
+		"items/template.obj": "[template]\nname=Bank note\ncode10=idle\nmodel=5000\n",
+		"seq.seq":            "[idle]\nframe=1,65535,4\nframe=2,65535,0\nwalkmerge=1,2\n",
+	})
+
+	jf := compileDir(t, dir)
+	objs, err := ReadObjTypes(jf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objs) != 3 {
+		t.Fatalf("got %d objs, want 3", len(objs))
+	}
+	coins := objs[0]
+	if coins.Name != "Coins" || !coins.Stackable || coins.Model != 2400 || coins.Members || coins.CertLink != 1 {
+		t.Errorf("coins = %+v", coins)
+	}
+	if objs[1].CertTemplate != 2 || objs[1].CertLink != 0 || objs[2].Code10 != 0 || objs[2].Model != 5000 {
+		t.Errorf("objs = %+v, %+v", objs[1], objs[2])
+	}
+
+	// keys are compiled in the order written; members=no writes nothing
+	p := packet.NewPacket(nil)
+	coins.Encode(p)
+	want := cat([]byte{2}, str("Coins"), []byte{11, 1, 0x09, 0x60, 97, 0, 1, 0})
+	if !bytes.Equal(p.Buf, want) {
+		t.Errorf("coins = % x, want % x", p.Buf, want)
+	}
+
+	seqs, err := ReadSeqTypes(jf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seqs) != 1 || len(seqs[0].Frames) != 2 || seqs[0].Frames[0].Delay != 4 || len(seqs[0].WalkMerge) != 2 {
+		t.Errorf("seqs = %+v", seqs)
+	}
+	if _, err := jf.Read(LocName + ".dat"); err == nil {
+		t.Error("loc.dat written without loc sources")
+	}
+}
+
+func TestCompile_server(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"npc.pack":   "0=goblin\n",
+		"param.pack": "3=death_drop\n",
+		"goblin.npc": "[goblin]\nname=Goblin\nhitpoints=5\nstrength=2\nrespawnrate=50\nparam=death_drop,\"bones\"\nparam=4,-1\n",
+	})
+
+	npcs, err := ReadNpcTypes(compileDir(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := packet.NewPacket(nil)
+	npcs[0].Encode(p)
+	if want := cat([]byte{2}, str("Goblin"), []byte{0}); !bytes.Equal(p.Buf, want) {
+		t.Errorf("Compile() goblin = % x, want % x", p.Buf, want)
+	}
+
+	npcs, err = ReadNpcTypes(compileDirWith(t, dir, CompileServer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	goblin := npcs[0]
+	if goblin.HitPoints() != 5 || goblin.Level(StatStrength) != 2 || goblin.Level(StatAttack) != 1 || goblin.RespawnRate != 50 {
+		t.Errorf("CompileServer() goblin = %+v", goblin)
+	}
+	want := Params{{Key: 3, IsString: true, Str: "bones"}, {Key: 4, Int: -1}}
+	if !reflect.DeepEqual(goblin.Params, want) {
+		t.Errorf("CompileServer() params = %+v, want %+v", goblin.Params, want)
+	}
+}
+
+func TestCompile_prebuilt(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"varp.dat": "\x00\x01\x00",
+		"varp.idx": "\x00\x01\x00\x01",
+		"flo.dat":  "only half of a pair",
+	})
+
+	jf := compileDir(t, dir)
+	varps, err := ReadVarpTypes(jf)
+	if err != nil || len(varps) != 1 {
+		t.Errorf("ReadVarpTypes() = %v, %v", varps, err)
+	}
+	if _, err := jf.Read(FloName + ".dat"); err == nil {
+		t.Error("flo.dat copied without flo.idx")
+	}
+}
+
+func TestCompile_prebuiltServerOps(t *testing.T) {
+	goblin := NewNpcType()
+	goblin.Name = "Goblin"
+	goblin.HuntRange = 3
+	goblin.Params.SetInt(7, 9)
+	want := &io.Jagfile{}
+	WriteServerNpcTypes(want, []*NpcType{NewNpcType(), goblin})
+	if err := want.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for _, file := range []string{NpcName + ".dat", NpcName + ".idx"} {
+		p, err := want.Read(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFiles(t, dir, map[string]string{file: string(p.Buf)})
+	}
+
+	npcs, err := ReadNpcTypes(compileDir(t, dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(npcs) != 2 || npcs[1].Name != "Goblin" {
+		t.Fatalf("ReadNpcTypes() = %+v, want the prebuilt npcs", npcs)
+	}
+	if _, ok := npcs[1].Params.Int(7); ok || npcs[1].Hunts() {
+		t.Errorf("client npc %+v has server-only properties", npcs[1])
+	}
+
+	got := compileDirWith(t, dir, CompileServer)
+	for _, file := range []string{NpcName + ".dat", NpcName + ".idx"} {
+		g, err := got.Read(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, _ := want.Read(file)
+		if !bytes.Equal(g.Buf, w.Buf) {
+			t.Errorf("server %s = % x, want % x", file, g.Buf, w.Buf)
+		}
+	}
+}
+
+func TestCompile_error(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown key",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\nname=A\ncolour=1\n"},
+			wantErr: `a.obj:3: unknown obj key "colour"`,
+		},
+		{
+			name:    "not in symbols",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\n\n[b]\n"},
+			wantErr: `a.obj:3: obj "b" is not in obj.pack`,
+		},
+		{
+			name:    "defined twice",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\n", "b.obj": "// again\n[a]\n"},
+			wantErr: `b.obj:2: obj "a" is already defined at `,
+		},
+		{
+			name:    "bad number",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\ncost=lots\n"},
+			wantErr: `a.obj:2: cost: "lots" is not a number`,
+		},
+		{
+			name:    "out of range",
+			files:   map[string]string{"loc.pack": "0=a\n", "a.loc": "[a]\nwidth=256\n"},
+			wantErr: `a.loc:2: width: 256 out of range 0 to 255`,
+		},
+		{
+			name:    "unknown ref",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\ncertlink=b\n"},
+			wantErr: `a.obj:2: certlink: unknown obj "b"`,
+		},
+		{
+			name:    "numeric ref past symbols",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\ncertlink=99999\n"},
+			wantErr: `a.obj:2: certlink: obj 99999 out of range`,
+		},
+		{
+			name:    "numeric ref not defined",
+			files:   map[string]string{"obj.pack": "0=a\n1=b\n", "a.obj": "[a]\ncertlink=2\n[b]\n"},
+			wantErr: `a.obj:2: certlink: obj 2 does not exist, there are 2`,
+		},
+		{
+			name:    "numeric ref none",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\ncertlink=65535\n"},
+			wantErr: `a.obj:2: certlink: obj 65535 out of range`,
+		},
+		{
+			name:    "mixed loc models",
+			files:   map[string]string{"loc.pack": "0=a\n", "a.loc": "[a]\nmodel=1,10\nmodel=2\n"},
+			wantErr: `a.loc:3: model: "2": every model needs a shape or none do`,
+		},
+		{
+			name:    "recol out of order",
+			files:   map[string]string{"npc.pack": "0=a\n", "a.npc": "[a]\nrecol2s=5\n"},
+			wantErr: `a.npc:2: recol2s: recol1 is missing`,
+		},
+		{
+			name:    "param set twice",
+			files:   map[string]string{"loc.pack": "0=a\n", "a.loc": "[a]\nparam=1,2\nparam=1,\"b\"\n"},
+			wantErr: `a.loc:3: param: param 1 is already set`,
+		},
+		{
+			name:    "key before config",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "name=A\n[a]\n"},
+			wantErr: `a.obj:1: name before the first config`,
+		},
+		{
+			name:    "malformed line",
+			files:   map[string]string{"obj.pack": "0=a\n", "a.obj": "[a]\nstackable\n"},
+			wantErr: `a.obj:2: malformed line "stackable"`,
+		},
+		{
+			name:    "missing config",
+			files:   map[string]string{"obj.pack": "0=a\n1=b\n", "a.obj": "[a]\n"},
+			wantErr: `obj 1 (b) has no source`,
+		},
+		{
+			name:    "malformed symbol",
+			files:   map[string]string{"obj.pack": "0=a\nb\n"},
+			wantErr: `obj.pack:2: malformed symbol "b"`,
+		},
+		{
+			name:    "duplicate symbol",
+			files:   map[string]string{"obj.pack": "0=a\n1=a\n"},
+			wantErr: `obj.pack:2: obj "a" is already id 0`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tt.files)
+
+			err := Compile(dir, &io.Jagfile{})
+			if err == nil {
+				t.Fatalf("Compile() error = nil, want %q", tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Compile() error = %q, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSymbols(t *testing.T) {
+	s := NewSymbols()
+	if err := s.Add(ObjName, 3, "coins"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(ObjName, 4, "coins"); err == nil {
+		t.Error("Add() duplicate name error = nil")
+	}
+	if err := s.Add(ObjName, 3, "gold"); err == nil {
+		t.Error("Add() duplicate id error = nil")
+	}
+	if got := s.Count(ObjName); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+
+	dir := t.TempDir()
+	if err := s.Save(dir); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSymbols(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, ok := loaded.ID(ObjName, "coins"); !ok || id != 3 {
+		t.Errorf("ID() = %d, %v, want 3, true", id, ok)
+	}
+	if _, ok := loaded.Name(LocName, 3); ok {
+		t.Error("Name() found a loc")
+	}
+
+	for _, name := range []string{"", "none", "123", "a-b", "a b"} {
+		if isSymbolName(name) {
+			t.Errorf("isSymbolName(%q) = true", name)
+		}
+	}
+}
+
+// TestConfigArchive_source decompiles the packed config archive and
+// checks it compiles back to the same bytes.
+func TestConfigArchive_source(t *testing.T) {
+	path := filepath.Join(projectpath.Root, "data", "pack", "client", "config")
+	want, err := io.LoadJagfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := Decompile(want, dir); err != nil {
+		t.Fatalf("Decompile() error = %v", err)
+	}
+	checkSameConfigs(t, compileDir(t, dir), want)
+}
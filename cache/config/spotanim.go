@@ -0,0 +1,123 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A SpotAnimType is a spot animation config: a model played on an
+// entity or a tile, such as a spell effect.
+type SpotAnimType struct {
+	opOrder
+
+	Model        int
+	Anim         int
+	DisposeAlpha bool
+	ResizeH      int
+	ResizeV      int
+	Orientation  int
+	Ambient      uint8
+	Contrast     uint8
+	// RecolSrc and RecolDst are the colours replaced and their
+	// replacements. A 0 source is unused.
+	RecolSrc [10]uint16
+	RecolDst [10]uint16
+}
+
+// NewSpotAnimType returns a spot animation config with the client's
+// defaults.
+func NewSpotAnimType() *SpotAnimType {
+	return &SpotAnimType{Model: -1, Anim: -1, ResizeH: 128, ResizeV: 128}
+}
+
+// ReadSpotAnimTypes decodes every spot animation config in the config
+// archive.
+func ReadSpotAnimTypes(jf *io.Jagfile) ([]*SpotAnimType, error) {
+	return readAll(jf, SpotAnimName, NewSpotAnimType)
+}
+
+// WriteSpotAnimTypes queues spotanims to be written to the config
+// archive.
+func WriteSpotAnimTypes(jf *io.Jagfile, spotanims []*SpotAnimType) {
+	writeAll(jf, SpotAnimName, spotanims, false)
+}
+
+// Decode gets the config from p.
+func (t *SpotAnimType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p.
+func (t *SpotAnimType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *SpotAnimType) decodeOp(p *packet.Packet, op uint8) error {
+	switch {
+	case op == 1:
+		t.Model = int(p.G2())
+	case op == 2:
+		t.Anim = int(p.G2())
+	case op == 3:
+		t.DisposeAlpha = true
+	case op == 4:
+		t.ResizeH = int(p.G2())
+	case op == 5:
+		t.ResizeV = int(p.G2())
+	case op == 6:
+		t.Orientation = int(p.G2())
+	case op == 7:
+		t.Ambient = p.G1()
+	case op == 8:
+		t.Contrast = p.G1()
+	case op >= 40 && op < 50:
+		t.RecolSrc[op-40] = p.G2()
+	case op >= 50 && op < 60:
+		t.RecolDst[op-50] = p.G2()
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *SpotAnimType) encodeOp(p *packet.Packet, op uint8) {
+	switch {
+	case op == 1:
+		p.P2(uint16(t.Model))
+	case op == 2:
+		p.P2(uint16(t.Anim))
+	case op == 4:
+		p.P2(uint16(t.ResizeH))
+	case op == 5:
+		p.P2(uint16(t.ResizeV))
+	case op == 6:
+		p.P2(uint16(t.Orientation))
+	case op == 7:
+		p.P1(t.Ambient)
+	case op == 8:
+		p.P1(t.Contrast)
+	case op >= 40 && op < 50:
+		p.P2(t.RecolSrc[op-40])
+	case op >= 50 && op < 60:
+		p.P2(t.RecolDst[op-50])
+	}
+}
+
+func (t *SpotAnimType) isFlag(op uint8) bool {
+	return op == 3
+}
+
+func (t *SpotAnimType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, t.Model != -1)
+	ops = appendIf(ops, 2, t.Anim != -1)
+	ops = appendIf(ops, 3, t.DisposeAlpha)
+	ops = appendIf(ops, 4, t.ResizeH != 128)
+	ops = appendIf(ops, 5, t.ResizeV != 128)
+	ops = appendIf(ops, 6, t.Orientation != 0)
+	ops = appendIf(ops, 7, t.Ambient != 0)
+	ops = appendIf(ops, 8, t.Contrast != 0)
+	for i, c := range t.RecolSrc {
+		ops = appendIf(ops, uint8(40+i), c != 0)
+	}
+	for i, c := range t.RecolDst {
+		ops = appendIf(ops, uint8(50+i), c != 0)
+	}
+	return ops
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// SymbolExt is the extension of symbol files. Each names the ids of one
+// kind, a config type or "model", with a line per id:
+//
+//	0=dwarf_remains
+//	1=tinderbox
+//
+// Lines starting with // are comments.
+const SymbolExt = ".pack"
+
+// Symbols names the ids of configs and models in source files.
+type Symbols struct {
+	ids   map[string]map[string]int
+	names map[string]map[int]string
+}
+
+// NewSymbols returns an empty symbol table.
+func NewSymbols() *Symbols {
+	return &Symbols{
+		ids:   make(map[string]map[string]int),
+		names: make(map[string]map[int]string),
+	}
+}
+
+// LoadSymbols reads every symbol file in dir. A missing dir holds no
+// symbols.
+func LoadSymbols(dir string) (*Symbols, error) {
+	s := NewSymbols()
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+SymbolExt))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := s.load(path); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func (s *Symbols) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	kind := strings.TrimSuffix(filepath.Base(path), SymbolExt)
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		ids, name, ok := strings.Cut(text, "=")
+		id, err := strconv.Atoi(ids)
+		if !ok || err != nil || id < 0 || !isSymbolName(name) {
+			return &SourceError{File: path, Line: line, Err: fmt.Errorf("malformed symbol %q", text)}
+		}
+		if err := s.Add(kind, id, name); err != nil {
+			return &SourceError{File: path, Line: line, Err: err}
+		}
+	}
+	return sc.Err()
+}
+
+// Save writes a symbol file for every kind to dir.
+func (s *Symbols) Save(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for kind, names := range s.names {
+		ids := slices.Sorted(maps.Keys(names))
+		var b strings.Builder
+		for _, id := range ids {
+			fmt.Fprintf(&b, "%d=%s\n", id, names[id])
+		}
+		if err := os.WriteFile(filepath.Join(dir, kind+SymbolExt), []byte(b.String()), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add names the id of kind. Names and ids must be unique within a kind.
+func (s *Symbols) Add(kind string, id int, name string) error {
+	if s.ids[kind] == nil {
+		s.ids[kind] = make(map[string]int)
+		s.names[kind] = make(map[int]string)
+	}
+	if other, ok := s.ids[kind][name]; ok && other != id {
+		return fmt.Errorf("%s %q is already id %d", kind, name, other)
+	}
+	if other, ok := s.names[kind][id]; ok && other != name {
+		return fmt.Errorf("%s %d is already named %q", kind, id, other)
+	}
+	s.ids[kind][name] = id
+	s.names[kind][id] = name
+	return nil
+}
+
+// ID returns the id named name.
+func (s *Symbols) ID(kind, name string) (int, bool) {
+	id, ok := s.ids[kind][name]
+	return id, ok
+}
+
+// Name returns the name of id.
+func (s *Symbols) Name(kind string, id int) (string, bool) {
+	name, ok := s.names[kind][id]
+	return name, ok
+}
+
+// Count returns one more than the highest id of kind, 0 if it has none.
+func (s *Symbols) Count(kind string) int {
+	n := 0
+	for id := range s.names[kind] {
+		n = max(n, id+1)
+	}
+	return n
+}
+
+// isSymbolName reports whether name can be used as a symbol: letters,
+// digits and underscores, not all digits.
+func isSymbolName(name string) bool {
+	if name == "" || name == "none" {
+		return false
+	}
+	digits := true
+	for _, r := range name {
+		switch {
+		case r >= '0' && r <= '9':
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			digits = false
+		default:
+			return false
+		}
+	}
+	return !digits
+}
+
+// A SourceError is an error in a source or symbol file.
+type SourceError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *SourceError) Unwrap() error { return e.Err }
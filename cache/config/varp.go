@@ -0,0 +1,108 @@
+package config
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A VarpType is a player variable config. Most of its opcodes are read
+// by the client without being used; they are named by number.
+type VarpType struct {
+	opOrder
+
+	Code1 uint8
+	Code2 uint8
+	Code3 bool
+	Code4 bool // cleared by opcode 4
+	// ClientCode selects built-in client behaviour when the varp
+	// changes, such as the brightness setting.
+	ClientCode uint16
+	Code6      bool
+	Code7      uint32
+	Code8      bool
+	Code10     string
+}
+
+// NewVarpType returns a varp config with the client's defaults.
+func NewVarpType() *VarpType {
+	return &VarpType{Code4: true}
+}
+
+// ReadVarpTypes decodes every varp config in the config archive.
+func ReadVarpTypes(jf *io.Jagfile) ([]*VarpType, error) {
+	return readAll(jf, VarpName, NewVarpType)
+}
+
+// WriteVarpTypes queues varps to be written to the config archive.
+func WriteVarpTypes(jf *io.Jagfile, varps []*VarpType) {
+	writeAll(jf, VarpName, varps, false)
+}
+
+// Decode gets the config from p.
+func (t *VarpType) Decode(p *packet.Packet) error { return decode(p, t) }
+
+// Encode puts the config to p.
+func (t *VarpType) Encode(p *packet.Packet) { encode(p, t, true) }
+
+func (t *VarpType) decodeOp(p *packet.Packet, op uint8) error {
+	switch op {
+	case 1:
+		t.Code1 = p.G1()
+	case 2:
+		t.Code2 = p.G1()
+	case 3:
+		t.Code3 = true
+	case 4:
+		t.Code4 = false
+	case 5:
+		t.ClientCode = p.G2()
+	case 6:
+		t.Code6 = true
+	case 7:
+		t.Code7 = p.G4()
+	case 8:
+		t.Code8 = true
+	case 10:
+		t.Code10 = p.GJStrLF()
+	default:
+		return errUnknownOpcode
+	}
+	return nil
+}
+
+func (t *VarpType) encodeOp(p *packet.Packet, op uint8) {
+	switch op {
+	case 1:
+		p.P1(t.Code1)
+	case 2:
+		p.P1(t.Code2)
+	case 5:
+		p.P2(t.ClientCode)
+	case 7:
+		p.P4(t.Code7)
+	case 10:
+		p.PJStrLF(t.Code10)
+	}
+}
+
+func (t *VarpType) isFlag(op uint8) bool {
+	switch op {
+	case 3, 4, 6, 8:
+		return true
+	}
+	return false
+}
+
+func (t *VarpType) ops() []uint8 {
+	var ops []uint8
+	ops = appendIf(ops, 1, t.Code1 != 0)
+	ops = appendIf(ops, 2, t.Code2 != 0)
+	ops = appendIf(ops, 3, t.Code3)
+	ops = appendIf(ops, 4, !t.Code4)
+	ops = appendIf(ops, 5, t.ClientCode != 0)
+	ops = appendIf(ops, 6, t.Code6)
+	ops = appendIf(ops, 7, t.Code7 != 0)
+	ops = appendIf(ops, 8, t.Code8)
+	ops = appendIf(ops, 10, t.Code10 != "")
+	return ops
+}
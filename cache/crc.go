@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Archives lists the client cache archives in the order their
+// checksums appear in the crc table. The first crc table entry
+// is unused and always 0.
+var Archives = []string{
+	"title",
+	"config",
+	"interface",
+	"media",
+	"models",
+	"textures",
+	"wordenc",
+	"sounds",
+}
+
+// CrcTableSize is the number of checksums in the crc table.
+const CrcTableSize = 9
+
+// A CrcTable holds the checksums the client uses to validate
+// the cache archives it has downloaded.
+type CrcTable struct {
+	Crcs [CrcTableSize]uint32
+}
+
+// NewCrcTable returns the checksums of the archives in dir/client.
+func NewCrcTable(dir string) (*CrcTable, error) {
+	t := &CrcTable{}
+
+	for i, name := range Archives {
+		p, err := packet.Load(filepath.Join(dir, "client", name), false)
+		if err != nil {
+			return nil, err
+		}
+		t.Crcs[i+1] = packet.GetCRC(p.Buf, 0, len(p.Buf))
+	}
+
+	return t, nil
+}
+
+// DecodeCrcTable reads a crc table in the form produced by [CrcTable.Encode].
+func DecodeCrcTable(src *packet.Packet) (*CrcTable, error) {
+	t := &CrcTable{}
+
+	for i := range t.Crcs {
+		crc, err := src.TryG4()
+		if err != nil {
+			return nil, err
+		}
+		t.Crcs[i] = crc
+	}
+
+	return t, nil
+}
+
+// Get returns the checksum of the named archive.
+func (t *CrcTable) Get(name string) (uint32, error) {
+	i := slices.Index(Archives, name)
+	if i == -1 {
+		return 0, errors.New("unknown archive")
+	}
+	return t.Crcs[i+1], nil
+}
+
+// Encode returns the crc table as sent to the client.
+func (t *CrcTable) Encode() []uint8 {
+	p := packet.NewPacket(make([]byte, 0, CrcTableSize*4))
+	for _, crc := range t.Crcs {
+		p.P4(crc)
+	}
+	return p.Buf
+}
+
+// Checksum returns the checksum of the encoded crc table.
+func (t *CrcTable) Checksum() uint32 {
+	b := t.Encode()
+	return packet.GetCRC(b, 0, len(b))
+}
+
+// Save writes the encoded crc table to dir/client/crc.
+func (t *CrcTable) Save(dir string) error {
+	b := t.Encode()
+	return packet.NewPacket(b).Save(filepath.Join(dir, "client", "crc"), len(b), 0)
+}
@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestCrcTable(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "client"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range Archives {
+		if err := os.WriteFile(filepath.Join(dir, "client", name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	table, err := NewCrcTable(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Crcs[0] != 0 {
+		t.Errorf("Crcs[0] = %v, want 0", table.Crcs[0])
+	}
+
+	crc, err := table.Get("config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := packet.GetCRC([]byte("config"), 0, len("config")); crc != want {
+		t.Errorf("Get(config) = %v, want %v", crc, want)
+	}
+	if _, err := table.Get("crc"); err == nil {
+		t.Error("Get(crc) should fail")
+	}
+
+	b := table.Encode()
+	if len(b) != CrcTableSize*4 {
+		t.Fatalf("len(Encode()) = %v, want %v", len(b), CrcTableSize*4)
+	}
+	decoded, err := DecodeCrcTable(packet.NewPacket(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *decoded != *table {
+		t.Errorf("DecodeCrcTable() = %v, want %v", decoded, table)
+	}
+	if _, err := DecodeCrcTable(packet.NewPacket(b[:20])); err == nil {
+		t.Error("DecodeCrcTable() of a truncated table should fail")
+	}
+}
@@ -0,0 +1,124 @@
+package mapsquare
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// GeneratedHeight is the Height of a tile whose height the client
+// generates.
+const GeneratedHeight = -1
+
+// A Tile is one tile of a landscape.
+type Tile struct {
+	// Height is the height below the tile beneath, in units of 8, or
+	// GeneratedHeight. The client reads a height of 1 as 0.
+	Height       int
+	Overlay      uint8 // floor drawn over the underlay, 0 for none
+	OverlayShape uint8 // 0-11
+	OverlayAngle uint8 // 0-3
+	Flags        uint8
+	Underlay     uint8 // 0 for none
+}
+
+// Land is the landscape of a map square, indexed by level, x and z.
+type Land [Levels][Size][Size]Tile
+
+// DecodeLand decodes a landscape file.
+func DecodeLand(src []byte) (*Land, error) {
+	p := packet.NewPacket(src)
+	land := &Land{}
+	for level := range Levels {
+		for x := range Size {
+			for z := range Size {
+				if err := decodeTile(p, &land[level][x][z]); err != nil {
+					return nil, fmt.Errorf("mapsquare: tile %d %d %d: %w", level, x, z, err)
+				}
+			}
+		}
+	}
+	if p.Len() != 0 {
+		return nil, fmt.Errorf("mapsquare: %d bytes after the landscape", p.Len())
+	}
+	return land, nil
+}
+
+func decodeTile(p *packet.Packet, t *Tile) error {
+	for {
+		opcode, err := p.TryG1()
+		if err != nil {
+			return err
+		}
+		switch {
+		case opcode == 0:
+			t.Height = GeneratedHeight
+			return nil
+		case opcode == 1:
+			height, err := p.TryG1()
+			if err != nil {
+				return err
+			}
+			t.Height = int(height)
+			return nil
+		case opcode <= 49:
+			if t.Overlay, err = p.TryG1(); err != nil {
+				return err
+			}
+			t.OverlayShape = (opcode - 2) / 4
+			t.OverlayAngle = (opcode - 2) & 3
+		case opcode <= 81:
+			t.Flags = opcode - 49
+		default:
+			t.Underlay = opcode - 81
+		}
+	}
+}
+
+// Encode encodes the landscape file.
+func (l *Land) Encode() ([]byte, error) {
+	p := packet.NewPacket(nil)
+	for level := range Levels {
+		for x := range Size {
+			for z := range Size {
+				if err := encodeTile(p, &l[level][x][z]); err != nil {
+					return nil, fmt.Errorf("mapsquare: tile %d %d %d: %w", level, x, z, err)
+				}
+			}
+		}
+	}
+	return p.Buf, nil
+}
+
+func encodeTile(p *packet.Packet, t *Tile) error {
+	if t.Overlay != 0 {
+		if t.OverlayShape > 11 || t.OverlayAngle > 3 {
+			return fmt.Errorf("overlay shape %d angle %d out of range", t.OverlayShape, t.OverlayAngle)
+		}
+		p.P1(2 + (t.OverlayShape<<2 | t.OverlayAngle))
+		p.P1(t.Overlay)
+	}
+	if t.Flags != 0 {
+		if t.Flags > 81-49 {
+			return fmt.Errorf("flags %#x out of range", t.Flags)
+		}
+		p.P1(49 + t.Flags)
+	}
+	if t.Underlay != 0 {
+		if t.Underlay > 255-81 {
+			return fmt.Errorf("underlay %d out of range", t.Underlay)
+		}
+		p.P1(81 + t.Underlay)
+	}
+	switch {
+	case t.Height == GeneratedHeight:
+		p.P1(0)
+	case t.Height >= 0 && t.Height <= 0xFF:
+		p.P1(1)
+		p.P1(uint8(t.Height))
+	default:
+		return errors.New("height out of range")
+	}
+	return nil
+}
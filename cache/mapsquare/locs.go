@@ -0,0 +1,104 @@
+package mapsquare
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A Loc is a loc placed in a map square.
+type Loc struct {
+	ID    int
+	Level int
+	X     int // within the square
+	Z     int
+	Shape uint8
+	Angle uint8
+}
+
+// pos returns the packed position of the loc, as it is delta encoded.
+func (l Loc) pos() int {
+	return l.Level<<12 | l.X<<6 | l.Z
+}
+
+// DecodeLocs decodes a locs file.
+func DecodeLocs(src []byte) ([]Loc, error) {
+	p := packet.NewPacket(src)
+	var locs []Loc
+	id := -1
+	for {
+		deltaID, err := p.TryGSmart()
+		if err != nil {
+			return nil, fmt.Errorf("mapsquare: loc id: %w", err)
+		}
+		if deltaID == 0 {
+			break
+		}
+		id += int(deltaID)
+
+		pos := 0
+		for {
+			deltaPos, err := p.TryGSmart()
+			if err != nil {
+				return nil, fmt.Errorf("mapsquare: loc %d position: %w", id, err)
+			}
+			if deltaPos == 0 {
+				break
+			}
+			pos += int(deltaPos) - 1
+
+			info, err := p.TryG1()
+			if err != nil {
+				return nil, fmt.Errorf("mapsquare: loc %d shape: %w", id, err)
+			}
+			locs = append(locs, Loc{
+				ID:    id,
+				Level: pos >> 12 & 0x3,
+				X:     pos >> 6 & 0x3F,
+				Z:     pos & 0x3F,
+				Shape: info >> 2,
+				Angle: info & 0x3,
+			})
+		}
+	}
+	if p.Len() != 0 {
+		return nil, fmt.Errorf("mapsquare: %d bytes after the locs", p.Len())
+	}
+	return locs, nil
+}
+
+// EncodeLocs encodes a locs file. The locs are sorted by id and
+// position, as the format requires.
+func EncodeLocs(locs []Loc) ([]byte, error) {
+	sorted := slices.Clone(locs)
+	slices.SortStableFunc(sorted, func(a, b Loc) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.pos(), b.pos()))
+	})
+
+	p := packet.NewPacket(nil)
+	lastID := -1
+	for i := 0; i < len(sorted); {
+		id := sorted[i].ID
+		if id <= lastID || id-lastID > 0x7FFF {
+			return nil, fmt.Errorf("mapsquare: loc id %d out of range", id)
+		}
+		p.PSmart(int32(id - lastID))
+		lastID = id
+
+		lastPos := 0
+		for ; i < len(sorted) && sorted[i].ID == id; i++ {
+			l := sorted[i]
+			if l.Level < 0 || l.Level >= Levels || l.X < 0 || l.X >= Size || l.Z < 0 || l.Z >= Size || l.Shape > 0x3F || l.Angle > 3 {
+				return nil, fmt.Errorf("mapsquare: loc %d at %d %d %d shape %d angle %d out of range", l.ID, l.Level, l.X, l.Z, l.Shape, l.Angle)
+			}
+			p.PSmart(int32(l.pos() - lastPos + 1))
+			lastPos = l.pos()
+			p.P1(l.Shape<<2 | l.Angle)
+		}
+		p.PSmart(0)
+	}
+	p.PSmart(0)
+	return p.Buf, nil
+}
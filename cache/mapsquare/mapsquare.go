@@ -0,0 +1,59 @@
+// Package mapsquare decodes and encodes the map square files in the
+// client's maps directory, which the server sends to the client in
+// DATA_LAND and DATA_LOC chunks. Each 64x64 tile square of the world
+// has two:
+//
+//	m<x>_<z>   landscape: per level, x and z, a run of tile opcodes
+//	l<x>_<z>   locs: loc ids and positions as deltas, in smarts
+//
+// The landscape tile opcodes are:
+//
+//	0       end of tile, height generated by the client
+//	1       end of tile, G1 height follows
+//	2-49    G1 overlay follows; opcode-2 is shape<<2 | angle
+//	50-81   flags are opcode-49
+//	82-255  underlay is opcode-81
+package mapsquare
+
+import (
+	"fmt"
+)
+
+// Size is the width and length of a map square in tiles.
+const Size = 64
+
+// Levels is the number of levels in a map square.
+const Levels = 4
+
+// LandName returns the name of the landscape file of the square at x, z.
+func LandName(x, z int) string {
+	return fmt.Sprintf("m%d_%d", x, z)
+}
+
+// LocsName returns the name of the locs file of the square at x, z.
+func LocsName(x, z int) string {
+	return fmt.Sprintf("l%d_%d", x, z)
+}
+
+// Tile flags.
+const (
+	TileBlocked      = 0x1 // cannot be walked on
+	TileBridge       = 0x2 // on level 1, the tiles below are raised to it
+	TileRemoveRoof   = 0x4 // roofs are hidden while standing here
+	TileVisibleBelow = 0x8 // drawn from the level below
+	TileForceDetail  = 0x10
+)
+
+// Loc shapes, which decide the layer and collision of a loc.
+const (
+	ShapeWallStraight        = 0
+	ShapeWallDiagonalCorner  = 1
+	ShapeWallL               = 2
+	ShapeWallSquareCorner    = 3
+	ShapeWallDecorStraight   = 4 // 4-8 are wall decorations
+	ShapeWallDiagonal        = 9
+	ShapeCentrepiece         = 10
+	ShapeCentrepieceDiagonal = 11
+	ShapeRoofStraight        = 12 // 12-21 are roofs
+	ShapeGroundDecor         = 22
+)
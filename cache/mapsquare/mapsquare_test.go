@@ -0,0 +1,237 @@
+package mapsquare
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestLand_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name        string
+		level, x, z int
+		tile        Tile
+	}{
+		{"generated", 0, 0, 0, Tile{Height: GeneratedHeight, Underlay: 12}},
+		{"building", 0, 20, 30, Tile{Height: 35, Overlay: 6, OverlayShape: 3, OverlayAngle: 2, Flags: TileBlocked | TileRemoveRoof}},
+		{"bridge", 1, 40, 10, Tile{Height: 0, Overlay: 174, Flags: TileBridge}},
+		{"limits", 3, 63, 63, Tile{Height: 255, Underlay: 174, Flags: 32}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := &Land{}
+			for level := range Levels {
+				for x := range Size {
+					for z := range Size {
+						want[level][x][z] = Tile{Height: GeneratedHeight}
+					}
+				}
+			}
+			want[tt.level][tt.x][tt.z] = tt.tile
+
+			data, err := want.Encode()
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := DecodeLand(data)
+			if err != nil {
+				t.Fatalf("DecodeLand() error = %v", err)
+			}
+			if *got != *want {
+				t.Error("DecodeLand(Encode()) differs from the original")
+			}
+		})
+	}
+}
+
+func TestDecodeLand(t *testing.T) {
+	// first tile: overlay 7 shape 1 angle 3, flags 1, underlay 5, height 20
+	data := append([]byte{9, 7, 50, 86, 1, 20}, make([]byte, Levels*Size*Size-1)...)
+	land, err := DecodeLand(data)
+	if err != nil {
+		t.Fatalf("DecodeLand() error = %v", err)
+	}
+	want := Tile{Height: 20, Overlay: 7, OverlayShape: 1, OverlayAngle: 3, Flags: TileBlocked, Underlay: 5}
+	if land[0][0][0] != want {
+		t.Errorf("tile 0 0 0 = %+v, want %+v", land[0][0][0], want)
+	}
+	if got := land[0][0][1]; got != (Tile{Height: GeneratedHeight}) {
+		t.Errorf("tile 0 0 1 = %+v, want generated height", got)
+	}
+	if got := land[3][63][63]; got != (Tile{Height: GeneratedHeight}) {
+		t.Errorf("tile 3 63 63 = %+v, want generated height", got)
+	}
+}
+
+func TestDecodeLand_error(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated", make([]byte, Levels*Size*Size-1)},
+		{"truncated height", append(make([]byte, Levels*Size*Size-1), 1)},
+		{"trailing", make([]byte, Levels*Size*Size+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeLand(tt.data); err == nil {
+				t.Error("DecodeLand() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLand_Encode_error(t *testing.T) {
+	tests := []struct {
+		name string
+		tile Tile
+	}{
+		{"height", Tile{Height: 256}},
+		{"overlay shape", Tile{Overlay: 1, OverlayShape: 12}},
+		{"flags", Tile{Flags: 33}},
+		{"underlay", Tile{Underlay: 175}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			land := &Land{}
+			land[2][5][6] = tt.tile
+			if _, err := land.Encode(); err == nil {
+				t.Error("Encode() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestLocs_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		locs []Loc
+		want []Loc // in id, position order
+	}{
+		{"one", []Loc{{ID: 1276, Level: 2, X: 0, Z: 0, Shape: ShapeCentrepiece, Angle: 1}}, nil},
+		{"sorted", []Loc{
+			{ID: 1276, Level: 0, X: 10, Z: 20, Shape: ShapeCentrepiece, Angle: 2},
+			{ID: 1276, Level: 0, X: 12, Z: 20, Shape: ShapeCentrepiece, Angle: 0},
+			{ID: 1530, Level: 0, X: 21, Z: 30, Shape: ShapeWallStraight, Angle: 1},
+			{ID: 1530, Level: 0, X: 21, Z: 30, Shape: ShapeWallDecorStraight, Angle: 1},
+		}, nil},
+		{"unsorted", []Loc{
+			{ID: 32000, Level: 1, X: 40, Z: 10, Shape: ShapeRoofStraight + 9, Angle: 0},
+			{ID: 1276, Level: 2, X: 0, Z: 0, Shape: ShapeCentrepiece, Angle: 1},
+			{ID: 1276, Level: 0, X: 10, Z: 20, Shape: ShapeCentrepiece, Angle: 2},
+			{ID: 0, Level: 3, X: 63, Z: 63, Shape: ShapeGroundDecor, Angle: 3},
+		}, []Loc{
+			{ID: 0, Level: 3, X: 63, Z: 63, Shape: ShapeGroundDecor, Angle: 3},
+			{ID: 1276, Level: 0, X: 10, Z: 20, Shape: ShapeCentrepiece, Angle: 2},
+			{ID: 1276, Level: 2, X: 0, Z: 0, Shape: ShapeCentrepiece, Angle: 1},
+			{ID: 32000, Level: 1, X: 40, Z: 10, Shape: ShapeRoofStraight + 9, Angle: 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := EncodeLocs(tt.locs)
+			if err != nil {
+				t.Fatalf("EncodeLocs() error = %v", err)
+			}
+			got, err := DecodeLocs(data)
+			if err != nil {
+				t.Fatalf("DecodeLocs() error = %v", err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.locs
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("DecodeLocs() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeLocs(t *testing.T) {
+	data := []byte{
+		0x84, 0xFD, // id -1 + 1277 = 1276
+		0x82, 0x95, // pos 0 + 661 - 1 = 660: x 10, z 20
+		42, // shape 10, angle 2
+		3,  // pos 660 + 3 - 1 = 662: x 10, z 22
+		0,  // shape 0, angle 0
+		0,
+		2, // id 1278
+		1, // pos 0
+		91,
+		0,
+		0,
+	}
+	want := []Loc{
+		{ID: 1276, X: 10, Z: 20, Shape: ShapeCentrepiece, Angle: 2},
+		{ID: 1276, X: 10, Z: 22},
+		{ID: 1278, Shape: ShapeGroundDecor, Angle: 3},
+	}
+
+	got, err := DecodeLocs(data)
+	if err != nil {
+		t.Fatalf("DecodeLocs() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeLocs() = %+v, want %+v", got, want)
+	}
+
+	encoded, err := EncodeLocs(want)
+	if err != nil {
+		t.Fatalf("EncodeLocs() error = %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Errorf("EncodeLocs() = % x, want % x", encoded, data)
+	}
+}
+
+func TestDecodeLocs_error(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"no position end", []byte{1, 1, 0}},
+		{"no shape", []byte{1, 1}},
+		{"no id end", []byte{1, 1, 0, 0}},
+		{"trailing", []byte{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeLocs(tt.data); err == nil {
+				t.Error("DecodeLocs() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestEncodeLocs_error(t *testing.T) {
+	tests := []struct {
+		name string
+		loc  Loc
+	}{
+		{"id", Loc{ID: -1}},
+		{"level", Loc{Level: 4}},
+		{"x", Loc{X: 64}},
+		{"z", Loc{Z: -1}},
+		{"shape", Loc{Shape: 64}},
+		{"angle", Loc{Angle: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := EncodeLocs([]Loc{tt.loc}); err == nil {
+				t.Error("EncodeLocs() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	if got := LandName(50, 50); got != "m50_50" {
+		t.Errorf("LandName() = %q, want m50_50", got)
+	}
+	if got := LocsName(48, 148); got != "l48_148" {
+		t.Errorf("LocsName() = %q, want l48_148", got)
+	}
+}
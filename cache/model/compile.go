@@ -0,0 +1,47 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+// Compile reads the model files in src, named <id>.ob2, and writes the
+// models archive files into jf. It has the signature of a pack.Compiler
+// for the models archive. Hidden files are skipped; any other file is
+// an error.
+func Compile(src string, jf *io.Jagfile) error {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	models := make(map[int]*Model)
+	for _, e := range entries {
+		name := e.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(src, name)
+		id, err := strconv.Atoi(strings.TrimSuffix(name, Ext))
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, Ext) || err != nil || id < 0 || id > 0xFFFF {
+			return fmt.Errorf("model: %s: not a model file, want <id>%s", path, Ext)
+		}
+		if _, ok := models[id]; ok {
+			return fmt.Errorf("model: %s: model %d is already defined", path, id)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if models[id], err = Decode(data); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return Write(jf, models)
+}
@@ -0,0 +1,313 @@
+// Package model converts between the models archive and model files.
+//
+// A model file, <id>.ob2, holds one model's sections: vertex flags,
+// face types, face priorities, face labels, face info, vertex labels,
+// face alphas, face vertices, face colours, texture axes and vertex x,
+// y and z, followed by an 18 byte trailer:
+//
+//	G2 vertex count, G2 face count, G1 textured face count, G1 has
+//	face info, G1 priority (255 if per face), G1 has face alphas,
+//	G1 has face labels, G1 has vertex labels, then the G2 lengths of
+//	the vertex x, y and z data and of the face vertex data
+//
+// The models archive stores every model's sections interleaved across
+// shared files, in id order. ob_head.dat holds a G2 count, then each
+// model's G2 id, G2 vertex count, G2 face count, G1 textured face
+// count, and its G1 has face info, priority, has face alphas, has face
+// labels and has vertex labels. The other files hold the sections:
+//
+//	ob_point1.dat   vertex flags
+//	ob_point2.dat   vertex x
+//	ob_point3.dat   vertex y
+//	ob_point4.dat   vertex z
+//	ob_point5.dat   vertex labels
+//	ob_vertex1.dat  face vertices
+//	ob_vertex2.dat  face types
+//	ob_face1.dat    face colours
+//	ob_face2.dat    face info
+//	ob_face3.dat    face priorities
+//	ob_face4.dat    face alphas
+//	ob_face5.dat    face labels
+//	ob_axis.dat     texture axes
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Ext is the extension of model files.
+const Ext = ".ob2"
+
+// trailerSize is the size of a model file's trailer.
+const trailerSize = 18
+
+// Sections of a model, in model file order.
+const (
+	vertexFlags = iota
+	faceTypes
+	facePriorities
+	faceLabels
+	faceInfo
+	vertexLabels
+	faceAlphas
+	faceVertices
+	faceColours
+	textureAxes
+	vertexX
+	vertexY
+	vertexZ
+	sectionCount
+)
+
+// files are the models archive files holding each section.
+var files = [sectionCount]string{
+	vertexFlags:    "ob_point1.dat",
+	vertexX:        "ob_point2.dat",
+	vertexY:        "ob_point3.dat",
+	vertexZ:        "ob_point4.dat",
+	vertexLabels:   "ob_point5.dat",
+	faceVertices:   "ob_vertex1.dat",
+	faceTypes:      "ob_vertex2.dat",
+	faceColours:    "ob_face1.dat",
+	faceInfo:       "ob_face2.dat",
+	facePriorities: "ob_face3.dat",
+	faceAlphas:     "ob_face4.dat",
+	faceLabels:     "ob_face5.dat",
+	textureAxes:    "ob_axis.dat",
+}
+
+// HeadName is the name of the file holding the model headers.
+const HeadName = "ob_head.dat"
+
+// A Header describes the sections of a model.
+type Header struct {
+	VertexCount       int
+	FaceCount         int
+	TexturedFaceCount int
+	HasInfo           bool
+	// Priority is the priority of every face, or 255 if each face has
+	// its own.
+	Priority        uint8
+	HasAlphas       bool
+	HasFaceLabels   bool
+	HasVertexLabels bool
+}
+
+// A Model is a model's header and the bytes of each of its sections.
+type Model struct {
+	Header
+	sections [sectionCount][]byte
+}
+
+// sizes returns the size of each section whose size follows from the
+// header alone; the vertex and face vertex data are -1.
+func (h *Header) sizes() [sectionCount]int {
+	var s [sectionCount]int
+	s[vertexFlags] = h.VertexCount
+	s[faceTypes] = h.FaceCount
+	if h.Priority == 255 {
+		s[facePriorities] = h.FaceCount
+	}
+	if h.HasFaceLabels {
+		s[faceLabels] = h.FaceCount
+	}
+	if h.HasInfo {
+		s[faceInfo] = h.FaceCount
+	}
+	if h.HasVertexLabels {
+		s[vertexLabels] = h.VertexCount
+	}
+	if h.HasAlphas {
+		s[faceAlphas] = h.FaceCount
+	}
+	s[faceColours] = h.FaceCount * 2
+	s[textureAxes] = h.TexturedFaceCount * 6
+	s[faceVertices], s[vertexX], s[vertexY], s[vertexZ] = -1, -1, -1, -1
+	return s
+}
+
+// Decode decodes a model file.
+func Decode(src []byte) (*Model, error) {
+	if len(src) < trailerSize {
+		return nil, errors.New("model: missing trailer")
+	}
+
+	p := packet.NewPacket(src)
+	p.Pos = len(src) - trailerSize
+	m := &Model{}
+	m.VertexCount = int(p.G2())
+	m.FaceCount = int(p.G2())
+	m.TexturedFaceCount = int(p.G1())
+	m.HasInfo = p.G1() == 1
+	m.Priority = p.G1()
+	m.HasAlphas = p.G1() == 1
+	m.HasFaceLabels = p.G1() == 1
+	m.HasVertexLabels = p.G1() == 1
+
+	sizes := m.sizes()
+	sizes[vertexX] = int(p.G2())
+	sizes[vertexY] = int(p.G2())
+	sizes[vertexZ] = int(p.G2())
+	sizes[faceVertices] = int(p.G2())
+
+	pos := 0
+	for i, n := range sizes {
+		if pos+n > len(src)-trailerSize {
+			return nil, errors.New("model: sections overrun the trailer")
+		}
+		m.sections[i] = src[pos : pos+n]
+		pos += n
+	}
+	if pos != len(src)-trailerSize {
+		return nil, fmt.Errorf("model: %d bytes before the trailer", len(src)-trailerSize-pos)
+	}
+	return m, nil
+}
+
+// Encode encodes m as a model file.
+func (m *Model) Encode() []byte {
+	p := packet.NewPacket(nil)
+	for _, s := range m.sections {
+		p.PData(s, len(s))
+	}
+	p.P2(uint16(m.VertexCount))
+	p.P2(uint16(m.FaceCount))
+	p.P1(uint8(m.TexturedFaceCount))
+	p.PBool(m.HasInfo)
+	p.P1(m.Priority)
+	p.PBool(m.HasAlphas)
+	p.PBool(m.HasFaceLabels)
+	p.PBool(m.HasVertexLabels)
+	p.P2(uint16(len(m.sections[vertexX])))
+	p.P2(uint16(len(m.sections[vertexY])))
+	p.P2(uint16(len(m.sections[vertexZ])))
+	p.P2(uint16(len(m.sections[faceVertices])))
+	return p.Buf
+}
+
+// Read decodes every model in the models archive jf, by id.
+func Read(jf *io.Jagfile) (models map[int]*Model, err error) {
+	head, err := jf.Read(HeadName)
+	if err != nil {
+		return nil, fmt.Errorf("model: %s: %w", HeadName, err)
+	}
+	var data [sectionCount]*packet.Packet
+	for i, name := range files {
+		if data[i], err = jf.Read(name); err != nil {
+			return nil, fmt.Errorf("model: %s: %w", name, err)
+		}
+	}
+
+	if models, err = readModels(head, data); err != nil {
+		return nil, fmt.Errorf("model: %w", err)
+	}
+	return models, nil
+}
+
+// readModels decodes the models listed in head from their sections in data.
+func readModels(head *packet.Packet, data [sectionCount]*packet.Packet) (models map[int]*Model, err error) {
+	defer packet.Recover(&err)
+
+	models = make(map[int]*Model)
+	for range head.G2() {
+		id := int(head.G2())
+		m := &Model{}
+		m.VertexCount = int(head.G2())
+		m.FaceCount = int(head.G2())
+		m.TexturedFaceCount = int(head.G1())
+		m.HasInfo = head.G1() == 1
+		m.Priority = head.G1()
+		m.HasAlphas = head.G1() == 1
+		m.HasFaceLabels = head.G1() == 1
+		m.HasVertexLabels = head.G1() == 1
+
+		// the vertex and face vertex data are sized by what they hold
+		sizes := m.sizes()
+		sizes[vertexX], sizes[vertexY], sizes[vertexZ] = 0, 0, 0
+		for v := range m.VertexCount {
+			flags := peek(data[vertexFlags], v)
+			for axis := range 3 {
+				if flags&(1<<axis) != 0 {
+					sizes[vertexX+axis] += smartSize(data[vertexX+axis], sizes[vertexX+axis])
+				}
+			}
+		}
+		sizes[faceVertices] = 0
+		for f := range m.FaceCount {
+			n := 1
+			if peek(data[faceTypes], f) == 1 {
+				n = 3
+			}
+			for range n {
+				sizes[faceVertices] += smartSize(data[faceVertices], sizes[faceVertices])
+			}
+		}
+
+		for i, n := range sizes {
+			m.sections[i] = make([]byte, n)
+			data[i].GData(m.sections[i], n)
+		}
+		models[id] = m
+	}
+	return models, nil
+}
+
+// peek returns the byte at offset in p's unread bytes.
+func peek(p *packet.Packet, offset int) uint8 {
+	if offset >= p.Len() {
+		panic(&packet.ReadError{Op: "G1", Offset: p.Pos + offset, Want: 1, Available: 0})
+	}
+	return p.Buf[p.Pos+offset]
+}
+
+// smartSize returns the size of the smart at offset in p's unread bytes.
+func smartSize(p *packet.Packet, offset int) int {
+	if peek(p, offset) < 0x80 {
+		return 1
+	}
+	return 2
+}
+
+// Write queues the models archive files, holding models in id order,
+// to be written to jf.
+func Write(jf *io.Jagfile, models map[int]*Model) error {
+	if len(models) > 0xFFFF {
+		return fmt.Errorf("model: %d models, want at most %d", len(models), 0xFFFF)
+	}
+
+	head := packet.NewPacket(nil)
+	var data [sectionCount]*packet.Packet
+	for i := range data {
+		// a section no model uses is still written, empty
+		data[i] = packet.NewPacket([]byte{})
+	}
+
+	head.P2(uint16(len(models)))
+	for _, id := range slices.Sorted(maps.Keys(models)) {
+		m := models[id]
+		head.P2(uint16(id))
+		head.P2(uint16(m.VertexCount))
+		head.P2(uint16(m.FaceCount))
+		head.P1(uint8(m.TexturedFaceCount))
+		head.PBool(m.HasInfo)
+		head.P1(m.Priority)
+		head.PBool(m.HasAlphas)
+		head.PBool(m.HasFaceLabels)
+		head.PBool(m.HasVertexLabels)
+		for i, s := range m.sections {
+			data[i].PData(s, len(s))
+		}
+	}
+
+	jf.Write(HeadName, head)
+	for i, name := range files {
+		jf.Write(name, data[i])
+	}
+	return nil
+}
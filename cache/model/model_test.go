@@ -0,0 +1,169 @@
+package model
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+// triangle is a model using every section but face labels and texture
+// axes, with one and two byte vertex smarts.
+func triangle() *Model {
+	m := &Model{Header: Header{
+		VertexCount:     3,
+		FaceCount:       1,
+		HasInfo:         true,
+		Priority:        255,
+		HasAlphas:       true,
+		HasVertexLabels: true,
+	}}
+	m.sections[vertexFlags] = []byte{7, 1, 2}
+	m.sections[faceTypes] = []byte{1}
+	m.sections[facePriorities] = []byte{3}
+	m.sections[faceInfo] = []byte{0}
+	m.sections[vertexLabels] = []byte{0, 0, 1}
+	m.sections[faceAlphas] = []byte{0x80}
+	m.sections[faceVertices] = []byte{0x40, 0x41, 0x42}
+	m.sections[faceColours] = []byte{0x12, 0x34}
+	m.sections[vertexX] = []byte{0x4A, 0xC0, 0xC8}
+	m.sections[vertexY] = []byte{0x30, 0x50}
+	m.sections[vertexZ] = []byte{0x45}
+	return m
+}
+
+// square is a textured model with a single priority and two byte face
+// vertex smarts.
+func square() *Model {
+	m := &Model{Header: Header{
+		VertexCount:       1,
+		FaceCount:         2,
+		TexturedFaceCount: 1,
+		Priority:          5,
+		HasFaceLabels:     true,
+	}}
+	m.sections[vertexFlags] = []byte{0}
+	m.sections[faceTypes] = []byte{0, 3}
+	m.sections[faceLabels] = []byte{1, 2}
+	m.sections[faceVertices] = []byte{0x80, 0x00, 0x41}
+	m.sections[faceColours] = []byte{0, 1, 0, 2}
+	m.sections[textureAxes] = []byte{0, 1, 0, 2, 0, 3}
+	return m
+}
+
+func TestDecode(t *testing.T) {
+	data := triangle().Encode()
+
+	trailer := []byte{0, 3, 0, 1, 0, 1, 255, 1, 0, 1, 0, 3, 0, 2, 0, 1, 0, 3}
+	if !bytes.Equal(data[len(data)-trailerSize:], trailer) {
+		t.Errorf("Encode() trailer = % x, want % x", data[len(data)-trailerSize:], trailer)
+	}
+
+	m, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !bytes.Equal(m.Encode(), data) {
+		t.Errorf("Encode() = % x, want % x", m.Encode(), data)
+	}
+
+	bad := [][]byte{
+		data[:trailerSize-1],
+		data[1:],
+		append([]byte{0}, data...),
+	}
+	for _, b := range bad {
+		if _, err := Decode(b); err == nil {
+			t.Errorf("Decode(% x) error = nil", b)
+		}
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	want := map[int]*Model{7: triangle(), 2: square()}
+
+	jf := &io.Jagfile{}
+	if err := Write(jf, want); err != nil {
+		t.Fatal(err)
+	}
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+
+	head, err := jf.Read(HeadName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Buf[2] != 0 || head.Buf[3] != 2 {
+		t.Errorf("first model id = % x, want 00 02", head.Buf[2:4])
+	}
+
+	got, err := Read(jf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Read() = %d models, want %d", len(got), len(want))
+	}
+	for id, m := range want {
+		if got[id] == nil || !bytes.Equal(got[id].Encode(), m.Encode()) {
+			t.Errorf("model %d = %v, want %v", id, got[id], m)
+		}
+	}
+}
+
+func TestRead_truncated(t *testing.T) {
+	m := triangle()
+	m.sections[vertexX] = m.sections[vertexX][:2]
+
+	jf := &io.Jagfile{}
+	if err := Write(jf, map[int]*Model{0: m}); err != nil {
+		t.Fatal(err)
+	}
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Read(jf); err == nil {
+		t.Error("Read() error = nil")
+	}
+}
+
+func TestCompile(t *testing.T) {
+	src := t.TempDir()
+	for name, data := range map[string][]byte{
+		"7.ob2":    triangle().Encode(),
+		"2.ob2":    square().Encode(),
+		".gitkeep": nil,
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	jf := &io.Jagfile{}
+	if err := Compile(src, jf); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(jf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !bytes.Equal(got[7].Encode(), triangle().Encode()) {
+		t.Errorf("Read() = %v, want models 2 and 7", got)
+	}
+
+	for _, name := range []string{"notes.txt", "x.ob2", "70000.ob2"} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, name), triangle().Encode(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := Compile(dir, &io.Jagfile{}); err == nil {
+			t.Errorf("Compile(%s) error = nil", name)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package cache
+
+// On-demand archives, by the id clients from revision 234 onwards
+// request their files with. These clients fetch models, anims, midis
+// and maps one file at a time rather than in cache archives.
+const (
+	OnDemandModels = 0
+	OnDemandAnims  = 1
+	OnDemandMidi   = 2
+	OnDemandMaps   = 3
+)
+
+// OnDemandCount is the number of on-demand archives.
+const OnDemandCount = 4
+
+// OnDemandArchives are the names of the on-demand archives, by id.
+// The packed files of each archive are stored in a directory of the
+// same name.
+var OnDemandArchives = [OnDemandCount]string{"models", "anims", "midi", "maps"}
+
+// VersionListNames are the names the versionlist archive uses for the
+// on-demand archives, by id, as in model_version and model_crc.
+var VersionListNames = [OnDemandCount]string{"model", "anim", "midi", "map"}
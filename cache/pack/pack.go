@@ -0,0 +1,170 @@
+// Package pack builds the client cache from a source tree.
+//
+// The source tree holds one directory per archive, named after the
+// archive, plus maps and songs directories:
+//
+//	src/title/index.dat        packed into client/title
+//	src/config/obj.dat         packed into client/config
+//	...
+//	src/maps/m50_50            compressed into client/maps/m50_50
+//	src/songs/scape_main.mid   compressed into client/songs/scape_main.mid
+//
+// By default every file in an archive directory is packed as-is under its
+// own name. A [Compiler] can be registered for an archive to build its
+// files from another form instead: package sprite compiles images into
+// sprites and package model compiles model files.
+package pack
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A Compiler writes the files of one archive into jf, reading from
+// the archive's source directory src.
+type Compiler func(src string, jf *io.Jagfile) error
+
+// A Packer builds the client cache in Out from the source tree in Src.
+type Packer struct {
+	Src string
+	Out string
+
+	compilers map[string]Compiler
+}
+
+// NewPacker returns a Packer that reads from src and writes to out.
+func NewPacker(src string, out string) *Packer {
+	return &Packer{
+		Src:       src,
+		Out:       out,
+		compilers: make(map[string]Compiler),
+	}
+}
+
+// Register sets the compiler used to build the named archive.
+func (pk *Packer) Register(archive string, c Compiler) {
+	pk.compilers[archive] = c
+}
+
+// PackAll packs every archive, the maps and songs, and writes the
+// crc table for the archives.
+func (pk *Packer) PackAll() error {
+	for _, name := range cache.Archives {
+		if err := pk.PackArchive(name); err != nil {
+			return err
+		}
+	}
+
+	if err := pk.PackMaps(); err != nil {
+		return err
+	}
+	if err := pk.PackSongs(); err != nil {
+		return err
+	}
+
+	table, err := cache.NewCrcTable(pk.Out)
+	if err != nil {
+		return err
+	}
+	return table.Save(pk.Out)
+}
+
+// PackArchive builds the named archive and writes it to Out/client.
+func (pk *Packer) PackArchive(name string) error {
+	src := filepath.Join(pk.Src, name)
+
+	compile, ok := pk.compilers[name]
+	if !ok {
+		compile = PackFiles
+	}
+
+	jf := &io.Jagfile{}
+	if err := compile(src, jf); err != nil {
+		return err
+	}
+
+	return jf.Save(filepath.Join(pk.Out, "client", name), false)
+}
+
+// PackMaps compresses each map file in Src/maps into Out/client/maps.
+func (pk *Packer) PackMaps() error {
+	return compressDir(filepath.Join(pk.Src, "maps"), filepath.Join(pk.Out, "client", "maps"))
+}
+
+// PackSongs compresses each song in Src/songs into Out/client/songs.
+func (pk *Packer) PackSongs() error {
+	return compressDir(filepath.Join(pk.Src, "songs"), filepath.Join(pk.Out, "client", "songs"))
+}
+
+// PackFiles writes every regular file in src into jf under its own name,
+// in name order. A missing src directory produces an empty archive.
+func PackFiles(src string, jf *io.Jagfile) error {
+	names, err := listFiles(src)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		p, err := packet.Load(filepath.Join(src, name), false)
+		if err != nil {
+			return err
+		}
+		jf.Write(name, p)
+	}
+
+	return nil
+}
+
+// compressDir writes a length-prefixed bzip2 copy of every file in src to dst.
+func compressDir(src string, dst string) error {
+	names, err := listFiles(src)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		p, err := packet.Load(filepath.Join(src, name), false)
+		if err != nil {
+			return err
+		}
+
+		compressed, err := io.BZip2Compress(p.Buf, true, false, 1, 0)
+		if err != nil {
+			return err
+		}
+
+		if err := packet.NewPacket(compressed).Save(filepath.Join(dst, name), len(compressed), 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listFiles returns the names of the regular files in dir, sorted.
+// Hidden files are skipped.
+func listFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+			names = append(names, e.Name())
+		}
+	}
+	slices.Sort(names)
+
+	return names, nil
+}
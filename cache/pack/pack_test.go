@@ -0,0 +1,97 @@
+package pack
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPacker_PackAll(t *testing.T) {
+	src := t.TempDir()
+	out := t.TempDir()
+
+	writeFile(t, filepath.Join(src, "title", "logo.dat"), []byte("logo"))
+	writeFile(t, filepath.Join(src, "title", "runes.dat"), []byte("runes"))
+	writeFile(t, filepath.Join(src, "title", ".gitkeep"), nil)
+	writeFile(t, filepath.Join(src, "sounds", "sounds.dat"), []byte{1, 2, 3})
+	writeFile(t, filepath.Join(src, "maps", "m50_50"), []byte("landscape"))
+	writeFile(t, filepath.Join(src, "songs", "scape_main.mid"), []byte("MThd"))
+
+	pk := NewPacker(src, out)
+	pk.Register("wordenc", func(dir string, jf *io.Jagfile) error {
+		jf.Write("badenc.txt", packet.NewPacket([]byte{0, 0, 0, 0}))
+		return nil
+	})
+	if err := pk.PackAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	title, err := io.LoadJagfile(filepath.Join(out, "client", "title"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title.FileCount != 2 {
+		t.Fatalf("title.FileCount = %v, want 2", title.FileCount)
+	}
+	if p, err := title.Read("runes.dat"); err != nil || p.String() != "runes" {
+		t.Errorf("title.Read(runes.dat) = %v, %v, want runes", p, err)
+	}
+
+	wordenc, err := io.LoadJagfile(filepath.Join(out, "client", "wordenc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wordenc.Read("badenc.txt"); err != nil {
+		t.Errorf("wordenc.Read(badenc.txt) error = %v", err)
+	}
+
+	config, err := io.LoadJagfile(filepath.Join(out, "client", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.FileCount != 0 {
+		t.Errorf("config.FileCount = %v, want 0", config.FileCount)
+	}
+
+	m, err := packet.Load(filepath.Join(out, "client", "maps", "m50_50"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := io.BZip2Decompress(m.Buf, 0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(raw) != "landscape" {
+		t.Errorf("m50_50 = %q, want landscape", raw)
+	}
+	if _, err := os.Stat(filepath.Join(out, "client", "songs", "scape_main.mid")); err != nil {
+		t.Error(err)
+	}
+
+	crc, err := packet.Load(filepath.Join(out, "client", "crc"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := cache.NewCrcTable(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(crc.Buf, table.Encode()) {
+		t.Errorf("crc = %v, want %v", crc.Buf, table.Encode())
+	}
+}
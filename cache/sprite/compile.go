@@ -0,0 +1,144 @@
+package sprite
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Ext is the extension of the images sprites are compiled from.
+const Ext = ".png"
+
+// Compile builds the sprites in src and writes them, with their
+// index.dat, into jf. It has the signature of a pack.Compiler for the
+// title, media and textures archives.
+//
+//	src/<name>.png        a sprite with one image
+//	src/<name>/0.png ...  a sprite with an image per file, numbered
+//	                      from 0
+//
+// Any other file, such as the title screen's title.dat, is written
+// as-is. Hidden files are skipped. Sprites are written in name order.
+// A source tree without images is packed as-is, index.dat included.
+func Compile(src string, jf *io.Jagfile) error {
+	entries, err := os.ReadDir(src)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	sprites := make(map[string][]string)
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		switch {
+		case strings.HasPrefix(name, "."):
+		case e.IsDir():
+			paths, err := imagePaths(filepath.Join(src, name))
+			if err != nil {
+				return err
+			}
+			sprites[name] = paths
+		case e.Type().IsRegular() && strings.HasSuffix(name, Ext):
+			sprites[strings.TrimSuffix(name, Ext)] = []string{filepath.Join(src, name)}
+		case e.Type().IsRegular():
+			files = append(files, name)
+		}
+	}
+	slices.Sort(files)
+
+	for _, name := range files {
+		if len(sprites) > 0 && name == IndexName {
+			return fmt.Errorf("sprite: %s: built from the images, remove it", filepath.Join(src, name))
+		}
+		if _, ok := sprites[strings.TrimSuffix(name, ".dat")]; ok {
+			return fmt.Errorf("sprite: %s: also built from images", filepath.Join(src, name))
+		}
+		p, err := packet.Load(filepath.Join(src, name), false)
+		if err != nil {
+			return err
+		}
+		jf.Write(name, p)
+	}
+	if len(sprites) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(sprites))
+	for name := range sprites {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	index := packet.NewPacket(nil)
+	for _, name := range names {
+		s, err := load(sprites[name])
+		if err != nil {
+			return fmt.Errorf("sprite: %s: %w", filepath.Join(src, name), err)
+		}
+		dat, err := s.Encode(index)
+		if err != nil {
+			return fmt.Errorf("sprite: %s: %w", filepath.Join(src, name), err)
+		}
+		jf.Write(name+".dat", packet.NewPacket(dat))
+	}
+	jf.Write(IndexName, index)
+	return nil
+}
+
+// imagePaths returns the images of a sprite directory in number order.
+func imagePaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(e.Name(), Ext))
+		if !e.Type().IsRegular() || !strings.HasSuffix(e.Name(), Ext) || err != nil || n < 0 {
+			return nil, fmt.Errorf("sprite: %s: want images named 0%s, 1%s, ...", filepath.Join(dir, e.Name()), Ext, Ext)
+		}
+		paths = append(paths, "")
+	}
+	for i := range paths {
+		paths[i] = filepath.Join(dir, strconv.Itoa(i)+Ext)
+		if _, err := os.Stat(paths[i]); err != nil {
+			return nil, fmt.Errorf("sprite: %s: images must be numbered from 0 without gaps", dir)
+		}
+	}
+	return paths, nil
+}
+
+func load(paths []string) (*Sprite, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no images")
+	}
+
+	imgs := make([]image.Image, len(paths))
+	for i, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		imgs[i], err = png.Decode(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return FromImages(imgs)
+}
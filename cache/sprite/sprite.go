@@ -0,0 +1,270 @@
+// Package sprite decodes and encodes the sprites of the title, media
+// and textures archives, and compiles them from images.
+//
+// Every sprite in an archive shares its index.dat, which holds the
+// headers of the sprites; <name>.dat holds a sprite's pixels:
+//
+//	<name>.dat   G2 offset of the sprite's header in index.dat, then
+//	             the palette indices of each image
+//	index.dat    each sprite's header: G2 width and G2 height of the
+//	             full sprite, G1 palette size, the G3 colours after the
+//	             transparent first one, then each image's G1 x and y
+//	             offset, G2 width and height, and G1 pixel order
+//
+// Pixel order 0 stores an image row by row, 1 column by column.
+// Fonts are stored the same way, with an image per glyph.
+package sprite
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// IndexName is the name of the file holding the sprite headers.
+const IndexName = "index.dat"
+
+// MaxColours is the most colours a sprite can have, besides transparent.
+const MaxColours = 0xFF
+
+// Pixel orders.
+const (
+	RowMajor    = 0
+	ColumnMajor = 1
+)
+
+// An Image is one image of a sprite, cropped to its opaque pixels.
+type Image struct {
+	X      int // offset from the left of the sprite
+	Y      int // offset from the top of the sprite
+	Width  int
+	Height int
+	// Pixels are palette indices, row by row. 0 is transparent.
+	Pixels []uint8
+}
+
+// A Sprite is a set of same sized images sharing a palette.
+type Sprite struct {
+	Width  int
+	Height int
+	// Palette holds the RGB colours of the images. Palette[0] is
+	// transparent.
+	Palette []uint32
+	Images  []Image
+}
+
+// Read decodes the named sprite, stored as name.dat, from jf.
+func Read(jf *io.Jagfile, name string) (*Sprite, error) {
+	dat, err := jf.Read(name + ".dat")
+	if err != nil {
+		return nil, fmt.Errorf("sprite: %s: %w", name, err)
+	}
+	index, err := jf.Read(IndexName)
+	if err != nil {
+		return nil, fmt.Errorf("sprite: %s: %w", IndexName, err)
+	}
+
+	s, err := Decode(dat.Buf, index.Buf)
+	if err != nil {
+		return nil, fmt.Errorf("sprite: %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// Decode decodes the sprite with pixels dat from its archive's index.
+// Images are read until the pixels run out.
+func Decode(dat []byte, index []byte) (s *Sprite, err error) {
+	defer packet.Recover(&err)
+
+	d := packet.NewPacket(dat)
+	idx := packet.NewPacket(index)
+	idx.Pos = int(d.G2())
+
+	s = &Sprite{}
+	s.Width = int(idx.G2())
+	s.Height = int(idx.G2())
+	s.Palette = make([]uint32, max(int(idx.G1()), 1))
+	for i := 1; i < len(s.Palette); i++ {
+		s.Palette[i] = idx.G3()
+	}
+
+	for d.Len() > 0 {
+		img := Image{
+			X:      int(idx.G1()),
+			Y:      int(idx.G1()),
+			Width:  int(idx.G2()),
+			Height: int(idx.G2()),
+		}
+		order := idx.G1()
+		img.Pixels = make([]uint8, img.Width*img.Height)
+		switch order {
+		case RowMajor:
+			for i := range img.Pixels {
+				img.Pixels[i] = d.G1()
+			}
+		case ColumnMajor:
+			for x := range img.Width {
+				for y := range img.Height {
+					img.Pixels[x+y*img.Width] = d.G1()
+				}
+			}
+		default:
+			return nil, fmt.Errorf("image %d: unknown pixel order %d", len(s.Images), order)
+		}
+		for _, px := range img.Pixels {
+			if int(px) >= len(s.Palette) {
+				return nil, fmt.Errorf("image %d: colour %d not in palette", len(s.Images), px)
+			}
+		}
+		s.Images = append(s.Images, img)
+	}
+	return s, nil
+}
+
+// Encode appends the sprite's header to index and returns its pixels,
+// to be stored as name.dat. Images are stored row by row.
+func (s *Sprite) Encode(index *packet.Packet) ([]byte, error) {
+	if err := s.check(); err != nil {
+		return nil, err
+	}
+	if len(index.Buf) > 0xFFFF {
+		return nil, errors.New("index.dat is full")
+	}
+
+	dat := packet.NewPacket(nil)
+	dat.P2(uint16(len(index.Buf)))
+
+	index.P2(uint16(s.Width))
+	index.P2(uint16(s.Height))
+	index.P1(uint8(len(s.Palette)))
+	for _, rgb := range s.Palette[1:] {
+		index.P3(rgb)
+	}
+	for _, img := range s.Images {
+		index.P1(uint8(img.X))
+		index.P1(uint8(img.Y))
+		index.P2(uint16(img.Width))
+		index.P2(uint16(img.Height))
+		index.P1(RowMajor)
+		dat.PData(img.Pixels, len(img.Pixels))
+	}
+	return dat.Buf, nil
+}
+
+func (s *Sprite) check() error {
+	if s.Width > 0xFFFF || s.Height > 0xFFFF {
+		return fmt.Errorf("%dx%d is too large", s.Width, s.Height)
+	}
+	if len(s.Palette) == 0 || len(s.Palette) > MaxColours+1 {
+		return fmt.Errorf("%d colours, want 0 to %d", len(s.Palette)-1, MaxColours)
+	}
+	for i, img := range s.Images {
+		if img.X > 0xFF || img.Y > 0xFF || img.X+img.Width > s.Width || img.Y+img.Height > s.Height {
+			return fmt.Errorf("image %d: %dx%d at %d,%d is out of bounds", i, img.Width, img.Height, img.X, img.Y)
+		}
+		if len(img.Pixels) != img.Width*img.Height {
+			return fmt.Errorf("image %d: %d pixels, want %d", i, len(img.Pixels), img.Width*img.Height)
+		}
+	}
+	return nil
+}
+
+// FromImages builds a sprite from images of the same size. Transparent
+// pixels, and magenta ones, which the original tools used for
+// transparency, are left out. Each image is cropped to its opaque
+// pixels; a fully transparent image is kept as one transparent pixel,
+// so it still takes space in the pixels. Black is stored as 0x000001,
+// as the client does, since 0 is transparent.
+func FromImages(imgs []image.Image) (*Sprite, error) {
+	if len(imgs) == 0 {
+		return nil, errors.New("no images")
+	}
+	size := imgs[0].Bounds().Size()
+
+	s := &Sprite{Width: size.X, Height: size.Y, Palette: []uint32{0}}
+	colours := make(map[uint32]uint8)
+	for i, src := range imgs {
+		b := src.Bounds()
+		if b.Size() != size {
+			return nil, fmt.Errorf("image %d: %dx%d, want %dx%d", i, b.Dx(), b.Dy(), size.X, size.Y)
+		}
+
+		crop := image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1)
+		empty := true
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				if _, ok := rgb(src.At(x, y)); !ok {
+					continue
+				}
+				if empty {
+					crop, empty = image.Rect(x, y, x+1, y+1), false
+				}
+				crop = crop.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+
+		img := Image{
+			X:      crop.Min.X - b.Min.X,
+			Y:      crop.Min.Y - b.Min.Y,
+			Width:  crop.Dx(),
+			Height: crop.Dy(),
+		}
+		img.Pixels = make([]uint8, 0, img.Width*img.Height)
+		for y := crop.Min.Y; y < crop.Max.Y; y++ {
+			for x := crop.Min.X; x < crop.Max.X; x++ {
+				c, ok := rgb(src.At(x, y))
+				if !ok {
+					img.Pixels = append(img.Pixels, 0)
+					continue
+				}
+				px, ok := colours[c]
+				if !ok {
+					if len(s.Palette) > MaxColours {
+						return nil, fmt.Errorf("more than %d colours", MaxColours)
+					}
+					px = uint8(len(s.Palette))
+					colours[c] = px
+					s.Palette = append(s.Palette, c)
+				}
+				img.Pixels = append(img.Pixels, px)
+			}
+		}
+		s.Images = append(s.Images, img)
+	}
+	return s, s.check()
+}
+
+// rgb returns the colour of c as stored in a palette, or false if c is
+// transparent.
+func rgb(c color.Color) (uint32, bool) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	v := uint32(n.R)<<16 | uint32(n.G)<<8 | uint32(n.B)
+	if n.A < 0x80 || v == 0xFF00FF {
+		return 0, false
+	}
+	if v == 0 {
+		v = 1
+	}
+	return v, true
+}
+
+// Image returns image i at the full size of the sprite.
+func (s *Sprite) Image(i int) *image.NRGBA {
+	img := s.Images[i]
+	dst := image.NewNRGBA(image.Rect(0, 0, s.Width, s.Height))
+	for y := range img.Height {
+		for x := range img.Width {
+			px := img.Pixels[x+y*img.Width]
+			if px == 0 {
+				continue
+			}
+			c := s.Palette[px]
+			dst.SetNRGBA(img.X+x, img.Y+y, color.NRGBA{R: uint8(c >> 16), G: uint8(c >> 8), B: uint8(c), A: 0xFF})
+		}
+	}
+	return dst
+}
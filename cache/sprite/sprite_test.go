@@ -0,0 +1,255 @@
+package sprite
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+var (
+	red     = color.NRGBA{R: 0xFF, A: 0xFF}
+	black   = color.NRGBA{A: 0xFF}
+	magenta = color.NRGBA{R: 0xFF, B: 0xFF, A: 0xFF}
+)
+
+// newImage returns a w by h image with the given pixels set.
+func newImage(w, h int, pixels map[image.Point]color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for pt, c := range pixels {
+		img.SetNRGBA(pt.X, pt.Y, c)
+	}
+	return img
+}
+
+func TestFromImages(t *testing.T) {
+	tests := []struct {
+		name    string
+		imgs    []image.Image
+		want    *Sprite
+		wantErr bool
+	}{
+		{
+			name: "cropped",
+			imgs: []image.Image{
+				newImage(4, 3, map[image.Point]color.NRGBA{{1, 1}: red, {2, 2}: black, {0, 0}: magenta}),
+			},
+			want: &Sprite{
+				Width: 4, Height: 3,
+				Palette: []uint32{0, 0xFF0000, 0x000001},
+				Images:  []Image{{X: 1, Y: 1, Width: 2, Height: 2, Pixels: []uint8{1, 0, 0, 2}}},
+			},
+		},
+		{
+			name: "shared palette",
+			imgs: []image.Image{
+				newImage(2, 1, map[image.Point]color.NRGBA{{0, 0}: red}),
+				newImage(2, 1, map[image.Point]color.NRGBA{{0, 0}: black, {1, 0}: red}),
+			},
+			want: &Sprite{
+				Width: 2, Height: 1,
+				Palette: []uint32{0, 0xFF0000, 0x000001},
+				Images: []Image{
+					{Width: 1, Height: 1, Pixels: []uint8{1}},
+					{Width: 2, Height: 1, Pixels: []uint8{2, 1}},
+				},
+			},
+		},
+		{
+			name: "transparent",
+			imgs: []image.Image{newImage(3, 3, nil)},
+			want: &Sprite{
+				Width: 3, Height: 3,
+				Palette: []uint32{0},
+				Images:  []Image{{Width: 1, Height: 1, Pixels: []uint8{0}}},
+			},
+		},
+		{
+			name:    "different sizes",
+			imgs:    []image.Image{newImage(2, 2, nil), newImage(2, 3, nil)},
+			wantErr: true,
+		},
+		{
+			name:    "no images",
+			wantErr: true,
+		},
+		{
+			name: "too many colours",
+			imgs: func() []image.Image {
+				img := image.NewNRGBA(image.Rect(0, 0, 16, 17))
+				for i := range 16 * 17 {
+					img.SetNRGBA(i%16, i/16, color.NRGBA{R: uint8(i), G: uint8(i >> 8), B: 1, A: 0xFF})
+				}
+				return []image.Image{img}
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromImages(tt.imgs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromImages() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromImages() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSprite_Encode(t *testing.T) {
+	s := &Sprite{
+		Width: 4, Height: 3,
+		Palette: []uint32{0, 0xFF0000},
+		Images: []Image{
+			{X: 1, Y: 2, Width: 2, Height: 1, Pixels: []uint8{1, 0}},
+			{Width: 1, Height: 1, Pixels: []uint8{1}},
+		},
+	}
+
+	index := packet.NewPacket([]byte{0xAA})
+	dat, err := s.Encode(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantDat := []byte{0, 1, 1, 0, 1}
+	wantIndex := []byte{
+		0xAA,
+		0, 4, 0, 3, // size
+		2, 0xFF, 0, 0, // palette
+		1, 2, 0, 2, 0, 1, 0, // image 0
+		0, 0, 0, 1, 0, 1, 0, // image 1
+	}
+	if !reflect.DeepEqual(dat, wantDat) {
+		t.Errorf("Encode() dat = % x, want % x", dat, wantDat)
+	}
+	if !reflect.DeepEqual(index.Buf, wantIndex) {
+		t.Errorf("Encode() index = % x, want % x", index.Buf, wantIndex)
+	}
+
+	got, err := Decode(dat, index.Buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, s) {
+		t.Errorf("Decode() = %+v, want %+v", got, s)
+	}
+
+	s.Images[0].X = 3
+	if _, err := s.Encode(packet.NewPacket(nil)); err == nil {
+		t.Error("Encode() out of bounds image error = nil")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	index := []byte{0, 2, 0, 2, 2, 0x12, 0x34, 0x56, 0, 0, 0, 2, 0, 2, 1}
+	tests := []struct {
+		name    string
+		dat     []byte
+		want    []uint8
+		wantErr bool
+	}{
+		{"column major", []byte{0, 0, 1, 0, 1, 1}, []uint8{1, 1, 0, 1}, false},
+		{"truncated", []byte{0, 0, 1, 0, 1}, nil, true},
+		{"not in palette", []byte{0, 0, 1, 0, 2, 1}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode(tt.dat, index)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got.Images[0].Pixels, tt.want) {
+				t.Errorf("Decode() pixels = %v, want %v", got.Images[0].Pixels, tt.want)
+			}
+		})
+	}
+}
+
+func writePNG(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompile(t *testing.T) {
+	src := t.TempDir()
+	logo := newImage(3, 2, map[image.Point]color.NRGBA{{0, 0}: red, {2, 1}: red})
+	writePNG(t, filepath.Join(src, "logo.png"), logo)
+	writePNG(t, filepath.Join(src, "runes", "0.png"), newImage(2, 2, map[image.Point]color.NRGBA{{1, 1}: black}))
+	writePNG(t, filepath.Join(src, "runes", "1.png"), newImage(2, 2, map[image.Point]color.NRGBA{{0, 0}: red}))
+	if err := os.WriteFile(filepath.Join(src, "title.dat"), []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	jf := &io.Jagfile{}
+	if err := Compile(src, jf); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+
+	if jf.FileCount != 4 {
+		t.Errorf("FileCount = %v, want 4", jf.FileCount)
+	}
+	if p, err := jf.Read("title.dat"); err != nil || p.String() != "jpeg" {
+		t.Errorf("Read(title.dat) = %v, %v, want jpeg", p, err)
+	}
+
+	s, err := Read(jf, "logo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Image(0), logo) {
+		t.Errorf("logo image = %v, want %v", s.Image(0), logo)
+	}
+
+	s, err = Read(jf, "runes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Images) != 2 || s.Images[1].Pixels[0] != 2 {
+		t.Errorf("runes = %+v, want 2 images with red last", s)
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"prebuilt index", []string{"logo.png", "index.dat"}},
+		{"prebuilt sprite", []string{"logo.png", "logo.dat"}},
+		{"gap", []string{"runes/0.png", "runes/2.png"}},
+		{"not an image", []string{"runes/0.png", "runes/notes.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := t.TempDir()
+			for _, name := range tt.files {
+				writePNG(t, filepath.Join(src, name), newImage(1, 1, nil))
+			}
+			if err := Compile(src, &io.Jagfile{}); err == nil {
+				t.Error("Compile() error = nil")
+			}
+		})
+	}
+}
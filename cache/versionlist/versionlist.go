@@ -0,0 +1,273 @@
+// Package versionlist decodes and encodes the versionlist archive, which
+// clients from revision 234 onwards use to know which on-demand files
+// exist and whether their cached copies are current.
+//
+// The archive holds, for each on-demand archive, named as in
+// [cache.VersionListNames]:
+//
+//	<name>_version   G2 per file
+//	<name>_crc       G4 per file
+//
+// and the indexes:
+//
+//	model_index   G1 flags per model
+//	anim_index    G2 per anim
+//	midi_index    G1 per midi
+//	map_index     G2 region, G2 landscape file, G2 loc file, G1 members
+//	              per map square
+package versionlist
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// mapSquareSize is the size of a map_index entry.
+const mapSquareSize = 7
+
+// A MapSquare maps a region to the files holding its landscape and locs.
+type MapSquare struct {
+	Region    uint16 // x<<8 | z, in map squares
+	Landscape uint16 // map file holding the landscape
+	Locs      uint16 // map file holding the locs
+	Members   bool
+}
+
+// X returns the x coordinate of the square.
+func (m MapSquare) X() int {
+	return int(m.Region >> 8)
+}
+
+// Z returns the z coordinate of the square.
+func (m MapSquare) Z() int {
+	return int(m.Region & 0xFF)
+}
+
+// Region returns the region id of the map square at x, z.
+func Region(x int, z int) uint16 {
+	return uint16(x<<8 | z)
+}
+
+// A VersionList is the decoded versionlist archive.
+type VersionList struct {
+	Versions [cache.OnDemandCount][]uint16
+	Crcs     [cache.OnDemandCount][]uint32
+
+	ModelIndex []uint8
+	AnimIndex  []uint16
+	MidiIndex  []uint8
+	MapIndex   []MapSquare
+}
+
+// Load returns the versionlist archive at path.
+func Load(path string) (*VersionList, error) {
+	jf, err := io.LoadJagfile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Read(jf)
+}
+
+// Read decodes the versionlist entries of jf.
+func Read(jf *io.Jagfile) (*VersionList, error) {
+	v := &VersionList{}
+
+	for i, name := range cache.VersionListNames {
+		p, err := jf.Read(name + "_version")
+		if err != nil {
+			return nil, err
+		}
+		if v.Versions[i], err = DecodeVersions(p); err != nil {
+			return nil, fmt.Errorf("%s_version: %w", name, err)
+		}
+
+		p, err = jf.Read(name + "_crc")
+		if err != nil {
+			return nil, err
+		}
+		if v.Crcs[i], err = DecodeCrcs(p); err != nil {
+			return nil, fmt.Errorf("%s_crc: %w", name, err)
+		}
+	}
+
+	p, err := jf.Read("model_index")
+	if err != nil {
+		return nil, err
+	}
+	v.ModelIndex = DecodeFlags(p)
+
+	p, err = jf.Read("anim_index")
+	if err != nil {
+		return nil, err
+	}
+	if v.AnimIndex, err = DecodeVersions(p); err != nil {
+		return nil, fmt.Errorf("anim_index: %w", err)
+	}
+
+	p, err = jf.Read("midi_index")
+	if err != nil {
+		return nil, err
+	}
+	v.MidiIndex = DecodeFlags(p)
+
+	p, err = jf.Read("map_index")
+	if err != nil {
+		return nil, err
+	}
+	if v.MapIndex, err = DecodeMapIndex(p); err != nil {
+		return nil, fmt.Errorf("map_index: %w", err)
+	}
+
+	return v, nil
+}
+
+// Write writes every versionlist entry into jf.
+func (v *VersionList) Write(jf *io.Jagfile) {
+	for i, name := range cache.VersionListNames {
+		jf.Write(name+"_version", EncodeVersions(v.Versions[i]))
+		jf.Write(name+"_crc", EncodeCrcs(v.Crcs[i]))
+	}
+
+	jf.Write("model_index", EncodeFlags(v.ModelIndex))
+	jf.Write("anim_index", EncodeVersions(v.AnimIndex))
+	jf.Write("midi_index", EncodeFlags(v.MidiIndex))
+	jf.Write("map_index", EncodeMapIndex(v.MapIndex))
+}
+
+// Save writes the versionlist archive to path.
+func (v *VersionList) Save(path string) error {
+	jf := &io.Jagfile{}
+	v.Write(jf)
+	return jf.Save(path, false)
+}
+
+// SetFile records the version and crc of a file, growing the tables of
+// the archive to hold it.
+func (v *VersionList) SetFile(archive int, file int, version uint16, data []byte) error {
+	if archive < 0 || archive >= cache.OnDemandCount {
+		return errors.New("versionlist: unknown archive")
+	}
+	if file < 0 || file > 0xFFFF {
+		return errors.New("versionlist: file out of range")
+	}
+
+	if n := file + 1; len(v.Versions[archive]) < n {
+		v.Versions[archive] = append(v.Versions[archive], make([]uint16, n-len(v.Versions[archive]))...)
+	}
+	if n := file + 1; len(v.Crcs[archive]) < n {
+		v.Crcs[archive] = append(v.Crcs[archive], make([]uint32, n-len(v.Crcs[archive]))...)
+	}
+
+	v.Versions[archive][file] = version
+	v.Crcs[archive][file] = packet.GetCRC(data, 0, len(data))
+	return nil
+}
+
+// FileCount returns the number of files in archive.
+func (v *VersionList) FileCount(archive int) int {
+	return len(v.Versions[archive])
+}
+
+// MapSquare returns the map_index entry for the map square at x, z.
+func (v *VersionList) MapSquare(x int, z int) (MapSquare, bool) {
+	region := Region(x, z)
+	for _, m := range v.MapIndex {
+		if m.Region == region {
+			return m, true
+		}
+	}
+	return MapSquare{}, false
+}
+
+// DecodeVersions decodes a table of G2 values, as used by the
+// <name>_version entries and anim_index.
+func DecodeVersions(src *packet.Packet) ([]uint16, error) {
+	if src.Len()%2 != 0 {
+		return nil, fmt.Errorf("versionlist: length %d is not a multiple of 2", src.Len())
+	}
+
+	values := make([]uint16, src.Len()/2)
+	for i := range values {
+		values[i] = src.G2()
+	}
+	return values, nil
+}
+
+// EncodeVersions encodes a table of G2 values.
+func EncodeVersions(values []uint16) *packet.Packet {
+	p := packet.NewPacket(make([]byte, 0, len(values)*2))
+	for _, value := range values {
+		p.P2(value)
+	}
+	return p
+}
+
+// DecodeCrcs decodes a <name>_crc table.
+func DecodeCrcs(src *packet.Packet) ([]uint32, error) {
+	if src.Len()%4 != 0 {
+		return nil, fmt.Errorf("versionlist: length %d is not a multiple of 4", src.Len())
+	}
+
+	crcs := make([]uint32, src.Len()/4)
+	for i := range crcs {
+		crcs[i] = src.G4()
+	}
+	return crcs, nil
+}
+
+// EncodeCrcs encodes a <name>_crc table.
+func EncodeCrcs(crcs []uint32) *packet.Packet {
+	p := packet.NewPacket(make([]byte, 0, len(crcs)*4))
+	for _, crc := range crcs {
+		p.P4(crc)
+	}
+	return p
+}
+
+// DecodeFlags decodes a table of G1 values, as used by model_index
+// and midi_index.
+func DecodeFlags(src *packet.Packet) []uint8 {
+	flags := make([]uint8, src.Len())
+	copy(flags, src.Bytes())
+	src.Pos += len(flags)
+	return flags
+}
+
+// EncodeFlags encodes a table of G1 values.
+func EncodeFlags(flags []uint8) *packet.Packet {
+	return packet.NewPacket(append([]uint8{}, flags...))
+}
+
+// DecodeMapIndex decodes map_index.
+func DecodeMapIndex(src *packet.Packet) ([]MapSquare, error) {
+	if src.Len()%mapSquareSize != 0 {
+		return nil, fmt.Errorf("versionlist: length %d is not a multiple of %d", src.Len(), mapSquareSize)
+	}
+
+	squares := make([]MapSquare, src.Len()/mapSquareSize)
+	for i := range squares {
+		squares[i] = MapSquare{
+			Region:    src.G2(),
+			Landscape: src.G2(),
+			Locs:      src.G2(),
+			Members:   src.GBool(),
+		}
+	}
+	return squares, nil
+}
+
+// EncodeMapIndex encodes map_index.
+func EncodeMapIndex(squares []MapSquare) *packet.Packet {
+	p := packet.NewPacket(make([]byte, 0, len(squares)*mapSquareSize))
+	for _, m := range squares {
+		p.P2(m.Region)
+		p.P2(m.Landscape)
+		p.P2(m.Locs)
+		p.PBool(m.Members)
+	}
+	return p
+}
@@ -0,0 +1,193 @@
+package versionlist
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestVersionList_RoundTrip(t *testing.T) {
+	want := &VersionList{
+		Versions: [cache.OnDemandCount][]uint16{
+			cache.OnDemandModels: {0, 0, 5},
+			cache.OnDemandAnims:  {1},
+			cache.OnDemandMidi:   {0, 3},
+			cache.OnDemandMaps:   {0, 0, 0, 2},
+		},
+		Crcs: [cache.OnDemandCount][]uint32{
+			cache.OnDemandModels: {0, 0, 0x8D2A3F11},
+			cache.OnDemandAnims:  {0x1F2E3D4C},
+			cache.OnDemandMidi:   {0, 0xFFFFFFFF},
+			cache.OnDemandMaps:   {0, 0, 0, 123},
+		},
+		ModelIndex: []uint8{0, 1, 8},
+		AnimIndex:  []uint16{0, 1, 2},
+		MidiIndex:  []uint8{1, 0},
+		MapIndex: []MapSquare{
+			{Region: Region(50, 50), Landscape: 0, Locs: 1, Members: false},
+			{Region: Region(40, 150), Landscape: 2, Locs: 3, Members: true},
+		},
+	}
+
+	jf := &io.Jagfile{}
+	want.Write(jf)
+	data, err := jf.Encode(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.NewJagfile(packet.NewPacket(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Read(decoded)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+}
+
+func TestVersionList_Save(t *testing.T) {
+	want := &VersionList{
+		Versions:   [cache.OnDemandCount][]uint16{cache.OnDemandModels: {4}, cache.OnDemandAnims: {}, cache.OnDemandMidi: {}, cache.OnDemandMaps: {}},
+		Crcs:       [cache.OnDemandCount][]uint32{cache.OnDemandModels: {99}, cache.OnDemandAnims: {}, cache.OnDemandMidi: {}, cache.OnDemandMaps: {}},
+		ModelIndex: []uint8{0},
+		AnimIndex:  []uint16{},
+		MidiIndex:  []uint8{},
+		MapIndex:   []MapSquare{},
+	}
+	path := filepath.Join(t.TempDir(), "versionlist")
+
+	if err := want.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() = %+v, want %+v", got, want)
+	}
+}
+
+func TestVersionList_SetFile(t *testing.T) {
+	v := &VersionList{}
+	if err := v.SetFile(cache.OnDemandModels, 3, 7, []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := v.FileCount(cache.OnDemandModels); got != 4 {
+		t.Errorf("FileCount() = %d, want 4", got)
+	}
+	if got := v.Versions[cache.OnDemandModels]; !reflect.DeepEqual(got, []uint16{0, 0, 0, 7}) {
+		t.Errorf("Versions = %v", got)
+	}
+	if got, want := v.Crcs[cache.OnDemandModels][3], packet.GetCRC([]byte("abc"), 0, 3); got != want {
+		t.Errorf("Crcs[3] = %d, want %d", got, want)
+	}
+
+	if err := v.SetFile(cache.OnDemandCount, 0, 0, nil); err == nil {
+		t.Error("SetFile() accepted unknown archive")
+	}
+}
+
+func TestVersionList_MapSquare(t *testing.T) {
+	v := &VersionList{MapIndex: []MapSquare{
+		{Region: Region(50, 50), Landscape: 0, Locs: 1, Members: false},
+		{Region: Region(40, 150), Landscape: 2, Locs: 3, Members: true},
+	}}
+
+	m, ok := v.MapSquare(40, 150)
+	if !ok || m.Landscape != 2 || m.Locs != 3 || !m.Members {
+		t.Errorf("MapSquare(40, 150) = %+v, %v", m, ok)
+	}
+	if m.X() != 40 || m.Z() != 150 {
+		t.Errorf("X(), Z() = %d, %d, want 40, 150", m.X(), m.Z())
+	}
+	if _, ok := v.MapSquare(1, 1); ok {
+		t.Error("MapSquare(1, 1) found a square")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		decode  func(*packet.Packet) (any, error)
+		input   []byte
+		want    any
+		wantErr bool
+	}{
+		{
+			name:   "versions",
+			decode: func(p *packet.Packet) (any, error) { return DecodeVersions(p) },
+			input:  []byte{0, 1, 0x12, 0x34},
+			want:   []uint16{1, 0x1234},
+		},
+		{
+			name:    "versions odd length",
+			decode:  func(p *packet.Packet) (any, error) { return DecodeVersions(p) },
+			input:   []byte{0, 1, 2},
+			wantErr: true,
+		},
+		{
+			name:   "crcs",
+			decode: func(p *packet.Packet) (any, error) { return DecodeCrcs(p) },
+			input:  []byte{0xde, 0xad, 0xbe, 0xef},
+			want:   []uint32{0xdeadbeef},
+		},
+		{
+			name:    "crcs truncated",
+			decode:  func(p *packet.Packet) (any, error) { return DecodeCrcs(p) },
+			input:   []byte{0, 0, 0, 1, 2},
+			wantErr: true,
+		},
+		{
+			name:   "flags",
+			decode: func(p *packet.Packet) (any, error) { return DecodeFlags(p), nil },
+			input:  []byte{1, 0, 8},
+			want:   []uint8{1, 0, 8},
+		},
+		{
+			name:   "map index",
+			decode: func(p *packet.Packet) (any, error) { return DecodeMapIndex(p) },
+			input:  []byte{0x32, 0x32, 0, 5, 0, 6, 1},
+			want:   []MapSquare{{Region: Region(50, 50), Landscape: 5, Locs: 6, Members: true}},
+		},
+		{
+			name:    "map index truncated",
+			decode:  func(p *packet.Packet) (any, error) { return DecodeMapIndex(p) },
+			input:   []byte{0x32, 0x32, 0, 5, 0, 6},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.decode(packet.NewPacket(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decode error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decode = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	squares := []MapSquare{{Region: Region(50, 50), Landscape: 5, Locs: 6, Members: true}}
+	if got, want := EncodeMapIndex(squares).Buf, []byte{0x32, 0x32, 0, 5, 0, 6, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeMapIndex() = %v, want %v", got, want)
+	}
+	if got, want := EncodeCrcs([]uint32{0xdeadbeef}).Buf, []byte{0xde, 0xad, 0xbe, 0xef}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeCrcs() = %v, want %v", got, want)
+	}
+	if got, want := EncodeVersions([]uint16{1, 0x1234}).Buf, []byte{0, 1, 0x12, 0x34}; !reflect.DeepEqual(got, want) {
+		t.Errorf("EncodeVersions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,343 @@
+package wordenc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Plaintext list files read by [LoadPlaintext] and written by
+// [WordFilter.SavePlaintext]. Lines starting with # are comments.
+//
+//	badwords.txt    a word per line, followed by its sorted combination
+//	                pairs, e.g. "word 1:5 2:7"
+//	domains.txt     a domain per line
+//	fragments.txt   a number per line, sorted
+//	tlds.txt        a type (1 to 3) and a tld per line, e.g. "1 com"
+//	whitelist.txt   a word per line, optional; server-only, it is
+//	                not written to the archive
+const (
+	BadWordsFile  = "badwords.txt"
+	DomainsFile   = "domains.txt"
+	FragmentsFile = "fragments.txt"
+	TLDsFile      = "tlds.txt"
+	WhitelistFile = "whitelist.txt"
+)
+
+// Compile reads the plaintext lists in src and writes the encoded lists
+// into jf. It has the signature of a pack.Compiler for the wordenc archive.
+func Compile(src string, jf *io.Jagfile) error {
+	w, err := LoadPlaintext(src)
+	if err != nil {
+		return err
+	}
+	return w.WriteJagfile(jf)
+}
+
+// WriteJagfile writes the encoded lists into jf under the names the
+// client reads them from. The whitelist is server-only and is not
+// written; the server loads it with [WordFilter.LoadWhitelist].
+func (w *WordFilter) WriteJagfile(jf *io.Jagfile) error {
+	bad, err := w.encodeBadWords()
+	if err != nil {
+		return err
+	}
+	domains, err := encodeWords(w.Domains)
+	if err != nil {
+		return err
+	}
+	fragments, err := w.encodeFragments()
+	if err != nil {
+		return err
+	}
+	tlds, err := w.encodeTLD()
+	if err != nil {
+		return err
+	}
+
+	jf.Write("fragmentsenc.txt", fragments)
+	jf.Write("badenc.txt", bad)
+	jf.Write("domainenc.txt", domains)
+	jf.Write("tldlist.txt", tlds)
+
+	return nil
+}
+
+// ReadJagfile returns the word filter encoded in jf.
+func ReadJagfile(jf *io.Jagfile) (*WordFilter, error) {
+	w := &WordFilter{}
+	if err := w.readAll(jf); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *WordFilter) encodeBadWords() (*packet.Packet, error) {
+	p := packet.NewPacket(nil)
+	p.P4(uint32(len(w.BadWords)))
+
+	for i, word := range w.BadWords {
+		if err := putWord(p, word); err != nil {
+			return nil, err
+		}
+
+		var combinations [][2]int
+		if i < len(w.BadCombinations) {
+			combinations = w.BadCombinations[i]
+		}
+		if len(combinations) > 255 {
+			return nil, fmt.Errorf("%q has too many combinations", string(word))
+		}
+		p.P1(uint8(len(combinations)))
+		for _, c := range combinations {
+			if c[0] < 0 || c[0] > 255 || c[1] < 0 || c[1] > 255 {
+				return nil, fmt.Errorf("%q has a combination out of range", string(word))
+			}
+			p.P1(uint8(c[0]))
+			p.P1(uint8(c[1]))
+		}
+	}
+
+	return p, nil
+}
+
+func encodeWords(words [][]rune) (*packet.Packet, error) {
+	p := packet.NewPacket(nil)
+	p.P4(uint32(len(words)))
+
+	for _, word := range words {
+		if err := putWord(p, word); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (w *WordFilter) encodeFragments() (*packet.Packet, error) {
+	p := packet.NewPacket(nil)
+	p.P4(uint32(len(w.Fragments)))
+
+	for _, fragment := range w.Fragments {
+		if fragment < 0 || fragment > 0xFFFF {
+			return nil, fmt.Errorf("fragment %d out of range", fragment)
+		}
+		p.P2(uint16(fragment))
+	}
+
+	return p, nil
+}
+
+func (w *WordFilter) encodeTLD() (*packet.Packet, error) {
+	if len(w.TLDTypes) != len(w.TLDs) {
+		return nil, errors.New("tld and tld type counts differ")
+	}
+
+	p := packet.NewPacket(nil)
+	p.P4(uint32(len(w.TLDs)))
+
+	for i, tld := range w.TLDs {
+		p.P1(w.TLDTypes[i])
+		if err := putWord(p, tld); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// putWord puts a length-prefixed word of single byte characters.
+func putWord(p *packet.Packet, word []rune) error {
+	if len(word) > 255 {
+		return fmt.Errorf("%q is too long", string(word))
+	}
+	p.P1(uint8(len(word)))
+	for _, c := range word {
+		if c > 0xFF {
+			return fmt.Errorf("%q contains a character that cannot be encoded", string(word))
+		}
+		p.P1(uint8(c))
+	}
+	return nil
+}
+
+// LoadPlaintext reads a word filter from the plaintext lists in dir.
+// Errors cite the file and line they were found on.
+func LoadPlaintext(dir string) (*WordFilter, error) {
+	w := &WordFilter{}
+
+	err := readLines(filepath.Join(dir, BadWordsFile), func(fields []string) error {
+		var combinations [][2]int
+		for _, field := range fields[1:] {
+			a, b, ok := strings.Cut(field, ":")
+			if !ok {
+				return fmt.Errorf("bad combination %q, want a:b", field)
+			}
+			first, err := strconv.Atoi(a)
+			if err != nil {
+				return fmt.Errorf("bad combination %q: %w", field, err)
+			}
+			second, err := strconv.Atoi(b)
+			if err != nil {
+				return fmt.Errorf("bad combination %q: %w", field, err)
+			}
+			c := [2]int{first, second}
+			if n := len(combinations); n > 0 && !comboLess(combinations[n-1], c) {
+				return fmt.Errorf("combination %q is not in sorted order", field)
+			}
+			combinations = append(combinations, c)
+		}
+		w.BadWords = append(w.BadWords, []rune(fields[0]))
+		w.BadCombinations = append(w.BadCombinations, combinations)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readLines(filepath.Join(dir, DomainsFile), func(fields []string) error {
+		if len(fields) != 1 {
+			return errors.New("want one domain per line")
+		}
+		w.Domains = append(w.Domains, []rune(fields[0]))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readLines(filepath.Join(dir, FragmentsFile), func(fields []string) error {
+		if len(fields) != 1 {
+			return errors.New("want one fragment per line")
+		}
+		fragment, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return err
+		}
+		if n := len(w.Fragments); n > 0 && w.Fragments[n-1] >= fragment {
+			return fmt.Errorf("fragment %d is not in sorted order", fragment)
+		}
+		w.Fragments = append(w.Fragments, fragment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readLines(filepath.Join(dir, TLDsFile), func(fields []string) error {
+		if len(fields) != 2 {
+			return errors.New("want a type and a tld")
+		}
+		typ, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return err
+		}
+		if typ < 1 || typ > 3 {
+			return fmt.Errorf("tld type %d out of range 1 to 3", typ)
+		}
+		w.TLDTypes = append(w.TLDTypes, uint8(typ))
+		w.TLDs = append(w.TLDs, []rune(fields[1]))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	whitelist := filepath.Join(dir, WhitelistFile)
+	if _, err := os.Stat(whitelist); err == nil {
+		if err := w.LoadWhitelist(whitelist); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func comboLess(a [2]int, b [2]int) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
+
+// readLines calls f with the whitespace-separated fields of each line of
+// the file at path, skipping blank lines and comments. Errors are
+// prefixed with the file and line number.
+func readLines(path string, f func(fields []string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if err := f(strings.Fields(text)); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// SavePlaintext writes the lists to dir in the form read by [LoadPlaintext].
+func (w *WordFilter) SavePlaintext(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for i, word := range w.BadWords {
+		b.WriteString(string(word))
+		if i < len(w.BadCombinations) {
+			for _, c := range w.BadCombinations[i] {
+				fmt.Fprintf(&b, " %d:%d", c[0], c[1])
+			}
+		}
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, BadWordsFile), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	b.Reset()
+	for _, domain := range w.Domains {
+		b.WriteString(string(domain))
+		b.WriteByte('\n')
+	}
+	if err := os.WriteFile(filepath.Join(dir, DomainsFile), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	b.Reset()
+	for _, fragment := range w.Fragments {
+		fmt.Fprintf(&b, "%d\n", fragment)
+	}
+	if err := os.WriteFile(filepath.Join(dir, FragmentsFile), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	b.Reset()
+	for i, tld := range w.TLDs {
+		fmt.Fprintf(&b, "%d %s\n", w.TLDTypes[i], string(tld))
+	}
+	if err := os.WriteFile(filepath.Join(dir, TLDsFile), []byte(b.String()), 0644); err != nil {
+		return err
+	}
+
+	if w.Whitelist != nil {
+		data := strings.Join(w.Whitelist, "\n") + "\n"
+		if err := os.WriteFile(filepath.Join(dir, WhitelistFile), []byte(data), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
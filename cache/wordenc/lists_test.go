@@ -0,0 +1,169 @@
+package wordenc
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func writeLists(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCompile_RoundTrip(t *testing.T) {
+	src := t.TempDir()
+	writeLists(t, src, map[string]string{
+		BadWordsFile:  "# comment\nfuck\nshit 1:5 2:3 2:7\n\n",
+		DomainsFile:   "badword\nexample\n",
+		FragmentsFile: "123\n4567\n",
+		TLDsFile:      "1 com\n2 net\n3 uk\n",
+		WhitelistFile: "cook\nsheet\n",
+	})
+
+	jf := &io.Jagfile{}
+	if err := Compile(src, jf); err != nil {
+		t.Fatalf("Compile() error = %v", err)
+	}
+
+	// go through the archive encoding as the client would see it
+	data, err := jf.Encode(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.NewJagfile(packet.NewPacket(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJagfile(decoded)
+	if err != nil {
+		t.Fatalf("ReadJagfile() error = %v", err)
+	}
+
+	want := &WordFilter{
+		BadWords:        [][]rune{[]rune("fuck"), []rune("shit")},
+		BadCombinations: [][][2]int{nil, {{1, 5}, {2, 3}, {2, 7}}},
+		Domains:         [][]rune{[]rune("badword"), []rune("example")},
+		Fragments:       []int{123, 4567},
+		TLDs:            [][]rune{[]rune("com"), []rune("net"), []rune("uk")},
+		TLDTypes:        []uint8{1, 2, 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadJagfile() = %+v, want %+v", got, want)
+	}
+	// the whitelist is server-only
+	if _, err := decoded.Read(WhitelistFile); err == nil {
+		t.Errorf("archive holds %s", WhitelistFile)
+	}
+
+	out := t.TempDir()
+	if err := got.SavePlaintext(out); err != nil {
+		t.Fatalf("SavePlaintext() error = %v", err)
+	}
+	wantFiles := map[string]string{
+		BadWordsFile:  "fuck\nshit 1:5 2:3 2:7\n",
+		DomainsFile:   "badword\nexample\n",
+		FragmentsFile: "123\n4567\n",
+		TLDsFile:      "1 com\n2 net\n3 uk\n",
+	}
+	for name, want := range wantFiles {
+		b, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("%s = %q, want %q", name, b, want)
+		}
+	}
+}
+
+func TestWordFilter_encode(t *testing.T) {
+	wf := &WordFilter{
+		BadWords:        [][]rune{[]rune("fuck")},
+		BadCombinations: [][][2]int{{{1, 2}}},
+		Domains:         [][]rune{[]rune("badword")},
+		Fragments:       []int{123},
+		TLDs:            [][]rune{[]rune("com")},
+		TLDTypes:        []uint8{1},
+	}
+
+	bad, err := wf.encodeBadWords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBad := []byte{0, 0, 0, 1, 4, 'f', 'u', 'c', 'k', 1, 1, 2}
+	if !reflect.DeepEqual(bad.Buf, wantBad) {
+		t.Errorf("encodeBadWords() = %v, want %v", bad.Buf, wantBad)
+	}
+
+	fragments, err := wf.encodeFragments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantFragments := []byte{0, 0, 0, 1, 0, 123}
+	if !reflect.DeepEqual(fragments.Buf, wantFragments) {
+		t.Errorf("encodeFragments() = %v, want %v", fragments.Buf, wantFragments)
+	}
+
+	tlds, err := wf.encodeTLD()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantTLDs := []byte{0, 0, 0, 1, 1, 3, 'c', 'o', 'm'}
+	if !reflect.DeepEqual(tlds.Buf, wantTLDs) {
+		t.Errorf("encodeTLD() = %v, want %v", tlds.Buf, wantTLDs)
+	}
+
+	// the readers must accept what the encoders produce
+	got := &WordFilter{}
+	got.readBadWords(bad)
+	got.readFragments(fragments)
+	got.readTLD(tlds)
+	if !reflect.DeepEqual(got.BadCombinations, wf.BadCombinations) || !reflect.DeepEqual(got.Fragments, wf.Fragments) || !reflect.DeepEqual(got.TLDs, wf.TLDs) {
+		t.Errorf("decoded %+v, want %+v", got, wf)
+	}
+}
+
+func TestLoadPlaintext_Errors(t *testing.T) {
+	valid := map[string]string{
+		BadWordsFile:  "fuck\n",
+		DomainsFile:   "badword\n",
+		FragmentsFile: "123\n",
+		TLDsFile:      "1 com\n",
+	}
+
+	tests := []struct {
+		name    string
+		file    string
+		data    string
+		wantErr string
+	}{
+		{name: "bad combination", file: BadWordsFile, data: "fuck\nshit 1-5\n", wantErr: BadWordsFile + ":2: bad combination"},
+		{name: "unsorted combination", file: BadWordsFile, data: "shit 2:3 1:5\n", wantErr: BadWordsFile + ":1: combination \"1:5\" is not in sorted order"},
+		{name: "unsorted fragment", file: FragmentsFile, data: "5\n3\n", wantErr: FragmentsFile + ":2: fragment 3 is not in sorted order"},
+		{name: "tld type", file: TLDsFile, data: "# types\n4 com\n", wantErr: TLDsFile + ":2: tld type 4 out of range"},
+		{name: "tld missing type", file: TLDsFile, data: "com\n", wantErr: TLDsFile + ":1: want a type and a tld"},
+		{name: "domain with spaces", file: DomainsFile, data: "bad word\n", wantErr: DomainsFile + ":1: want one domain per line"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeLists(t, dir, valid)
+			writeLists(t, dir, map[string]string{tt.file: tt.data})
+
+			_, err := LoadPlaintext(dir)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadPlaintext() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package wordenc
 
 import (
+	"os"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -16,8 +17,19 @@ type WordFilter struct {
 	Fragments       []int
 	TLDs            [][]rune
 	TLDTypes        []uint8
+
+	// Whitelist holds words that are restored after filtering wherever
+	// they appear in the original message. A nil Whitelist means
+	// DefaultWhitelist; an empty one disables the stage.
+	Whitelist []string
 }
 
+// DefaultWhitelist is the whitelist used when none is configured.
+var DefaultWhitelist = []string{"cook", "cook's", "cooks", "seeks", "sheet"}
+
+// LoadWordFilter loads the word filter from the packed cache in dir:
+// the lists from the client's wordenc archive, and the whitelist, if
+// there is one, from the server's data.
 func LoadWordFilter(dir string) (*WordFilter, error) {
 	jf, err := io.LoadJagfile(filepath.Join(dir, "client", "wordenc"))
 	if err != nil {
@@ -30,7 +42,14 @@ func LoadWordFilter(dir string) (*WordFilter, error) {
 		return nil, err
 	}
 
-	return w, err
+	whitelist := filepath.Join(dir, "server", WhitelistFile)
+	if _, err := os.Stat(whitelist); err == nil {
+		if err := w.LoadWhitelist(whitelist); err != nil {
+			return nil, err
+		}
+	}
+
+	return w, nil
 }
 
 func (w *WordFilter) readAll(jf *io.Jagfile) error {
@@ -62,6 +81,17 @@ func (w *WordFilter) readAll(jf *io.Jagfile) error {
 	return nil
 }
 
+// LoadWhitelist replaces the whitelist with the words in the file at
+// path, one per line. Blank lines and lines starting with # are skipped.
+func (w *WordFilter) LoadWhitelist(path string) error {
+	words, err := io.ReadWordlist(path)
+	if err != nil {
+		return err
+	}
+	w.Whitelist = words
+	return nil
+}
+
 func (w *WordFilter) readTLD(buf *packet.Packet) {
 	count := buf.G4()
 
@@ -146,31 +176,135 @@ func isCharacterAllowed(c rune) bool {
 	return (c >= ' ' && c <= '\u007f') || c == ' ' || c == '\n' || c == '\t' || c == '£' || c == '€'
 }
 
+// A Reason identifies the filter stage that masked part of a message.
+type Reason int
+
+const (
+	ReasonTLD Reason = iota + 1
+	ReasonBadWord
+	ReasonDomain
+	ReasonFragment
+)
+
+func (r Reason) String() string {
+	switch r {
+	case ReasonTLD:
+		return "tld"
+	case ReasonBadWord:
+		return "bad word"
+	case ReasonDomain:
+		return "domain"
+	case ReasonFragment:
+		return "fragment"
+	default:
+		return "unknown"
+	}
+}
+
+// A Mask is a span of a filtered message that was masked with '*'.
+// Start and End are rune offsets into the filtered message, End exclusive.
+type Mask struct {
+	Start  int
+	End    int
+	Reason Reason
+}
+
+// Filter returns input with any bad words, domains, TLDs and
+// number fragments masked.
+func (w *WordFilter) Filter(input string) string {
+	output, _ := filterReport(w, input, false)
+	return output
+}
+
+// FilterReport is like [WordFilter.Filter] but also returns the spans
+// of the result that were masked, and which stage masked them.
+func (w *WordFilter) FilterReport(input string) (string, []Mask) {
+	return filterReport(w, input, true)
+}
+
 func filter(wf *WordFilter, input string) string {
+	output, _ := filterReport(wf, input, false)
+	return output
+}
+
+func filterReport(wf *WordFilter, input string, report bool) (string, []Mask) {
 	outputPre := []rune(input)
 	filterCharacters(outputPre)
 	trimmed := strings.TrimSpace(string(outputPre))
 	lowercase := strings.ToLower(trimmed)
 	output := []rune(lowercase)
-	filterTLD(wf, output)
-	filterBadWords(wf, output)
-	filterDomains(wf, output)
-	filterFragments(output)
-
-	whitelist := []string{"cook", "cook's", "cooks", "seeks", "sheet"}
-
-	for index := range whitelist {
-		offset := -1
-		for offset = strings.Index(lowercase[offset+1:], whitelist[index]); offset != -1; {
-			whitelisted := []rune(whitelist[index])
-			for charIndex := range whitelisted {
-				output[charIndex+offset] = whitelisted[charIndex]
+
+	var reasons []Reason
+	stage := func(reason Reason, f func()) {
+		if !report {
+			f()
+			return
+		}
+		if reasons == nil {
+			reasons = make([]Reason, len(output))
+		}
+		before := slices.Clone(output)
+		f()
+		for i := range output {
+			if output[i] == '*' && before[i] != '*' && reasons[i] == 0 {
+				reasons[i] = reason
 			}
 		}
 	}
+
+	stage(ReasonTLD, func() { filterTLD(wf, output) })
+	stage(ReasonBadWord, func() { filterBadWords(wf, output) })
+	stage(ReasonDomain, func() { filterDomains(wf, output) })
+	stage(ReasonFragment, func() { filterFragments(output) })
+
+	whitelist := wf.Whitelist
+	if whitelist == nil {
+		whitelist = DefaultWhitelist
+	}
+	applyWhitelist(output, []rune(lowercase), whitelist)
+
 	replaceUppercases(output, []rune(trimmed))
 	formatUppercases(output)
-	return strings.TrimSpace(string(output))
+
+	var masks []Mask
+	if report {
+		masks = collectMasks(output, reasons)
+	}
+	return strings.TrimSpace(string(output)), masks
+}
+
+// applyWhitelist restores every occurrence of each whitelisted word
+// in the original lowercase message to the filtered output. Words are
+// matched case-insensitively.
+func applyWhitelist(output []rune, lowercase []rune, whitelist []string) {
+	for _, word := range whitelist {
+		whitelisted := []rune(strings.ToLower(word))
+		if len(whitelisted) == 0 {
+			continue
+		}
+		for offset := 0; offset+len(whitelisted) <= len(lowercase); offset++ {
+			if slices.Equal(lowercase[offset:offset+len(whitelisted)], whitelisted) {
+				copy(output[offset:], whitelisted)
+			}
+		}
+	}
+}
+
+// collectMasks groups consecutive masked runes of output with the
+// same reason into spans.
+func collectMasks(output []rune, reasons []Reason) []Mask {
+	var masks []Mask
+	for i := range output {
+		if output[i] != '*' || reasons[i] == 0 {
+			continue
+		}
+		if n := len(masks); n > 0 && masks[n-1].End == i && masks[n-1].Reason == reasons[i] {
+			masks[n-1].End++
+			continue
+		}
+		masks = append(masks, Mask{Start: i, End: i + 1, Reason: reasons[i]})
+	}
+	return masks
 }
 
 func isSymbol(r rune) bool {
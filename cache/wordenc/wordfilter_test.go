@@ -1,10 +1,13 @@
 package wordenc
 
 import (
+	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/zsrv/rs-server-225/internal/projectpath"
+	"github.com/zsrv/rs-server-225/jagex2/io"
 	"github.com/zsrv/rs-server-225/jagex2/wordpack"
 )
 
@@ -266,3 +269,193 @@ func Benchmark_filter(b *testing.B) {
 		filter(wf, "badword [dot] com")
 	}
 }
+
+func TestWordFilter_FilterReport(t *testing.T) {
+	wf := &WordFilter{
+		BadWords:        [][]rune{[]rune("fuck")},
+		BadCombinations: [][][2]int{nil},
+		Domains:         [][]rune{[]rune("badword")},
+		Fragments:       []int{123},
+		TLDs:            [][]rune{[]rune("com")},
+		TLDTypes:        []uint8{1},
+	}
+
+	tests := []struct {
+		name      string
+		input     string
+		want      string
+		wantMasks []Mask
+	}{
+		{
+			name:      "bad word",
+			input:     "Well fuck man",
+			want:      "Well **** man",
+			wantMasks: []Mask{{Start: 5, End: 9, Reason: ReasonBadWord}},
+		},
+		{
+			name:      "tld",
+			input:     "Visit badword.com now",
+			want:      "Visit *********** now",
+			wantMasks: []Mask{{Start: 6, End: 17, Reason: ReasonTLD}},
+		},
+		{
+			name:      "domain",
+			input:     "Me@badword",
+			want:      "Me@*******",
+			wantMasks: []Mask{{Start: 3, End: 10, Reason: ReasonDomain}},
+		},
+		{
+			name:  "several reasons",
+			input: "Fuck badword.com",
+			want:  "**** ***********",
+			wantMasks: []Mask{
+				{Start: 0, End: 4, Reason: ReasonBadWord},
+				{Start: 5, End: 16, Reason: ReasonTLD},
+			},
+		},
+		{
+			name:      "clean",
+			input:     "Hello world",
+			want:      "Hello world",
+			wantMasks: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, masks := wf.FilterReport(tt.input)
+			if got != tt.want {
+				t.Errorf("FilterReport() = %v, want %v", got, tt.want)
+			}
+			if !slices.Equal(masks, tt.wantMasks) {
+				t.Errorf("FilterReport() masks = %+v, want %+v", masks, tt.wantMasks)
+			}
+			if filtered := wf.Filter(tt.input); filtered != got {
+				t.Errorf("Filter() = %v, want %v", filtered, got)
+			}
+		})
+	}
+}
+
+func Test_collectMasks(t *testing.T) {
+	output := []rune("ab**c***")
+	reasons := []Reason{0, 0, ReasonFragment, ReasonFragment, ReasonTLD, ReasonTLD, ReasonTLD, ReasonDomain}
+
+	want := []Mask{
+		{Start: 2, End: 4, Reason: ReasonFragment},
+		{Start: 5, End: 7, Reason: ReasonTLD},
+		{Start: 7, End: 8, Reason: ReasonDomain},
+	}
+	if got := collectMasks(output, reasons); !slices.Equal(got, want) {
+		t.Errorf("collectMasks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWordFilter_Whitelist(t *testing.T) {
+	tests := []struct {
+		name      string
+		whitelist []string
+		badWords  []string
+		input     string
+		want      string
+	}{
+		{
+			name:     "default whitelist restores every occurrence",
+			badWords: []string{"coo"},
+			input:    "I cook, you cook, we all cook",
+			want:     "I cook, you cook, we all cook",
+		},
+		{
+			name:     "default whitelist does not restore other words",
+			badWords: []string{"coo"},
+			input:    "Coo cook coo",
+			want:     "*** cook ***",
+		},
+		{
+			name:      "custom whitelist",
+			whitelist: []string{"Scunthorpe"},
+			badWords:  []string{"cunt"},
+			input:     "Scunthorpe and scunthorpe",
+			want:      "Scunthorpe and scunthorpe",
+		},
+		{
+			name:      "empty whitelist",
+			whitelist: []string{},
+			badWords:  []string{"coo"},
+			input:     "Cook",
+			want:      "***k",
+		},
+		{
+			name:     "whitelist after a multi-byte character",
+			badWords: []string{"coo"},
+			input:    "£5 cook",
+			want:     "£5 cook",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &WordFilter{Whitelist: tt.whitelist}
+			for _, word := range tt.badWords {
+				wf.BadWords = append(wf.BadWords, []rune(word))
+				wf.BadCombinations = append(wf.BadCombinations, nil)
+			}
+			if got := wf.Filter(tt.input); got != tt.want {
+				t.Errorf("Filter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordFilter_LoadWhitelist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "whitelist.txt")
+	if err := os.WriteFile(path, []byte("# words\nSheet\n\nseeks\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wf := &WordFilter{}
+	if err := wf.LoadWhitelist(path); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Sheet", "seeks"}; !slices.Equal(wf.Whitelist, want) {
+		t.Errorf("Whitelist = %v, want %v", wf.Whitelist, want)
+	}
+}
+
+func TestLoadWordFilter_serverWhitelist(t *testing.T) {
+	src := t.TempDir()
+	writeLists(t, src, map[string]string{
+		BadWordsFile:  "fuck\n",
+		DomainsFile:   "",
+		FragmentsFile: "",
+		TLDsFile:      "1 com\n",
+	})
+	jf := &io.Jagfile{}
+	if err := Compile(src, jf); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := jf.Save(filepath.Join(dir, "client", "wordenc"), false); err != nil {
+		t.Fatal(err)
+	}
+	wf, err := LoadWordFilter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wf.Whitelist != nil {
+		t.Errorf("Whitelist = %v, want nil", wf.Whitelist)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "server"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "server", WhitelistFile), []byte("cook\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wf, err = LoadWordFilter(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cook"}; !slices.Equal(wf.Whitelist, want) {
+		t.Errorf("Whitelist = %v, want %v", wf.Whitelist, want)
+	}
+}
@@ -0,0 +1,50 @@
+// Command config converts the config archive between the source files
+// content designers edit and the archive the client loads.
+//
+//	config -src data/src/config -out data/pack/client/config
+//	config -d -src data/pack/client/config -out data/src/config
+//
+// With -server the compiled archive keeps the server-only properties,
+// for the server's copy in data/pack/server/config.
+//
+// See package config for the source format.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/zsrv/rs-server-225/cache/config"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+func main() {
+	decompile := flag.Bool("d", false, "decompile an archive into source files")
+	server := flag.Bool("server", false, "keep server-only properties")
+	src := flag.String("src", "data/src/config", "source directory, or archive with -d")
+	out := flag.String("out", "data/pack/client/config", "archive to write, or source directory with -d")
+	flag.Parse()
+
+	if *decompile {
+		jf, err := io.LoadJagfile(*src)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := config.Decompile(jf, *out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	compile := config.Compile
+	if *server {
+		compile = config.CompileServer
+	}
+	jf := &io.Jagfile{}
+	if err := compile(*src, jf); err != nil {
+		log.Fatal(err)
+	}
+	if err := jf.Save(*out, false); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,73 @@
+// Command pack builds the client cache archives, maps, songs and
+// crc table from a source tree. See package pack for the layout.
+//
+// If src/wordenc holds plaintext lists, the wordenc archive is compiled
+// from them rather than packed as-is. Likewise the config archive is
+// compiled from config sources if src/config holds symbol files, and
+// the server's copy, keeping server-only properties, is written to
+// out/server/config.
+//
+// The word filter whitelist is server-only, so src/wordenc/whitelist.txt
+// is copied to out/server/whitelist.txt rather than packed.
+//
+// The title, media and textures archives are built with sprite.Compile,
+// which compiles any images in them into sprites. If src/models holds
+// model files, the models archive is built from them.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/zsrv/rs-server-225/cache/config"
+	"github.com/zsrv/rs-server-225/cache/model"
+	"github.com/zsrv/rs-server-225/cache/pack"
+	"github.com/zsrv/rs-server-225/cache/sprite"
+	"github.com/zsrv/rs-server-225/cache/wordenc"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func main() {
+	src := flag.String("src", "data/src", "source tree to read")
+	out := flag.String("out", "data/pack", "directory to write the packed cache to")
+	flag.Parse()
+
+	pk := pack.NewPacker(*src, *out)
+	if _, err := os.Stat(filepath.Join(*src, "wordenc", wordenc.BadWordsFile)); err == nil {
+		pk.Register("wordenc", wordenc.Compile)
+	}
+	syms, _ := filepath.Glob(filepath.Join(*src, "config", "*"+config.SymbolExt))
+	if len(syms) > 0 {
+		pk.Register("config", config.Compile)
+	}
+	for _, name := range []string{"title", "media", "textures"} {
+		pk.Register(name, sprite.Compile)
+	}
+	if models, _ := filepath.Glob(filepath.Join(*src, "models", "*"+model.Ext)); len(models) > 0 {
+		pk.Register("models", model.Compile)
+	}
+	if err := pk.PackAll(); err != nil {
+		log.Fatal(err)
+	}
+
+	if len(syms) > 0 {
+		jf := &io.Jagfile{}
+		if err := config.CompileServer(filepath.Join(*src, "config"), jf); err != nil {
+			log.Fatal(err)
+		}
+		if err := jf.Save(filepath.Join(*out, "server", "config"), false); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if whitelist, err := packet.Load(filepath.Join(*src, "wordenc", wordenc.WhitelistFile), false); err == nil {
+		if err := whitelist.Save(filepath.Join(*out, "server", wordenc.WhitelistFile), len(whitelist.Buf), 0); err != nil {
+			log.Fatal(err)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,47 @@
+// Command rsakeygen generates an RSA key pair for the login block.
+//
+// The private key is written as a PEM file for the server to load with
+// packet.LoadRSAKey. The modulus and public exponent are printed in the
+// decimal form the 225 client's BigInteger constants use, so the client
+// can be patched to match.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func main() {
+	bits := flag.Int("bits", 512, "key size in bits")
+	out := flag.String("out", "data/config/private.pem", "path to write the PEM encoded private key")
+	flag.Parse()
+
+	key, err := packet.GenerateRSAKey(*bits)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	pemBytes, err := key.MarshalPEM()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// the private key is readable only by its owner
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, pemBytes, 0600); err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Fprintf(os.Stderr, "wrote %d-bit private key to %s\n", *bits, *out)
+	fmt.Printf("modulus:  %s\n", key.N.String())
+	fmt.Printf("exponent: %s\n", key.E.String())
+	fmt.Printf("modulus (hex):  %s\n", key.N.Text(16))
+	fmt.Printf("exponent (hex): %s\n", key.E.Text(16))
+}
@@ -0,0 +1,56 @@
+// Command unhash lists the entries of Jagfile archives, recovering the
+// names of entries that are not in the built-in dictionary from a
+// wordlist and, optionally, by brute force.
+//
+//	unhash -words names.txt -suffix .dat,.idx -max 5 data/pack/client/media
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+func main() {
+	words := flag.String("words", "", "wordlist of candidate names, one per line")
+	suffix := flag.String("suffix", ".dat", "comma-separated suffixes to try after each candidate")
+	maxLen := flag.Int("max", 0, "longest name to brute force, not counting the suffix")
+	charset := flag.String("charset", io.DefaultCharset, "characters to brute force with")
+	flag.Parse()
+
+	var candidates []string
+	if *words != "" {
+		var err error
+		candidates, err = io.ReadWordlist(*words)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	opts := io.UnhashOptions{
+		Words:    candidates,
+		Suffixes: strings.Split(*suffix, ","),
+		Charset:  *charset,
+		MaxLen:   *maxLen,
+	}
+
+	for _, path := range flag.Args() {
+		jf, err := io.LoadJagfile(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Println(path)
+		for i := range jf.FileCount {
+			names := io.DefaultDictionary.Unhash(jf.FileHash[i], opts)
+			name := "?"
+			if len(names) > 0 {
+				name = strings.Join(names, " | ")
+			}
+			fmt.Printf("  %11d %8d %s\n", int32(jf.FileHash[i]), jf.FileUnpackedSize[i], name)
+		}
+	}
+}
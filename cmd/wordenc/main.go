@@ -0,0 +1,46 @@
+// Command wordenc converts the word filter lists between the plaintext
+// form moderators edit and the wordenc archive the client loads.
+//
+//	wordenc -src data/src/wordenc -out data/pack/client/wordenc
+//	wordenc -d -src data/pack/client/wordenc -out data/src/wordenc
+//
+// See wordenc.LoadPlaintext for the plaintext format.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/zsrv/rs-server-225/cache/wordenc"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+func main() {
+	decode := flag.Bool("d", false, "decode an archive into plaintext lists")
+	src := flag.String("src", "data/src/wordenc", "plaintext directory, or archive with -d")
+	out := flag.String("out", "data/pack/client/wordenc", "archive to write, or plaintext directory with -d")
+	flag.Parse()
+
+	if *decode {
+		jf, err := io.LoadJagfile(*src)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w, err := wordenc.ReadJagfile(jf)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := w.SavePlaintext(*out); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	jf := &io.Jagfile{}
+	if err := wordenc.Compile(*src, jf); err != nil {
+		log.Fatal(err)
+	}
+	if err := jf.Save(*out, false); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+package io
+
+import (
+	"io"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// SeedOffset is added to each login seed int to key the
+// server-to-client direction of a game connection.
+const SeedOffset = 50
+
+// An IsaacPair holds the opcode ciphers for both directions
+// of a game connection.
+type IsaacPair struct {
+	Encoder *Isaac // encrypts opcodes we send
+	Decoder *Isaac // decrypts opcodes we receive
+}
+
+// NewServerIsaacPair returns the ciphers for the server end of a
+// connection, keyed with the four seed ints from the login block.
+// The client encrypts with the seed as-is, and the server encrypts
+// with each seed int increased by [SeedOffset].
+func NewServerIsaacPair(seed [4]uint32) *IsaacPair {
+	return &IsaacPair{
+		Encoder: NewIsaac(offsetSeed(seed)),
+		Decoder: NewIsaac(seed),
+	}
+}
+
+// NewClientIsaacPair returns the ciphers for the client end of a
+// connection. It is the mirror of [NewServerIsaacPair].
+func NewClientIsaacPair(seed [4]uint32) *IsaacPair {
+	return &IsaacPair{
+		Encoder: NewIsaac(seed),
+		Decoder: NewIsaac(offsetSeed(seed)),
+	}
+}
+
+func offsetSeed(seed [4]uint32) [4]uint32 {
+	for i := range seed {
+		seed[i] += SeedOffset
+	}
+	return seed
+}
+
+// EncryptOpcode returns opcode encrypted with the next value of the cipher.
+func (is *Isaac) EncryptOpcode(opcode uint8) uint8 {
+	return opcode + uint8(is.GetNext())
+}
+
+// DecryptOpcode returns b decrypted with the next value of the cipher.
+func (is *Isaac) DecryptOpcode(b uint8) uint8 {
+	return b - uint8(is.GetNext())
+}
+
+// WriteOpcode writes opcode to w, encrypted.
+func (ip *IsaacPair) WriteOpcode(w io.Writer, opcode uint8) error {
+	_, err := w.Write([]byte{ip.Encoder.EncryptOpcode(opcode)})
+	return err
+}
+
+// ReadOpcode reads one encrypted opcode from r. The cipher is only
+// advanced if a byte was read.
+func (ip *IsaacPair) ReadOpcode(r io.Reader) (uint8, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return 0, err
+	}
+	return ip.Decoder.DecryptOpcode(b[0]), nil
+}
+
+// POpcode puts opcode into p, encrypted.
+func (ip *IsaacPair) POpcode(p *packet.Packet, opcode uint8) {
+	p.P1(ip.Encoder.EncryptOpcode(opcode))
+}
+
+// GOpcode gets one encrypted opcode from p. The cipher is only
+// advanced if a byte was read.
+func (ip *IsaacPair) GOpcode(p *packet.Packet) (uint8, error) {
+	b, err := p.TryG1()
+	if err != nil {
+		return 0, err
+	}
+	return ip.Decoder.DecryptOpcode(b), nil
+}
@@ -2,20 +2,13 @@ package io
 
 import (
 	"errors"
-	"maps"
+	"fmt"
 	"slices"
 	"strings"
 
 	"github.com/zsrv/rs-server-225/jagex2/packet"
 )
 
-func init() {
-	for i := range knownNames {
-		//knownHashes[i] = genHash(knownNames[i])
-		nameToHash[knownNames[i]] = genHash(knownNames[i])
-	}
-}
-
 func genHash(name string) uint32 {
 	hash := uint32(0)
 	name = strings.ToUpper(name)
@@ -36,6 +29,12 @@ type JagQueueFile struct {
 	NewHash uint32
 }
 
+// A Jagfile is a named file archive. The zero value is an empty archive
+// ready to have files written to it.
+//
+// Changes made with [Jagfile.Write], [Jagfile.Delete] and [Jagfile.Rename]
+// are queued, and take effect when [Jagfile.ApplyQueue] or
+// [Jagfile.Save] is called.
 type Jagfile struct {
 	Data             []uint8
 	FileCount        int
@@ -48,6 +47,13 @@ type Jagfile struct {
 
 	FileQueue []JagQueueFile
 	FileWrite [][]uint8
+
+	// packed is the compressed archive body when the archive was loaded
+	// with whole-archive compression, and dirty is set once the file
+	// table has changed. Together they let an untouched archive be saved
+	// byte-for-byte as it was loaded.
+	packed []uint8
+	dirty  bool
 }
 
 func (jf *Jagfile) Get(index int) (*packet.Packet, error) {
@@ -55,11 +61,18 @@ func (jf *Jagfile) Get(index int) (*packet.Packet, error) {
 		return nil, errors.New("index out of range")
 	}
 
+	if jf.FileWrite != nil && jf.FileWrite[index] != nil {
+		return packet.NewPacket(slices.Clone(jf.FileWrite[index])), nil
+	}
+
 	if jf.Data == nil {
 		return nil, errors.New("data is nil")
 	}
 
-	src := jf.Data[jf.FilePos[index] : jf.FilePos[index]+int(jf.FilePackedSize[index])]
+	src, err := jf.stored(index)
+	if err != nil {
+		return nil, err
+	}
 	if jf.Unpacked {
 		return packet.NewPacket(src), nil
 	} else {
@@ -71,6 +84,18 @@ func (jf *Jagfile) Get(index int) (*packet.Packet, error) {
 	}
 }
 
+// stored returns the bytes of an entry exactly as they are held in Data:
+// compressed when the archive is compressed per file, or uncompressed when
+// the archive was compressed whole.
+func (jf *Jagfile) stored(index int) ([]uint8, error) {
+	start := jf.FilePos[index]
+	end := start + int(jf.FilePackedSize[index])
+	if start < 0 || end > len(jf.Data) {
+		return nil, errors.New("file extends past end of archive")
+	}
+	return jf.Data[start:end], nil
+}
+
 func (jf *Jagfile) Read(name string) (*packet.Packet, error) {
 	hash := genHash(name)
 
@@ -83,13 +108,15 @@ func (jf *Jagfile) Read(name string) (*packet.Packet, error) {
 	return nil, errors.New("file not found")
 }
 
+// Write queues name to be added to the archive, or replaced if it is
+// already present, with the written contents of data.
 func (jf *Jagfile) Write(name string, data *packet.Packet) {
 	hash := genHash(name)
 
 	jf.FileQueue = append(jf.FileQueue, JagQueueFile{
 		Hash:  hash,
 		Name:  name,
-		Data:  data.Buf[:data.Pos],
+		Data:  slices.Clone(data.Buf),
 		Write: true,
 	})
 }
@@ -117,30 +144,38 @@ func (jf *Jagfile) Rename(oldName string, newName string) {
 	})
 }
 
-func (jf *Jagfile) Save(path string, doNotCompressWhole bool) error {
-	buf := packet.AllocPacket(5)
+// ApplyQueue applies the queued writes, deletions and renames to the
+// file table in the order they were made, and empties the queue.
+func (jf *Jagfile) ApplyQueue() error {
+	if jf.FileWrite == nil {
+		jf.FileWrite = make([][]uint8, jf.FileCount)
+	}
 
-	for i := range jf.FileQueue {
-		queued := jf.FileQueue[i]
-		index := slices.Index(jf.FileHash, queued.Hash)
+	for _, queued := range jf.FileQueue {
+		index := slices.Index(jf.FileHash[:jf.FileCount], queued.Hash)
 
 		if queued.Write {
+			if queued.Data == nil {
+				return errors.New("data is nil")
+			}
+
 			if index == -1 {
 				index = jf.FileCount
 				jf.FileCount++
 
-				jf.FileHash[index] = queued.Hash
-				jf.FileName[index] = queued.Name
-			}
-
-			if queued.Data == nil {
-				return errors.New("data is nil")
+				jf.FileHash = append(jf.FileHash, queued.Hash)
+				jf.FileName = append(jf.FileName, queued.Name)
+				jf.FileUnpackedSize = append(jf.FileUnpackedSize, 0)
+				jf.FilePackedSize = append(jf.FilePackedSize, 0)
+				jf.FilePos = append(jf.FilePos, -1)
+				jf.FileWrite = append(jf.FileWrite, nil)
 			}
 
 			jf.FileUnpackedSize[index] = uint32(len(queued.Data))
 			jf.FilePackedSize[index] = uint32(len(queued.Data))
 			jf.FilePos[index] = -1
 			jf.FileWrite[index] = queued.Data
+			jf.dirty = true
 		}
 
 		if queued.Delete && index != -1 {
@@ -149,7 +184,9 @@ func (jf *Jagfile) Save(path string, doNotCompressWhole bool) error {
 			jf.FileUnpackedSize = slices.Delete(jf.FileUnpackedSize, index, index+1)
 			jf.FilePackedSize = slices.Delete(jf.FilePackedSize, index, index+1)
 			jf.FilePos = slices.Delete(jf.FilePos, index, index+1)
+			jf.FileWrite = slices.Delete(jf.FileWrite, index, index+1)
 			jf.FileCount--
+			jf.dirty = true
 		}
 
 		if queued.Rename && index != -1 {
@@ -161,76 +198,144 @@ func (jf *Jagfile) Save(path string, doNotCompressWhole bool) error {
 				return errors.New("new name is zero")
 			}
 
+			if other := slices.Index(jf.FileHash, queued.NewHash); other != -1 && other != index {
+				return errors.New("new name already exists")
+			}
+
 			jf.FileHash[index] = queued.NewHash
 			jf.FileName[index] = queued.NewName
+			jf.dirty = true
 		}
+	}
+
+	jf.FileQueue = nil
+	return nil
+}
+
+// unpacked returns the uncompressed contents of an entry.
+func (jf *Jagfile) unpacked(index int) ([]uint8, error) {
+	if jf.FileWrite[index] != nil {
+		return jf.FileWrite[index], nil
+	}
+	src, err := jf.stored(index)
+	if err != nil {
+		return nil, err
+	}
+	if jf.Unpacked {
+		return src, nil
+	}
+	return BZip2Decompress(slices.Clone(src), int(jf.FileUnpackedSize[index]), true, false)
+}
 
-		jf.FileQueue = slices.Delete(jf.FileQueue, i, i+1)
-		i--
+// packedFile returns the per-file compressed contents of an entry.
+// Entries loaded from an archive compressed per file are returned as
+// they were loaded so they are not recompressed.
+func (jf *Jagfile) packedFile(index int) ([]uint8, error) {
+	if jf.FileWrite[index] == nil && !jf.Unpacked {
+		return jf.stored(index)
+	}
+	data, err := jf.unpacked(index)
+	if err != nil {
+		return nil, err
 	}
+	return BZip2Compress(data, false, true, 1, 0)
+}
 
-	var compressWhole bool
-	if jf.FileCount == 1 {
-		compressWhole = true
+// Encode applies the queue and returns the archive in its on-disk form.
+// If compressWhole is set the archive body is bzip2 compressed as a
+// single stream, otherwise each file is compressed on its own.
+func (jf *Jagfile) Encode(compressWhole bool) ([]uint8, error) {
+	if err := jf.ApplyQueue(); err != nil {
+		return nil, err
 	}
 
-	if doNotCompressWhole && compressWhole {
-		compressWhole = false
+	// an untouched archive that was compressed whole is kept as loaded
+	if compressWhole && jf.Unpacked && !jf.dirty && jf.packed != nil {
+		out := packet.NewPacket(make([]byte, 0, 6+len(jf.packed)))
+		out.P3(uint32(len(jf.Data)))
+		out.P3(uint32(len(jf.packed)))
+		out.PData(jf.packed, len(jf.packed))
+		return out.Buf, nil
 	}
 
+	files := make([][]uint8, jf.FileCount)
+	for i := range jf.FileCount {
+		var err error
+		if compressWhole {
+			files[i], err = jf.unpacked(i)
+		} else {
+			files[i], err = jf.packedFile(i)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	buf := packet.AllocPacket(packet.SizeUnimaginable)
+	defer buf.Release()
+
 	// write header
 	buf.P2(uint16(jf.FileCount))
 	for i := range jf.FileCount {
 		buf.P4(jf.FileHash[i])
-		buf.P3(jf.FileUnpackedSize[i])
-
-		if jf.FileWrite[i] != nil && !compressWhole {
-			var err error
-			jf.FileWrite[i], err = BZip2Compress(jf.FileWrite[i], false, true, 1, 0)
-			if err != nil {
-				return err
+		if compressWhole {
+			buf.P3(uint32(len(files[i])))
+			buf.P3(uint32(len(files[i])))
+		} else {
+			unpackedSize := jf.FileUnpackedSize[i]
+			if jf.FileWrite[i] != nil {
+				unpackedSize = uint32(len(jf.FileWrite[i]))
 			}
-
-			jf.FilePackedSize[i] = uint32(len(jf.FileWrite[i]))
+			buf.P3(unpackedSize)
+			buf.P3(uint32(len(files[i])))
 		}
-
-		buf.P3(jf.FilePackedSize[i])
 	}
 
 	// write files
 	for i := range jf.FileCount {
-		data := jf.FileWrite[i]
-		buf.PData(data, len(data))
+		buf.PData(files[i], len(files[i]))
 	}
 
-	jag := packet.AllocPacket(5)
-	jag.P3(uint32(buf.Pos))
-
+	body := buf.Buf
 	if compressWhole {
-		b, err := BZip2Compress(buf.Buf, false, true, 1, 0)
+		var err error
+		body, err = BZip2Compress(buf.Buf, false, true, 1, 0)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		buf = packet.NewPacket(b)
 	}
 
-	if compressWhole {
-		jag.P3(uint32(buf.Len()))
-		jag.PData(buf.Bytes(), buf.Len())
-	} else {
-		jag.P3(uint32(buf.Pos))
-		jag.PData(buf.Bytes(), buf.Pos)
-	}
+	jag := packet.NewPacket(make([]byte, 0, 6+len(body)))
+	jag.P3(uint32(len(buf.Buf)))
+	jag.P3(uint32(len(body)))
+	jag.PData(body, len(body))
+
+	return jag.Buf, nil
+}
 
-	if err := jag.Save(path, jag.Pos, 0); err != nil {
+// Save writes the archive to path. An archive holding a single file is
+// compressed whole unless doNotCompressWhole is set; any other archive is
+// compressed per file. Use [Jagfile.SaveCompression] to choose explicitly.
+func (jf *Jagfile) Save(path string, doNotCompressWhole bool) error {
+	if err := jf.ApplyQueue(); err != nil {
 		return err
 	}
-	buf.Release()
-	jag.Release()
+	return jf.SaveCompression(path, jf.FileCount == 1 && !doNotCompressWhole)
+}
 
-	return nil
+// SaveCompression writes the archive to path, compressing the archive
+// whole if compressWhole is set or per file otherwise.
+func (jf *Jagfile) SaveCompression(path string, compressWhole bool) error {
+	b, err := jf.Encode(compressWhole)
+	if err != nil {
+		return err
+	}
+	return packet.NewPacket(b).Save(path, len(b), 0)
 }
 
+// Deconstruct splits the name.dat/name.idx pair of a config archive
+// into its entries, returning the entry count and each entry's size,
+// offset into name.dat and crc.
 func (jf *Jagfile) Deconstruct(name string) (uint16, []int, []int, []uint32, error) {
 	dat, err := jf.Read(name + ".dat")
 	if err != nil {
@@ -254,10 +359,13 @@ func (jf *Jagfile) Deconstruct(name string) (uint16, []int, []int, []uint32, err
 		offset += sizes[i]
 	}
 
+	if offset > len(dat.Buf) {
+		return 0, nil, nil, nil, fmt.Errorf("%s.idx sizes exceed %s.dat", name, name)
+	}
+
 	checksums := make([]uint32, count)
 	for i := range count {
-		dat.Pos = offsets[i]
-		checksums[i] = packet.GetCRC(dat.Bytes(), offset, sizes[i])
+		checksums[i] = packet.GetCRC(dat.Buf, offsets[i], sizes[i])
 	}
 
 	return count, sizes, offsets, checksums, nil
@@ -270,15 +378,24 @@ func NewJagfile(src *packet.Packet) (*Jagfile, error) {
 
 	jf := &Jagfile{}
 
-	unpackedSize := src.G3()
-	packedSize := src.G3()
+	unpackedSize, err := src.TryG3()
+	if err != nil {
+		return nil, err
+	}
+	packedSize, err := src.TryG3()
+	if err != nil {
+		return nil, err
+	}
 
 	if unpackedSize == packedSize {
 		jf.Data = src.Buf
 		jf.Unpacked = false
 	} else {
-		var err error
-		jf.Data, err = BZip2Decompress(src.Buf, int(unpackedSize), true, false)
+		if src.Len() < int(packedSize) {
+			return nil, errors.New("archive is truncated")
+		}
+		jf.packed = src.Buf[src.Pos : src.Pos+int(packedSize)]
+		jf.Data, err = BZip2Decompress(slices.Clone(jf.packed), int(unpackedSize), true, false)
 		if err != nil {
 			return nil, err
 		}
@@ -287,7 +404,11 @@ func NewJagfile(src *packet.Packet) (*Jagfile, error) {
 		jf.Unpacked = true
 	}
 
-	jf.FileCount = int(src.G2())
+	fileCount, err := src.TryG2()
+	if err != nil {
+		return nil, err
+	}
+	jf.FileCount = int(fileCount)
 
 	// initialize
 	jf.FileHash = make([]uint32, jf.FileCount)
@@ -295,17 +416,17 @@ func NewJagfile(src *packet.Packet) (*Jagfile, error) {
 	jf.FileUnpackedSize = make([]uint32, jf.FileCount)
 	jf.FilePackedSize = make([]uint32, jf.FileCount)
 	jf.FilePos = make([]int, jf.FileCount)
+	jf.FileWrite = make([][]uint8, jf.FileCount)
+
+	if src.Len() < jf.FileCount*10 {
+		return nil, errors.New("archive is truncated")
+	}
 
 	pos := uint32(src.Pos + jf.FileCount*10)
 	for i := range jf.FileCount {
 		jf.FileHash[i] = src.G4()
 
-		for k, v := range maps.All(nameToHash) {
-			if v == jf.FileHash[i] {
-				jf.FileName[i] = k
-				break
-			}
-		}
+		jf.FileName[i], _ = DefaultDictionary.Lookup(jf.FileHash[i])
 
 		jf.FileUnpackedSize[i] = src.G3()
 		jf.FilePackedSize[i] = src.G3()
@@ -314,6 +435,10 @@ func NewJagfile(src *packet.Packet) (*Jagfile, error) {
 		pos += jf.FilePackedSize[i]
 	}
 
+	if int(pos) > len(src.Buf) {
+		return nil, errors.New("archive is truncated")
+	}
+
 	return jf, nil
 }
 
@@ -558,6 +683,8 @@ var knownNames = []string{
 	"domainenc.txt",
 	"fragmentsenc.txt",
 	"tldlist.txt",
+	// server only
+	"whitelist.txt",
 
 	// sounds
 	"sounds.dat",
@@ -569,5 +696,3 @@ var knownNames = []string{
 	"overlay.dat",
 	"size.dat", // added later
 }
-
-var nameToHash = make(map[string]uint32, len(knownNames))
@@ -1,9 +1,13 @@
 package io
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 
+	"github.com/zsrv/rs-server-225/internal/projectpath"
 	"github.com/zsrv/rs-server-225/jagex2/packet"
 )
 
@@ -198,3 +202,248 @@ func TestJagfileRename(t *testing.T) {
 		t.Fatalf("jf.FileQueue[0].NewName = %v, want %v", jf.FileQueue[0].NewName, "gnomeball_buttons.dat")
 	}
 }
+
+func TestJagfileRoundTrip(t *testing.T) {
+	files := map[string][]byte{
+		"obj.dat":      []byte("the quick brown fox jumps over the lazy dog"),
+		"obj.idx":      {0, 1, 0, 43},
+		"hitmarks.dat": {255},
+		"runes.dat":    {},
+	}
+	names := []string{"obj.dat", "obj.idx", "hitmarks.dat", "runes.dat"}
+
+	tests := []struct {
+		name          string
+		compressWhole bool
+	}{
+		{name: "compressed per file", compressWhole: false},
+		{name: "compressed whole", compressWhole: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jf := &Jagfile{}
+			for _, name := range names {
+				jf.Write(name, packet.NewPacket(files[name]))
+			}
+
+			b, err := jf.Encode(tt.compressWhole)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(jf.FileQueue) != 0 {
+				t.Fatalf("len(jf.FileQueue) = %v, want 0", len(jf.FileQueue))
+			}
+
+			loaded, err := NewJagfile(packet.NewPacket(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if loaded.FileCount != len(names) {
+				t.Fatalf("loaded.FileCount = %v, want %v", loaded.FileCount, len(names))
+			}
+			if loaded.Unpacked != tt.compressWhole {
+				t.Fatalf("loaded.Unpacked = %v, want %v", loaded.Unpacked, tt.compressWhole)
+			}
+			for i, name := range names {
+				if loaded.FileName[i] != name {
+					t.Errorf("loaded.FileName[%v] = %v, want %v", i, loaded.FileName[i], name)
+				}
+				p, err := loaded.Read(name)
+				if err != nil {
+					t.Fatalf("Read(%v) error = %v", name, err)
+				}
+				if !slices.Equal(p.Buf, files[name]) {
+					t.Errorf("Read(%v) = %v, want %v", name, p.Buf, files[name])
+				}
+			}
+
+			// load -> save -> load must not change anything
+			again, err := loaded.Encode(tt.compressWhole)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(again, b) {
+				t.Errorf("re-encoded archive differs from the original")
+			}
+		})
+	}
+}
+
+func TestJagfileQueue(t *testing.T) {
+	jf := &Jagfile{}
+	jf.Write("a.dat", packet.NewPacket([]byte{1}))
+	jf.Write("b.dat", packet.NewPacket([]byte{2}))
+	jf.Write("c.dat", packet.NewPacket([]byte{3}))
+	b, err := jf.Encode(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewJagfile(packet.NewPacket(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Write("a.dat", packet.NewPacket([]byte{4, 4}))
+	loaded.Delete("b.dat")
+	loaded.Rename("c.dat", "d.dat")
+	loaded.Write("e.dat", packet.NewPacket([]byte{5}))
+	loaded.Delete("missing.dat")
+
+	b, err = loaded.Encode(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := NewJagfile(packet.NewPacket(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]byte{
+		"a.dat": {4, 4},
+		"d.dat": {3},
+		"e.dat": {5},
+	}
+	if result.FileCount != len(want) {
+		t.Fatalf("result.FileCount = %v, want %v", result.FileCount, len(want))
+	}
+	for name, data := range want {
+		p, err := result.Read(name)
+		if err != nil {
+			t.Fatalf("Read(%v) error = %v", name, err)
+		}
+		if !slices.Equal(p.Buf, data) {
+			t.Errorf("Read(%v) = %v, want %v", name, p.Buf, data)
+		}
+	}
+	for _, name := range []string{"b.dat", "c.dat"} {
+		if _, err := result.Read(name); err == nil {
+			t.Errorf("Read(%v) should fail", name)
+		}
+	}
+}
+
+func TestJagfileRenameCollision(t *testing.T) {
+	jf := &Jagfile{}
+	jf.Write("a.dat", packet.NewPacket([]byte{1}))
+	jf.Write("b.dat", packet.NewPacket([]byte{2}))
+	jf.Rename("a.dat", "b.dat")
+
+	if err := jf.ApplyQueue(); err == nil {
+		t.Fatal("ApplyQueue() should fail when renaming onto an existing file")
+	}
+}
+
+func TestJagfileSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client", "title")
+
+	jf := &Jagfile{}
+	jf.Write("logo.dat", packet.NewPacket([]byte("logo")))
+	if err := jf.Save(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadJagfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Unpacked {
+		t.Error("a single file archive should be compressed whole")
+	}
+	p, err := loaded.Read("logo.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.String() != "logo" {
+		t.Errorf("Read(logo.dat) = %v, want logo", p.String())
+	}
+}
+
+func TestJagfileTruncated(t *testing.T) {
+	jf := &Jagfile{}
+	jf.Write("a.dat", packet.NewPacket([]byte("hello")))
+	b, err := jf.Encode(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for n := range len(b) - 1 {
+		if _, err := NewJagfile(packet.NewPacket(b[:n])); err == nil {
+			t.Errorf("NewJagfile() of %v/%v bytes should fail", n, len(b))
+		}
+	}
+}
+
+func TestJagfileDeconstruct(t *testing.T) {
+	jf := &Jagfile{}
+	jf.Write("test.dat", packet.NewPacket([]byte{0, 2, 1, 0, 2, 3, 0}))
+	jf.Write("test.idx", packet.NewPacket([]byte{0, 2, 0, 2, 0, 3}))
+	jf.Write("bad.dat", packet.NewPacket([]byte{0, 1, 0}))
+	jf.Write("bad.idx", packet.NewPacket([]byte{0, 1, 0, 5}))
+	if err := jf.ApplyQueue(); err != nil {
+		t.Fatal(err)
+	}
+
+	count, sizes, offsets, crcs, err := jf.Deconstruct("test")
+	if err != nil {
+		t.Fatalf("Deconstruct() error = %v", err)
+	}
+	if count != 2 || !slices.Equal(sizes, []int{2, 3}) || !slices.Equal(offsets, []int{2, 4}) {
+		t.Errorf("Deconstruct() = %d, %v, %v, want 2, [2 3], [2 4]", count, sizes, offsets)
+	}
+	wantCrcs := []uint32{packet.GetCRC([]byte{1, 0}, 0, 2), packet.GetCRC([]byte{2, 3, 0}, 0, 3)}
+	if !slices.Equal(crcs, wantCrcs) {
+		t.Errorf("Deconstruct() crcs = %v, want %v", crcs, wantCrcs)
+	}
+
+	if _, _, _, _, err := jf.Deconstruct("bad"); err == nil {
+		t.Error("Deconstruct() error = nil for sizes past the end of bad.dat")
+	}
+	if _, _, _, _, err := jf.Deconstruct("missing"); err == nil {
+		t.Error("Deconstruct() error = nil for a missing config")
+	}
+}
+
+// TestJagfileReencode rebuilds each packed client archive from its
+// decompressed files and checks the result matches the original bytes,
+// in the compression the original used.
+func TestJagfileReencode(t *testing.T) {
+	dir := filepath.Join(projectpath.Root, "data", "pack", "client")
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	modes := make(map[bool]bool)
+	for _, name := range []string{"title", "config", "interface", "media", "models", "textures", "wordenc", "sounds"} {
+		t.Run(name, func(t *testing.T) {
+			want, err := packet.Load(filepath.Join(dir, name), false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			jf, err := NewJagfile(packet.NewPacket(slices.Clone(want.Buf)))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rebuilt := &Jagfile{}
+			for i := range jf.FileCount {
+				p, err := jf.Get(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				rebuilt.FileQueue = append(rebuilt.FileQueue, JagQueueFile{Hash: jf.FileHash[i], Write: true, Data: p.Buf})
+			}
+
+			got, err := rebuilt.Encode(jf.Unpacked)
+			if err != nil {
+				t.Fatal(err)
+			}
+			modes[jf.Unpacked] = true
+			if !bytes.Equal(got, want.Buf) {
+				t.Errorf("re-encoded archive differs (compressed whole: %v)", jf.Unpacked)
+			}
+		})
+	}
+	if !modes[true] || !modes[false] {
+		t.Errorf("archives cover compressed whole %v and per file %v, want both", modes[true], modes[false])
+	}
+}
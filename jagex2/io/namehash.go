@@ -0,0 +1,221 @@
+package io
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// HashName returns the hash a [Jagfile] stores for name.
+func HashName(name string) uint32 {
+	return genHash(name)
+}
+
+// A Dictionary maps Jagfile name hashes back to the names they were
+// made from. It is safe for concurrent use by multiple goroutines.
+type Dictionary struct {
+	mu    sync.RWMutex
+	names map[uint32]string
+}
+
+// NewDictionary returns a dictionary holding names.
+func NewDictionary(names ...string) *Dictionary {
+	d := &Dictionary{names: make(map[uint32]string, len(names))}
+	d.AddAll(names)
+	return d
+}
+
+// DefaultDictionary holds every name known to appear in the archives of
+// the revisions this server has seen. [NewJagfile] uses it to name entries.
+var DefaultDictionary = NewDictionary(knownNames...)
+
+// Add registers name and returns its hash. If a different name with the
+// same hash is already registered, the first one is kept.
+func (d *Dictionary) Add(name string) uint32 {
+	hash := genHash(name)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.names[hash]; !ok {
+		d.names[hash] = name
+	}
+	return hash
+}
+
+// AddAll registers every name in names.
+func (d *Dictionary) AddAll(names []string) {
+	for _, name := range names {
+		d.Add(name)
+	}
+}
+
+// LoadWordlist registers every name in the wordlist at path.
+// See [ReadWordlist].
+func (d *Dictionary) LoadWordlist(path string) error {
+	names, err := ReadWordlist(path)
+	if err != nil {
+		return err
+	}
+	d.AddAll(names)
+	return nil
+}
+
+// ReadWordlist returns each line of the file at path.
+// Blank lines and lines starting with # are skipped.
+func ReadWordlist(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, scanner.Err()
+}
+
+// Lookup returns the name registered for hash.
+func (d *Dictionary) Lookup(hash uint32) (string, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	name, ok := d.names[hash]
+	return name, ok
+}
+
+// Len returns the number of registered names.
+func (d *Dictionary) Len() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return len(d.names)
+}
+
+// UnhashOptions controls the search done by [Dictionary.Unhash].
+type UnhashOptions struct {
+	// Words are tried as-is and combined with each of Suffixes.
+	Words []string
+	// Suffixes are appended to every candidate, e.g. ".dat" or ".idx".
+	// The empty suffix is always tried.
+	Suffixes []string
+	// Charset is the alphabet used for brute force. Defaults to
+	// lowercase letters, digits and underscore.
+	Charset string
+	// MaxLen is the longest name tried by brute force, not counting the
+	// suffix. Each extra character multiplies the work by len(Charset);
+	// 0 disables brute force.
+	MaxLen int
+}
+
+// DefaultCharset is the brute force alphabet used when none is given.
+const DefaultCharset = "abcdefghijklmnopqrstuvwxyz0123456789_"
+
+// Unhash searches for names that hash to hash. The dictionary is checked
+// first; if the name is not registered, the words in opts are tried and
+// then, if opts.MaxLen is set, every name up to opts.MaxLen characters.
+// Names found among the words are registered with the dictionary. Brute
+// force results are only returned: the hash is only 32 bits, so they
+// may be collisions rather than the original name, and the caller must
+// decide whether to [Dictionary.Add] them.
+func (d *Dictionary) Unhash(hash uint32, opts UnhashOptions) []string {
+	if name, ok := d.Lookup(hash); ok {
+		return []string{name}
+	}
+
+	suffixes := opts.Suffixes
+	if !slices.Contains(suffixes, "") {
+		suffixes = append([]string{""}, suffixes...)
+	}
+
+	var found []string
+	for _, word := range opts.Words {
+		for _, suffix := range suffixes {
+			if genHash(word+suffix) == hash {
+				found = append(found, word+suffix)
+			}
+		}
+	}
+	for _, name := range found {
+		d.Add(name)
+	}
+
+	if len(found) == 0 && opts.MaxLen > 0 {
+		charset := opts.Charset
+		if charset == "" {
+			charset = DefaultCharset
+		}
+		for _, suffix := range suffixes {
+			for _, prefix := range bruteForce(prefixHash(hash, suffix), charset, opts.MaxLen) {
+				found = append(found, prefix+suffix)
+			}
+		}
+	}
+	return found
+}
+
+// prefixHash returns the hash a prefix must have for prefix+suffix to
+// hash to hash. The hash is linear, so
+// genHash(prefix+suffix) = genHash(prefix)*61^len(suffix) + genHash(suffix),
+// and 61 is odd so it is invertible modulo 2^32.
+func prefixHash(hash uint32, suffix string) uint32 {
+	pow := uint32(1)
+	for range suffix {
+		pow *= 61
+	}
+	return (hash - genHash(suffix)) * inverse(pow)
+}
+
+// inverse returns the multiplicative inverse of odd x modulo 2^32.
+func inverse(x uint32) uint32 {
+	// Newton's method doubles the number of correct bits each step.
+	y := x
+	for range 5 {
+		y *= 2 - x*y
+	}
+	return y
+}
+
+// bruteForce returns every name of 1 to maxLen characters from charset
+// whose hash is target.
+func bruteForce(target uint32, charset string, maxLen int) []string {
+	upper := []byte(strings.ToUpper(charset))
+	var valid [256]bool
+	for _, c := range upper {
+		valid[c] = true
+	}
+
+	var found []string
+	buf := make([]byte, maxLen)
+
+	var search func(depth int, hash uint32, length int)
+	search = func(depth int, hash uint32, length int) {
+		if depth == length-1 {
+			// solve for the last character instead of trying them all
+			c := target - hash*61 + 32
+			if c < 256 && valid[c] {
+				buf[depth] = byte(c)
+				found = append(found, strings.ToLower(string(buf[:length])))
+			}
+			return
+		}
+		for _, c := range upper {
+			buf[depth] = c
+			search(depth+1, hash*61+uint32(c)-32, length)
+		}
+	}
+
+	for length := 1; length <= maxLen; length++ {
+		search(0, 0, length)
+	}
+	return found
+}
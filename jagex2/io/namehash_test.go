@@ -0,0 +1,141 @@
+package io
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestDictionary_Lookup(t *testing.T) {
+	d := NewDictionary("hitmarks.dat")
+
+	if name, ok := d.Lookup(-1502153170 & 0xFFFFFFFF); !ok || name != "hitmarks.dat" {
+		t.Errorf("Lookup() = %v, %v, want hitmarks.dat, true", name, ok)
+	}
+	if _, ok := d.Lookup(genHash("kekw")); ok {
+		t.Error("Lookup(kekw) should fail")
+	}
+
+	if hash := d.Add("kekw"); hash != genHash("kekw") {
+		t.Errorf("Add() = %v, want %v", hash, genHash("kekw"))
+	}
+	if name, ok := d.Lookup(genHash("kekw")); !ok || name != "kekw" {
+		t.Errorf("Lookup() = %v, %v, want kekw, true", name, ok)
+	}
+	if d.Len() != 2 {
+		t.Errorf("Len() = %v, want 2", d.Len())
+	}
+}
+
+func TestDictionary_LoadWordlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.txt")
+	if err := os.WriteFile(path, []byte("# revision 289\nl_0.dat\n\n  m_0.dat  \n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewDictionary()
+	if err := d.LoadWordlist(path); err != nil {
+		t.Fatal(err)
+	}
+	if d.Len() != 2 {
+		t.Fatalf("Len() = %v, want 2", d.Len())
+	}
+	if name, ok := d.Lookup(genHash("m_0.dat")); !ok || name != "m_0.dat" {
+		t.Errorf("Lookup() = %v, %v, want m_0.dat, true", name, ok)
+	}
+}
+
+func TestDictionary_Unhash(t *testing.T) {
+	tests := []struct {
+		name string
+		opts UnhashOptions
+		hash uint32
+		want []string
+		// registered is whether the result is added to the dictionary
+		registered bool
+	}{
+		{
+			name:       "known",
+			hash:       genHash("obj.idx"),
+			want:       []string{"obj.idx"},
+			registered: true,
+		},
+		{
+			name:       "word with suffix",
+			opts:       UnhashOptions{Words: []string{"mapmarker", "mapedge"}, Suffixes: []string{".dat"}},
+			hash:       genHash("mapedge.dat"),
+			want:       []string{"mapedge.dat"},
+			registered: true,
+		},
+		{
+			name: "brute force with suffix",
+			opts: UnhashOptions{Suffixes: []string{".dat"}, MaxLen: 3},
+			hash: genHash("q9z.dat"),
+			want: []string{"q9z.dat"},
+		},
+		{
+			name: "brute force",
+			opts: UnhashOptions{MaxLen: 4},
+			hash: genHash("a_1b"),
+			want: []string{"a_1b"},
+		},
+		{
+			name: "not found",
+			opts: UnhashOptions{Words: []string{"nothing"}},
+			hash: genHash("something"),
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDictionary(knownNames...)
+			got := d.Unhash(tt.hash, tt.opts)
+			if !slices.Equal(got, tt.want) {
+				t.Fatalf("Unhash() = %v, want %v", got, tt.want)
+			}
+			name, ok := d.Lookup(tt.hash)
+			if tt.registered && (!ok || name != got[0]) {
+				t.Errorf("Lookup() after Unhash() = %v, %v, want %v", name, ok, got[0])
+			} else if !tt.registered && ok {
+				t.Errorf("Lookup() after Unhash() = %v, want not registered", name)
+			}
+		})
+	}
+}
+
+func TestNewJagfile_RuntimeName(t *testing.T) {
+	jf := &Jagfile{}
+	jf.Write("custom_sprite.dat", packet.NewPacket([]byte{1}))
+	b, err := jf.Encode(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := NewJagfile(packet.NewPacket(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FileName[0] != "" {
+		t.Fatalf("FileName[0] = %v, want empty", loaded.FileName[0])
+	}
+
+	DefaultDictionary.Add("custom_sprite.dat")
+	loaded, err = NewJagfile(packet.NewPacket(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.FileName[0] != "custom_sprite.dat" {
+		t.Errorf("FileName[0] = %v, want custom_sprite.dat", loaded.FileName[0])
+	}
+}
+
+func Test_inverse(t *testing.T) {
+	for _, x := range []uint32{1, 61, 61 * 61 * 61 * 61, 0xFFFFFFFF} {
+		if got := x * inverse(x); got != 1 {
+			t.Errorf("%v * inverse(%v) = %v, want 1", x, x, got)
+		}
+	}
+}
@@ -22,6 +22,7 @@ type Packet struct {
 	Pos      int    // read at &Buf[Pos], write at &Buf[len(Buf)]
 	lastRead readOp // last read operation, so that Unread* can work correctly.
 	BitPos   int
+	pooled   bool // set while the packet is idle in a Pool
 }
 
 // mine - *read* offset - not advanced by write operations
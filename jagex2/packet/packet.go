@@ -2,29 +2,11 @@ package packet
 
 import (
 	"bufio"
-	"container/list"
-	"errors"
 	"hash/crc32"
-	"log"
-	"math/big"
 	"os"
 	"path/filepath"
 )
 
-var cacheMinCount int
-var cacheMidCount int
-var cacheMaxCount int
-var cacheBigCount int
-var cacheHugeCount int
-var cacheUnimaginableCount int
-
-var cacheMin list.List
-var cacheMid list.List
-var cacheMax list.List
-var cacheBig list.List
-var cacheHuge list.List
-var cacheUnimaginable list.List
-
 // GetCRC returns the checksum of length bytes in src
 // beginning at offset.
 func GetCRC(src []uint8, offset int, length int) uint32 {
@@ -39,55 +21,6 @@ func CheckCRC(src []uint8, offset int, length int, expected uint32) bool {
 	return checksum == expected
 }
 
-// TODO: needs test
-func AllocPacket(typ int) *Packet {
-	var p *Packet = nil
-
-	if typ == 0 && cacheMinCount > 0 {
-		p = cacheMin.Remove(cacheMin.Front()).(*Packet)
-		cacheMinCount--
-	} else if typ == 1 && cacheMidCount > 0 {
-		p = cacheMid.Remove(cacheMid.Front()).(*Packet)
-		cacheMidCount--
-	} else if typ == 2 && cacheMaxCount > 0 {
-		p = cacheMax.Remove(cacheMax.Front()).(*Packet)
-		cacheMaxCount--
-	} else if typ == 3 && cacheBigCount > 0 {
-		p = cacheBig.Remove(cacheBig.Front()).(*Packet)
-		cacheBigCount--
-	} else if typ == 4 && cacheHugeCount > 0 {
-		p = cacheHuge.Remove(cacheHuge.Front()).(*Packet)
-		cacheHugeCount--
-	} else if typ == 5 && cacheUnimaginableCount > 0 {
-		p = cacheUnimaginable.Remove(cacheUnimaginable.Front()).(*Packet)
-		cacheUnimaginableCount--
-	}
-
-	if p != nil {
-		p.Pos = 0
-		p.BitPos = 0
-		return p
-	}
-
-	switch typ {
-	case 0:
-		return &Packet{Buf: make([]byte, 0, 100)}
-	case 1:
-		return &Packet{Buf: make([]byte, 0, 5000)}
-	case 2:
-		return &Packet{Buf: make([]byte, 0, 30000)}
-	case 3:
-		return &Packet{Buf: make([]byte, 0, 100000)}
-	case 4:
-		return &Packet{Buf: make([]byte, 0, 500000)}
-	case 5:
-		return &Packet{Buf: make([]byte, 0, 2000000)}
-	default:
-		return &Packet{Buf: make([]byte, 0, typ)}
-	}
-
-}
-
 func Load(path string, seekToEnd bool) (*Packet, error) {
 	file, err := os.ReadFile(path)
 	if err != nil {
@@ -102,32 +35,6 @@ func Load(path string, seekToEnd bool) (*Packet, error) {
 	return p, nil
 }
 
-// TODO: test
-func (p *Packet) Release() {
-	p.Pos = 0
-	p.BitPos = 0
-
-	if len(p.Buf) == 100 && cacheMinCount < 1000 {
-		cacheMin.PushBack(p)
-		cacheMinCount++
-	} else if len(p.Buf) == 5000 && cacheMidCount < 250 {
-		cacheMid.PushBack(p)
-		cacheMidCount++
-	} else if len(p.Buf) == 30000 && cacheMaxCount < 50 {
-		cacheMax.PushBack(p)
-		cacheMaxCount++
-	} else if len(p.Buf) == 100000 && cacheBigCount < 10 {
-		cacheBig.PushBack(p)
-		cacheBigCount++
-	} else if len(p.Buf) == 500000 && cacheHugeCount < 5 {
-		cacheHuge.PushBack(p)
-		cacheHugeCount++
-	} else if len(p.Buf) == 2000000 && cacheUnimaginableCount < 2 {
-		cacheUnimaginable.PushBack(p)
-		cacheUnimaginableCount++
-	}
-}
-
 // TODO: test
 func (p *Packet) Save(filePath string, length int, start int) error {
 	dir := filepath.Dir(filePath)
@@ -157,17 +64,17 @@ func (p *Packet) Save(filePath string, length int, start int) error {
 }
 
 // Readers
+//
+// The getters below panic with a [*ReadError] when the buffer is truncated.
+// Use the Try variants in packetchecked.go when decoding untrusted input.
 
 // G1 gets 1 unsigned byte.
-// TODO: error isn't returned if there are no bytes to read sometimes. handle this for all getters somehow
 func (p *Packet) G1() uint8 {
-	b := make([]byte, 1)
-	_, err := p.Read(b)
+	v, err := p.TryG1()
 	if err != nil {
 		panic(err)
-		//fmt.Println("ERROR: EOF") // this is hit sometimes with js5
 	}
-	return b[0]
+	return v
 }
 
 // G1B gets 1 signed byte.
@@ -177,13 +84,11 @@ func (p *Packet) G1B() int8 {
 
 // G2 gets 2 unsigned bytes.
 func (p *Packet) G2() uint16 {
-	b := make([]byte, 2)
-	_, err := p.Read(b)
+	v, err := p.TryG2()
 	if err != nil {
 		panic(err)
-		//fmt.Println("ERROR: G2 EOF")
 	}
-	return uint16(b[0])<<8 | uint16(b[1])
+	return v
 }
 
 // G2S gets 2 signed bytes.
@@ -193,48 +98,47 @@ func (p *Packet) G2S() int16 {
 
 // IG2 gets 2 unsigned bytes represented in little-endian byte order.
 func (p *Packet) IG2() uint16 {
-	b := make([]byte, 2)
-	_, err := p.Read(b)
+	v, err := p.TryIG2()
 	if err != nil {
 		panic(err)
 	}
-	return uint16(b[0]) | uint16(b[1])<<8
+	return v
 }
 
 // G3 gets 3 unsigned bytes.
 func (p *Packet) G3() uint32 {
-	b := make([]byte, 3)
-	_, err := p.Read(b)
+	v, err := p.TryG3()
 	if err != nil {
 		panic(err)
 	}
-	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])
+	return v
 }
 
 // G4 gets 4 unsigned bytes.
 func (p *Packet) G4() uint32 {
-	b := make([]byte, 4)
-	_, err := p.Read(b)
+	v, err := p.TryG4()
 	if err != nil {
 		panic(err)
-		//fmt.Println("ERROR: G4 PANIC")
 	}
-	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3])
+	return v
 }
 
 // IG4 gets 4 unsigned bytes represented in little-endian byte order.
 func (p *Packet) IG4() uint32 {
-	b := make([]byte, 4)
-	_, err := p.Read(b)
+	v, err := p.TryIG4()
 	if err != nil {
 		panic(err)
 	}
-	return uint32(b[3])<<24 | uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0])
+	return v
 }
 
 // G8 gets 8 unsigned bytes.
 func (p *Packet) G8() uint64 {
-	return (uint64(p.G4()) << 32) + uint64(p.G4())
+	v, err := p.TryG8()
+	if err != nil {
+		panic(err)
+	}
+	return v
 }
 
 // GBool gets one byte and returns true if the value is 1,
@@ -246,19 +150,12 @@ func (p *Packet) GBool() bool {
 // GJStr gets a JagString, reading from the Packet
 // until terminator is reached.
 func (p *Packet) GJStr(terminator byte) string {
-	// TODO: optimize this
-	if p.Len() == 0 {
-		log.Println("NO BYTES AVAILABLE IN GJSTR")
-		return ""
-	}
 	// TODO: review the Packet.java version for charset
-	start := p.Pos
-	for p.Buf[p.Pos] != terminator {
-		p.Pos++
+	s, err := p.TryGJStr(terminator)
+	if err != nil {
+		panic(err)
 	}
-	p.Pos++
-	length := p.Pos - start - 1
-	return string(p.Buf[start : start+length])
+	return s
 }
 
 // GJStrLF gets a newline-terminated JagString.
@@ -273,30 +170,28 @@ func (p *Packet) GJStrNUL() string {
 
 // GData gets data.
 func (p *Packet) GData(dest []byte, length int) {
-	// TODO: optimize
-	for i := 0; i < length; i++ {
-		dest[i] = p.Buf[p.Pos]
-		p.Pos++
+	if err := p.TryGData(dest, length); err != nil {
+		panic(err)
 	}
 }
 
 // GSmart gets a Smart value (range 0 to 32767).
 func (p *Packet) GSmart() uint16 {
-	if p.Buf[p.Pos] >= 128 {
-		return p.G2() - 32768
-	} else {
-		return uint16(p.G1())
+	v, err := p.TryGSmart()
+	if err != nil {
+		panic(err)
 	}
+	return v
 }
 
 // GSmartS gets a signed Smart value (range -16384 to 16383).
 func (p *Packet) GSmartS() int32 {
 	// TODO: 2004scape server has this as uint.. maybe? maybe not
-	if p.Buf[p.Pos] >= 128 {
-		return int32(p.G2() - 49152)
-	} else {
-		return int32(p.G1() - 64)
+	v, err := p.TryGSmartS()
+	if err != nil {
+		panic(err)
 	}
+	return v
 }
 
 // Writers
@@ -482,73 +377,3 @@ func (p *Packet) PSmartS(value int32) {
 		panic("value out of range")
 	}
 }
-
-// RSA
-
-// RSAEnc RSA-encrypts the buffer contents.
-func (p *Packet) RSAEnc(modulus *big.Int, exponent *big.Int) {
-	//length := p.Pos
-	length := p.Len()
-	//p.Pos = 0
-
-	plaintextBytes := make([]byte, length)
-	p.GData(plaintextBytes, length)
-
-	plaintext := new(big.Int).SetBytes(plaintextBytes)
-	ciphertext := plaintext.Exp(plaintext, exponent, modulus)
-	ciphertextBytes := ciphertext.Bytes()
-
-	//p.Pos = 0
-	p.Reset()
-	p.P1(uint8(len(ciphertextBytes)))
-	p.PData(ciphertextBytes, len(ciphertextBytes))
-}
-
-func (p *Packet) RSADec() (*Packet, error) {
-	// TODO: add a test for this
-	// TODO: make two funcs: one that can use raw key components (for the original key)
-	// and one that can use a PEM/DER key from disk or something (normal keys for later)
-
-	// we aren't using BigInteger, so we have to do this manually
-	numBytes := p.G1()
-	rsax := make([]byte, numBytes)
-	p.GData(rsax, int(numBytes))
-	if len(rsax) == 65 && rsax[0] == 0 {
-		// Java BigInteger adds a 0 to indicate it's unsigned
-		rsax = rsax[1:]
-	} else if len(rsax) == 63 {
-		// Java BigInteger didn't pad to 64
-		temp := make([]byte, 64)
-		copy(temp[1:], rsax)
-		rsax = temp
-	}
-
-	// TODO: move this into an init() or something, and make key a package-level var?
-	// private exponent
-	keyD, ok := new(big.Int).SetString("571fb062048b61721ebfcf1e877153241b70c3aa26edb0f9f06a1b2be07c4e45eaba4fc356ea806cbed298d38613590a53fde0383c3a411758516293240925e5", 16)
-	if !ok {
-		return nil, errors.New("bad keyD")
-	}
-	// modulus
-	keyN, ok := new(big.Int).SetString("0088c38748a58228f7261cdc340b5691d7d0975dee0ecdb717609e6bf971eb3fe723ef9d130e4686813739768ad9472eb46d8bfcc042c1a5fcb05e931f632eea5d", 16)
-	if !ok {
-		return nil, errors.New("bad keyN")
-	}
-
-	// RSA raw decryption (no padding)
-	// better: take decrypt() from crypto/rsa/rsa.go
-	c := new(big.Int).SetBytes(rsax)
-	decrypted := c.Exp(c, keyD, keyN).Bytes()
-	//decryptedBuf := NewBuffer(decrypted)
-	decryptedBuf := NewPacket(decrypted)
-
-	// BigInteger would also remove all the preceding 0s, so we seek past them
-	//for decryptedBuf.Peek1() == 0 {
-	//	decryptedBuf.Seek(1)
-	//}
-	for decryptedBuf.Buf[decryptedBuf.Pos] == 0 {
-		decryptedBuf.G1()
-	}
-
-	return decryptedBuf, nil
-}
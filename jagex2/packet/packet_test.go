@@ -1,6 +1,8 @@
 package packet
 
 import (
+	"errors"
+	"io"
 	"math/big"
 	"slices"
 	"testing"
@@ -699,7 +701,7 @@ func TestPacket_GSmartS(t *testing.T) {
 				Pos:      0,
 				lastRead: 0,
 			},
-			want: 0xC0CA,
+			want: -16182,
 		},
 		{
 			name: "150, 202",
@@ -708,7 +710,35 @@ func TestPacket_GSmartS(t *testing.T) {
 				Pos:      0,
 				lastRead: 0,
 			},
-			want: 0xD6CA,
+			want: -10550,
+		},
+		{
+			name: "0",
+			fields: fields{
+				Buf: []byte{0},
+			},
+			want: -64,
+		},
+		{
+			name: "127",
+			fields: fields{
+				Buf: []byte{0x7F},
+			},
+			want: 63,
+		},
+		{
+			name: "128, 0",
+			fields: fields{
+				Buf: []byte{0x80, 0x00},
+			},
+			want: -16384,
+		},
+		{
+			name: "255, 255",
+			fields: fields{
+				Buf: []byte{0xFF, 0xFF},
+			},
+			want: 16383,
 		},
 	}
 	for _, tt := range tests {
@@ -725,6 +755,236 @@ func TestPacket_GSmartS(t *testing.T) {
 	}
 }
 
+func TestPacket_Truncated(t *testing.T) {
+	type fields struct {
+		Buf []byte
+		Pos int
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		read   func(p *Packet) error
+		unsafe func(p *Packet)
+		want   ReadError
+	}{
+		{
+			name:   "G1 empty",
+			fields: fields{Buf: []byte{}},
+			read:   func(p *Packet) error { _, err := p.TryG1(); return err },
+			unsafe: func(p *Packet) { p.G1() },
+			want:   ReadError{Op: "G1", Offset: 0, Want: 1, Available: 0},
+		},
+		{
+			name:   "G1B at end",
+			fields: fields{Buf: []byte{1, 2}, Pos: 2},
+			read:   func(p *Packet) error { _, err := p.TryG1B(); return err },
+			unsafe: func(p *Packet) { p.G1B() },
+			want:   ReadError{Op: "G1", Offset: 2, Want: 1, Available: 0},
+		},
+		{
+			name:   "G2",
+			fields: fields{Buf: []byte{1, 2, 3}, Pos: 2},
+			read:   func(p *Packet) error { _, err := p.TryG2(); return err },
+			unsafe: func(p *Packet) { p.G2() },
+			want:   ReadError{Op: "G2", Offset: 2, Want: 2, Available: 1},
+		},
+		{
+			name:   "G2S",
+			fields: fields{Buf: []byte{1}},
+			read:   func(p *Packet) error { _, err := p.TryG2S(); return err },
+			unsafe: func(p *Packet) { p.G2S() },
+			want:   ReadError{Op: "G2", Offset: 0, Want: 2, Available: 1},
+		},
+		{
+			name:   "IG2",
+			fields: fields{Buf: []byte{1}},
+			read:   func(p *Packet) error { _, err := p.TryIG2(); return err },
+			unsafe: func(p *Packet) { p.IG2() },
+			want:   ReadError{Op: "IG2", Offset: 0, Want: 2, Available: 1},
+		},
+		{
+			name:   "G3",
+			fields: fields{Buf: []byte{1, 2, 3, 4}, Pos: 2},
+			read:   func(p *Packet) error { _, err := p.TryG3(); return err },
+			unsafe: func(p *Packet) { p.G3() },
+			want:   ReadError{Op: "G3", Offset: 2, Want: 3, Available: 2},
+		},
+		{
+			name:   "G4",
+			fields: fields{Buf: []byte{1, 2, 3}},
+			read:   func(p *Packet) error { _, err := p.TryG4(); return err },
+			unsafe: func(p *Packet) { p.G4() },
+			want:   ReadError{Op: "G4", Offset: 0, Want: 4, Available: 3},
+		},
+		{
+			name:   "IG4",
+			fields: fields{Buf: []byte{1, 2, 3}},
+			read:   func(p *Packet) error { _, err := p.TryIG4(); return err },
+			unsafe: func(p *Packet) { p.IG4() },
+			want:   ReadError{Op: "IG4", Offset: 0, Want: 4, Available: 3},
+		},
+		{
+			name:   "G8",
+			fields: fields{Buf: []byte{1, 2, 3, 4, 5, 6, 7}},
+			read:   func(p *Packet) error { _, err := p.TryG8(); return err },
+			unsafe: func(p *Packet) { p.G8() },
+			want:   ReadError{Op: "G8", Offset: 0, Want: 8, Available: 7},
+		},
+		{
+			name:   "GBool",
+			fields: fields{Buf: []byte{}},
+			read:   func(p *Packet) error { _, err := p.TryGBool(); return err },
+			unsafe: func(p *Packet) { p.GBool() },
+			want:   ReadError{Op: "G1", Offset: 0, Want: 1, Available: 0},
+		},
+		{
+			name:   "GJStr missing terminator",
+			fields: fields{Buf: []byte{0, 112, 97, 115, 115}, Pos: 1},
+			read:   func(p *Packet) error { _, err := p.TryGJStr(0); return err },
+			unsafe: func(p *Packet) { p.GJStr(0) },
+			want:   ReadError{Op: "GJStr", Offset: 1, Want: 5, Available: 4},
+		},
+		{
+			name:   "GJStr empty",
+			fields: fields{Buf: []byte{}},
+			read:   func(p *Packet) error { _, err := p.TryGJStr(10); return err },
+			unsafe: func(p *Packet) { p.GJStr(10) },
+			want:   ReadError{Op: "GJStr", Offset: 0, Want: 1, Available: 0},
+		},
+		{
+			name:   "GJStrLF missing terminator",
+			fields: fields{Buf: []byte{72, 105}},
+			read:   func(p *Packet) error { _, err := p.TryGJStrLF(); return err },
+			unsafe: func(p *Packet) { p.GJStrLF() },
+			want:   ReadError{Op: "GJStr", Offset: 0, Want: 3, Available: 2},
+		},
+		{
+			name:   "GJStrNUL missing terminator",
+			fields: fields{Buf: []byte{72, 105}},
+			read:   func(p *Packet) error { _, err := p.TryGJStrNUL(); return err },
+			unsafe: func(p *Packet) { p.GJStrNUL() },
+			want:   ReadError{Op: "GJStr", Offset: 0, Want: 3, Available: 2},
+		},
+		{
+			name:   "GData",
+			fields: fields{Buf: []byte{1, 2, 3, 4}, Pos: 1},
+			read:   func(p *Packet) error { return p.TryGData(make([]byte, 4), 4) },
+			unsafe: func(p *Packet) { p.GData(make([]byte, 4), 4) },
+			want:   ReadError{Op: "GData", Offset: 1, Want: 4, Available: 3},
+		},
+		{
+			name:   "GSmart empty",
+			fields: fields{Buf: []byte{}},
+			read:   func(p *Packet) error { _, err := p.TryGSmart(); return err },
+			unsafe: func(p *Packet) { p.GSmart() },
+			want:   ReadError{Op: "GSmart", Offset: 0, Want: 1, Available: 0},
+		},
+		{
+			name:   "GSmart two byte form",
+			fields: fields{Buf: []byte{0x96}},
+			read:   func(p *Packet) error { _, err := p.TryGSmart(); return err },
+			unsafe: func(p *Packet) { p.GSmart() },
+			want:   ReadError{Op: "GSmart", Offset: 0, Want: 2, Available: 1},
+		},
+		{
+			name:   "GSmartS empty",
+			fields: fields{Buf: []byte{}},
+			read:   func(p *Packet) error { _, err := p.TryGSmartS(); return err },
+			unsafe: func(p *Packet) { p.GSmartS() },
+			want:   ReadError{Op: "GSmartS", Offset: 0, Want: 1, Available: 0},
+		},
+		{
+			name:   "GSmartS two byte form",
+			fields: fields{Buf: []byte{0x80}},
+			read:   func(p *Packet) error { _, err := p.TryGSmartS(); return err },
+			unsafe: func(p *Packet) { p.GSmartS() },
+			want:   ReadError{Op: "GSmartS", Offset: 0, Want: 2, Available: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Packet{
+				Buf: tt.fields.Buf,
+				Pos: tt.fields.Pos,
+			}
+			err := tt.read(p)
+			if !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("error = %v, want %v", err, io.ErrUnexpectedEOF)
+			}
+			var re *ReadError
+			if !errors.As(err, &re) {
+				t.Fatalf("error = %T, want *ReadError", err)
+			}
+			if *re != tt.want {
+				t.Errorf("error = %+v, want %+v", *re, tt.want)
+			}
+			if p.Pos != tt.fields.Pos {
+				t.Errorf("Pos = %v, want %v", p.Pos, tt.fields.Pos)
+			}
+
+			defer func() {
+				r := recover()
+				if e, ok := r.(error); !ok || !errors.Is(e, io.ErrUnexpectedEOF) {
+					t.Errorf("panic = %v, want %v", r, io.ErrUnexpectedEOF)
+				}
+			}()
+			tt.unsafe(p)
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	decode := func(p *Packet) (v uint16, err error) {
+		defer Recover(&err)
+		return p.G2(), nil
+	}
+
+	if v, err := decode(NewPacket([]byte{1, 2})); err != nil || v != 0x0102 {
+		t.Errorf("decode() = %v, %v, want %v, nil", v, err, 0x0102)
+	}
+	_, err := decode(NewPacket([]byte{1}))
+	var re *ReadError
+	if !errors.As(err, &re) || re.Op != "G2" {
+		t.Errorf("decode() error = %v, want a G2 *ReadError", err)
+	}
+
+	defer func() {
+		if r := recover(); r != "other" {
+			t.Errorf("panic = %v, want other", r)
+		}
+	}()
+	func() (err error) {
+		defer Recover(&err)
+		panic("other")
+	}()
+}
+
+func TestPacket_TryG(t *testing.T) {
+	p := NewPacket([]byte{1, 0, 2, 0, 0, 3, 0, 0, 0, 4, 104, 105, 0, 0x80, 0xCA})
+
+	if v, err := p.TryG1(); err != nil || v != 1 {
+		t.Fatalf("TryG1() = %v, %v, want 1, nil", v, err)
+	}
+	if v, err := p.TryG2(); err != nil || v != 2 {
+		t.Fatalf("TryG2() = %v, %v, want 2, nil", v, err)
+	}
+	if v, err := p.TryG3(); err != nil || v != 3 {
+		t.Fatalf("TryG3() = %v, %v, want 3, nil", v, err)
+	}
+	if v, err := p.TryG4(); err != nil || v != 4 {
+		t.Fatalf("TryG4() = %v, %v, want 4, nil", v, err)
+	}
+	if v, err := p.TryGJStrNUL(); err != nil || v != "hi" {
+		t.Fatalf("TryGJStrNUL() = %v, %v, want hi, nil", v, err)
+	}
+	if v, err := p.TryGSmart(); err != nil || v != 0xCA {
+		t.Fatalf("TryGSmart() = %v, %v, want %v, nil", v, err, 0xCA)
+	}
+	if _, err := p.TryG1(); err == nil {
+		t.Fatal("TryG1() at end of buffer should fail")
+	}
+}
+
 func TestPacket_P1(t *testing.T) {
 	type fields struct {
 		Buf      []byte
@@ -31,22 +31,22 @@ func (p *Packet) AccessBytes() {
 }
 
 // GBit returns the next n bits in the [Packet].
-func (p *Packet) GBit(n int) uint8 {
+func (p *Packet) GBit(n int) int {
 	bytePos := p.BitPos >> 3
 	bitsRemaining := 8 - (p.BitPos & 0x7)
-	value := uint8(0)
+	value := 0
 	p.BitPos += n
 
 	for ; n > bitsRemaining; bitsRemaining = 8 {
-		value += (p.Buf[bytePos] & uint8(bitmask[bitsRemaining])) << (n - bitsRemaining)
+		value += int(uint32(p.Buf[bytePos])&bitmask[bitsRemaining]) << (n - bitsRemaining)
 		bytePos++
 		n -= bitsRemaining
 	}
 
 	if n == bitsRemaining {
-		value += p.Buf[bytePos] & uint8(bitmask[bitsRemaining])
+		value += int(uint32(p.Buf[bytePos]) & bitmask[bitsRemaining])
 	} else {
-		value += (p.Buf[bytePos] >> (bitsRemaining - n)) & uint8(bitmask[n])
+		value += int(uint32(p.Buf[bytePos]>>(bitsRemaining-n)) & bitmask[n])
 	}
 
 	return value
@@ -58,8 +58,8 @@ func (p *Packet) PBit(n int, value int) {
 	p.BitPos += n
 
 	// grow if necessary
-	if bytePos+1 > p.Len() {
-		_, err := p.Write(make([]byte, (bytePos+1)-p.Len()))
+	if bytePos+1 > len(p.Buf) {
+		_, err := p.Write(make([]byte, (bytePos+1)-len(p.Buf)))
 		if err != nil {
 			panic(err)
 		}
@@ -72,9 +72,8 @@ func (p *Packet) PBit(n int, value int) {
 		n -= remaining
 
 		// grow if necessary
-		if bytePos+1 > p.Len() {
-			//b.Grow((bytePos + 1) - b.Len())
-			p.Write(make([]byte, (bytePos+1)-p.Len()))
+		if bytePos+1 > len(p.Buf) {
+			p.Write(make([]byte, (bytePos+1)-len(p.Buf)))
 		}
 	}
 
@@ -25,3 +25,21 @@ func TestPacketBit(t *testing.T) {
 		t.Fatalf("GBit(7) = %v, want 13", res)
 	}
 }
+
+func TestPacketBit_wide(t *testing.T) {
+	p := NewPacket(nil)
+	p.AccessBits()
+	p.PBit(3, 5)
+	p.PBit(11, 2047)
+	p.PBit(13, 0x1234)
+	p.PBit(1, 1)
+	p.AccessBytes()
+
+	result := NewPacket(p.Buf)
+	result.AccessBits()
+	for _, tt := range []struct{ n, want int }{{3, 5}, {11, 2047}, {13, 0x1234}, {1, 1}} {
+		if got := result.GBit(tt.n); got != tt.want {
+			t.Errorf("GBit(%d) = %#x, want %#x", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package packet
+
+import (
+	"fmt"
+	"io"
+)
+
+// A ReadError is returned by the checked getters when the [Packet]
+// does not hold enough bytes to satisfy a read. It wraps
+// [io.ErrUnexpectedEOF] so callers can match it with errors.Is.
+type ReadError struct {
+	Op        string // getter that failed, e.g. "G4"
+	Offset    int    // read position when the getter was called
+	Want      int    // number of bytes the getter needed (at least)
+	Available int    // number of unread bytes remaining
+}
+
+func (e *ReadError) Error() string {
+	return fmt.Sprintf("packet: %s at offset %d: want %d bytes, have %d", e.Op, e.Offset, e.Want, e.Available)
+}
+
+func (e *ReadError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// Recover stops a panic from a getter that ran out of bytes and sets
+// *err to its [*ReadError]. Decoders that use the panicking getters
+// defer it:
+//
+//	func decode(p *Packet) (err error) {
+//		defer packet.Recover(&err)
+//		...
+//	}
+//
+// Any other panic is not recovered.
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		readErr, ok := r.(*ReadError)
+		if !ok {
+			panic(r)
+		}
+		*err = readErr
+	}
+}
+
+// need returns a [*ReadError] if fewer than n bytes remain unread.
+func (p *Packet) need(op string, n int) error {
+	if n < 0 || p.Pos < 0 || p.Len() < n {
+		return &ReadError{Op: op, Offset: p.Pos, Want: n, Available: max(p.Len(), 0)}
+	}
+	return nil
+}
+
+// Checked readers
+//
+// These mirror the getters in packet.go, but return an error instead of
+// panicking when the buffer is truncated. The read position is left
+// unchanged when an error is returned.
+
+// TryG1 gets 1 unsigned byte.
+func (p *Packet) TryG1() (uint8, error) {
+	if err := p.need("G1", 1); err != nil {
+		return 0, err
+	}
+	v := p.Buf[p.Pos]
+	p.Pos++
+	p.lastRead = opRead
+	return v, nil
+}
+
+// TryG1B gets 1 signed byte.
+func (p *Packet) TryG1B() (int8, error) {
+	v, err := p.TryG1()
+	return int8(v), err
+}
+
+// TryG2 gets 2 unsigned bytes.
+func (p *Packet) TryG2() (uint16, error) {
+	if err := p.need("G2", 2); err != nil {
+		return 0, err
+	}
+	b := p.Buf[p.Pos : p.Pos+2]
+	p.Pos += 2
+	p.lastRead = opRead
+	return uint16(b[0])<<8 | uint16(b[1]), nil
+}
+
+// TryG2S gets 2 signed bytes.
+func (p *Packet) TryG2S() (int16, error) {
+	v, err := p.TryG2()
+	return int16(v), err
+}
+
+// TryIG2 gets 2 unsigned bytes represented in little-endian byte order.
+func (p *Packet) TryIG2() (uint16, error) {
+	if err := p.need("IG2", 2); err != nil {
+		return 0, err
+	}
+	b := p.Buf[p.Pos : p.Pos+2]
+	p.Pos += 2
+	p.lastRead = opRead
+	return uint16(b[0]) | uint16(b[1])<<8, nil
+}
+
+// TryG3 gets 3 unsigned bytes.
+func (p *Packet) TryG3() (uint32, error) {
+	if err := p.need("G3", 3); err != nil {
+		return 0, err
+	}
+	b := p.Buf[p.Pos : p.Pos+3]
+	p.Pos += 3
+	p.lastRead = opRead
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+}
+
+// TryG4 gets 4 unsigned bytes.
+func (p *Packet) TryG4() (uint32, error) {
+	if err := p.need("G4", 4); err != nil {
+		return 0, err
+	}
+	b := p.Buf[p.Pos : p.Pos+4]
+	p.Pos += 4
+	p.lastRead = opRead
+	return uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]), nil
+}
+
+// TryIG4 gets 4 unsigned bytes represented in little-endian byte order.
+func (p *Packet) TryIG4() (uint32, error) {
+	if err := p.need("IG4", 4); err != nil {
+		return 0, err
+	}
+	b := p.Buf[p.Pos : p.Pos+4]
+	p.Pos += 4
+	p.lastRead = opRead
+	return uint32(b[3])<<24 | uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0]), nil
+}
+
+// TryG8 gets 8 unsigned bytes.
+func (p *Packet) TryG8() (uint64, error) {
+	if err := p.need("G8", 8); err != nil {
+		return 0, err
+	}
+	hi, _ := p.TryG4()
+	lo, _ := p.TryG4()
+	return uint64(hi)<<32 | uint64(lo), nil
+}
+
+// TryGBool gets one byte and returns true if the value is 1,
+// or false if the value is anything else.
+func (p *Packet) TryGBool() (bool, error) {
+	v, err := p.TryG1()
+	return v == 1, err
+}
+
+// TryGJStr gets a JagString, reading from the Packet
+// until terminator is reached. An error is returned if
+// the terminator is not found before the end of the buffer.
+func (p *Packet) TryGJStr(terminator byte) (string, error) {
+	if p.Pos < 0 || p.Pos > len(p.Buf) {
+		return "", p.need("GJStr", 1)
+	}
+	i := IndexByte(p.Buf[p.Pos:], terminator)
+	if i == -1 {
+		return "", &ReadError{Op: "GJStr", Offset: p.Pos, Want: p.Len() + 1, Available: p.Len()}
+	}
+	s := string(p.Buf[p.Pos : p.Pos+i])
+	p.Pos += i + 1
+	p.lastRead = opRead
+	return s, nil
+}
+
+// TryGJStrLF gets a newline-terminated JagString.
+func (p *Packet) TryGJStrLF() (string, error) {
+	return p.TryGJStr(10)
+}
+
+// TryGJStrNUL gets a NUL-terminated JagString.
+func (p *Packet) TryGJStrNUL() (string, error) {
+	return p.TryGJStr(0)
+}
+
+// TryGData gets length bytes of data into dest.
+func (p *Packet) TryGData(dest []byte, length int) error {
+	if err := p.need("GData", length); err != nil {
+		return err
+	}
+	if length > len(dest) {
+		return fmt.Errorf("packet: GData at offset %d: dest too small (%d < %d)", p.Pos, len(dest), length)
+	}
+	copy(dest, p.Buf[p.Pos:p.Pos+length])
+	p.Pos += length
+	p.lastRead = opRead
+	return nil
+}
+
+// TryGSmart gets a Smart value (range 0 to 32767).
+func (p *Packet) TryGSmart() (uint16, error) {
+	if err := p.need("GSmart", 1); err != nil {
+		return 0, err
+	}
+	if p.Buf[p.Pos] >= 128 {
+		if err := p.need("GSmart", 2); err != nil {
+			return 0, err
+		}
+		v, _ := p.TryG2()
+		return v - 32768, nil
+	}
+	v, _ := p.TryG1()
+	return uint16(v), nil
+}
+
+// TryGSmartS gets a signed Smart value (range -16384 to 16383).
+func (p *Packet) TryGSmartS() (int32, error) {
+	if err := p.need("GSmartS", 1); err != nil {
+		return 0, err
+	}
+	if p.Buf[p.Pos] >= 128 {
+		if err := p.need("GSmartS", 2); err != nil {
+			return 0, err
+		}
+		v, _ := p.TryG2()
+		return int32(v) - 49152, nil
+	}
+	v, _ := p.TryG1()
+	return int32(v) - 64, nil
+}
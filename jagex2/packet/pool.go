@@ -0,0 +1,151 @@
+package packet
+
+import "sync"
+
+// Size classes accepted by [AllocPacket]. Any other typ value is
+// treated as a raw capacity and is not served from the pool.
+const (
+	SizeMin          = 0 // 100 bytes
+	SizeMid          = 1 // 5000 bytes
+	SizeMax          = 2 // 30000 bytes
+	SizeBig          = 3 // 100000 bytes
+	SizeHuge         = 4 // 500000 bytes
+	SizeUnimaginable = 5 // 2000000 bytes
+)
+
+type sizeClass struct {
+	capacity int // buffer capacity handed out for this class
+	limit    int // maximum number of idle packets kept
+}
+
+var sizeClasses = [...]sizeClass{
+	SizeMin:          {capacity: 100, limit: 1000},
+	SizeMid:          {capacity: 5000, limit: 250},
+	SizeMax:          {capacity: 30000, limit: 50},
+	SizeBig:          {capacity: 100000, limit: 10},
+	SizeHuge:         {capacity: 500000, limit: 5},
+	SizeUnimaginable: {capacity: 2000000, limit: 2},
+}
+
+// PoolStats reports the activity of one size class of a [Pool].
+type PoolStats struct {
+	Capacity int    // buffer capacity of the class
+	Idle     int    // packets currently waiting to be reused
+	Hits     uint64 // allocations served from the idle list
+	Misses   uint64 // allocations that had to make a new buffer
+	Releases uint64 // packets accepted back into the idle list
+	Discards uint64 // packets dropped because the idle list was full
+}
+
+// A Pool recycles [Packet] buffers by size class. It is safe for
+// concurrent use by multiple goroutines.
+type Pool struct {
+	mu    sync.Mutex
+	idle  [len(sizeClasses)][]*Packet
+	stats [len(sizeClasses)]PoolStats
+}
+
+// NewPool returns an empty [Pool].
+func NewPool() *Pool {
+	pl := &Pool{}
+	for i := range sizeClasses {
+		pl.stats[i].Capacity = sizeClasses[i].capacity
+	}
+	return pl
+}
+
+// Alloc returns an empty packet from size class typ (see [SizeMin] and
+// friends), reusing a released packet when one is available. Any other
+// typ allocates a new packet with a capacity of typ bytes.
+func (pl *Pool) Alloc(typ int) *Packet {
+	if typ < 0 || typ >= len(sizeClasses) {
+		return &Packet{Buf: make([]byte, 0, max(typ, 0))}
+	}
+
+	pl.mu.Lock()
+	if n := len(pl.idle[typ]); n > 0 {
+		p := pl.idle[typ][n-1]
+		pl.idle[typ][n-1] = nil
+		pl.idle[typ] = pl.idle[typ][:n-1]
+		pl.stats[typ].Hits++
+		p.pooled = false
+		pl.mu.Unlock()
+
+		return p
+	}
+	pl.stats[typ].Misses++
+	pl.mu.Unlock()
+
+	return &Packet{Buf: make([]byte, 0, sizeClasses[typ].capacity)}
+}
+
+// Release resets p and returns it to the largest size class whose capacity
+// it can satisfy. Packets smaller than the smallest class, and packets that
+// are already in the pool, are ignored. p must not be used after Release.
+func (pl *Pool) Release(p *Packet) {
+	if p == nil {
+		return
+	}
+
+	// pooled and the buffer are only touched under mu, so that two
+	// goroutines releasing the same packet cannot both add it
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	if p.pooled {
+		return
+	}
+
+	typ := -1
+	for i := len(sizeClasses) - 1; i >= 0; i-- {
+		if cap(p.Buf) >= sizeClasses[i].capacity {
+			typ = i
+			break
+		}
+	}
+	if typ == -1 {
+		return
+	}
+	if len(pl.idle[typ]) >= sizeClasses[typ].limit {
+		pl.stats[typ].Discards++
+		return
+	}
+	p.Reset()
+	p.BitPos = 0
+	p.pooled = true
+	pl.idle[typ] = append(pl.idle[typ], p)
+	pl.stats[typ].Releases++
+}
+
+// Stats returns a snapshot of the statistics for each size class,
+// indexed by size class.
+func (pl *Pool) Stats() []PoolStats {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	stats := make([]PoolStats, len(sizeClasses))
+	for i := range stats {
+		stats[i] = pl.stats[i]
+		stats[i].Idle = len(pl.idle[i])
+	}
+	return stats
+}
+
+// defaultPool backs [AllocPacket] and [Packet.Release].
+var defaultPool = NewPool()
+
+// AllocPacket returns an empty packet of size class typ from the
+// default pool. See [Pool.Alloc].
+func AllocPacket(typ int) *Packet {
+	return defaultPool.Alloc(typ)
+}
+
+// Release returns the packet to the default pool. See [Pool.Release].
+func (p *Packet) Release() {
+	defaultPool.Release(p)
+}
+
+// DefaultPoolStats returns the statistics of the default pool.
+func DefaultPoolStats() []PoolStats {
+	return defaultPool.Stats()
+}
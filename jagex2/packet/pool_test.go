@@ -0,0 +1,175 @@
+package packet
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPool_Alloc(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  int
+		want int
+	}{
+		{name: "min", typ: SizeMin, want: 100},
+		{name: "mid", typ: SizeMid, want: 5000},
+		{name: "max", typ: SizeMax, want: 30000},
+		{name: "big", typ: SizeBig, want: 100000},
+		{name: "huge", typ: SizeHuge, want: 500000},
+		{name: "unimaginable", typ: SizeUnimaginable, want: 2000000},
+		{name: "raw capacity", typ: 64, want: 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPool().Alloc(tt.typ)
+			if got := p.Cap(); got != tt.want {
+				t.Errorf("Cap() = %v, want %v", got, tt.want)
+			}
+			if got := len(p.Buf); got != 0 {
+				t.Errorf("len(Buf) = %v, want 0", got)
+			}
+		})
+	}
+}
+
+func TestPool_Reuse(t *testing.T) {
+	pl := NewPool()
+
+	p := pl.Alloc(SizeMid)
+	p.P4(0xDEADBEEF)
+	p.G1()
+	p.BitPos = 12
+	pl.Release(p)
+
+	q := pl.Alloc(SizeMid)
+	if q != p {
+		t.Fatal("Alloc() did not reuse the released packet")
+	}
+	if q.Pos != 0 || q.BitPos != 0 || len(q.Buf) != 0 {
+		t.Fatalf("reused packet not reset: Pos = %v, BitPos = %v, len(Buf) = %v", q.Pos, q.BitPos, len(q.Buf))
+	}
+
+	stats := pl.Stats()[SizeMid]
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Releases != 1 || stats.Idle != 0 {
+		t.Fatalf("Stats() = %+v", stats)
+	}
+}
+
+func TestPool_ReleaseByCapacity(t *testing.T) {
+	pl := NewPool()
+
+	// grown past its class, but still only big enough for SizeMid
+	p := pl.Alloc(SizeMin)
+	p.PData(make([]byte, 6000), 6000)
+	pl.Release(p)
+
+	if got := pl.Stats()[SizeMid].Idle; got != 1 {
+		t.Fatalf("Stats()[SizeMid].Idle = %v, want 1", got)
+	}
+	if got := pl.Alloc(SizeMid); got != p {
+		t.Fatal("Alloc(SizeMid) did not reuse the grown packet")
+	}
+
+	// too small for any class
+	pl.Release(NewPacket(make([]byte, 10)))
+	for i, s := range pl.Stats() {
+		if s.Idle != 0 {
+			t.Fatalf("Stats()[%v].Idle = %v, want 0", i, s.Idle)
+		}
+	}
+}
+
+func TestPool_Limit(t *testing.T) {
+	pl := NewPool()
+
+	limit := sizeClasses[SizeUnimaginable].limit
+	packets := make([]*Packet, limit+1)
+	for i := range packets {
+		packets[i] = pl.Alloc(SizeUnimaginable)
+	}
+	for _, p := range packets {
+		pl.Release(p)
+	}
+
+	stats := pl.Stats()[SizeUnimaginable]
+	if stats.Idle != limit {
+		t.Errorf("Idle = %v, want %v", stats.Idle, limit)
+	}
+	if stats.Discards != 1 {
+		t.Errorf("Discards = %v, want 1", stats.Discards)
+	}
+}
+
+func TestPool_DoubleRelease(t *testing.T) {
+	pl := NewPool()
+
+	p := pl.Alloc(SizeMin)
+	pl.Release(p)
+	pl.Release(p)
+
+	if got := pl.Stats()[SizeMin].Idle; got != 1 {
+		t.Fatalf("Idle = %v, want 1", got)
+	}
+	if a, b := pl.Alloc(SizeMin), pl.Alloc(SizeMin); a == b {
+		t.Fatal("the same packet was handed out twice")
+	}
+}
+
+func TestPool_ConcurrentDoubleRelease(t *testing.T) {
+	pl := NewPool()
+
+	for range 100 {
+		p := pl.Alloc(SizeMin)
+		var wg sync.WaitGroup
+		for range 2 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pl.Release(p)
+			}()
+		}
+		wg.Wait()
+
+		if got := pl.Stats()[SizeMin].Idle; got != 1 {
+			t.Fatalf("Idle = %v after releasing one packet twice, want 1", got)
+		}
+		pl.Alloc(SizeMin)
+	}
+}
+
+func TestPool_Concurrent(t *testing.T) {
+	pl := NewPool()
+
+	var wg sync.WaitGroup
+	for g := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 500 {
+				typ := (g + i) % 3
+				p := pl.Alloc(typ)
+				if len(p.Buf) != 0 {
+					t.Errorf("Alloc(%v) returned a dirty packet", typ)
+					return
+				}
+				p.P4(uint32(g))
+				p.P4(uint32(i))
+				if p.G4() != uint32(g) || p.G4() != uint32(i) {
+					t.Errorf("packet shared between goroutines")
+					return
+				}
+				pl.Release(p)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var allocs, releases uint64
+	for _, s := range pl.Stats() {
+		allocs += s.Hits + s.Misses
+		releases += s.Releases + s.Discards
+	}
+	if allocs != 16*500 || releases != 16*500 {
+		t.Fatalf("allocs = %v, releases = %v, want %v", allocs, releases, 16*500)
+	}
+}
@@ -0,0 +1,228 @@
+package packet
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// An RSAKey holds the components used to encrypt and decrypt the
+// login block. E is optional and only needed for [Packet.RSAEncKey];
+// Primes is optional and only needed to marshal the key.
+type RSAKey struct {
+	N      *big.Int // modulus
+	E      *big.Int // public exponent
+	D      *big.Int // private exponent
+	Primes []*big.Int
+}
+
+// DefaultRSAKey is the key the unmodified 225 client was built with.
+var DefaultRSAKey = mustRSAKeyHex(
+	"0088c38748a58228f7261cdc340b5691d7d0975dee0ecdb717609e6bf971eb3fe723ef9d130e4686813739768ad9472eb46d8bfcc042c1a5fcb05e931f632eea5d",
+	"571fb062048b61721ebfcf1e877153241b70c3aa26edb0f9f06a1b2be07c4e45eaba4fc356ea806cbed298d38613590a53fde0383c3a411758516293240925e5",
+)
+
+func mustRSAKeyHex(n string, d string) *RSAKey {
+	key, err := NewRSAKeyHex(n, d)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// NewRSAKey returns a key built from the raw modulus n and private exponent d.
+func NewRSAKey(n *big.Int, d *big.Int) (*RSAKey, error) {
+	if n == nil || n.Sign() <= 0 {
+		return nil, errors.New("rsa: bad modulus")
+	}
+	if d == nil || d.Sign() <= 0 {
+		return nil, errors.New("rsa: bad private exponent")
+	}
+	return &RSAKey{N: n, D: d}, nil
+}
+
+// NewRSAKeyHex is like [NewRSAKey] but takes hexadecimal components.
+func NewRSAKeyHex(n string, d string) (*RSAKey, error) {
+	keyN, ok := new(big.Int).SetString(n, 16)
+	if !ok {
+		return nil, errors.New("rsa: bad modulus")
+	}
+	keyD, ok := new(big.Int).SetString(d, 16)
+	if !ok {
+		return nil, errors.New("rsa: bad private exponent")
+	}
+	return NewRSAKey(keyN, keyD)
+}
+
+// GenerateRSAKey returns a new random key of the given size in bits.
+// The 225 client expects a 512-bit key.
+func GenerateRSAKey(bits int) (*RSAKey, error) {
+	if bits < 64 || bits%2 != 0 {
+		return nil, fmt.Errorf("rsa: bad key size %d", bits)
+	}
+
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+	for {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		q, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		return &RSAKey{N: n, E: e, D: d, Primes: []*big.Int{p, q}}, nil
+	}
+}
+
+// ParseRSAKeyDER parses a PKCS #1 or PKCS #8 DER encoded private key.
+func ParseRSAKeyDER(der []byte) (*RSAKey, error) {
+	if priv, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return fromPrivateKey(priv), nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	priv, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("rsa: unsupported key type %T", key)
+	}
+	return fromPrivateKey(priv), nil
+}
+
+// ParseRSAKeyPEM parses the first PEM block in data as a private key.
+func ParseRSAKeyPEM(data []byte) (*RSAKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("rsa: no PEM block found")
+	}
+	return ParseRSAKeyDER(block.Bytes)
+}
+
+// LoadRSAKey reads a PEM or DER encoded private key from path.
+func LoadRSAKey(path string) (*RSAKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		return ParseRSAKeyDER(block.Bytes)
+	}
+	return ParseRSAKeyDER(data)
+}
+
+func fromPrivateKey(priv *rsa.PrivateKey) *RSAKey {
+	return &RSAKey{
+		N:      priv.N,
+		E:      big.NewInt(int64(priv.E)),
+		D:      priv.D,
+		Primes: priv.Primes,
+	}
+}
+
+// MarshalPEM encodes the key as a PKCS #1 "RSA PRIVATE KEY" PEM block.
+// The key must have been generated or loaded with its primes.
+func (k *RSAKey) MarshalPEM() ([]byte, error) {
+	if len(k.Primes) < 2 || k.E == nil || !k.E.IsInt64() {
+		return nil, errors.New("rsa: key has no primes or public exponent")
+	}
+
+	priv := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{N: k.N, E: int(k.E.Int64())},
+		D:         k.D,
+		Primes:    k.Primes,
+	}
+	priv.Precompute()
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(priv),
+	}), nil
+}
+
+// RSAEnc RSA-encrypts the buffer contents.
+func (p *Packet) RSAEnc(modulus *big.Int, exponent *big.Int) {
+	length := p.Len()
+
+	plaintextBytes := make([]byte, length)
+	p.GData(plaintextBytes, length)
+
+	plaintext := new(big.Int).SetBytes(plaintextBytes)
+	ciphertext := plaintext.Exp(plaintext, exponent, modulus)
+	ciphertextBytes := ciphertext.Bytes()
+
+	p.Reset()
+	p.P1(uint8(len(ciphertextBytes)))
+	p.PData(ciphertextBytes, len(ciphertextBytes))
+}
+
+// RSAEncKey RSA-encrypts the buffer contents with the public half of key.
+func (p *Packet) RSAEncKey(key *RSAKey) error {
+	if key == nil || key.N == nil || key.E == nil {
+		return errors.New("rsa: key has no public exponent")
+	}
+	p.RSAEnc(key.N, key.E)
+	return nil
+}
+
+// RSADec reads an RSA block from the packet and decrypts it with
+// [DefaultRSAKey].
+func (p *Packet) RSADec() (*Packet, error) {
+	return p.RSADecKey(DefaultRSAKey)
+}
+
+// RSADecKey reads a length-prefixed RSA block from the packet and
+// decrypts it with key, returning the plaintext as a new packet.
+func (p *Packet) RSADecKey(key *RSAKey) (*Packet, error) {
+	if key == nil || key.N == nil || key.D == nil {
+		return nil, errors.New("rsa: key has no private exponent")
+	}
+
+	numBytes, err := p.TryG1()
+	if err != nil {
+		return nil, err
+	}
+	rsax := make([]byte, numBytes)
+	if err := p.TryGData(rsax, int(numBytes)); err != nil {
+		return nil, err
+	}
+
+	// RSA raw decryption (no padding). Java BigInteger may add a leading
+	// 0 or drop leading zeros when encoding, SetBytes handles both.
+	c := new(big.Int).SetBytes(rsax)
+	if c.Cmp(key.N) >= 0 {
+		return nil, errors.New("rsa: block larger than modulus")
+	}
+	decrypted := c.Exp(c, key.D, key.N).Bytes()
+
+	// BigInteger would also remove all the preceding 0s, so we seek past them
+	decryptedBuf := NewPacket(decrypted)
+	for decryptedBuf.Len() > 0 && decryptedBuf.Buf[decryptedBuf.Pos] == 0 {
+		decryptedBuf.Pos++
+	}
+
+	return decryptedBuf, nil
+}
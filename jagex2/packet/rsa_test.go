@@ -0,0 +1,169 @@
+package packet
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRSAKey_RoundTrip(t *testing.T) {
+	key, err := GenerateRSAKey(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.N.BitLen() != 512 {
+		t.Fatalf("N.BitLen() = %v, want 512", key.N.BitLen())
+	}
+
+	tests := []struct {
+		name  string
+		plain []byte
+	}{
+		{
+			name:  "login block",
+			plain: []byte{10, 0, 0, 0, 1, 0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 4, 0, 0, 0, 5, 'u', 's', 'e', 'r', 10, 'p', 'a', 's', 's', 10},
+		},
+		{
+			name:  "single byte",
+			plain: []byte{10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := NewPacket(slices.Clone(tt.plain))
+			if err := enc.RSAEncKey(key); err != nil {
+				t.Fatal(err)
+			}
+
+			dec, err := enc.RSADecKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dec.Bytes(); !slices.Equal(got, tt.plain) {
+				t.Errorf("RSADecKey() = %v, want %v", got, tt.plain)
+			}
+		})
+	}
+}
+
+func TestPacket_RSADec(t *testing.T) {
+	type fields struct {
+		Buf []byte
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   []byte
+	}{
+		{
+			name: "default key",
+			fields: fields{
+				Buf: []byte{8, 1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			want: []byte{0x47, 0xb6, 0xac, 0xd9, 0xd9, 0xcd, 0x6e, 0xdd, 0xa8, 0xb4, 0xd7, 0x1e, 0xcc, 0xdd, 0x2e, 0x84, 0x52, 0x62, 0x9e, 0xd, 0x6e, 0xc9, 0x7b, 0xa0, 0xeb, 0x64, 0x71, 0xf9, 0xfc, 0x41, 0x51, 0x64, 0xf9, 0xe2, 0x96, 0x25, 0xdd, 0xeb, 0x33, 0xd7, 0x34, 0x7c, 0xf1, 0xf6, 0xff, 0xb0, 0x85, 0x26, 0x63, 0x4b, 0xe6, 0x31, 0xa3, 0x4c, 0x96, 0x7e, 0x89, 0xfa, 0x5a, 0xfe, 0xbf, 0xd0, 0xd8, 0x8d},
+		},
+		{
+			name: "java leading zero",
+			fields: fields{
+				Buf: []byte{9, 0, 1, 2, 3, 4, 5, 6, 7, 8},
+			},
+			want: []byte{0x47, 0xb6, 0xac, 0xd9, 0xd9, 0xcd, 0x6e, 0xdd, 0xa8, 0xb4, 0xd7, 0x1e, 0xcc, 0xdd, 0x2e, 0x84, 0x52, 0x62, 0x9e, 0xd, 0x6e, 0xc9, 0x7b, 0xa0, 0xeb, 0x64, 0x71, 0xf9, 0xfc, 0x41, 0x51, 0x64, 0xf9, 0xe2, 0x96, 0x25, 0xdd, 0xeb, 0x33, 0xd7, 0x34, 0x7c, 0xf1, 0xf6, 0xff, 0xb0, 0x85, 0x26, 0x63, 0x4b, 0xe6, 0x31, 0xa3, 0x4c, 0x96, 0x7e, 0x89, 0xfa, 0x5a, 0xfe, 0xbf, 0xd0, 0xd8, 0x8d},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Packet{
+				Buf: tt.fields.Buf,
+			}
+			got, err := p.RSADec()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got.Bytes(), tt.want) {
+				t.Errorf("RSADec() = %v, want %v", got.Bytes(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRSAKey_Truncated(t *testing.T) {
+	p := NewPacket([]byte{64, 1, 2, 3})
+	if _, err := p.RSADecKey(DefaultRSAKey); err == nil {
+		t.Fatal("RSADecKey() on a truncated block should fail")
+	}
+}
+
+func TestRSAKey_EncodeLoad(t *testing.T) {
+	key, err := GenerateRSAKey(512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pemBytes, err := key.MarshalPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		t.Fatal("MarshalPEM() did not produce a PEM block")
+	}
+
+	priv, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"key.pem":       pemBytes,
+		"key.der":       block.Bytes,
+		"key.pkcs8.der": pkcs8,
+	}
+	for name, data := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, data, 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadRSAKey(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.N.Cmp(key.N) != 0 || got.D.Cmp(key.D) != 0 || got.E.Cmp(key.E) != 0 {
+				t.Fatalf("LoadRSAKey() = %+v, want %+v", got, key)
+			}
+
+			enc := NewPacket([]byte("hello"))
+			if err := enc.RSAEncKey(key); err != nil {
+				t.Fatal(err)
+			}
+			dec, err := enc.RSADecKey(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dec.String() != "hello" {
+				t.Errorf("RSADecKey() = %v, want hello", dec.String())
+			}
+		})
+	}
+}
+
+func TestNewRSAKeyHex(t *testing.T) {
+	if _, err := NewRSAKeyHex("zz", "01"); err == nil {
+		t.Error("NewRSAKeyHex() with a bad modulus should fail")
+	}
+	if _, err := NewRSAKeyHex("01", ""); err == nil {
+		t.Error("NewRSAKeyHex() with a bad exponent should fail")
+	}
+	if _, err := NewRSAKeyHex("0d", "05"); err != nil {
+		t.Errorf("NewRSAKeyHex() error = %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+// Package wordpack implements the nibble-packed text encoding used by
+// public chat and private messages.
+package wordpack
+
+import (
+	"strings"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// MaxLength is the longest message, in characters, that [Pack] encodes.
+const MaxLength = 80
+
+// table holds every character that can be packed. The 13 most common
+// characters are packed in a single nibble, the rest in three nibbles.
+var table = []rune{
+	' ', 'e', 't', 'a', 'o', 'i', 'h', 'n', 's', 'r', 'd', 'l', 'u', 'm',
+	'w', 'c', 'y', 'f', 'g', 'p', 'b', 'v', 'k', 'x', 'j', 'q', 'z', '0',
+	'1', '2', '3', '4', '5', '6', '7', '8', '9', ' ', '!', '?', '.', ',',
+	':', ';', '(', ')', '-', '&', '*', '\\', '\'', '@', '#', '+', '=', '£',
+	'$', '%', '"', '[', ']',
+}
+
+// Unpack gets length bytes of packed text from p and returns it
+// in sentence case.
+func Unpack(p *packet.Packet, length int) (string, error) {
+	chars := make([]rune, 0, length*2)
+	carry := -1
+
+	for range length {
+		value, err := p.TryG1()
+		if err != nil {
+			return "", err
+		}
+
+		for _, nibble := range [2]int{int(value>>4) & 0xF, int(value) & 0xF} {
+			if carry != -1 {
+				chars = append(chars, table[(carry<<4)+nibble-195])
+				carry = -1
+			} else if nibble < 13 {
+				chars = append(chars, table[nibble])
+			} else {
+				carry = nibble
+			}
+		}
+	}
+
+	return toSentenceCase(chars), nil
+}
+
+// Pack puts str into p as packed text. Only the first [MaxLength]
+// characters are packed, and characters missing from the table are
+// packed as spaces.
+func Pack(p *packet.Packet, str string) {
+	chars := []rune(strings.ToLower(str))
+	if len(chars) > MaxLength {
+		chars = chars[:MaxLength]
+	}
+
+	carry := -1
+	for _, c := range chars {
+		index := 0
+		for j := range table {
+			if c == table[j] {
+				index = j
+				break
+			}
+		}
+
+		if index > 12 {
+			index += 195
+		}
+
+		if carry == -1 {
+			if index < 13 {
+				carry = index
+			} else {
+				p.P1(uint8(index))
+			}
+		} else if index < 13 {
+			p.P1(uint8((carry << 4) + index))
+			carry = -1
+		} else {
+			p.P1(uint8((carry << 4) + (index >> 4)))
+			carry = index & 0xF
+		}
+	}
+
+	if carry != -1 {
+		p.P1(uint8(carry << 4))
+	}
+}
+
+// ToSentenceCase lowercases input, then uppercases the first letter
+// of the input and the first letter after each '.', '!' or '?'.
+func ToSentenceCase(input string) string {
+	return toSentenceCase([]rune(strings.ToLower(input)))
+}
+
+func toSentenceCase(chars []rune) string {
+	punctuation := true
+	for i, c := range chars {
+		if punctuation && c >= 'a' && c <= 'z' {
+			chars[i] = c - 'a' + 'A'
+			punctuation = false
+		}
+		if c == '.' || c == '!' || c == '?' {
+			punctuation = true
+		}
+	}
+	return string(chars)
+}
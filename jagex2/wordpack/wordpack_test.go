@@ -0,0 +1,145 @@
+package wordpack
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestPack(t *testing.T) {
+	type args struct {
+		str string
+	}
+	tests := []struct {
+		name string
+		args args
+		want []byte
+	}{
+		{
+			name: "hello",
+			args: args{str: "hello"},
+			want: []byte{0x61, 0xBB, 0x40},
+		},
+		{
+			name: "uppercase is packed lowercase",
+			args: args{str: "HELLO"},
+			want: []byte{0x61, 0xBB, 0x40},
+		},
+		{
+			name: "extended character after a carry",
+			args: args{str: "a!"},
+			want: []byte{0x3E, 0x90},
+		},
+		{
+			name: "extended character without a carry",
+			args: args{str: "!"},
+			want: []byte{0xE9},
+		},
+		{
+			name: "unknown characters become spaces",
+			args: args{str: "a^b"},
+			want: []byte{0x30, 0xD7},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := packet.NewPacket(nil)
+			Pack(p, tt.args.str)
+			if got := p.Buf; !slices.Equal(got, tt.want) {
+				t.Errorf("Pack() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	tests := []struct {
+		name    string
+		buf     []byte
+		length  int
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "hello",
+			buf:    []byte{0x61, 0xBB, 0x40},
+			length: 3,
+			want:   "Hello ",
+		},
+		{
+			name:   "extended character",
+			buf:    []byte{0xE9},
+			length: 1,
+			want:   "!",
+		},
+		{
+			name:    "truncated",
+			buf:     []byte{0x61},
+			length:  3,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Unpack(packet.NewPacket(tt.buf), tt.length)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unpack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Unpack() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPackUnpack(t *testing.T) {
+	tests := []struct {
+		name string
+		str  string
+		want string
+	}{
+		{name: "sentence", str: "hello world", want: "Hello world"},
+		{name: "sentences", str: "hi. how are you? fine!", want: "Hi. How are you? Fine!"},
+		{name: "every character", str: "etaoihnsrdlumwcyfgpbvkxjqz0123456789 !?.,:;()-&*\\'@#+=£$%\"[]", want: "Etaoihnsrdlumwcyfgpbvkxjqz0123456789 !?.,:;()-&*\\'@#+=£$%\"[]"},
+		{name: "truncated to 80", str: string(slices.Repeat([]byte{'a'}, 90)), want: "A" + string(slices.Repeat([]byte{'a'}, 79))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := packet.NewPacket(nil)
+			Pack(p, tt.str)
+
+			got, err := Unpack(p, len(p.Buf))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// an odd number of single nibble characters leaves a trailing space
+			if len(got) == len(tt.want)+1 && got[len(got)-1] == ' ' {
+				got = got[:len(got)-1]
+			}
+			if got != tt.want {
+				t.Errorf("Unpack(Pack()) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToSentenceCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "hello world", want: "Hello world"},
+		{input: "EF^&N*DGTF", want: "Ef^&n*dgtf"},
+		{input: "well, anyways. what is up?", want: "Well, anyways. What is up?"},
+		{input: "..:::.4 r s", want: "..:::.4 R s"},
+		{input: "", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := ToSentenceCase(tt.input); got != tt.want {
+				t.Errorf("ToSentenceCase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package collision keeps the collision flags of every tile in the
+// world, built from the map squares the same way the client builds its
+// own.
+package collision
+
+import (
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/cache/mapsquare"
+)
+
+// Tile collision flags. The wall flags name the side of the tile the
+// wall is on; the projectile flags are the walk flags shifted left by 9.
+const (
+	WallNorthWest = 0x1
+	WallNorth     = 0x2
+	WallNorthEast = 0x4
+	WallEast      = 0x8
+	WallSouthEast = 0x10
+	WallSouth     = 0x20
+	WallSouthWest = 0x40
+	WallWest      = 0x80
+	Loc           = 0x100
+
+	ProjWallNorthWest = WallNorthWest << 9
+	ProjWallNorth     = WallNorth << 9
+	ProjWallNorthEast = WallNorthEast << 9
+	ProjWallEast      = WallEast << 9
+	ProjWallSouthEast = WallSouthEast << 9
+	ProjWallSouth     = WallSouth << 9
+	ProjWallSouthWest = WallSouthWest << 9
+	ProjWallWest      = WallWest << 9
+	ProjLoc           = Loc << 9
+
+	Floor = 0x200000 // blocked tile or blocking ground decoration
+)
+
+// projShift turns walk flags into projectile flags.
+const projShift = 9
+
+// A LocType is the part of a loc config collision needs.
+type LocType struct {
+	Width      int
+	Length     int
+	BlockWalk  bool
+	BlockRange bool
+	Active     bool // can be interacted with
+}
+
+// LocTypes looks up a loc config by id.
+type LocTypes func(id int) (LocType, bool)
+
+// squareKey returns the key of the map square holding the tile x, z.
+func squareKey(x, z int) uint16 {
+	return uint16(x>>6<<8 | z>>6)
+}
+
+type square [mapsquare.Levels][mapsquare.Size * mapsquare.Size]uint32
+
+// A Map holds the collision flags of the world. Map squares are
+// allocated as they are first written to. A Map is not safe for
+// concurrent writes.
+type Map struct {
+	squares map[uint16]*square
+}
+
+// NewMap returns an empty Map.
+func NewMap() *Map {
+	return &Map{squares: make(map[uint16]*square)}
+}
+
+func inBounds(x, z, level int) bool {
+	return x >= 0 && x < 0x4000 && z >= 0 && z < 0x4000 && level >= 0 && level < mapsquare.Levels
+}
+
+// Get returns the flags of the tile at x, z on level. Tiles in map
+// squares that were never loaded have no flags.
+func (m *Map) Get(x, z, level int) uint32 {
+	if !inBounds(x, z, level) {
+		return 0
+	}
+	sq := m.squares[squareKey(x, z)]
+	if sq == nil {
+		return 0
+	}
+	return sq[level][(x&63)<<6|z&63]
+}
+
+// Add sets flags on the tile at x, z on level.
+func (m *Map) Add(x, z, level int, flags uint32) {
+	if !inBounds(x, z, level) {
+		return
+	}
+	key := squareKey(x, z)
+	sq := m.squares[key]
+	if sq == nil {
+		sq = &square{}
+		m.squares[key] = sq
+	}
+	sq[level][(x&63)<<6|z&63] |= flags
+}
+
+// Remove clears flags on the tile at x, z on level.
+func (m *Map) Remove(x, z, level int, flags uint32) {
+	if !inBounds(x, z, level) {
+		return
+	}
+	if sq := m.squares[squareKey(x, z)]; sq != nil {
+		sq[level][(x&63)<<6|z&63] &^= flags
+	}
+}
+
+// SetBlocked marks the tile at x, z on level as not walkable.
+func (m *Map) SetBlocked(x, z, level int) {
+	m.Add(x, z, level, Floor)
+}
+
+// A wallSide is a flag set on a wall's own tile and the flag set on the
+// neighbouring tile it faces.
+type wallSide struct {
+	flag      uint32
+	dx, dz    int
+	neighbour uint32
+}
+
+var (
+	straightWalls = [4][]wallSide{
+		{{WallWest, -1, 0, WallEast}},
+		{{WallNorth, 0, 1, WallSouth}},
+		{{WallEast, 1, 0, WallWest}},
+		{{WallSouth, 0, -1, WallNorth}},
+	}
+	cornerWalls = [4][]wallSide{
+		{{WallNorthWest, -1, 1, WallSouthEast}},
+		{{WallNorthEast, 1, 1, WallSouthWest}},
+		{{WallSouthEast, 1, -1, WallNorthWest}},
+		{{WallSouthWest, -1, -1, WallNorthEast}},
+	}
+	lWalls = [4][]wallSide{
+		{{WallWest, -1, 0, WallEast}, {WallNorth, 0, 1, WallSouth}},
+		{{WallNorth, 0, 1, WallSouth}, {WallEast, 1, 0, WallWest}},
+		{{WallEast, 1, 0, WallWest}, {WallSouth, 0, -1, WallNorth}},
+		{{WallSouth, 0, -1, WallNorth}, {WallWest, -1, 0, WallEast}},
+	}
+)
+
+// AddWall adds a wall of the given shape (0-3) and angle.
+func (m *Map) AddWall(x, z, level int, shape, angle uint8, blockRange bool) {
+	var sides []wallSide
+	switch shape {
+	case mapsquare.ShapeWallStraight:
+		sides = straightWalls[angle&3]
+	case mapsquare.ShapeWallDiagonalCorner, mapsquare.ShapeWallSquareCorner:
+		sides = cornerWalls[angle&3]
+	case mapsquare.ShapeWallL:
+		sides = lWalls[angle&3]
+	}
+	for _, s := range sides {
+		m.Add(x, z, level, s.flag)
+		m.Add(x+s.dx, z+s.dz, level, s.neighbour)
+		if blockRange {
+			m.Add(x, z, level, s.flag<<projShift)
+			m.Add(x+s.dx, z+s.dz, level, s.neighbour<<projShift)
+		}
+	}
+}
+
+// AddLoc blocks the tiles under a width by length loc at x, z. Angles 1
+// and 3 swap the width and length.
+func (m *Map) AddLoc(x, z, level, width, length int, angle uint8, blockRange bool) {
+	if angle&1 == 1 {
+		width, length = length, width
+	}
+	flags := uint32(Loc)
+	if blockRange {
+		flags |= ProjLoc
+	}
+	for tx := x; tx < x+width; tx++ {
+		for tz := z; tz < z+length; tz++ {
+			m.Add(tx, tz, level, flags)
+		}
+	}
+}
+
+// AddMapSquare adds the blocked tiles and locs of the map square at
+// mx, mz. The bridge flag on level 1 moves everything above a tile down
+// a level, as the client does.
+func (m *Map) AddMapSquare(mx, mz int, land *mapsquare.Land, locs []mapsquare.Loc, types LocTypes) error {
+	baseX, baseZ := mx<<6, mz<<6
+	for level := range mapsquare.Levels {
+		for x := range mapsquare.Size {
+			for z := range mapsquare.Size {
+				if land[level][x][z].Flags&mapsquare.TileBlocked == 0 {
+					continue
+				}
+				if l := collisionLevel(land, level, x, z); l >= 0 {
+					m.SetBlocked(baseX+x, baseZ+z, l)
+				}
+			}
+		}
+	}
+
+	for _, loc := range locs {
+		typ, ok := types(loc.ID)
+		if !ok {
+			return fmt.Errorf("collision: map square %d %d: unknown loc %d", mx, mz, loc.ID)
+		}
+		level := collisionLevel(land, loc.Level, loc.X, loc.Z)
+		if level < 0 {
+			continue
+		}
+		m.addLoc(baseX+loc.X, baseZ+loc.Z, level, loc, typ)
+	}
+	return nil
+}
+
+// collisionLevel returns the level the collision of the tile at x, z on
+// level belongs to, -1 if it has none.
+func collisionLevel(land *mapsquare.Land, level, x, z int) int {
+	if land[1][x][z].Flags&mapsquare.TileBridge != 0 {
+		return level - 1
+	}
+	return level
+}
+
+func (m *Map) addLoc(x, z, level int, loc mapsquare.Loc, typ LocType) {
+	switch {
+	case loc.Shape == mapsquare.ShapeGroundDecor:
+		if typ.BlockWalk && typ.Active {
+			m.SetBlocked(x, z, level)
+		}
+	case loc.Shape <= mapsquare.ShapeWallSquareCorner:
+		if typ.BlockWalk {
+			m.AddWall(x, z, level, loc.Shape, loc.Angle, typ.BlockRange)
+		}
+	case loc.Shape < mapsquare.ShapeWallDiagonal:
+		// wall decorations do not block
+	default:
+		if typ.BlockWalk {
+			m.AddLoc(x, z, level, typ.Width, typ.Length, loc.Angle, typ.BlockRange)
+		}
+	}
+}
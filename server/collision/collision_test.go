@@ -0,0 +1,139 @@
+package collision
+
+import (
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache/mapsquare"
+)
+
+var testLocTypes = map[int]LocType{
+	1: {Width: 1, Length: 1, BlockWalk: true, BlockRange: true}, // wall
+	2: {Width: 1, Length: 1, BlockWalk: true},                   // fence
+	3: {Width: 2, Length: 3, BlockWalk: true, BlockRange: true}, // table
+	4: {Width: 1, Length: 1, BlockWalk: true, Active: true},     // ground decor
+	5: {Width: 1, Length: 1, BlockWalk: true},                   // inactive ground decor
+	6: {Width: 1, Length: 1},                                    // flowers
+	7: {Width: 1, Length: 1, BlockWalk: true, BlockRange: true}, // wall decor
+}
+
+func lookupTestLocType(id int) (LocType, bool) {
+	t, ok := testLocTypes[id]
+	return t, ok
+}
+
+// loadTestMapSquare encodes and decodes a sample map square at 50, 50
+// and adds it to a new Map.
+func loadTestMapSquare(t *testing.T) *Map {
+	t.Helper()
+
+	land := &mapsquare.Land{}
+	land[0][5][5].Flags = mapsquare.TileBlocked
+	land[1][40][40].Flags = mapsquare.TileBridge | mapsquare.TileBlocked
+	land[0][40][40].Flags = mapsquare.TileBlocked // under the bridge: no level below
+	land[2][40][40].Flags = mapsquare.TileBlocked
+
+	locs := []mapsquare.Loc{
+		{ID: 1, X: 0, Z: 10, Shape: mapsquare.ShapeWallStraight, Angle: 0},
+		{ID: 2, X: 20, Z: 20, Shape: mapsquare.ShapeWallL, Angle: 1},
+		{ID: 1, X: 30, Z: 30, Shape: mapsquare.ShapeWallDiagonalCorner, Angle: 2},
+		{ID: 3, X: 10, Z: 50, Shape: mapsquare.ShapeCentrepiece, Angle: 1},
+		{ID: 4, X: 1, Z: 1, Shape: mapsquare.ShapeGroundDecor},
+		{ID: 5, X: 2, Z: 1, Shape: mapsquare.ShapeGroundDecor},
+		{ID: 6, X: 3, Z: 1, Shape: mapsquare.ShapeCentrepiece},
+		{ID: 7, X: 4, Z: 1, Shape: mapsquare.ShapeWallDecorStraight},
+		{ID: 3, Level: 1, X: 40, Z: 40, Shape: mapsquare.ShapeCentrepiece},
+	}
+
+	landData, err := land.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locsData, err := mapsquare.EncodeLocs(locs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	land, err = mapsquare.DecodeLand(landData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locs, err = mapsquare.DecodeLocs(locsData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMap()
+	if err := m.AddMapSquare(50, 50, land, locs, lookupTestLocType); err != nil {
+		t.Fatalf("AddMapSquare() error = %v", err)
+	}
+	return m
+}
+
+func TestMap_AddMapSquare(t *testing.T) {
+	m := loadTestMapSquare(t)
+	const base = 50 << 6
+
+	tests := []struct {
+		name        string
+		x, z, level int
+		want        uint32
+	}{
+		{"blocked tile", 5, 5, 0, Floor},
+		{"blocked tile, other level", 5, 5, 1, 0},
+		{"straight wall", 0, 10, 0, WallWest | ProjWallWest},
+		{"straight wall, previous square", -1, 10, 0, WallEast | ProjWallEast},
+		{"l wall", 20, 20, 0, WallNorth | WallEast},
+		{"l wall, north", 20, 21, 0, WallSouth},
+		{"l wall, east", 21, 20, 0, WallWest},
+		{"corner wall", 30, 30, 0, WallSouthEast | ProjWallSouthEast},
+		{"corner wall, south east", 31, 29, 0, WallNorthWest | ProjWallNorthWest},
+		{"rotated table", 10, 50, 0, Loc | ProjLoc},
+		{"rotated table, far corner", 12, 51, 0, Loc | ProjLoc},
+		{"rotated table, past length", 10, 52, 0, 0},
+		{"rotated table, past width", 13, 50, 0, 0},
+		{"active ground decor", 1, 1, 0, Floor},
+		{"inactive ground decor", 2, 1, 0, 0},
+		{"non-blocking loc", 3, 1, 0, 0},
+		{"wall decor", 4, 1, 0, 0},
+		{"bridge, level 1 moved down", 40, 40, 0, Floor | Loc | ProjLoc},
+		{"bridge, level 2 moved down", 40, 40, 1, Floor},
+		{"bridge, table end", 41, 42, 0, Loc | ProjLoc},
+		{"bridge, table not on level 1", 41, 42, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Get(base+tt.x, base+tt.z, tt.level); got != tt.want {
+				t.Errorf("Get() = %#x, want %#x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMap_AddMapSquare_unknownLoc(t *testing.T) {
+	m := NewMap()
+	locs := []mapsquare.Loc{{ID: 99}}
+	if err := m.AddMapSquare(50, 50, &mapsquare.Land{}, locs, lookupTestLocType); err == nil {
+		t.Error("AddMapSquare() error = nil, want error")
+	}
+}
+
+func TestMap_Remove(t *testing.T) {
+	m := NewMap()
+	m.AddWall(100, 100, 0, mapsquare.ShapeWallStraight, 1, true)
+	m.Remove(100, 100, 0, WallNorth|ProjWallNorth)
+	if got := m.Get(100, 100, 0); got != 0 {
+		t.Errorf("Get() = %#x after Remove, want 0", got)
+	}
+	if got := m.Get(100, 101, 0); got != WallSouth|ProjWallSouth {
+		t.Errorf("Get() = %#x, want %#x", got, WallSouth|ProjWallSouth)
+	}
+
+	// out of bounds and unloaded tiles are ignored
+	m.Add(-1, 0, 0, Floor)
+	m.Remove(5000, 5000, 0, Floor)
+	if got := m.Get(-1, 0, 0); got != 0 {
+		t.Errorf("Get() = %#x out of bounds, want 0", got)
+	}
+	if got := m.Get(100, 100, 4); got != 0 {
+		t.Errorf("Get() = %#x on level 4, want 0", got)
+	}
+}
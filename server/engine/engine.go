@@ -0,0 +1,266 @@
+// Package engine runs the game world in ticks.
+//
+// Every tick runs the same phases in the same order: queued client input,
+// npcs, players, zones, info and finally flushing each client's messages.
+// Game code registers a handler for the phases it takes part in. Run ticks
+// at [DefaultTickRate]; tests call [Engine.Tick] to advance the world one
+// tick at a time, so that game logic is deterministic.
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultTickRate is the time between ticks when TickRate is not set.
+const DefaultTickRate = 600 * time.Millisecond
+
+// ErrEngineClosed is returned by [Engine.Run] after [Engine.Close].
+var ErrEngineClosed = errors.New("engine: closed")
+
+// ErrRunning is returned by [Engine.Run] if the engine is already running.
+var ErrRunning = errors.New("engine: already running")
+
+// A Phase is one step of a tick.
+type Phase int
+
+const (
+	PhaseInput   Phase = iota // queued client input
+	PhaseNpcs                 // npc scripts and movement
+	PhasePlayers              // player scripts and movement
+	PhaseZones                // zone updates
+	PhaseInfo                 // player and npc info
+	PhaseFlush                // outbound messages
+	NumPhases
+)
+
+var phaseNames = [NumPhases]string{
+	PhaseInput:   "input",
+	PhaseNpcs:    "npcs",
+	PhasePlayers: "players",
+	PhaseZones:   "zones",
+	PhaseInfo:    "info",
+	PhaseFlush:   "flush",
+}
+
+func (p Phase) String() string {
+	if p < 0 || p >= NumPhases {
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// A Handler runs its part of a phase. tick counts up from 1.
+type Handler func(tick uint64)
+
+// PhaseStats is how long a phase has taken.
+type PhaseStats struct {
+	Last  time.Duration
+	Max   time.Duration
+	Total time.Duration
+}
+
+// Stats is how long ticks have taken.
+type Stats struct {
+	Ticks uint64
+	// Overruns counts the ticks that took longer than the tick rate,
+	// delaying the ticks after them.
+	Overruns uint64
+	Last     time.Duration
+	Max      time.Duration
+	Phases   [NumPhases]PhaseStats
+}
+
+// An Engine runs the phases of each tick.
+type Engine struct {
+	// TickRate is the time between the starts of ticks.
+	TickRate time.Duration
+	// ErrorLog logs handlers that panic and ticks that overrun. If nil,
+	// the log package's standard logger is used.
+	ErrorLog *log.Logger
+
+	// tickMu is held while a tick runs.
+	tickMu sync.Mutex
+
+	mu       sync.Mutex
+	handlers [NumPhases][]Handler
+	tick     uint64
+	input    []func()
+	stats    Stats
+	running  bool
+	closed   bool
+	done     chan struct{}
+}
+
+// New returns an engine with no handlers.
+func New() *Engine {
+	return &Engine{}
+}
+
+// Handle adds h to phase. Handlers of a phase run in the order they were
+// added. It is safe to call at any time, including from a handler; a
+// handler added during a tick first runs in the next tick.
+func (e *Engine) Handle(phase Phase, h Handler) {
+	if phase < 0 || phase >= NumPhases {
+		panic(fmt.Sprintf("engine: unknown phase %d", int(phase)))
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.handlers[phase] = append(e.handlers[phase], h)
+}
+
+// Queue adds f to the input of the next tick. It is safe to call from
+// any goroutine, such as a connection's reader. Queued funcs run in the
+// order they were queued, at the start of the input phase.
+func (e *Engine) Queue(f func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.input = append(e.input, f)
+}
+
+// CurrentTick returns the number of ticks run.
+func (e *Engine) CurrentTick() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tick
+}
+
+// Stats returns how long ticks have taken.
+func (e *Engine) Stats() Stats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.stats
+}
+
+// Tick runs one tick now and returns how long it took.
+func (e *Engine) Tick() time.Duration {
+	e.tickMu.Lock()
+	defer e.tickMu.Unlock()
+
+	e.mu.Lock()
+	e.tick++
+	tick := e.tick
+	input := e.input
+	e.input = nil
+	handlers := e.handlers
+	e.mu.Unlock()
+
+	var phases [NumPhases]time.Duration
+	start := time.Now()
+	for phase := range NumPhases {
+		phaseStart := time.Now()
+		if phase == PhaseInput {
+			for _, f := range input {
+				e.run(phase, tick, func(uint64) { f() })
+			}
+		}
+		for _, h := range handlers[phase] {
+			e.run(phase, tick, h)
+		}
+		phases[phase] = time.Since(phaseStart)
+	}
+	elapsed := time.Since(start)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stats.Ticks++
+	e.stats.Last = elapsed
+	e.stats.Max = max(e.stats.Max, elapsed)
+	for phase, d := range phases {
+		s := &e.stats.Phases[phase]
+		s.Last = d
+		s.Max = max(s.Max, d)
+		s.Total += d
+	}
+	return elapsed
+}
+
+// run calls h, logging a panic rather than stopping the world.
+func (e *Engine) run(phase Phase, tick uint64, h Handler) {
+	defer func() {
+		if r := recover(); r != nil {
+			e.logf("engine: tick %d: %s phase panicked: %v", tick, phase, r)
+		}
+	}()
+	h(tick)
+}
+
+// Run ticks until [Engine.Close] is called. A tick that overruns the tick
+// rate is logged, and the next tick starts at once.
+// It always returns a non-nil error.
+func (e *Engine) Run() error {
+	e.mu.Lock()
+	switch {
+	case e.closed:
+		e.mu.Unlock()
+		return ErrEngineClosed
+	case e.running:
+		e.mu.Unlock()
+		return ErrRunning
+	}
+	e.running = true
+	done := e.doneChan()
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
+
+	rate := e.TickRate
+	if rate <= 0 {
+		rate = DefaultTickRate
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-done:
+			return ErrEngineClosed
+		case <-timer.C:
+		}
+
+		elapsed := e.Tick()
+		wait := rate - elapsed
+		if wait < 0 {
+			e.mu.Lock()
+			e.stats.Overruns++
+			tick := e.tick
+			e.mu.Unlock()
+			e.logf("engine: tick %d took %v, overrunning the %v tick rate", tick, elapsed, rate)
+			wait = 0
+		}
+		timer.Reset(wait)
+	}
+}
+
+// Close stops [Engine.Run] after the current tick.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.closed {
+		e.closed = true
+		close(e.doneChan())
+	}
+	return nil
+}
+
+// doneChan returns the channel closed by Close. e.mu must be held.
+func (e *Engine) doneChan() chan struct{} {
+	if e.done == nil {
+		e.done = make(chan struct{})
+	}
+	return e.done
+}
+
+func (e *Engine) logf(format string, args ...any) {
+	if e.ErrorLog != nil {
+		e.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
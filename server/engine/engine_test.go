@@ -0,0 +1,171 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngine_Tick(t *testing.T) {
+	e := New()
+	var got []string
+	record := func(name string) Handler {
+		return func(tick uint64) { got = append(got, name) }
+	}
+	// added out of order; phases still run in order
+	e.Handle(PhaseFlush, record("flush"))
+	e.Handle(PhaseInfo, record("info"))
+	e.Handle(PhasePlayers, record("players 1"))
+	e.Handle(PhasePlayers, record("players 2"))
+	e.Handle(PhaseZones, record("zones"))
+	e.Handle(PhaseNpcs, record("npcs"))
+	e.Handle(PhaseInput, record("input"))
+	e.Queue(func() { got = append(got, "queued 1") })
+	e.Queue(func() { got = append(got, "queued 2") })
+
+	e.Tick()
+	want := []string{"queued 1", "queued 2", "input", "npcs", "players 1", "players 2", "zones", "info", "flush"}
+	if !slices.Equal(got, want) {
+		t.Errorf("first tick ran %v, want %v", got, want)
+	}
+
+	got = nil
+	e.Tick()
+	if want := want[2:]; !slices.Equal(got, want) {
+		t.Errorf("second tick ran %v, want %v", got, want)
+	}
+	if tick := e.CurrentTick(); tick != 2 {
+		t.Errorf("CurrentTick() = %d, want 2", tick)
+	}
+}
+
+func TestEngine_Tick_queueDuringTick(t *testing.T) {
+	e := New()
+	var ran []uint64
+	e.Handle(PhasePlayers, func(tick uint64) {
+		if tick == 1 {
+			e.Queue(func() { ran = append(ran, e.CurrentTick()) })
+		}
+	})
+	e.Tick()
+	if len(ran) != 0 {
+		t.Fatalf("input queued during tick 1 ran in tick %v", ran)
+	}
+	e.Tick()
+	if !slices.Equal(ran, []uint64{2}) {
+		t.Errorf("input queued during tick 1 ran in ticks %v, want [2]", ran)
+	}
+}
+
+func TestEngine_Tick_handleDuringTick(t *testing.T) {
+	e := New()
+	var ran []uint64
+	e.Handle(PhaseInput, func(tick uint64) {
+		if tick == 1 {
+			e.Handle(PhaseNpcs, func(tick uint64) { ran = append(ran, tick) })
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.Tick()
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Tick() deadlocked adding a handler")
+	}
+	if len(ran) != 0 {
+		t.Fatalf("handler added during tick 1 ran in tick %v", ran)
+	}
+	e.Tick()
+	if !slices.Equal(ran, []uint64{2}) {
+		t.Errorf("handler added during tick 1 ran in ticks %v, want [2]", ran)
+	}
+}
+
+func TestEngine_Tick_panic(t *testing.T) {
+	var buf bytes.Buffer
+	e := New()
+	e.ErrorLog = log.New(&buf, "", 0)
+	ran := false
+	e.Handle(PhaseNpcs, func(uint64) { panic("bad npc") })
+	e.Handle(PhaseFlush, func(uint64) { ran = true })
+
+	e.Tick()
+	if !ran {
+		t.Error("later phases did not run after a panic")
+	}
+	if !strings.Contains(buf.String(), "npcs phase panicked: bad npc") {
+		t.Errorf("log = %q", buf.String())
+	}
+}
+
+func TestEngine_Stats(t *testing.T) {
+	e := New()
+	e.Handle(PhaseZones, func(uint64) { time.Sleep(2 * time.Millisecond) })
+	for range 3 {
+		e.Tick()
+	}
+
+	s := e.Stats()
+	if s.Ticks != 3 {
+		t.Errorf("Ticks = %d, want 3", s.Ticks)
+	}
+	zones := s.Phases[PhaseZones]
+	if zones.Last < 2*time.Millisecond || zones.Max < zones.Last || zones.Total < 6*time.Millisecond {
+		t.Errorf("zones stats = %+v", zones)
+	}
+	if s.Last < zones.Last || s.Max < s.Last {
+		t.Errorf("tick stats = %+v", s)
+	}
+}
+
+func TestEngine_Run(t *testing.T) {
+	var buf bytes.Buffer
+	e := New()
+	e.TickRate = 5 * time.Millisecond
+	e.ErrorLog = log.New(&buf, "", 0)
+	ticks := make(chan uint64, 100)
+	e.Handle(PhaseFlush, func(tick uint64) {
+		if tick == 2 {
+			time.Sleep(10 * time.Millisecond) // overrun
+		}
+		ticks <- tick
+	})
+
+	errc := make(chan error, 1)
+	go func() { errc <- e.Run() }()
+	for want := uint64(1); want <= 4; want++ {
+		if got := <-ticks; got != want {
+			t.Fatalf("tick %d, want %d", got, want)
+		}
+	}
+	e.Close()
+	if err := <-errc; !errors.Is(err, ErrEngineClosed) {
+		t.Errorf("Run() error = %v, want %v", err, ErrEngineClosed)
+	}
+	if s := e.Stats(); s.Overruns < 1 {
+		t.Errorf("Overruns = %d, want at least 1", s.Overruns)
+	}
+	if !strings.Contains(buf.String(), "overrunning") {
+		t.Errorf("log = %q", buf.String())
+	}
+	if err := e.Run(); !errors.Is(err, ErrEngineClosed) {
+		t.Errorf("Run() after Close error = %v", err)
+	}
+}
+
+func TestPhase_String(t *testing.T) {
+	if got := PhaseInfo.String(); got != "info" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := NumPhases.String(); got != "Phase(6)" {
+		t.Errorf("String() = %q", got)
+	}
+}
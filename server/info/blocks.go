@@ -0,0 +1,36 @@
+package info
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func pAnim(p *packet.Packet, a *Anim) {
+	p.P2(uint16(a.Seq))
+	p.P1(a.Delay)
+}
+
+func pFaceEntity(p *packet.Packet, f *FaceEntity) {
+	p.P2(uint16(f.Target))
+}
+
+func pSay(p *packet.Packet, s *Say) {
+	p.PJStrLF(s.Text)
+}
+
+func pDamage(p *packet.Packet, d *Damage) {
+	p.P1(d.Amount)
+	p.P1(d.Type)
+	p.P1(d.Health)
+	p.P1(d.MaxHealth)
+}
+
+// pFaceCoord puts the centre of the tile, in half tiles.
+func pFaceCoord(p *packet.Packet, f *FaceCoord) {
+	p.P2(uint16(f.X*2 + 1))
+	p.P2(uint16(f.Z*2 + 1))
+}
+
+func pSpotAnim(p *packet.Packet, s *SpotAnim) {
+	p.P2(s.ID)
+	p.P4(uint32(s.Height)<<16 | uint32(s.Delay))
+}
@@ -0,0 +1,188 @@
+// Package info encodes the PLAYER_INFO and NPC_INFO messages, which tell
+// each client how the entities around it moved and changed in a tick.
+//
+// Each message starts with bit packed movement for the entities the client
+// already knows about, then adds new entities in view, and ends with the
+// masked update blocks of every entity that changed.
+package info
+
+// ViewDistance is how many tiles away, on each axis, another entity can
+// be and still be shown to a player.
+const ViewDistance = 15
+
+// MaxBodySize is the largest info message the client can read.
+const MaxBodySize = 5000
+
+// A Coord is an absolute tile position.
+type Coord struct {
+	X     int
+	Z     int
+	Level int
+}
+
+// Within reports whether o is on the same level as c and at most dist
+// tiles away on each axis.
+func (c Coord) Within(o Coord, dist int) bool {
+	return c.Level == o.Level && abs(c.X-o.X) <= dist && abs(c.Z-o.Z) <= dist
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// A Direction is one of the eight directions an entity can step in.
+type Direction int
+
+const (
+	NorthWest Direction = iota
+	North
+	NorthEast
+	West
+	East
+	SouthWest
+	South
+	SouthEast
+)
+
+var directionDeltas = [...]struct{ dx, dz int }{
+	NorthWest: {-1, 1},
+	North:     {0, 1},
+	NorthEast: {1, 1},
+	West:      {-1, 0},
+	East:      {1, 0},
+	SouthWest: {-1, -1},
+	South:     {0, -1},
+	SouthEast: {1, -1},
+}
+
+// Delta returns the change in x and z of a step in direction d.
+func (d Direction) Delta() (dx, dz int) {
+	delta := directionDeltas[d]
+	return delta.dx, delta.dz
+}
+
+// DirectionOf returns the direction of a step of dx and dz, each -1, 0 or
+// 1. It returns false if there is no such step.
+func DirectionOf(dx, dz int) (Direction, bool) {
+	for d, delta := range directionDeltas {
+		if delta.dx == dx && delta.dz == dz {
+			return Direction(d), true
+		}
+	}
+	return 0, false
+}
+
+// A MoveType is how an entity moved in a tick.
+type MoveType int
+
+const (
+	MoveNone MoveType = iota
+	MoveWalk
+	MoveRun
+	MoveTeleport
+)
+
+// A Movement is how an entity moved in a tick.
+type Movement struct {
+	Type MoveType
+	Walk Direction // first step, for MoveWalk and MoveRun
+	Run  Direction // second step, for MoveRun
+	// Jump is set on a teleport to place the entity at once, rather than
+	// letting the client walk it there.
+	Jump bool
+}
+
+// Anim plays an animation.
+type Anim struct {
+	Seq   int // -1 stops the current animation
+	Delay uint8
+}
+
+// FaceEntity turns to face another entity.
+type FaceEntity struct {
+	// Target is an npc's nid, or 32768 plus a player's pid.
+	// -1 stops facing.
+	Target int
+}
+
+// FacePlayer returns the FaceEntity target of a player.
+func FacePlayer(pid int) int { return 32768 + pid }
+
+// Say shows text over an entity's head.
+type Say struct {
+	Text string
+}
+
+// Damage shows a hitsplat and health bar.
+type Damage struct {
+	Amount    uint8
+	Type      uint8
+	Health    uint8
+	MaxHealth uint8
+}
+
+// FaceCoord turns to face a tile.
+type FaceCoord struct {
+	X int
+	Z int
+}
+
+// SpotAnim plays a spotanim on an entity.
+type SpotAnim struct {
+	ID     uint16
+	Height uint16
+	Delay  uint16 // client cycles
+}
+
+// An entity holds the state players and npcs share. The movement and
+// update blocks are set during a tick and cleared once every client has
+// been sent its info.
+type entity struct {
+	Coord    Coord
+	Movement Movement
+
+	// Update blocks for this tick, nil if unchanged.
+	Anim       *Anim
+	FaceEntity *FaceEntity
+	Say        *Say
+	Damage     *Damage
+	FaceCoord  *FaceCoord
+	SpotAnim   *SpotAnim
+}
+
+// Step moves one tile in direction d. The first step in a tick is a
+// walk, the second makes it a run; any more are not allowed.
+func (e *entity) Step(d Direction) {
+	switch e.Movement.Type {
+	case MoveNone:
+		e.Movement = Movement{Type: MoveWalk, Walk: d}
+	case MoveWalk:
+		e.Movement.Type = MoveRun
+		e.Movement.Run = d
+	default:
+		panic("info: stepped more than twice in a tick")
+	}
+	dx, dz := d.Delta()
+	e.Coord.X += dx
+	e.Coord.Z += dz
+}
+
+// Teleport moves to c. The client walks there unless jump is set.
+func (e *entity) Teleport(c Coord, jump bool) {
+	e.Coord = c
+	e.Movement = Movement{Type: MoveTeleport, Jump: jump}
+}
+
+// reset clears the movement and update blocks.
+func (e *entity) reset() {
+	e.Movement = Movement{}
+	e.Anim = nil
+	e.FaceEntity = nil
+	e.Say = nil
+	e.Damage = nil
+	e.FaceCoord = nil
+	e.SpotAnim = nil
+}
@@ -0,0 +1,131 @@
+package info
+
+import (
+	"sync"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// MaxNpcs is one more than the largest nid. Nid 8191 ends the list of
+// added npcs, so cannot be used.
+const MaxNpcs = 8192
+
+// Npc update masks, in the order their blocks are written.
+const (
+	NpcAnim       = 0x2
+	NpcFaceEntity = 0x4
+	NpcSay        = 0x8
+	NpcDamage     = 0x10
+	NpcChangeType = 0x20
+	NpcSpotAnim   = 0x40
+	NpcFaceCoord  = 0x80
+)
+
+// An Npc is what the info encoder needs to know of an npc. The movement
+// and update blocks are set during a tick, and cleared by [Npc.Reset]
+// once every client has been sent its info.
+type Npc struct {
+	entity
+	Nid  int
+	Type int // npc config, sent when the npc is added
+
+	typeChanged bool
+
+	mu    sync.Mutex
+	block []byte
+}
+
+// NewNpc returns an npc of type typ at c.
+func NewNpc(nid, typ int, c Coord) *Npc {
+	n := &Npc{Nid: nid, Type: typ}
+	n.Coord = c
+	return n
+}
+
+// SetType changes the npc into another type, for the clients that can
+// already see it.
+func (n *Npc) SetType(typ int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.Type = typ
+	n.typeChanged = true
+	n.block = nil
+}
+
+// Reset clears the movement and update blocks at the end of a tick.
+func (n *Npc) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.entity.reset()
+	n.typeChanged = false
+	n.block = nil
+}
+
+// mask returns the update blocks set this tick.
+func (n *Npc) mask() uint8 {
+	var mask uint8
+	if n.Anim != nil {
+		mask |= NpcAnim
+	}
+	if n.FaceEntity != nil {
+		mask |= NpcFaceEntity
+	}
+	if n.Say != nil {
+		mask |= NpcSay
+	}
+	if n.Damage != nil {
+		mask |= NpcDamage
+	}
+	if n.typeChanged {
+		mask |= NpcChangeType
+	}
+	if n.SpotAnim != nil {
+		mask |= NpcSpotAnim
+	}
+	if n.FaceCoord != nil {
+		mask |= NpcFaceCoord
+	}
+	return mask
+}
+
+// updateBlock returns the npc's update blocks, encoded once per tick and
+// shared by every client that is sent them. It returns nil if the npc has
+// not changed.
+func (n *Npc) updateBlock() []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.block != nil {
+		return n.block
+	}
+
+	mask := n.mask()
+	if mask == 0 {
+		return nil
+	}
+
+	p := packet.NewPacket(nil)
+	p.P1(mask)
+	if mask&NpcAnim != 0 {
+		pAnim(p, n.Anim)
+	}
+	if mask&NpcFaceEntity != 0 {
+		pFaceEntity(p, n.FaceEntity)
+	}
+	if mask&NpcSay != 0 {
+		pSay(p, n.Say)
+	}
+	if mask&NpcDamage != 0 {
+		pDamage(p, n.Damage)
+	}
+	if mask&NpcChangeType != 0 {
+		p.P2(uint16(n.Type))
+	}
+	if mask&NpcSpotAnim != 0 {
+		pSpotAnim(p, n.SpotAnim)
+	}
+	if mask&NpcFaceCoord != 0 {
+		pFaceCoord(p, n.FaceCoord)
+	}
+	n.block = p.Buf
+	return n.block
+}
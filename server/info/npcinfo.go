@@ -0,0 +1,104 @@
+package info
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/protocol"
+	"github.com/zsrv/rs-server-225/server/protocol/serverprot"
+)
+
+// MaxLocalNpcs is the most npcs a client can be shown.
+const MaxLocalNpcs = 255
+
+// DefaultMaxNpcAdds is the default for [NpcInfo.MaxAdds].
+const DefaultMaxNpcAdds = 40
+
+// endOfNpcs is the nid that ends the list of added npcs.
+const endOfNpcs = MaxNpcs - 1
+
+// An NpcInfo holds the npcs one client knows about, and builds its
+// NPC_INFO message each tick.
+type NpcInfo struct {
+	// MaxAdds is the most npcs added in a tick. The rest are added in
+	// later ticks, so that a crowd does not arrive in one large message.
+	MaxAdds int
+
+	local   []int // nids of the npcs shown, in the client's order
+	isLocal [MaxNpcs]bool
+}
+
+// NewNpcInfo returns the info of a client that has just logged in.
+func NewNpcInfo() *NpcInfo {
+	return &NpcInfo{MaxAdds: DefaultMaxNpcAdds}
+}
+
+// Local returns the nids of the npcs the client is shown.
+func (ni *NpcInfo) Local() []int {
+	return ni.local
+}
+
+// Build returns the NPC_INFO message of the client playing self. npcs
+// holds every npc in the world, indexed by nid, with nil for free nids.
+func (ni *NpcInfo) Build(self *Player, npcs []*Npc) *protocol.Raw {
+	bits := packet.NewPacket(nil)
+	bits.AccessBits()
+	blocks := packet.NewPacket(nil)
+
+	bits.PBit(8, len(ni.local))
+	local := ni.local[:0]
+	for _, nid := range ni.local {
+		n := npc(npcs, nid)
+		if n == nil || n.Movement.Type == MoveTeleport || !self.Coord.Within(n.Coord, ViewDistance) {
+			bits.PBit(1, 1)
+			bits.PBit(2, 3)
+			ni.isLocal[nid] = false
+			continue
+		}
+		block := n.updateBlock()
+		putMovement(bits, n.Movement, block != nil)
+		blocks.PData(block, len(block))
+		local = append(local, nid)
+	}
+	ni.local = local
+
+	adds := 0
+	for _, n := range npcs {
+		if len(ni.local) >= MaxLocalNpcs || adds >= ni.MaxAdds {
+			break
+		}
+		if n == nil || ni.isLocal[n.Nid] || !self.Coord.Within(n.Coord, ViewDistance) {
+			continue
+		}
+		block := n.updateBlock()
+		// the npc's bits, the end of the list and the blocks must fit
+		if (bits.BitPos+35+13+7)/8+len(blocks.Buf)+len(block) > MaxBodySize {
+			break
+		}
+
+		bits.PBit(13, n.Nid)
+		bits.PBit(11, n.Type)
+		bits.PBit(5, n.Coord.X-self.Coord.X)
+		bits.PBit(5, n.Coord.Z-self.Coord.Z)
+		putBool(bits, block != nil)
+		blocks.PData(block, len(block))
+		ni.local = append(ni.local, n.Nid)
+		ni.isLocal[n.Nid] = true
+		adds++
+	}
+
+	if len(blocks.Buf) > 0 {
+		bits.PBit(13, endOfNpcs)
+	}
+	bits.AccessBytes()
+
+	body := bits.Buf
+	body = append(body, blocks.Buf...)
+	return &protocol.Raw{Op: serverprot.OpcodeNpcInfo, Data: body}
+}
+
+// npc returns the npc with nid, or nil.
+func npc(npcs []*Npc, nid int) *Npc {
+	if nid < 0 || nid >= len(npcs) {
+		return nil
+	}
+	return npcs[nid]
+}
@@ -0,0 +1,115 @@
+package info
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNpcInfo_Build(t *testing.T) {
+	self := NewPlayer(1, Coord{X: 3200, Z: 3200}, Appearance{})
+	npcs := make([]*Npc, 10)
+	npcs[5] = NewNpc(5, 1, Coord{X: 3201, Z: 3199})
+	npcs[9] = NewNpc(9, 300, Coord{X: 3185, Z: 3215})
+	info := NewNpcInfo()
+
+	ticks := []struct {
+		name   string
+		update func()
+		want   []byte
+	}{
+		{
+			name:   "add",
+			update: func() { npcs[9].Say = &Say{Text: "Hello"} },
+			want: []byte{
+				0x00, 0x00, 0x28, 0x01, 0x0f, 0xc0, 0x09, 0x25, 0x91, 0x7f, 0xff, 0xe0,
+				NpcSay, 'H', 'e', 'l', 'l', 'o', '\n',
+			},
+		},
+		{
+			name: "walk",
+			update: func() {
+				npcs[5].Step(East)
+				npcs[5].Anim = &Anim{Seq: 806}
+			},
+			want: []byte{0x02, 0xb2, 0xff, 0xf8, NpcAnim, 0x03, 0x26, 0x00},
+		},
+		{
+			name: "run and remove",
+			update: func() {
+				npcs[5].Step(North)
+				npcs[5].Step(NorthEast)
+				npcs[9].Teleport(Coord{X: 3100, Z: 3100}, true)
+			},
+			want: []byte{0x02, 0xc5, 0x38},
+		},
+		{
+			name:   "idle",
+			update: func() {},
+			want:   []byte{0x01, 0x00},
+		},
+	}
+	for _, tt := range ticks {
+		tt.update()
+		msg := info.Build(self, npcs)
+		if !bytes.Equal(msg.Data, tt.want) {
+			t.Errorf("%s: Build() = % x, want % x", tt.name, msg.Data, tt.want)
+		}
+		for _, n := range npcs {
+			if n != nil {
+				n.Reset()
+			}
+		}
+	}
+	if got := info.Local(); len(got) != 1 || got[0] != 5 {
+		t.Errorf("Local() = %v, want [5]", got)
+	}
+}
+
+func TestNpc_updateBlock(t *testing.T) {
+	n := NewNpc(1, 50, Coord{})
+	if b := n.updateBlock(); b != nil {
+		t.Errorf("updateBlock() = % x, want nil", b)
+	}
+
+	n.Anim = &Anim{Seq: -1, Delay: 2}
+	n.FaceEntity = &FaceEntity{Target: FacePlayer(3)}
+	n.Say = &Say{Text: "Hi"}
+	n.Damage = &Damage{Amount: 5, Type: 1, Health: 10, MaxHealth: 20}
+	n.SetType(51)
+	n.SpotAnim = &SpotAnim{ID: 80, Height: 100, Delay: 3}
+	n.FaceCoord = &FaceCoord{X: 3200, Z: 3201}
+	want := []byte{
+		0xfe,
+		0xff, 0xff, 0x02,
+		0x80, 0x03,
+		'H', 'i', '\n',
+		5, 1, 10, 20,
+		0x00, 0x33,
+		0x00, 0x50, 0x00, 0x64, 0x00, 0x03,
+		0x19, 0x01, 0x19, 0x03,
+	}
+	if b := n.updateBlock(); !bytes.Equal(b, want) {
+		t.Errorf("updateBlock() = % x, want % x", b, want)
+	}
+
+	n.Reset()
+	if b := n.updateBlock(); b != nil {
+		t.Errorf("updateBlock() after Reset = % x, want nil", b)
+	}
+}
+
+func TestNpcInfo_Build_maxAdds(t *testing.T) {
+	self := NewPlayer(1, Coord{X: 3200, Z: 3200}, Appearance{})
+	npcs := make([]*Npc, 100)
+	for nid := range npcs {
+		npcs[nid] = NewNpc(nid, 1, Coord{X: 3190 + nid%20, Z: 3195 + nid/20})
+	}
+	info := NewNpcInfo()
+
+	for i, want := range []int{DefaultMaxNpcAdds, 2 * DefaultMaxNpcAdds, len(npcs)} {
+		info.Build(self, npcs)
+		if got := len(info.Local()); got != want {
+			t.Errorf("tick %d: %d local npcs, want %d", i, got, want)
+		}
+	}
+}
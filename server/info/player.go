@@ -0,0 +1,276 @@
+package info
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/jagex2/wordpack"
+)
+
+// MaxPlayers is one more than the largest pid. Pid 2047 ends the list of
+// added players, so cannot be used.
+const MaxPlayers = 2048
+
+// Player update masks, in the order their blocks are written.
+const (
+	PlayerAppearance = 0x1
+	PlayerAnim       = 0x2
+	PlayerFaceEntity = 0x4
+	PlayerSay        = 0x8
+	PlayerDamage     = 0x10
+	PlayerFaceCoord  = 0x20
+	PlayerChat       = 0x40
+	playerBigMask    = 0x80 // the mask continues in a second byte
+	PlayerSpotAnim   = 0x100
+	PlayerExactMove  = 0x200
+)
+
+// Appearance slots the client reads, and the range of values a Body slot
+// can hold.
+const (
+	BodySlots   = 12
+	ColourSlots = 5
+
+	BodyKit = 0x100 // plus an idk kit
+	BodyObj = 0x200 // plus a worn obj
+)
+
+// An Appearance is how a player looks.
+type Appearance struct {
+	Gender   uint8
+	HeadIcon uint8
+	// Body holds what is drawn in each slot: 0 for nothing, BodyKit
+	// plus an idk kit, or BodyObj plus an obj.
+	Body    [BodySlots]uint16
+	Colours [ColourSlots]uint8
+
+	ReadyAnim     uint16
+	TurnAnim      uint16
+	WalkAnim      uint16
+	WalkBackAnim  uint16
+	WalkLeftAnim  uint16
+	WalkRightAnim uint16
+	RunAnim       uint16
+
+	Name        uint64 // base37 encoded
+	CombatLevel uint8
+}
+
+func (a *Appearance) encode() []byte {
+	p := packet.NewPacket(nil)
+	p.P1(a.Gender)
+	p.P1(a.HeadIcon)
+	for _, part := range a.Body {
+		if part == 0 {
+			p.P1(0)
+		} else {
+			p.P2(part)
+		}
+	}
+	for _, colour := range a.Colours {
+		p.P1(colour)
+	}
+	for _, anim := range [...]uint16{a.ReadyAnim, a.TurnAnim, a.WalkAnim, a.WalkBackAnim, a.WalkLeftAnim, a.WalkRightAnim, a.RunAnim} {
+		p.P2(anim)
+	}
+	p.P8(a.Name)
+	p.P1(a.CombatLevel)
+	return p.Buf
+}
+
+// Chat is a public chat message.
+type Chat struct {
+	Colour  uint8
+	Effect  uint8
+	Type    uint8 // staff mod level, for the crown
+	Message string
+}
+
+// ExactMove slides a player between two tiles over a set time, as when
+// crossing an agility obstacle.
+type ExactMove struct {
+	StartX     int // absolute tiles
+	StartZ     int
+	EndX       int
+	EndZ       int
+	StartCycle uint16 // client cycles from now
+	EndCycle   uint16
+	Direction  uint8 // 0-3: north, east, south, west
+}
+
+// appearanceVersion numbers every appearance set, so that a client that
+// has seen a player's appearance is not sent it again.
+var appearanceVersion atomic.Uint64
+
+// A Player is what the info encoders need to know of a player. The
+// movement and update blocks are set during a tick, and cleared by
+// [Player.Reset] once every client has been sent its info.
+type Player struct {
+	entity
+	Pid int
+
+	// Update blocks only players have, nil if unchanged.
+	Chat      *Chat
+	ExactMove *ExactMove
+
+	appearance        Appearance
+	appearanceVersion uint64
+	appearanceChanged bool
+
+	mu              sync.Mutex
+	appearanceBlock []byte
+	blocks          map[blockKey][]byte
+}
+
+// blockKey identifies an encoded update block. The exact move block is
+// relative to the client's map, so is cached for each origin.
+type blockKey struct {
+	mask             uint16
+	originX, originZ int
+}
+
+// NewPlayer returns a player at c with appearance a.
+func NewPlayer(pid int, c Coord, a Appearance) *Player {
+	pl := &Player{Pid: pid}
+	pl.Coord = c
+	pl.SetAppearance(a)
+	return pl
+}
+
+// Appearance returns how the player looks.
+func (pl *Player) Appearance() Appearance {
+	return pl.appearance
+}
+
+// SetAppearance changes how the player looks, and sends it to every
+// client that can see the player.
+func (pl *Player) SetAppearance(a Appearance) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.appearance = a
+	pl.appearanceVersion = appearanceVersion.Add(1)
+	pl.appearanceChanged = true
+	pl.appearanceBlock = nil
+	pl.blocks = nil
+}
+
+// Reset clears the movement and update blocks at the end of a tick.
+func (pl *Player) Reset() {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	pl.entity.reset()
+	pl.Chat = nil
+	pl.ExactMove = nil
+	pl.appearanceChanged = false
+	pl.blocks = nil
+}
+
+// mask returns the update blocks set this tick.
+func (pl *Player) mask() uint16 {
+	var mask uint16
+	if pl.appearanceChanged {
+		mask |= PlayerAppearance
+	}
+	if pl.Anim != nil {
+		mask |= PlayerAnim
+	}
+	if pl.FaceEntity != nil {
+		mask |= PlayerFaceEntity
+	}
+	if pl.Say != nil {
+		mask |= PlayerSay
+	}
+	if pl.Damage != nil {
+		mask |= PlayerDamage
+	}
+	if pl.FaceCoord != nil {
+		mask |= PlayerFaceCoord
+	}
+	if pl.Chat != nil {
+		mask |= PlayerChat
+	}
+	if pl.SpotAnim != nil {
+		mask |= PlayerSpotAnim
+	}
+	if pl.ExactMove != nil {
+		mask |= PlayerExactMove
+	}
+	return mask
+}
+
+// block returns the update blocks in mask, for a client whose map starts
+// at originX, originZ. Blocks are encoded once per tick and shared by
+// every client that is sent them.
+func (pl *Player) block(mask uint16, originX, originZ int) []byte {
+	key := blockKey{mask: mask}
+	if mask&PlayerExactMove != 0 {
+		key.originX, key.originZ = originX, originZ
+	}
+
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+	if b, ok := pl.blocks[key]; ok {
+		return b
+	}
+
+	p := packet.NewPacket(nil)
+	if mask > 0xff {
+		mask |= playerBigMask
+		p.P1(uint8(mask))
+		p.P1(uint8(mask >> 8))
+	} else {
+		p.P1(uint8(mask))
+	}
+
+	if mask&PlayerAppearance != 0 {
+		if pl.appearanceBlock == nil {
+			pl.appearanceBlock = pl.appearance.encode()
+		}
+		p.P1(uint8(len(pl.appearanceBlock)))
+		p.PData(pl.appearanceBlock, len(pl.appearanceBlock))
+	}
+	if mask&PlayerAnim != 0 {
+		pAnim(p, pl.Anim)
+	}
+	if mask&PlayerFaceEntity != 0 {
+		pFaceEntity(p, pl.FaceEntity)
+	}
+	if mask&PlayerSay != 0 {
+		pSay(p, pl.Say)
+	}
+	if mask&PlayerDamage != 0 {
+		pDamage(p, pl.Damage)
+	}
+	if mask&PlayerFaceCoord != 0 {
+		pFaceCoord(p, pl.FaceCoord)
+	}
+	if mask&PlayerChat != 0 {
+		p.P1(pl.Chat.Colour)
+		p.P1(pl.Chat.Effect)
+		p.P1(pl.Chat.Type)
+		p.P1(0)
+		start := len(p.Buf)
+		wordpack.Pack(p, pl.Chat.Message)
+		p.PSize1(len(p.Buf) - start)
+	}
+	if mask&PlayerSpotAnim != 0 {
+		pSpotAnim(p, pl.SpotAnim)
+	}
+	if mask&PlayerExactMove != 0 {
+		m := pl.ExactMove
+		p.P1(uint8(m.StartX - originX))
+		p.P1(uint8(m.StartZ - originZ))
+		p.P1(uint8(m.EndX - originX))
+		p.P1(uint8(m.EndZ - originZ))
+		p.P2(m.StartCycle)
+		p.P2(m.EndCycle)
+		p.P1(m.Direction)
+	}
+
+	if pl.blocks == nil {
+		pl.blocks = make(map[blockKey][]byte)
+	}
+	pl.blocks[key] = p.Buf
+	return p.Buf
+}
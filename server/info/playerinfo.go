@@ -0,0 +1,186 @@
+package info
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/protocol"
+	"github.com/zsrv/rs-server-225/server/protocol/serverprot"
+)
+
+// MaxLocalPlayers is the most other players a client can be shown.
+const MaxLocalPlayers = 255
+
+// endOfPlayers is the pid that ends the list of added players.
+const endOfPlayers = MaxPlayers - 1
+
+// A PlayerInfo holds what one client knows of the players around it, and
+// builds its PLAYER_INFO message each tick.
+type PlayerInfo struct {
+	// OriginX and OriginZ are the absolute tile at the south west corner
+	// of the map the client has loaded, as sent in REBUILD_NORMAL.
+	OriginX int
+	OriginZ int
+
+	local   []int // pids of the other players shown, in the client's order
+	isLocal [MaxPlayers]bool
+	// appearances holds the version of the appearance last sent for each
+	// pid. The client keeps them after a player leaves its view.
+	appearances [MaxPlayers]uint64
+}
+
+// NewPlayerInfo returns the info of a client that has just logged in.
+func NewPlayerInfo() *PlayerInfo {
+	return &PlayerInfo{}
+}
+
+// Local returns the pids of the other players the client is shown.
+func (pi *PlayerInfo) Local() []int {
+	return pi.local
+}
+
+// Build returns the PLAYER_INFO message of the client playing self.
+// players holds every player in the world, indexed by pid, with nil for
+// free pids.
+func (pi *PlayerInfo) Build(self *Player, players []*Player) *protocol.Raw {
+	bits := packet.NewPacket(nil)
+	bits.AccessBits()
+	blocks := packet.NewPacket(nil)
+
+	// The client shows its own chat as soon as it is typed.
+	mask := pi.updateMask(self) &^ PlayerChat
+	pi.putLocalMovement(bits, self, mask != 0)
+	pi.putBlock(blocks, self, mask)
+
+	bits.PBit(8, len(pi.local))
+	local := pi.local[:0]
+	for _, pid := range pi.local {
+		other := player(players, pid)
+		if other == nil || other.Movement.Type == MoveTeleport || !self.Coord.Within(other.Coord, ViewDistance) {
+			bits.PBit(1, 1)
+			bits.PBit(2, 3)
+			pi.isLocal[pid] = false
+			continue
+		}
+		mask := pi.updateMask(other)
+		putMovement(bits, other.Movement, mask != 0)
+		pi.putBlock(blocks, other, mask)
+		local = append(local, pid)
+	}
+	pi.local = local
+
+	for _, other := range players {
+		if len(pi.local) >= MaxLocalPlayers {
+			break
+		}
+		if other == nil || other == self || pi.isLocal[other.Pid] || !self.Coord.Within(other.Coord, ViewDistance) {
+			continue
+		}
+		mask := pi.updateMask(other)
+		var block []byte
+		if mask != 0 {
+			block = other.block(mask, pi.OriginX, pi.OriginZ)
+		}
+		// the player's bits, the end of the list and the blocks must fit
+		if (bits.BitPos+23+11+7)/8+len(blocks.Buf)+len(block) > MaxBodySize {
+			break
+		}
+
+		bits.PBit(11, other.Pid)
+		bits.PBit(5, other.Coord.X-self.Coord.X)
+		bits.PBit(5, other.Coord.Z-self.Coord.Z)
+		bits.PBit(1, 1) // jump
+		putBool(bits, mask != 0)
+		if mask != 0 {
+			blocks.PData(block, len(block))
+			pi.appearances[other.Pid] = other.appearanceVersion
+		}
+		pi.local = append(pi.local, other.Pid)
+		pi.isLocal[other.Pid] = true
+	}
+
+	if len(blocks.Buf) > 0 {
+		bits.PBit(11, endOfPlayers)
+	}
+	bits.AccessBytes()
+
+	body := bits.Buf
+	body = append(body, blocks.Buf...)
+	return &protocol.Raw{Op: serverprot.OpcodePlayerInfo, Data: body}
+}
+
+// player returns the player with pid, or nil.
+func player(players []*Player, pid int) *Player {
+	if pid < 0 || pid >= len(players) {
+		return nil
+	}
+	return players[pid]
+}
+
+// updateMask returns the update blocks to send for pl, adding its
+// appearance if the client has not been sent it.
+func (pi *PlayerInfo) updateMask(pl *Player) uint16 {
+	mask := pl.mask()
+	if pi.appearances[pl.Pid] != pl.appearanceVersion {
+		mask |= PlayerAppearance
+	}
+	return mask
+}
+
+// putBlock puts the update blocks of pl in mask, if any.
+func (pi *PlayerInfo) putBlock(p *packet.Packet, pl *Player, mask uint16) {
+	if mask == 0 {
+		return
+	}
+	block := pl.block(mask, pi.OriginX, pi.OriginZ)
+	p.PData(block, len(block))
+	if mask&PlayerAppearance != 0 {
+		pi.appearances[pl.Pid] = pl.appearanceVersion
+	}
+}
+
+// putLocalMovement puts the movement of the client's own player, which
+// alone can teleport without being removed.
+func (pi *PlayerInfo) putLocalMovement(p *packet.Packet, self *Player, hasMask bool) {
+	if self.Movement.Type != MoveTeleport {
+		putMovement(p, self.Movement, hasMask)
+		return
+	}
+	p.PBit(1, 1)
+	p.PBit(2, 3)
+	p.PBit(2, self.Coord.Level)
+	p.PBit(7, self.Coord.X-pi.OriginX)
+	p.PBit(7, self.Coord.Z-pi.OriginZ)
+	putBool(p, self.Movement.Jump)
+	putBool(p, hasMask)
+}
+
+// putMovement puts a walk, run or no movement.
+func putMovement(p *packet.Packet, m Movement, hasMask bool) {
+	switch m.Type {
+	case MoveWalk:
+		p.PBit(1, 1)
+		p.PBit(2, 1)
+		p.PBit(3, int(m.Walk))
+		putBool(p, hasMask)
+	case MoveRun:
+		p.PBit(1, 1)
+		p.PBit(2, 2)
+		p.PBit(3, int(m.Walk))
+		p.PBit(3, int(m.Run))
+		putBool(p, hasMask)
+	default:
+		if hasMask {
+			p.PBit(1, 1)
+			p.PBit(2, 0)
+		} else {
+			p.PBit(1, 0)
+		}
+	}
+}
+
+func putBool(p *packet.Packet, b bool) {
+	if b {
+		p.PBit(1, 1)
+	} else {
+		p.PBit(1, 0)
+	}
+}
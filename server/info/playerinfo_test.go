@@ -0,0 +1,402 @@
+package info
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/jagex2/wordpack"
+)
+
+// testClient reads PLAYER_INFO the way the client does.
+type testClient struct {
+	t                *testing.T
+	originX, originZ int
+	self             Coord
+	local            []int
+	coords           map[int]Coord
+	appearances      map[int][]byte // kept after a player is removed
+}
+
+// testUpdate is the decoded update blocks of one player.
+type testUpdate struct {
+	Mask       uint16
+	Appearance []byte
+	Anim       *Anim
+	FaceEntity *FaceEntity
+	Say        *Say
+	Damage     *Damage
+	FaceCoord  *FaceCoord
+	Chat       *Chat
+	SpotAnim   *SpotAnim
+	ExactMove  *ExactMove
+}
+
+// read reads body, returning the moves and updates of each player.
+// The client's own player has pid -1.
+func (c *testClient) read(body []byte) (map[int]Movement, map[int]testUpdate) {
+	c.t.Helper()
+	p := packet.NewPacket(body)
+	p.AccessBits()
+	moves := make(map[int]Movement)
+	var updates []int
+
+	readMove := func(pid int) (Movement, bool) {
+		if p.GBit(1) == 0 {
+			return Movement{}, false
+		}
+		switch p.GBit(2) {
+		case 0:
+			return Movement{}, true
+		case 1:
+			m := Movement{Type: MoveWalk, Walk: Direction(p.GBit(3))}
+			return m, p.GBit(1) == 1
+		case 2:
+			m := Movement{Type: MoveRun, Walk: Direction(p.GBit(3)), Run: Direction(p.GBit(3))}
+			return m, p.GBit(1) == 1
+		default:
+			if pid != -1 {
+				return Movement{Type: -1}, false // removed
+			}
+			c.self.Level = p.GBit(2)
+			c.self.X = c.originX + p.GBit(7)
+			c.self.Z = c.originZ + p.GBit(7)
+			m := Movement{Type: MoveTeleport, Jump: p.GBit(1) == 1}
+			return m, p.GBit(1) == 1
+		}
+	}
+	step := func(coord *Coord, m Movement) {
+		if m.Type == MoveWalk || m.Type == MoveRun {
+			dx, dz := m.Walk.Delta()
+			coord.X, coord.Z = coord.X+dx, coord.Z+dz
+		}
+		if m.Type == MoveRun {
+			dx, dz := m.Run.Delta()
+			coord.X, coord.Z = coord.X+dx, coord.Z+dz
+		}
+	}
+
+	m, hasMask := readMove(-1)
+	step(&c.self, m)
+	moves[-1] = m
+	if hasMask {
+		updates = append(updates, -1)
+	}
+
+	count := p.GBit(8)
+	if count > len(c.local) {
+		c.t.Fatalf("player count %d, client has %d", count, len(c.local))
+	}
+	var local []int
+	for _, pid := range c.local[:count] {
+		m, hasMask := readMove(pid)
+		moves[pid] = m
+		if m.Type == -1 {
+			delete(c.coords, pid)
+			continue
+		}
+		coord := c.coords[pid]
+		step(&coord, m)
+		c.coords[pid] = coord
+		local = append(local, pid)
+		if hasMask {
+			updates = append(updates, pid)
+		}
+	}
+	c.local = local
+
+	for p.BitPos+10 < len(body)*8 {
+		pid := p.GBit(11)
+		if pid == endOfPlayers {
+			break
+		}
+		dx, dz := p.GBit(5), p.GBit(5)
+		if dx > 15 {
+			dx -= 32
+		}
+		if dz > 15 {
+			dz -= 32
+		}
+		if p.GBit(1) != 1 {
+			c.t.Errorf("player %d added without jump", pid)
+		}
+		c.coords[pid] = Coord{X: c.self.X + dx, Z: c.self.Z + dz, Level: c.self.Level}
+		c.local = append(c.local, pid)
+		moves[pid] = Movement{Type: MoveTeleport, Jump: true}
+		if p.GBit(1) == 1 {
+			updates = append(updates, pid)
+		}
+	}
+	p.AccessBytes()
+
+	blocks := make(map[int]testUpdate)
+	for _, pid := range updates {
+		var u testUpdate
+		u.Mask = uint16(p.G1())
+		if u.Mask&playerBigMask != 0 {
+			u.Mask += uint16(p.G1()) << 8
+			u.Mask &^= playerBigMask
+		}
+		if u.Mask&PlayerAppearance != 0 {
+			u.Appearance = make([]byte, p.G1())
+			p.GData(u.Appearance, len(u.Appearance))
+			c.appearances[pid] = u.Appearance
+		}
+		if u.Mask&PlayerAnim != 0 {
+			u.Anim = &Anim{Seq: int(p.G2S()), Delay: p.G1()}
+		}
+		if u.Mask&PlayerFaceEntity != 0 {
+			u.FaceEntity = &FaceEntity{Target: int(p.G2())}
+			if u.FaceEntity.Target == 0xffff {
+				u.FaceEntity.Target = -1
+			}
+		}
+		if u.Mask&PlayerSay != 0 {
+			u.Say = &Say{Text: p.GJStrLF()}
+		}
+		if u.Mask&PlayerDamage != 0 {
+			u.Damage = &Damage{Amount: p.G1(), Type: p.G1(), Health: p.G1(), MaxHealth: p.G1()}
+		}
+		if u.Mask&PlayerFaceCoord != 0 {
+			u.FaceCoord = &FaceCoord{X: (int(p.G2()) - 1) / 2, Z: (int(p.G2()) - 1) / 2}
+		}
+		if u.Mask&PlayerChat != 0 {
+			u.Chat = &Chat{Colour: p.G1(), Effect: p.G1(), Type: p.G1()}
+			var err error
+			if u.Chat.Message, err = wordpack.Unpack(p, int(p.G1())); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		if u.Mask&PlayerSpotAnim != 0 {
+			id, heightDelay := p.G2(), p.G4()
+			u.SpotAnim = &SpotAnim{ID: id, Height: uint16(heightDelay >> 16), Delay: uint16(heightDelay)}
+		}
+		if u.Mask&PlayerExactMove != 0 {
+			u.ExactMove = &ExactMove{
+				StartX: c.originX + int(p.G1()), StartZ: c.originZ + int(p.G1()),
+				EndX: c.originX + int(p.G1()), EndZ: c.originZ + int(p.G1()),
+				StartCycle: p.G2(), EndCycle: p.G2(), Direction: p.G1(),
+			}
+		}
+		blocks[pid] = u
+	}
+	if p.Len() != 0 {
+		c.t.Fatalf("%d bytes left after update blocks", p.Len())
+	}
+	return moves, blocks
+}
+
+func testAppearance(name uint64) Appearance {
+	return Appearance{
+		Body:      [BodySlots]uint16{0, 0, 0, 0, BodyKit + 18, 0, BodyKit + 26, BodyKit + 36, BodyKit + 0, BodyKit + 33, BodyKit + 42, BodyKit + 10},
+		Colours:   [ColourSlots]uint8{1, 2, 3, 4, 0},
+		ReadyAnim: 808, TurnAnim: 823, WalkAnim: 819, WalkBackAnim: 820, WalkLeftAnim: 821, WalkRightAnim: 822, RunAnim: 824,
+		Name:        name,
+		CombatLevel: 3,
+	}
+}
+
+// tick builds the info of every client, then resets the players.
+func tick(t *testing.T, players []*Player, infos map[int]*PlayerInfo, clients map[int]*testClient) (map[int]map[int]Movement, map[int]map[int]testUpdate) {
+	t.Helper()
+	moves := make(map[int]map[int]Movement)
+	updates := make(map[int]map[int]testUpdate)
+	for pid, info := range infos {
+		msg := info.Build(players[pid], players)
+		if len(msg.Data) > MaxBodySize {
+			t.Fatalf("info of %d is %d bytes", pid, len(msg.Data))
+		}
+		moves[pid], updates[pid] = clients[pid].read(msg.Data)
+	}
+	for _, pl := range players {
+		if pl != nil {
+			pl.Reset()
+		}
+	}
+	return moves, updates
+}
+
+func TestPlayerInfo_Build(t *testing.T) {
+	players := make([]*Player, 10)
+	players[1] = NewPlayer(1, Coord{X: 3222, Z: 3218}, testAppearance(1))
+	players[2] = NewPlayer(2, Coord{X: 3225, Z: 3210}, testAppearance(2))
+	infos := map[int]*PlayerInfo{1: {OriginX: 3168, OriginZ: 3168}}
+	clients := map[int]*testClient{1: &testClient{t: t, originX: 3168, originZ: 3168, self: players[1].Coord, coords: map[int]Coord{}, appearances: map[int][]byte{}}}
+
+	// both appearances are sent
+	_, updates := tick(t, players, infos, clients)
+	if got := updates[1][-1]; got.Mask != PlayerAppearance || len(got.Appearance) == 0 {
+		t.Errorf("own update = %+v, want appearance", got)
+	}
+	if got := updates[1][2]; got.Mask != PlayerAppearance {
+		t.Errorf("added player update = %+v, want appearance", got)
+	}
+	if got := clients[1].coords[2]; got != players[2].Coord {
+		t.Errorf("added player at %+v, want %+v", got, players[2].Coord)
+	}
+
+	// nothing changed
+	moves, updates := tick(t, players, infos, clients)
+	if len(updates[1]) != 0 || moves[1][2] != (Movement{}) {
+		t.Errorf("idle tick: moves %+v, updates %+v", moves[1], updates[1])
+	}
+
+	// walk and run
+	players[1].Step(North)
+	players[2].Step(East)
+	players[2].Step(NorthEast)
+	players[2].Anim = &Anim{Seq: 866, Delay: 0}
+	moves, updates = tick(t, players, infos, clients)
+	if got := moves[1][-1]; got != (Movement{Type: MoveWalk, Walk: North}) {
+		t.Errorf("own move = %+v", got)
+	}
+	if got := moves[1][2]; got != (Movement{Type: MoveRun, Walk: East, Run: NorthEast}) {
+		t.Errorf("other move = %+v", got)
+	}
+	if got := updates[1][2]; got.Mask != PlayerAnim || *got.Anim != (Anim{Seq: 866}) {
+		t.Errorf("other update = %+v", got)
+	}
+	if clients[1].self != players[1].Coord || clients[1].coords[2] != players[2].Coord {
+		t.Errorf("client positions %+v %+v, want %+v %+v", clients[1].self, clients[1].coords[2], players[1].Coord, players[2].Coord)
+	}
+
+	// the other player teleports within view: removed and added again,
+	// without resending its appearance
+	players[2].Teleport(Coord{X: 3230, Z: 3230}, true)
+	moves, updates = tick(t, players, infos, clients)
+	if got := moves[1][2]; got.Type != MoveTeleport {
+		t.Errorf("teleported player move = %+v", got)
+	}
+	if _, ok := updates[1][2]; ok {
+		t.Errorf("teleported player was sent %+v", updates[1][2])
+	}
+	if got := clients[1].coords[2]; got != players[2].Coord {
+		t.Errorf("teleported player at %+v, want %+v", got, players[2].Coord)
+	}
+
+	// out of view
+	players[2].Teleport(Coord{X: 3300, Z: 3230}, true)
+	tick(t, players, infos, clients)
+	if len(clients[1].local) != 0 || len(infos[1].Local()) != 0 {
+		t.Errorf("local players = %v, %v, want none", clients[1].local, infos[1].Local())
+	}
+
+	// own teleport
+	players[1].Teleport(Coord{X: 3200, Z: 3201, Level: 1}, true)
+	moves, _ = tick(t, players, infos, clients)
+	if got := moves[1][-1]; got != (Movement{Type: MoveTeleport, Jump: true}) || clients[1].self != players[1].Coord {
+		t.Errorf("own teleport = %+v to %+v, want %+v", got, clients[1].self, players[1].Coord)
+	}
+
+	// a new appearance is sent to a client that saw the old one
+	players[2].Teleport(Coord{X: 3201, Z: 3201, Level: 1}, true)
+	players[2].SetAppearance(testAppearance(22))
+	_, updates = tick(t, players, infos, clients)
+	if got := updates[1][2]; got.Mask != PlayerAppearance {
+		t.Errorf("changed appearance update = %+v", got)
+	}
+}
+
+func TestPlayerInfo_blocks(t *testing.T) {
+	players := make([]*Player, 3)
+	players[1] = NewPlayer(1, Coord{X: 3222, Z: 3218}, testAppearance(1))
+	players[2] = NewPlayer(2, Coord{X: 3223, Z: 3218}, testAppearance(2))
+	infos := map[int]*PlayerInfo{1: {OriginX: 3168, OriginZ: 3168}, 2: {OriginX: 3168, OriginZ: 3168}}
+	clients := map[int]*testClient{
+		1: &testClient{t: t, originX: 3168, originZ: 3168, self: players[1].Coord, coords: map[int]Coord{}, appearances: map[int][]byte{}},
+		2: &testClient{t: t, originX: 3168, originZ: 3168, self: players[2].Coord, coords: map[int]Coord{}, appearances: map[int][]byte{}},
+	}
+	tick(t, players, infos, clients)
+
+	pl := players[2]
+	pl.Anim = &Anim{Seq: -1, Delay: 5}
+	pl.FaceEntity = &FaceEntity{Target: FacePlayer(1)}
+	pl.Say = &Say{Text: "Taste vengeance!"}
+	pl.Damage = &Damage{Amount: 12, Type: 1, Health: 40, MaxHealth: 99}
+	pl.FaceCoord = &FaceCoord{X: 3224, Z: 3219}
+	pl.Chat = &Chat{Colour: 2, Effect: 1, Type: 1, Message: "Hi there"}
+	pl.SpotAnim = &SpotAnim{ID: 86, Height: 100, Delay: 20}
+	pl.ExactMove = &ExactMove{StartX: 3223, StartZ: 3218, EndX: 3225, EndZ: 3218, StartCycle: 30, EndCycle: 60, Direction: 1}
+	want := testUpdate{
+		Mask:       PlayerAnim | PlayerFaceEntity | PlayerSay | PlayerDamage | PlayerFaceCoord | PlayerChat | PlayerSpotAnim | PlayerExactMove,
+		Anim:       pl.Anim,
+		FaceEntity: pl.FaceEntity,
+		Say:        pl.Say,
+		Damage:     pl.Damage,
+		FaceCoord:  pl.FaceCoord,
+		Chat:       pl.Chat,
+		SpotAnim:   pl.SpotAnim,
+		ExactMove:  pl.ExactMove,
+	}
+
+	_, updates := tick(t, players, infos, clients)
+	if got := updates[1][2]; !reflect.DeepEqual(got, want) {
+		t.Errorf("update = %+v, want %+v", got, want)
+	}
+	// the player's own chat is not echoed back
+	want.Mask &^= PlayerChat
+	want.Chat = nil
+	if got := updates[2][-1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("own update = %+v, want %+v", got, want)
+	}
+}
+
+func TestPlayer_block_cache(t *testing.T) {
+	pl := NewPlayer(1, Coord{X: 3222, Z: 3218}, testAppearance(1))
+	pl.Anim = &Anim{Seq: 1}
+
+	a := pl.block(PlayerAnim|PlayerAppearance, 3168, 3168)
+	b := pl.block(PlayerAnim|PlayerAppearance, 3120, 3168)
+	if &a[0] != &b[0] {
+		t.Error("block() encoded the same blocks twice")
+	}
+
+	pl.ExactMove = &ExactMove{StartX: 3222, StartZ: 3218, EndX: 3223, EndZ: 3218}
+	a = pl.block(PlayerExactMove, 3168, 3168)
+	b = pl.block(PlayerExactMove, 3120, 3168)
+	if a[2] == b[2] {
+		t.Error("block() shared an exact move between map origins")
+	}
+
+	pl.Reset()
+	if mask := pl.mask(); mask != 0 {
+		t.Errorf("mask() after Reset = %#x", mask)
+	}
+}
+
+func TestPlayerInfo_Build_full(t *testing.T) {
+	players := make([]*Player, MaxLocalPlayers+10)
+	for pid := 1; pid < len(players); pid++ {
+		players[pid] = NewPlayer(pid, Coord{X: 3190 + pid%25, Z: 3200 + pid/25}, testAppearance(uint64(pid)))
+		players[pid].Chat = &Chat{Message: "Hello world how are you doing today"}
+	}
+	players[1].Coord = Coord{X: 3202, Z: 3205}
+	info := &PlayerInfo{OriginX: 3152, OriginZ: 3152}
+	client := &testClient{t: t, originX: 3152, originZ: 3152, self: players[1].Coord, coords: map[int]Coord{}, appearances: map[int][]byte{}}
+
+	for range 10 {
+		msg := info.Build(players[1], players)
+		if len(msg.Data) > MaxBodySize {
+			t.Fatalf("Build() = %d bytes, more than %d", len(msg.Data), MaxBodySize)
+		}
+		client.read(msg.Data)
+		for _, pl := range players[1:] {
+			pl.Reset()
+		}
+	}
+	if n := len(info.Local()); n != MaxLocalPlayers {
+		t.Errorf("local players = %d, want %d", n, MaxLocalPlayers)
+	}
+}
+
+func TestDirectionOf(t *testing.T) {
+	for d := NorthWest; d <= SouthEast; d++ {
+		dx, dz := d.Delta()
+		if got, ok := DirectionOf(dx, dz); !ok || got != d {
+			t.Errorf("DirectionOf(%d, %d) = %v, %v, want %v", dx, dz, got, ok, d)
+		}
+	}
+	if _, ok := DirectionOf(0, 0); ok {
+		t.Error("DirectionOf(0, 0) = ok")
+	}
+}
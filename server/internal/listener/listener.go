@@ -0,0 +1,106 @@
+// Package listener runs the accept loops shared by the login, JAGGRAB
+// and on-demand servers.
+package listener
+
+import (
+	"log"
+	"net"
+	"sync"
+)
+
+// A Server accepts connections on any number of listeners until it is
+// closed. It is embedded by the servers of each service, which supply
+// the handler for their connections. The zero value is ready to use.
+type Server struct {
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	closed    bool
+}
+
+// ListenAndServe listens on the TCP address addr and calls
+// [Server.Serve].
+func (s *Server) ListenAndServe(addr string, errClosed error, handle func(net.Conn)) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l, errClosed, handle)
+}
+
+// Serve accepts connections on l and calls handle with each on its own
+// goroutine. It always returns a non-nil error, errClosed once
+// [Server.Close] has been called, and closes l.
+func (s *Server) Serve(l net.Listener, errClosed error, handle func(net.Conn)) error {
+	if !s.track(l) {
+		l.Close()
+		return errClosed
+	}
+	defer s.untrack(l)
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.Closed() {
+				return errClosed
+			}
+			return err
+		}
+		go handle(conn)
+	}
+}
+
+// Close stops every listener passed to [Server.Serve]. Connections
+// already accepted are left to their handlers.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	clear(s.listeners)
+	return err
+}
+
+// Closed reports whether [Server.Close] has been called.
+func (s *Server) Closed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+func (s *Server) track(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if s.listeners == nil {
+		s.listeners = make(map[net.Listener]struct{})
+	}
+	s.listeners[l] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, l)
+}
+
+// Logf logs to l, or to the log package's standard logger if l is nil.
+func Logf(l *log.Logger, format string, args ...any) {
+	if l != nil {
+		l.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
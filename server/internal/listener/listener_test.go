@@ -0,0 +1,64 @@
+package listener
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+var errTestClosed = errors.New("test: server closed")
+
+func TestServer_Serve(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{}
+	accepted := make(chan net.Conn)
+	served := make(chan error)
+	go func() { served <- s.Serve(l, errTestClosed, func(conn net.Conn) { accepted <- conn }) }()
+
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	conn := <-accepted
+	defer conn.Close()
+
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if err := <-served; err != errTestClosed {
+		t.Errorf("Serve() = %v, want %v", err, errTestClosed)
+	}
+	if !s.Closed() {
+		t.Error("Closed() = false after Close()")
+	}
+
+	// the accepted connection is left open
+	if _, err := client.Write([]byte{1}); err != nil {
+		t.Fatal(err)
+	}
+	var b [1]byte
+	if _, err := conn.Read(b[:]); err != nil {
+		t.Errorf("Read() from accepted connection error = %v", err)
+	}
+}
+
+func TestServer_Serve_afterClose(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{}
+	s.Close()
+	if err := s.Serve(l, errTestClosed, func(net.Conn) {}); err != errTestClosed {
+		t.Errorf("Serve() = %v, want %v", err, errTestClosed)
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept() error = %v, want the listener closed", err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package jaggrab serves the client cache archives to the 225 client.
+// Map files are sent over the game connection instead; see package maps.
+//
+// The client requests each archive by name with its crc appended, for
+// example "/title1234567", and the crc table as "crc" followed by a
+// random number to defeat caches. Requests arrive either as HTTP GETs
+// or over the JAGGRAB line protocol:
+//
+//	JAGGRAB /title1234567\n\n
+//
+// to which the server replies with the file and closes the connection.
+package jaggrab
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// CrcName is the name the crc table is served under.
+const CrcName = "crc"
+
+// A File is a file served to the client.
+type File struct {
+	Name    string
+	Data    []byte
+	CRC     uint32
+	ModTime time.Time
+}
+
+// A FileSet holds the files served to the client. The crc table is kept
+// up to date as archives are added. It is safe for concurrent use.
+type FileSet struct {
+	mu    sync.RWMutex
+	files map[string]*File
+	crcs  cache.CrcTable
+}
+
+// NewFileSet returns an empty file set. It serves an all-zero crc table
+// until archives are added.
+func NewFileSet() *FileSet {
+	fs := &FileSet{files: make(map[string]*File)}
+	fs.updateCrcTable(time.Now())
+	return fs
+}
+
+// LoadFileSet returns a file set holding every archive in dir/client.
+func LoadFileSet(dir string) (*FileSet, error) {
+	fs := NewFileSet()
+
+	for _, name := range cache.Archives {
+		p, err := packet.Load(filepath.Join(dir, "client", name), false)
+		if err != nil {
+			return nil, err
+		}
+		fs.Add(name, p.Buf)
+	}
+
+	return fs, nil
+}
+
+// Add adds or replaces the named file. If name is one of
+// [cache.Archives] the crc table is updated to match.
+func (fs *FileSet) Add(name string, data []byte) {
+	now := time.Now()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.files[name] = &File{
+		Name:    name,
+		Data:    data,
+		CRC:     packet.GetCRC(data, 0, len(data)),
+		ModTime: now,
+	}
+
+	if i := slices.Index(cache.Archives, name); i != -1 {
+		fs.crcs.Crcs[i+1] = fs.files[name].CRC
+		fs.updateCrcTable(now)
+	}
+}
+
+// AddJagfile encodes jf and adds it under name. As with [io.Jagfile.Save],
+// an archive holding a single file is compressed whole.
+func (fs *FileSet) AddJagfile(name string, jf *io.Jagfile) error {
+	if err := jf.ApplyQueue(); err != nil {
+		return err
+	}
+	data, err := jf.Encode(jf.FileCount == 1)
+	if err != nil {
+		return err
+	}
+	fs.Add(name, data)
+	return nil
+}
+
+// updateCrcTable regenerates the crc table file. fs.mu must be held.
+func (fs *FileSet) updateCrcTable(now time.Time) {
+	data := fs.crcs.Encode()
+	fs.files[CrcName] = &File{
+		Name:    CrcName,
+		Data:    data,
+		CRC:     fs.crcs.Checksum(),
+		ModTime: now,
+	}
+}
+
+// CrcTable returns the checksums of the archives in the set.
+func (fs *FileSet) CrcTable() cache.CrcTable {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	return fs.crcs
+}
+
+// Get returns the named file.
+func (fs *FileSet) Get(name string) (*File, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	f, ok := fs.files[name]
+	return f, ok
+}
+
+var errMalformedPath = errors.New("jaggrab: malformed path")
+
+// Resolve returns the file requested by path, a file name followed by
+// an optional number such as "/title1234567". The number is normally
+// the file's crc as a signed Java int. exact reports whether it matched
+// the crc of the file returned. The name must match a file exactly, so
+// names ending in a digit cannot be resolved.
+func (fs *FileSet) Resolve(path string) (f *File, exact bool, ok bool) {
+	name, num := splitPath(strings.TrimPrefix(path, "/"))
+	crc, err := parseCRC(num)
+	if err != nil {
+		return nil, false, false
+	}
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	f, ok = fs.files[name]
+	if !ok {
+		return nil, false, false
+	}
+	return f, num != "" && crc == f.CRC, true
+}
+
+// splitPath splits path into the file name and the number following it.
+func splitPath(path string) (name string, num string) {
+	i := len(path)
+	for i > 0 && path[i-1] >= '0' && path[i-1] <= '9' {
+		i--
+	}
+	if i > 0 && i < len(path) && path[i-1] == '-' {
+		i--
+	}
+	return path[:i], path[i:]
+}
+
+// parseCRC parses the number following a file name. Java prints crcs as
+// signed ints, but unsigned values are accepted too.
+func parseCRC(s string) (uint32, error) {
+	if s == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || v < -1<<31 || v > 1<<32-1 {
+		return 0, errMalformedPath
+	}
+	return uint32(v), nil
+}
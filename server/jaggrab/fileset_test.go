@@ -0,0 +1,128 @@
+package jaggrab
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestFileSet_Resolve(t *testing.T) {
+	fs := NewFileSet()
+	fs.Add("title", []byte("title data"))
+	fs.Add("config", []byte("config data"))
+
+	title, _ := fs.Get("title")
+
+	tests := []struct {
+		name      string
+		path      string
+		want      string
+		wantExact bool
+		wantOk    bool
+	}{
+		{name: "signed crc", path: "/title" + javaInt(title.CRC), want: "title", wantExact: true, wantOk: true},
+		{name: "unsigned crc", path: "/title" + formatUint(title.CRC), want: "title", wantExact: true, wantOk: true},
+		{name: "stale crc", path: "/title1", want: "title", wantOk: true},
+		{name: "no crc", path: "/config", want: "config", wantOk: true},
+		{name: "crc table", path: "/crc123456", want: CrcName, wantOk: true},
+		{name: "negative stale crc", path: "/title-1", want: "title", wantOk: true},
+		{name: "crc out of range", path: "/title99999999999", wantOk: false},
+		{name: "name prefix", path: "/titl" + javaInt(title.CRC), wantOk: false},
+		{name: "name and crc run together", path: "/titlex" + javaInt(title.CRC), wantOk: false},
+		{name: "unknown", path: "/sounds123", wantOk: false},
+		{name: "junk suffix", path: "/title.jar", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, exact, ok := fs.Resolve(tt.path)
+			if ok != tt.wantOk {
+				t.Fatalf("Resolve() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if f.Name != tt.want || exact != tt.wantExact {
+				t.Errorf("Resolve() = %q, %v, want %q, %v", f.Name, exact, tt.want, tt.wantExact)
+			}
+		})
+	}
+}
+
+func TestFileSet_CrcTable(t *testing.T) {
+	fs := NewFileSet()
+	fs.Add("title", []byte("title data"))
+	fs.Add("config", []byte("config data"))
+
+	table := fs.CrcTable()
+	if got, want := table.Crcs[1], packet.GetCRC([]byte("title data"), 0, 10); got != want {
+		t.Errorf("title crc = %d, want %d", got, want)
+	}
+	if table.Crcs[0] != 0 || table.Crcs[3] != 0 {
+		t.Errorf("CrcTable() = %v, want unused entries 0", table.Crcs)
+	}
+
+	crc, ok := fs.Get(CrcName)
+	if !ok {
+		t.Fatal("crc table not in file set")
+	}
+	if !bytes.Equal(crc.Data, table.Encode()) {
+		t.Errorf("crc file = %v, want %v", crc.Data, table.Encode())
+	}
+
+	fs.Add("config", []byte("new config"))
+	if got := fs.CrcTable(); got.Crcs[2] != packet.GetCRC([]byte("new config"), 0, 10) {
+		t.Errorf("config crc not updated: %v", got.Crcs)
+	}
+}
+
+func TestLoadFileSet(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "client"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range cache.Archives {
+		if err := os.WriteFile(filepath.Join(dir, "client", name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fs, err := LoadFileSet(dir)
+	if err != nil {
+		t.Fatalf("LoadFileSet() error = %v", err)
+	}
+	want, err := cache.NewCrcTable(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.CrcTable(); got != *want {
+		t.Errorf("CrcTable() = %v, want %v", got, want)
+	}
+}
+
+func TestFileSet_AddJagfile(t *testing.T) {
+	jf := &io.Jagfile{}
+	jf.Write("data", packet.NewPacket([]byte{1, 2, 3}))
+
+	fs := NewFileSet()
+	if err := fs.AddJagfile("media", jf); err != nil {
+		t.Fatalf("AddJagfile() error = %v", err)
+	}
+
+	f, ok := fs.Get("media")
+	if !ok {
+		t.Fatal("media not added")
+	}
+	decoded, err := io.NewJagfile(packet.NewPacket(f.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := decoded.Read("data")
+	if err != nil || !bytes.Equal(p.Buf, []byte{1, 2, 3}) {
+		t.Errorf("Read() = %v, %v", p, err)
+	}
+}
@@ -0,0 +1,157 @@
+package jaggrab
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	stdio "io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zsrv/rs-server-225/server/internal/listener"
+)
+
+// DefaultTimeout is how long a JAGGRAB connection may take to send its
+// request and receive the file when Timeout is not set.
+const DefaultTimeout = 30 * time.Second
+
+// maxRequestLength limits the JAGGRAB request line.
+const maxRequestLength = 256
+
+// lingerTimeout is how long a connection is drained after the file has
+// been written, see closeWriteAndWait.
+const lingerTimeout = 500 * time.Millisecond
+
+// ErrServerClosed is returned by [Server.Serve] after [Server.Close].
+var ErrServerClosed = errors.New("jaggrab: server closed")
+
+// A Server serves a [FileSet] over JAGGRAB and, as an [http.Handler],
+// over HTTP.
+type Server struct {
+	Files *FileSet
+	// Timeout bounds each JAGGRAB connection.
+	Timeout time.Duration
+	// ErrorLog logs failed JAGGRAB requests. If nil, the log package's
+	// standard logger is used.
+	ErrorLog *log.Logger
+
+	listener.Server
+}
+
+// NewServer returns a server for files.
+func NewServer(files *FileSet) *Server {
+	return &Server{Files: files}
+}
+
+// ServeHTTP serves the file named by the request path. Files requested
+// with a matching crc never change and may be cached indefinitely; any
+// other request must be revalidated. Conditional requests are answered
+// using the file's crc as its ETag.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	f, exact, ok := s.Files.Resolve(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/octet-stream")
+	h.Set("ETag", etag(f))
+	if exact {
+		h.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		h.Set("Cache-Control", "no-cache")
+	}
+
+	http.ServeContent(w, r, "", f.ModTime, bytes.NewReader(f.Data))
+}
+
+func etag(f *File) string {
+	return fmt.Sprintf(`"%08x"`, f.CRC)
+}
+
+// ListenAndServe listens on the TCP address addr and calls [Server.Serve].
+func (s *Server) ListenAndServe(addr string) error {
+	return s.Server.ListenAndServe(addr, ErrServerClosed, s.handle)
+}
+
+// Serve accepts JAGGRAB connections on l and serves each on its own
+// goroutine. It always returns a non-nil error and closes l.
+// [Server.Close] stops every listener passed to Serve.
+func (s *Server) Serve(l net.Listener) error {
+	return s.Server.Serve(l, ErrServerClosed, s.handle)
+}
+
+func (s *Server) handle(conn net.Conn) {
+	if err := s.ServeConn(conn); err != nil {
+		listener.Logf(s.ErrorLog, "jaggrab: %s: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// ServeConn reads one JAGGRAB request from conn, writes the file and
+// closes conn. Requests for unknown files are answered by closing conn.
+func (s *Server) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+
+	path, err := readRequest(bufio.NewReaderSize(conn, maxRequestLength))
+	if err != nil {
+		return err
+	}
+
+	f, _, ok := s.Files.Resolve(path)
+	if !ok {
+		return fmt.Errorf("%q not found", path)
+	}
+
+	if _, err := conn.Write(f.Data); err != nil {
+		return err
+	}
+	closeWriteAndWait(conn)
+	return nil
+}
+
+// closeWriteAndWait flushes the file to the client before conn is closed.
+// Closing a connection with unread input, such as the blank line after
+// the request, can reset it and discard data the client has not read.
+func closeWriteAndWait(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+	conn.SetReadDeadline(time.Now().Add(lingerTimeout))
+	stdio.Copy(stdio.Discard, conn)
+}
+
+// readRequest reads a "JAGGRAB /path" request line and returns the path.
+// The blank line the client sends after the request is not waited for.
+func readRequest(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return "", errors.New("request line too long")
+	} else if err != nil {
+		return "", err
+	}
+
+	path, ok := strings.CutPrefix(strings.TrimRight(string(line), "\r\n"), "JAGGRAB ")
+	if !ok {
+		return "", fmt.Errorf("malformed request %q", line)
+	}
+	return path, nil
+}
@@ -0,0 +1,217 @@
+package jaggrab
+
+import (
+	"bufio"
+	"bytes"
+	stdio "io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func javaInt(crc uint32) string {
+	return strconv.Itoa(int(int32(crc)))
+}
+
+func formatUint(crc uint32) string {
+	return strconv.FormatUint(uint64(crc), 10)
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	fs := NewFileSet()
+	fs.Add("title", []byte("title data"))
+	fs.Add("config", []byte("config data"))
+	title, _ := fs.Get("title")
+
+	ts := httptest.NewServer(NewServer(fs))
+	defer ts.Close()
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		header           map[string]string
+		wantStatus       int
+		wantBody         []byte
+		wantCacheControl string
+	}{
+		{
+			name:             "archive",
+			path:             "/title" + javaInt(title.CRC),
+			wantStatus:       http.StatusOK,
+			wantBody:         title.Data,
+			wantCacheControl: "public, max-age=31536000, immutable",
+		},
+		{
+			name:             "stale crc",
+			path:             "/title0",
+			wantStatus:       http.StatusOK,
+			wantBody:         title.Data,
+			wantCacheControl: "no-cache",
+		},
+		{
+			name:       "if-none-match",
+			path:       "/title" + javaInt(title.CRC),
+			header:     map[string]string{"If-None-Match": etag(title)},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "if-none-match stale etag",
+			path:       "/title" + javaInt(title.CRC),
+			header:     map[string]string{"If-None-Match": `"00000000"`},
+			wantStatus: http.StatusOK,
+			wantBody:   title.Data,
+		},
+		{
+			name:       "if-modified-since",
+			path:       "/title",
+			header:     map[string]string{"If-Modified-Since": title.ModTime.UTC().Add(1e9).Format(http.TimeFormat)},
+			wantStatus: http.StatusNotModified,
+		},
+		{
+			name:       "head",
+			method:     http.MethodHead,
+			path:       "/title",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "not found",
+			path:       "/sounds0",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "post",
+			method:     http.MethodPost,
+			path:       "/title",
+			wantStatus: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+
+			resp, err := ts.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := stdio.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != nil && !bytes.Equal(body, tt.wantBody) {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if tt.wantCacheControl != "" {
+				if got := resp.Header.Get("Cache-Control"); got != tt.wantCacheControl {
+					t.Errorf("Cache-Control = %q, want %q", got, tt.wantCacheControl)
+				}
+			}
+		})
+	}
+}
+
+// jaggrab fetches path as the client does over JAGGRAB.
+func jaggrab(addr string, path string) ([]byte, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("JAGGRAB " + path + "\n\n")); err != nil {
+		return nil, err
+	}
+	return stdio.ReadAll(conn)
+}
+
+func TestServer_Serve(t *testing.T) {
+	fs := NewFileSet()
+	fs.Add("title", []byte("title data"))
+	fs.Add("config", []byte("config data"))
+	title, _ := fs.Get("title")
+	crc, _ := fs.Get(CrcName)
+
+	s := NewServer(fs)
+	s.ErrorLog = log.New(stdio.Discard, "", 0)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+
+	tests := []struct {
+		name string
+		path string
+		want []byte
+	}{
+		{name: "archive", path: "/title" + javaInt(title.CRC), want: title.Data},
+		{name: "crc table", path: "/crc987654", want: crc.Data},
+		{name: "not found", path: "/sounds0", want: []byte{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := jaggrab(l.Addr().String(), tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("jaggrab() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; err != ErrServerClosed {
+		t.Errorf("Serve() = %v, want %v", err, ErrServerClosed)
+	}
+}
+
+func Test_readRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "request", input: "JAGGRAB /title123\n\n", want: "/title123"},
+		{name: "crlf", input: "JAGGRAB /crc1\r\n\r\n", want: "/crc1"},
+		{name: "http", input: "GET /title HTTP/1.1\r\n", wantErr: true},
+		{name: "unterminated", input: "JAGGRAB /title", wantErr: true},
+		{name: "too long", input: "JAGGRAB /" + string(bytes.Repeat([]byte("a"), maxRequestLength)) + "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := bufio.NewReaderSize(strings.NewReader(tt.input), maxRequestLength)
+			got, err := readRequest(r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readRequest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readRequest() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
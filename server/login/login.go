@@ -0,0 +1,183 @@
+// Package login implements the 225 login handshake.
+//
+// On connect the server sends an 8 byte seed. The client replies with a
+// login opcode, a length byte and a login block:
+//
+//	G1   revision (225)
+//	G1   low memory (0 or 1)
+//	G4*9 crc table
+//	RSA  G1 magic (10), G4*4 isaac seed, G4 uid, username, password
+//
+// The last two ints of the isaac seed are the server seed. The server
+// replies with a single [Response] byte.
+package login
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Revision is the client revision accepted by the server.
+const Revision = 225
+
+// Login opcodes sent by the client after reading the server seed.
+const (
+	OpNewLogin  = 16
+	OpReconnect = 18
+)
+
+// rsaMagic is the first byte of a decrypted login block.
+const rsaMagic = 10
+
+// MaxUsernameLength is the longest username the client can send.
+const MaxUsernameLength = 12
+
+// A Response is the single byte the server replies to a login with.
+type Response uint8
+
+const (
+	ResponseRetry              Response = 1  // try again in 2 seconds
+	ResponseOK                 Response = 2  // login accepted
+	ResponseInvalidCredentials Response = 3  // invalid username or password
+	ResponseBanned             Response = 4  // account disabled
+	ResponseAlreadyLoggedIn    Response = 5  // account already logged in
+	ResponseUpdated            Response = 6  // client out of date
+	ResponseWorldFull          Response = 7  // world is full
+	ResponseServerOffline      Response = 8  // login server offline
+	ResponseLoginLimit         Response = 9  // too many connections from the address
+	ResponseBadSession         Response = 10 // bad session id
+	ResponseRejected           Response = 11 // login server rejected session
+	ResponseMembersWorld       Response = 12 // members account needed
+	ResponseCouldNotComplete   Response = 13 // could not complete login
+	ResponseServerUpdating     Response = 14 // server being updated
+	ResponseReconnectOK        Response = 15 // reconnect accepted
+	ResponseTooManyAttempts    Response = 16 // login attempts exceeded
+	ResponseMembersArea        Response = 17 // standing in a members area
+)
+
+var (
+	ErrUnknownOpcode = errors.New("login: unknown opcode")
+	ErrBadMagic      = errors.New("login: bad rsa block magic")
+)
+
+// A Request is a decoded login block.
+type Request struct {
+	Reconnect bool
+	Revision  uint8
+	LowMemory bool
+	Crcs      [cache.CrcTableSize]uint32
+	Seed      [4]uint32
+	UID       uint32
+	Username  string
+	Password  string
+}
+
+// ServerSeed returns the server seed the client echoed in the isaac seed.
+func (r *Request) ServerSeed() uint64 {
+	return uint64(r.Seed[2])<<32 | uint64(r.Seed[3])
+}
+
+// DecodeRequest decodes the login block sent with opcode. The RSA part
+// of the block is decrypted with key.
+func DecodeRequest(opcode uint8, src *packet.Packet, key *packet.RSAKey) (*Request, error) {
+	r := &Request{}
+
+	switch opcode {
+	case OpNewLogin:
+	case OpReconnect:
+		r.Reconnect = true
+	default:
+		return nil, ErrUnknownOpcode
+	}
+
+	var err error
+	if r.Revision, err = src.TryG1(); err != nil {
+		return nil, err
+	}
+	if r.LowMemory, err = src.TryGBool(); err != nil {
+		return nil, err
+	}
+	for i := range r.Crcs {
+		if r.Crcs[i], err = src.TryG4(); err != nil {
+			return nil, err
+		}
+	}
+
+	rsa, err := src.RSADecKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	magic, err := rsa.TryG1()
+	if err != nil {
+		return nil, err
+	}
+	if magic != rsaMagic {
+		return nil, ErrBadMagic
+	}
+	for i := range r.Seed {
+		if r.Seed[i], err = rsa.TryG4(); err != nil {
+			return nil, err
+		}
+	}
+	if r.UID, err = rsa.TryG4(); err != nil {
+		return nil, err
+	}
+	if r.Username, err = rsa.TryGJStrLF(); err != nil {
+		return nil, err
+	}
+	if r.Password, err = rsa.TryGJStrLF(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Encode returns the login opcode and block for r, as the client sends
+// them. The RSA part is encrypted with the public half of key.
+func (r *Request) Encode(key *packet.RSAKey) (uint8, []byte, error) {
+	rsa := packet.NewPacket(nil)
+	rsa.P1(rsaMagic)
+	for _, seed := range r.Seed {
+		rsa.P4(seed)
+	}
+	rsa.P4(r.UID)
+	rsa.PJStrLF(r.Username)
+	rsa.PJStrLF(r.Password)
+	if err := rsa.RSAEncKey(key); err != nil {
+		return 0, nil, err
+	}
+
+	p := packet.NewPacket(nil)
+	p.P1(r.Revision)
+	p.PBool(r.LowMemory)
+	for _, crc := range r.Crcs {
+		p.P4(crc)
+	}
+	p.PData(rsa.Buf, len(rsa.Buf))
+
+	opcode := uint8(OpNewLogin)
+	if r.Reconnect {
+		opcode = OpReconnect
+	}
+	return opcode, p.Buf, nil
+}
+
+// NormalizeUsername returns username in the form used to identify a
+// player: lowercase, with underscores as spaces and surrounding spaces
+// removed. It returns false if the name cannot be a valid username.
+func NormalizeUsername(username string) (string, bool) {
+	name := strings.TrimSpace(strings.ReplaceAll(strings.ToLower(username), "_", " "))
+	if name == "" || len(name) > MaxUsernameLength {
+		return "", false
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != ' ' {
+			return "", false
+		}
+	}
+	return name, true
+}
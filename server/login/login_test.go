@@ -0,0 +1,131 @@
+package login
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+var testKey = mustGenerateKey()
+
+func mustGenerateKey() *packet.RSAKey {
+	key, err := packet.GenerateRSAKey(512)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+func TestRequest_EncodeDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *Request
+	}{
+		{
+			name: "new login",
+			req: &Request{
+				Revision: Revision,
+				Crcs:     [9]uint32{0, 1, 2, 3, 4, 5, 6, 7, 8},
+				Seed:     [4]uint32{0x11111111, 0x22222222, 0xdeadbeef, 0xcafebabe},
+				UID:      1234,
+				Username: "Bob",
+				Password: "hunter2",
+			},
+		},
+		{
+			name: "reconnect low memory",
+			req: &Request{
+				Reconnect: true,
+				Revision:  Revision,
+				LowMemory: true,
+				Username:  "alice",
+				Password:  "pass",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opcode, body, err := tt.req.Encode(testKey)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			got, err := DecodeRequest(opcode, packet.NewPacket(body), testKey)
+			if err != nil {
+				t.Fatalf("DecodeRequest() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.req) {
+				t.Errorf("DecodeRequest() = %+v, want %+v", got, tt.req)
+			}
+		})
+	}
+}
+
+func TestDecodeRequest_Errors(t *testing.T) {
+	req := &Request{Revision: Revision, Username: "bob", Password: "pass"}
+	opcode, body, err := req.Encode(testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	badMagic := packet.NewPacket(nil)
+	badMagic.P1(9)
+	badMagic.PJStrLF("bob")
+	if err := badMagic.RSAEncKey(testKey); err != nil {
+		t.Fatal(err)
+	}
+	badMagicBody := append(append([]byte{}, body[:38]...), badMagic.Buf...)
+
+	tests := []struct {
+		name   string
+		opcode uint8
+		body   []byte
+		want   error
+	}{
+		{name: "unknown opcode", opcode: 14, body: body, want: ErrUnknownOpcode},
+		{name: "truncated header", opcode: opcode, body: body[:10], want: io.ErrUnexpectedEOF},
+		{name: "truncated rsa block", opcode: opcode, body: body[:len(body)-1], want: io.ErrUnexpectedEOF},
+		{name: "bad magic", opcode: opcode, body: badMagicBody, want: ErrBadMagic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeRequest(tt.opcode, packet.NewPacket(tt.body), testKey)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("DecodeRequest() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequest_ServerSeed(t *testing.T) {
+	r := &Request{Seed: [4]uint32{1, 2, 0x01234567, 0x89abcdef}}
+	if got, want := r.ServerSeed(), uint64(0x0123456789abcdef); got != want {
+		t.Errorf("ServerSeed() = %#x, want %#x", got, want)
+	}
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     string
+		wantOk   bool
+	}{
+		{username: "Bob", want: "bob", wantOk: true},
+		{username: "Zezima_99", want: "zezima 99", wantOk: true},
+		{username: "  padded ", want: "padded", wantOk: true},
+		{username: "", wantOk: false},
+		{username: "___", wantOk: false},
+		{username: "thirteenchars", wantOk: false},
+		{username: "bad-name", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.username, func(t *testing.T) {
+			got, ok := NormalizeUsername(tt.username)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("NormalizeUsername() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
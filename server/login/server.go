@@ -0,0 +1,283 @@
+package login
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	stdio "io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/internal/listener"
+)
+
+// DefaultMaxPlayers is the number of players a [Server] allows online
+// when MaxPlayers is not set.
+const DefaultMaxPlayers = 2000
+
+// DefaultTimeout is how long a [Server] waits for a client to finish
+// the handshake when Timeout is not set.
+const DefaultTimeout = 10 * time.Second
+
+// ErrServerClosed is returned by [Server.Serve] after [Server.Close].
+var ErrServerClosed = errors.New("login: server closed")
+
+// An Authenticator checks the credentials of a login request. It returns
+// [ResponseOK] to accept the login, or the response to refuse it with,
+// such as [ResponseInvalidCredentials] or [ResponseBanned].
+type Authenticator interface {
+	Authenticate(req *Request) Response
+}
+
+// AuthenticatorFunc adapts a function to an [Authenticator].
+type AuthenticatorFunc func(req *Request) Response
+
+func (f AuthenticatorFunc) Authenticate(req *Request) Response {
+	return f(req)
+}
+
+// A RejectError is returned by [Server.Handshake] when a login is
+// refused. The response has already been sent to the client.
+type RejectError struct {
+	Username string
+	Response Response
+}
+
+func (e *RejectError) Error() string {
+	return fmt.Sprintf("login: %q refused with response %d", e.Username, e.Response)
+}
+
+// A Session is an accepted login. The handler owns the connection and
+// must call Close when the player leaves.
+type Session struct {
+	Conn     net.Conn
+	Request  *Request
+	Username string        // normalized, see NormalizeUsername
+	Isaac    *io.IsaacPair // opcode ciphers for the game connection
+
+	server    *Server
+	closeOnce sync.Once
+}
+
+// Close closes the connection and frees the username for another login.
+func (s *Session) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.server.release(s)
+		err = s.Conn.Close()
+	})
+	return err
+}
+
+// A Server accepts game connections and performs the login handshake.
+type Server struct {
+	// Key decrypts the RSA part of the login block.
+	// If nil, packet.DefaultRSAKey is used.
+	Key *packet.RSAKey
+	// Crcs are the checksums of the cache archives. Clients whose crc
+	// table differs are told the game has been updated. If nil, the
+	// table is not checked.
+	Crcs *cache.CrcTable
+	// Auth checks credentials. If nil, every login is accepted.
+	Auth Authenticator
+	// Handler is called with each accepted session, on the goroutine
+	// serving its connection. If nil, sessions are closed immediately.
+	Handler func(s *Session)
+	// MaxPlayers is the number of players allowed online at once.
+	MaxPlayers int
+	// Timeout is how long a client has to complete the handshake.
+	Timeout time.Duration
+	// ErrorLog logs failed handshakes. If nil, the log package's
+	// standard logger is used.
+	ErrorLog *log.Logger
+
+	listener.Server
+
+	mu     sync.Mutex
+	online map[string]*Session
+}
+
+// ListenAndServe listens on the TCP address addr and calls [Server.Serve].
+func (s *Server) ListenAndServe(addr string) error {
+	return s.Server.ListenAndServe(addr, ErrServerClosed, s.handle)
+}
+
+// Serve accepts connections on l and serves each on its own goroutine.
+// It always returns a non-nil error and closes l.
+func (s *Server) Serve(l net.Listener) error {
+	return s.Server.Serve(l, ErrServerClosed, s.handle)
+}
+
+// Close stops every listener passed to [Server.Serve] and refuses
+// further logins. Sessions already handed to the Handler are left open.
+func (s *Server) Close() error {
+	return s.Server.Close()
+}
+
+// Online returns the number of players logged in.
+func (s *Server) Online() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.online)
+}
+
+func (s *Server) handle(conn net.Conn) {
+	session, err := s.Handshake(conn)
+	if err != nil {
+		listener.Logf(s.ErrorLog, "login: %s: %v", conn.RemoteAddr(), err)
+		conn.Close()
+		return
+	}
+
+	if s.Handler == nil {
+		session.Close()
+		return
+	}
+	s.Handler(session)
+}
+
+// Handshake performs the login handshake on conn. On success the
+// response has been sent and the returned session owns conn. On
+// failure the caller must close conn; a [*RejectError] means the
+// client was sent the refusal.
+func (s *Server) Handshake(conn net.Conn) (*Session, error) {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, err
+	}
+
+	var seed [8]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(seed[:]); err != nil {
+		return nil, err
+	}
+
+	var header [2]byte
+	if _, err := stdio.ReadFull(conn, header[:]); err != nil {
+		return nil, err
+	}
+	body := make([]byte, header[1])
+	if _, err := stdio.ReadFull(conn, body); err != nil {
+		return nil, err
+	}
+
+	key := s.Key
+	if key == nil {
+		key = packet.DefaultRSAKey
+	}
+	req, err := DecodeRequest(header[0], packet.NewPacket(body), key)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &Session{
+		Conn:    conn,
+		Request: req,
+		Isaac:   io.NewServerIsaacPair(req.Seed),
+		server:  s,
+	}
+
+	response := s.check(session, binary.BigEndian.Uint64(seed[:]))
+	if _, err := conn.Write([]byte{uint8(response)}); err != nil {
+		if response == ResponseOK || response == ResponseReconnectOK {
+			session.Close()
+		}
+		return nil, err
+	}
+	if response != ResponseOK && response != ResponseReconnectOK {
+		return nil, &RejectError{Username: req.Username, Response: response}
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		session.Close()
+		return nil, err
+	}
+	return session, nil
+}
+
+// check decides the response to session's login. If the login is
+// accepted the session is registered as online.
+func (s *Server) check(session *Session, serverSeed uint64) Response {
+	req := session.Request
+
+	if req.Revision != Revision {
+		return ResponseUpdated
+	}
+	if s.Crcs != nil && req.Crcs != s.Crcs.Crcs {
+		return ResponseUpdated
+	}
+	if req.ServerSeed() != serverSeed {
+		return ResponseBadSession
+	}
+
+	username, ok := NormalizeUsername(req.Username)
+	if !ok {
+		return ResponseInvalidCredentials
+	}
+	session.Username = username
+
+	if s.Auth != nil {
+		if response := s.Auth.Authenticate(req); response != ResponseOK {
+			return response
+		}
+	}
+
+	return s.register(session)
+}
+
+// register marks session as online. A reconnect takes over the
+// session already online under the same name.
+func (s *Server) register(session *Session) Response {
+	if s.Closed() {
+		return ResponseServerUpdating
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.online == nil {
+		s.online = make(map[string]*Session)
+	}
+
+	maxPlayers := s.MaxPlayers
+	if maxPlayers == 0 {
+		maxPlayers = DefaultMaxPlayers
+	}
+
+	if old, ok := s.online[session.Username]; ok {
+		if !session.Request.Reconnect {
+			return ResponseAlreadyLoggedIn
+		}
+		// the old connection is dead as far as the client is concerned
+		old.Conn.Close()
+		s.online[session.Username] = session
+		return ResponseReconnectOK
+	}
+
+	if len(s.online) >= maxPlayers {
+		return ResponseWorldFull
+	}
+	s.online[session.Username] = session
+	return ResponseOK
+}
+
+func (s *Server) release(session *Session) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.online[session.Username] == session {
+		delete(s.online, session.Username)
+	}
+}
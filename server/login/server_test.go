@@ -0,0 +1,197 @@
+package login
+
+import (
+	"encoding/binary"
+	"errors"
+	stdio "io"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+)
+
+var testCrcs = &cache.CrcTable{Crcs: [9]uint32{0, 10, 20, 30, 40, 50, 60, 70, 80}}
+
+// login performs the client end of the handshake on conn and returns
+// the response. The isaac seed is filled in from the server seed before
+// modify is called.
+func login(conn net.Conn, req Request, modify func(r *Request)) (Response, error) {
+	var seed [8]byte
+	if _, err := stdio.ReadFull(conn, seed[:]); err != nil {
+		return 0, err
+	}
+	serverSeed := binary.BigEndian.Uint64(seed[:])
+	req.Seed = [4]uint32{1, 2, uint32(serverSeed >> 32), uint32(serverSeed)}
+	if modify != nil {
+		modify(&req)
+	}
+
+	opcode, body, err := req.Encode(testKey)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := conn.Write(append([]byte{opcode, uint8(len(body))}, body...)); err != nil {
+		return 0, err
+	}
+
+	var response [1]byte
+	if _, err := stdio.ReadFull(conn, response[:]); err != nil {
+		return 0, err
+	}
+	return Response(response[0]), nil
+}
+
+func TestServer_Handshake(t *testing.T) {
+	valid := Request{Revision: Revision, Crcs: testCrcs.Crcs, Username: "bob", Password: "password"}
+
+	tests := []struct {
+		name   string
+		online []string
+		modify func(r *Request)
+		want   Response
+	}{
+		{name: "ok", want: ResponseOK},
+		{name: "invalid password", modify: func(r *Request) { r.Password = "wrong" }, want: ResponseInvalidCredentials},
+		{name: "invalid username", modify: func(r *Request) { r.Username = "b@b" }, want: ResponseInvalidCredentials},
+		{name: "banned", modify: func(r *Request) { r.Username = "banned" }, want: ResponseBanned},
+		{name: "old revision", modify: func(r *Request) { r.Revision = 224 }, want: ResponseUpdated},
+		{name: "crc mismatch", modify: func(r *Request) { r.Crcs[3]++ }, want: ResponseUpdated},
+		{name: "wrong server seed", modify: func(r *Request) { r.Seed[3]++ }, want: ResponseBadSession},
+		{name: "already logged in", online: []string{"Bob"}, want: ResponseAlreadyLoggedIn},
+		{name: "reconnect", online: []string{"bob"}, modify: func(r *Request) { r.Reconnect = true }, want: ResponseReconnectOK},
+		{name: "reconnect not online", modify: func(r *Request) { r.Reconnect = true }, want: ResponseOK},
+		{name: "world full", online: []string{"alice", "carol"}, want: ResponseWorldFull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{
+				Key:        testKey,
+				Crcs:       testCrcs,
+				MaxPlayers: 2,
+				ErrorLog:   log.New(stdio.Discard, "", 0),
+				Auth: AuthenticatorFunc(func(req *Request) Response {
+					switch {
+					case req.Username == "banned":
+						return ResponseBanned
+					case req.Password != "password":
+						return ResponseInvalidCredentials
+					}
+					return ResponseOK
+				}),
+			}
+
+			for _, name := range tt.online {
+				server, client := net.Pipe()
+				go func() {
+					r := valid
+					r.Username = name
+					login(client, r, nil)
+				}()
+				if _, err := s.Handshake(server); err != nil {
+					t.Fatalf("logging in %q: %v", name, err)
+				}
+			}
+
+			server, client := net.Pipe()
+			defer client.Close()
+
+			var response Response
+			var clientErr error
+			done := make(chan struct{})
+			go func() {
+				response, clientErr = login(client, valid, tt.modify)
+				close(done)
+			}()
+
+			session, err := s.Handshake(server)
+			<-done
+			if clientErr != nil {
+				t.Fatalf("client: %v", clientErr)
+			}
+			if response != tt.want {
+				t.Errorf("response = %d, want %d", response, tt.want)
+			}
+
+			if tt.want == ResponseOK || tt.want == ResponseReconnectOK {
+				if err != nil {
+					t.Fatalf("Handshake() error = %v", err)
+				}
+				if session.Username != "bob" || session.Isaac == nil {
+					t.Errorf("Handshake() session = %+v", session)
+				}
+				return
+			}
+
+			var reject *RejectError
+			if !errors.As(err, &reject) || reject.Response != tt.want {
+				t.Errorf("Handshake() error = %v, want RejectError %d", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Serve(t *testing.T) {
+	sessions := make(chan *Session, 1)
+	s := &Server{
+		Key:        testKey,
+		Crcs:       testCrcs,
+		MaxPlayers: 2,
+		ErrorLog:   log.New(stdio.Discard, "", 0),
+		Auth:       AuthenticatorFunc(func(*Request) Response { return ResponseOK }),
+		Handler:    func(session *Session) { sessions <- session },
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	req := Request{Revision: Revision, Crcs: testCrcs.Crcs, Username: "bob", Password: "password"}
+	if got, err := login(conn, req, nil); err != nil || got != ResponseOK {
+		t.Fatalf("login() = %d, %v, want %d", got, err, ResponseOK)
+	}
+
+	var session *Session
+	select {
+	case session = <-sessions:
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler not called")
+	}
+	if got := s.Online(); got != 1 {
+		t.Errorf("Online() = %d, want 1", got)
+	}
+
+	// the session's ciphers must pair with the client's
+	session.Isaac.WriteOpcode(session.Conn, 73)
+	var b [1]byte
+	if _, err := stdio.ReadFull(conn, b[:]); err != nil {
+		t.Fatal(err)
+	}
+	client := io.NewClientIsaacPair(session.Request.Seed)
+	if got := client.Decoder.DecryptOpcode(b[0]); got != 73 {
+		t.Errorf("decrypted opcode = %d, want 73", got)
+	}
+
+	session.Close()
+	if got := s.Online(); got != 0 {
+		t.Errorf("Online() after Close = %d, want 0", got)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve() = %v, want %v", err, ErrServerClosed)
+	}
+}
@@ -0,0 +1,226 @@
+// Package maps sends map square files to the 225 client over the game
+// connection.
+//
+// REBUILD_NORMAL tells the client the crcs of the map square files
+// around it. It requests those it does not have cached with
+// REBUILD_GETMAPS, and each is sent back in DATA_LAND or DATA_LOC
+// chunks followed by DATA_LAND_DONE or DATA_LOC_DONE. Files are sent as
+// the client unpacks them: bzip2 with the decompressed length in place
+// of the "BZh1" header.
+package maps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	stdio "io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/zsrv/rs-server-225/cache/mapsquare"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/protocol"
+	"github.com/zsrv/rs-server-225/server/protocol/clientprot"
+	"github.com/zsrv/rs-server-225/server/protocol/serverprot"
+)
+
+// bzip2BlockMagic follows the length prefix of a compressed map file.
+var bzip2BlockMagic = []byte("1AY&SY")
+
+// A File is a map file in the form sent to the client.
+type File struct {
+	Name string
+	Data []byte
+	CRC  uint32
+}
+
+// A Store holds the map files sent to the client. It is safe for
+// concurrent use.
+type Store struct {
+	mu    sync.RWMutex
+	files map[string]*File
+}
+
+// NewStore returns an empty store.
+func NewStore() *Store {
+	return &Store{files: make(map[string]*File)}
+}
+
+// LoadStore returns a store holding every map file in dir, as written
+// by the packer to client/maps. A missing dir gives an empty store.
+// Files that are not map files are skipped.
+func LoadStore(dir string) (*Store, error) {
+	s := NewStore()
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !isMapName(e.Name()) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.Add(e.Name(), data); err != nil {
+			return nil, fmt.Errorf("maps: %s: %w", e.Name(), err)
+		}
+	}
+	return s, nil
+}
+
+// Add adds or replaces a map file named as by [mapsquare.LandName] or
+// [mapsquare.LocsName]. data may be compressed as the client expects,
+// gzipped, or not compressed at all; it is sent in the client's form.
+func (s *Store) Add(name string, data []byte) error {
+	if !isMapName(name) {
+		return fmt.Errorf("maps: %q is not a map file name", name)
+	}
+	data, err := compressMap(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = &File{
+		Name: name,
+		Data: data,
+		CRC:  packet.GetCRC(data, 0, len(data)),
+	}
+	return nil
+}
+
+// Get returns the named map file.
+func (s *Store) Get(name string) (*File, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.files[name]
+	return f, ok
+}
+
+// MapSquare returns the landscape and locs files of the map square at
+// x, z.
+func (s *Store) MapSquare(x, z int) (land, locs *File, ok bool) {
+	land, landOk := s.Get(mapsquare.LandName(x, z))
+	locs, locsOk := s.Get(mapsquare.LocsName(x, z))
+	return land, locs, landOk && locsOk
+}
+
+// RebuildNormal returns the REBUILD_NORMAL that loads the zones around
+// zone x, z, listing every map square they cover that the store holds.
+func (s *Store) RebuildNormal(zoneX, zoneZ uint16) *serverprot.RebuildNormal {
+	m := &serverprot.RebuildNormal{ZoneX: zoneX, ZoneZ: zoneZ}
+	for x := (int(zoneX) - 6) / 8; x <= (int(zoneX)+6)/8; x++ {
+		for z := (int(zoneZ) - 6) / 8; z <= (int(zoneZ)+6)/8; z++ {
+			land, locs, ok := s.MapSquare(x, z)
+			if !ok {
+				continue
+			}
+			m.MapSquares = append(m.MapSquares, serverprot.RebuildMapSquare{
+				X:       uint8(x),
+				Z:       uint8(z),
+				LandCrc: land.CRC,
+				LocCrc:  locs.CRC,
+			})
+		}
+	}
+	return m
+}
+
+// Send writes the files requested by REBUILD_GETMAPS to w. Files the
+// store does not have are skipped and reported in the returned error,
+// after the others have been written.
+func (s *Store) Send(w *protocol.Writer, req *clientprot.RebuildGetMaps) error {
+	var missing []error
+	for _, r := range req.Requests {
+		var name string
+		switch r.Type {
+		case clientprot.MapLand:
+			name = mapsquare.LandName(int(r.X), int(r.Z))
+		case clientprot.MapLoc:
+			name = mapsquare.LocsName(int(r.X), int(r.Z))
+		default:
+			missing = append(missing, fmt.Errorf("maps: unknown map file type %d", r.Type))
+			continue
+		}
+
+		f, ok := s.Get(name)
+		if !ok {
+			missing = append(missing, fmt.Errorf("maps: %s does not exist", name))
+			continue
+		}
+		if err := send(w, r, f.Data); err != nil {
+			return err
+		}
+	}
+	return errors.Join(missing...)
+}
+
+// send writes data in chunks followed by the done message for its type.
+func send(w *protocol.Writer, r clientprot.MapRequest, data []byte) error {
+	chunks, err := serverprot.MapChunks(r.X, r.Z, data)
+	if err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		var m protocol.Encoder = &serverprot.DataLand{MapChunk: c}
+		if r.Type == clientprot.MapLoc {
+			m = &serverprot.DataLoc{MapChunk: c}
+		}
+		if err := w.WriteMessage(m); err != nil {
+			return err
+		}
+	}
+
+	var done protocol.Encoder = &serverprot.DataLandDone{X: r.X, Z: r.Z}
+	if r.Type == clientprot.MapLoc {
+		done = &serverprot.DataLocDone{X: r.X, Z: r.Z}
+	}
+	return w.WriteMessage(done)
+}
+
+// compressMap returns data compressed as the client expects.
+func compressMap(data []byte) ([]byte, error) {
+	switch {
+	case len(data) >= 4+len(bzip2BlockMagic) && bytes.Equal(data[4:4+len(bzip2BlockMagic)], bzip2BlockMagic):
+		return data, nil
+	case len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = stdio.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	return io.BZip2Compress(data, true, false, 1, 0)
+}
+
+// isMapName reports whether name is a map file name such as "m50_50"
+// or "l50_50".
+func isMapName(name string) bool {
+	if len(name) < 2 || (name[0] != 'm' && name[0] != 'l') {
+		return false
+	}
+	xs, zs, found := strings.Cut(name[1:], "_")
+	if !found {
+		return false
+	}
+	x, errX := strconv.Atoi(xs)
+	z, errZ := strconv.Atoi(zs)
+	return errX == nil && errZ == nil &&
+		x >= 0 && x <= 0xFF && z >= 0 && z <= 0xFF &&
+		strconv.Itoa(x) == xs && strconv.Itoa(z) == zs
+}
@@ -0,0 +1,323 @@
+package maps
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache/mapsquare"
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/protocol"
+	"github.com/zsrv/rs-server-225/server/protocol/clientprot"
+	"github.com/zsrv/rs-server-225/server/protocol/serverprot"
+)
+
+// decompressMap unpacks a map file as the client does.
+func decompressMap(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	got, err := io.BZip2Decompress(bytes.Clone(data), 0, false, true)
+	if err != nil {
+		t.Fatalf("BZip2Decompress() error = %v", err)
+	}
+	return got
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStore_Add(t *testing.T) {
+	land := []byte("landscape")
+	compressed, err := io.BZip2Compress(land, true, false, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"raw", land},
+		{"bzip2", compressed},
+		{"gzip", gzipBytes(t, land)},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStore()
+			if err := s.Add("m50_50", tt.data); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+			f, ok := s.Get("m50_50")
+			if !ok {
+				t.Fatal("m50_50 not added")
+			}
+			want := land
+			if tt.data == nil {
+				want = []byte{}
+			}
+			if got := decompressMap(t, f.Data); !bytes.Equal(got, want) {
+				t.Errorf("stored map decompresses to %d bytes, want %d", len(got), len(want))
+			}
+			if f.CRC != packet.GetCRC(f.Data, 0, len(f.Data)) {
+				t.Errorf("CRC = %d, want the crc of the data sent", f.CRC)
+			}
+		})
+	}
+
+	t.Run("bzip2 sent as stored", func(t *testing.T) {
+		s := NewStore()
+		if err := s.Add("l50_50", compressed); err != nil {
+			t.Fatal(err)
+		}
+		if f, _ := s.Get("l50_50"); !bytes.Equal(f.Data, compressed) {
+			t.Error("precompressed map was recompressed")
+		}
+	})
+}
+
+func TestStore_Add_badName(t *testing.T) {
+	for _, name := range []string{"title", "m50", "m50_", "x50_50", "m-1_50", "m50_256", "m050_50", "m50_50.dat"} {
+		if err := NewStore().Add(name, []byte{}); err == nil {
+			t.Errorf("Add(%q) error = nil, want error", name)
+		}
+	}
+}
+
+func TestLoadStore(t *testing.T) {
+	land, locs := []byte("landscape"), []byte("locs")
+	dir := t.TempDir()
+	for name, data := range map[string][]byte{
+		"m50_50":    land,
+		"l50_50":    locs,
+		"m50_51":    land,
+		"README":    []byte("not a map"),
+		"l51_50.gz": locs,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := LoadStore(dir)
+	if err != nil {
+		t.Fatalf("LoadStore() error = %v", err)
+	}
+
+	m, l, ok := s.MapSquare(50, 50)
+	if !ok {
+		t.Fatal("MapSquare(50, 50) not found")
+	}
+	if !bytes.Equal(decompressMap(t, m.Data), land) || !bytes.Equal(decompressMap(t, l.Data), locs) {
+		t.Error("MapSquare(50, 50) files differ from the map directory")
+	}
+	if _, _, ok := s.MapSquare(50, 51); ok {
+		t.Error("MapSquare(50, 51) found without a locs file")
+	}
+	for _, name := range []string{"README", "l51_50.gz"} {
+		if _, ok := s.Get(name); ok {
+			t.Errorf("%s loaded", name)
+		}
+	}
+
+	if _, err := LoadStore(filepath.Join(dir, "missing")); err != nil {
+		t.Errorf("LoadStore() missing dir error = %v", err)
+	}
+}
+
+func TestStore_RebuildNormal(t *testing.T) {
+	s := NewStore()
+	for _, name := range []string{"m50_50", "l50_50", "m51_50", "l51_50", "m50_49"} {
+		if err := s.Add(name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// zone 406 is 6 zones from the east edge of square 50
+	m := s.RebuildNormal(406, 402)
+	if m.ZoneX != 406 || m.ZoneZ != 402 {
+		t.Errorf("RebuildNormal() zone = %d, %d, want 406, 402", m.ZoneX, m.ZoneZ)
+	}
+	land, locs, _ := s.MapSquare(51, 50)
+	want := []serverprot.RebuildMapSquare{
+		{X: 50, Z: 50},
+		{X: 51, Z: 50, LandCrc: land.CRC, LocCrc: locs.CRC},
+	}
+	if len(m.MapSquares) != len(want) {
+		t.Fatalf("RebuildNormal() = %+v, want %+v", m.MapSquares, want)
+	}
+	for i, sq := range m.MapSquares {
+		if sq.X != want[i].X || sq.Z != want[i].Z {
+			t.Errorf("map square %d = %d_%d, want %d_%d", i, sq.X, sq.Z, want[i].X, want[i].Z)
+		}
+	}
+	if m.MapSquares[1] != want[1] {
+		t.Errorf("map square 1 = %+v, want %+v", m.MapSquares[1], want[1])
+	}
+}
+
+// client is a stand-in 225 client on the game connection.
+type client struct {
+	w *protocol.Writer
+	r *protocol.Reader
+}
+
+func TestStore_Send(t *testing.T) {
+	l := &mapsquare.Land{}
+	l[0][10][12] = mapsquare.Tile{Height: 20, Underlay: 3, Flags: mapsquare.TileBlocked}
+	land, err := l.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locs, err := mapsquare.EncodeLocs([]mapsquare.Loc{
+		{ID: 1276, X: 10, Z: 20, Shape: mapsquare.ShapeCentrepiece, Angle: 2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// large enough to need several chunks
+	big := bytes.Repeat([]byte("landscape "), 2*serverprot.MapChunkSize)
+
+	s := NewStore()
+	for name, data := range map[string][]byte{"m50_50": land, "l50_50": locs, "m51_50": big, "l51_50": locs} {
+		if err := s.Add(name, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rebuild := s.RebuildNormal(406, 402)
+
+	seed := [4]uint32{1, 2, 3, 4}
+	serverIsaac := io.NewServerIsaacPair(seed)
+	clientIsaac := io.NewClientIsaacPair(seed)
+	var toServer, toClient bytes.Buffer
+	c := client{
+		w: protocol.NewWriter(&toServer, clientIsaac.Encoder, clientprot.Registry),
+		r: protocol.NewReader(&toClient, clientIsaac.Decoder, serverprot.Registry),
+	}
+	sr := protocol.NewReader(&toServer, serverIsaac.Decoder, clientprot.Registry)
+	sw := protocol.NewWriter(&toClient, serverIsaac.Encoder, serverprot.Registry)
+
+	// the client has no files cached, so it asks for all of them
+	req := &clientprot.RebuildGetMaps{}
+	for _, sq := range rebuild.MapSquares {
+		req.Requests = append(req.Requests,
+			clientprot.MapRequest{Type: clientprot.MapLand, X: sq.X, Z: sq.Z},
+			clientprot.MapRequest{Type: clientprot.MapLoc, X: sq.X, Z: sq.Z})
+	}
+	if err := c.w.WriteMessage(req); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := sr.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(sw, m.(*clientprot.RebuildGetMaps)); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if err := sw.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// assemble the files as the client does, checking each against the
+	// crc it was sent in REBUILD_NORMAL
+	crcs := make(map[string]uint32)
+	for _, sq := range rebuild.MapSquares {
+		crcs[mapsquare.LandName(int(sq.X), int(sq.Z))] = sq.LandCrc
+		crcs[mapsquare.LocsName(int(sq.X), int(sq.Z))] = sq.LocCrc
+	}
+	buffers := make(map[string][]byte)
+	got := make(map[string][]byte)
+	chunk := func(name string, c serverprot.MapChunk) {
+		if len(buffers[name]) != int(c.Length) {
+			buffers[name] = make([]byte, c.Length)
+		}
+		copy(buffers[name][c.Offset:], c.Data)
+	}
+	for len(got) < len(req.Requests) {
+		m, err := c.r.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v after %d files", err, len(got))
+		}
+		switch m := m.(type) {
+		case *serverprot.DataLand:
+			chunk(mapsquare.LandName(int(m.X), int(m.Z)), m.MapChunk)
+		case *serverprot.DataLoc:
+			chunk(mapsquare.LocsName(int(m.X), int(m.Z)), m.MapChunk)
+		case *serverprot.DataLandDone:
+			name := mapsquare.LandName(int(m.X), int(m.Z))
+			got[name] = buffers[name]
+		case *serverprot.DataLocDone:
+			name := mapsquare.LocsName(int(m.X), int(m.Z))
+			got[name] = buffers[name]
+		default:
+			t.Fatalf("ReadMessage() = %T", m)
+		}
+	}
+	if toClient.Len() != 0 {
+		t.Errorf("%d bytes left after the last file", toClient.Len())
+	}
+
+	want := map[string][]byte{"m50_50": land, "l50_50": locs, "m51_50": big, "l51_50": locs}
+	for name, data := range want {
+		if crc := packet.GetCRC(got[name], 0, len(got[name])); crc != crcs[name] {
+			t.Errorf("%s crc = %d, want %d", name, crc, crcs[name])
+		}
+		if !bytes.Equal(decompressMap(t, got[name]), data) {
+			t.Errorf("%s differs from the stored file", name)
+		}
+	}
+
+	decoded, err := mapsquare.DecodeLand(decompressMap(t, got["m50_50"]))
+	if err != nil {
+		t.Fatalf("DecodeLand() error = %v", err)
+	}
+	if tile := decoded[0][10][12]; tile.Height != 20 || tile.Flags != mapsquare.TileBlocked {
+		t.Errorf("tile 0 10 12 = %+v", tile)
+	}
+}
+
+func TestStore_Send_missing(t *testing.T) {
+	s := NewStore()
+	if err := s.Add("m50_50", []byte("land")); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	w := protocol.NewWriter(&buf, nil, serverprot.Registry)
+	err := s.Send(w, &clientprot.RebuildGetMaps{Requests: []clientprot.MapRequest{
+		{Type: clientprot.MapLoc, X: 50, Z: 50},
+		{Type: clientprot.MapLand, X: 50, Z: 50},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "l50_50") {
+		t.Errorf("Send() error = %v, want l50_50 missing", err)
+	}
+	w.Flush()
+
+	r := protocol.NewReader(&buf, nil, serverprot.Registry)
+	m, err := r.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m.(*serverprot.DataLand); !ok {
+		t.Errorf("first message = %T, want the land file still sent", m)
+	}
+}
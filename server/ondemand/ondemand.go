@@ -0,0 +1,132 @@
+// Package ondemand serves the on-demand file requests of the clients
+// from revision 234 onwards, which fetch models, animations, midi and
+// maps individually rather than in archives.
+//
+// A client opens a connection with the byte 15, to which the server
+// replies with 8 bytes the client ignores. The client then sends 4 byte
+// requests:
+//
+//	G1 archive, G2 file, G1 priority
+//
+// and the server replies to each with the gzipped file split into
+// chunks of up to 500 bytes, each preceded by a 6 byte header:
+//
+//	P1 archive, P2 file, P2 file size, P1 chunk index
+//
+// A missing file is answered with a single header of size 0.
+package ondemand
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// ConnectionType is the first byte sent on an on-demand connection.
+const ConnectionType = 15
+
+// ChunkSize is the most file data sent after a chunk header.
+const ChunkSize = 500
+
+// HeaderSize is the size of a chunk header.
+const HeaderSize = 6
+
+// RequestSize is the size of a request.
+const RequestSize = 4
+
+// MaxFileSize is the largest file the chunk header can describe.
+const MaxFileSize = 0xFFFF
+
+// A Priority is the urgency of a request.
+type Priority uint8
+
+const (
+	// PriorityPrefetch is used for files fetched in the background.
+	PriorityPrefetch Priority = 0
+	// PriorityLoading is used for files needed before the client has
+	// logged in.
+	PriorityLoading Priority = 1
+	// PriorityUrgent is used for files the game is waiting on.
+	PriorityUrgent Priority = 2
+	// PriorityKeepAlive marks a request that only keeps the
+	// connection open. It asks for no file.
+	PriorityKeepAlive Priority = 10
+)
+
+// priorityCount is the number of priorities that ask for a file.
+const priorityCount = 3
+
+var errUnknownArchive = errors.New("ondemand: unknown archive")
+
+// A Request asks for a file.
+type Request struct {
+	Archive  uint8
+	File     uint16
+	Priority Priority
+}
+
+// DecodeRequest decodes a request. Keep-alive requests are returned
+// with [PriorityKeepAlive] and no validation.
+func DecodeRequest(src *packet.Packet) (Request, error) {
+	var r Request
+	var err error
+
+	if r.Archive, err = src.TryG1(); err != nil {
+		return r, err
+	}
+	if r.File, err = src.TryG2(); err != nil {
+		return r, err
+	}
+	priority, err := src.TryG1()
+	if err != nil {
+		return r, err
+	}
+	r.Priority = Priority(priority)
+
+	if r.Priority == PriorityKeepAlive {
+		return r, nil
+	}
+	if r.Archive >= cache.OnDemandCount {
+		return r, errUnknownArchive
+	}
+	if r.Priority >= priorityCount {
+		return r, fmt.Errorf("ondemand: unknown priority %d", r.Priority)
+	}
+	return r, nil
+}
+
+// Encode puts the request as the client sends it.
+func (r Request) Encode(p *packet.Packet) {
+	p.P1(r.Archive)
+	p.P2(r.File)
+	p.P1(uint8(r.Priority))
+}
+
+// ChunkCount returns the number of chunks a file of size bytes is sent
+// in. A missing or empty file is sent as one empty chunk.
+func ChunkCount(size int) int {
+	return max(1, (size+ChunkSize-1)/ChunkSize)
+}
+
+// PChunk puts chunk index of data, the file requested by r, preceded
+// by its header.
+func PChunk(p *packet.Packet, r Request, data []byte, chunk int) error {
+	if len(data) > MaxFileSize {
+		return fmt.Errorf("ondemand: %s file %d is too large (%d bytes)", cache.OnDemandArchives[r.Archive], r.File, len(data))
+	}
+	if chunk < 0 || chunk >= ChunkCount(len(data)) {
+		return fmt.Errorf("ondemand: chunk %d out of range", chunk)
+	}
+
+	start := chunk * ChunkSize
+	end := min(start+ChunkSize, len(data))
+
+	p.P1(r.Archive)
+	p.P2(r.File)
+	p.P2(uint16(len(data)))
+	p.P1(uint8(chunk))
+	p.PData(data[start:end], end-start)
+	return nil
+}
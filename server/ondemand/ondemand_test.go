@@ -0,0 +1,151 @@
+package ondemand
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   []byte
+		want    Request
+		wantErr error
+	}{
+		{name: "urgent model", input: []byte{0, 0x01, 0x2c, 2}, want: Request{Archive: cache.OnDemandModels, File: 300, Priority: PriorityUrgent}},
+		{name: "prefetch map", input: []byte{3, 0, 73, 0}, want: Request{Archive: cache.OnDemandMaps, File: 73, Priority: PriorityPrefetch}},
+		{name: "keep alive", input: []byte{0, 0, 0, 10}, want: Request{Priority: PriorityKeepAlive}},
+		{name: "unknown archive", input: []byte{4, 0, 1, 2}, wantErr: errUnknownArchive},
+		{name: "truncated", input: []byte{0, 0, 1}, wantErr: io.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeRequest(packet.NewPacket(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DecodeRequest() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("DecodeRequest() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := DecodeRequest(packet.NewPacket([]byte{0, 0, 1, 3})); err == nil {
+		t.Error("DecodeRequest() accepted unknown priority")
+	}
+}
+
+func TestRequest_Encode(t *testing.T) {
+	r := Request{Archive: cache.OnDemandMidi, File: 0x1234, Priority: PriorityLoading}
+	p := packet.NewPacket(nil)
+	r.Encode(p)
+
+	if want := []byte{2, 0x12, 0x34, 1}; !bytes.Equal(p.Buf, want) {
+		t.Errorf("Encode() = %v, want %v", p.Buf, want)
+	}
+	if got, err := DecodeRequest(p); err != nil || got != r {
+		t.Errorf("DecodeRequest() = %+v, %v, want %+v", got, err, r)
+	}
+}
+
+func TestPChunk(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab}, 1100)
+	r := Request{Archive: cache.OnDemandAnims, File: 7}
+
+	tests := []struct {
+		name     string
+		data     []byte
+		chunk    int
+		wantHead []byte
+		wantLen  int
+		wantErr  bool
+	}{
+		{name: "first", data: data, chunk: 0, wantHead: []byte{1, 0, 7, 0x04, 0x4c, 0}, wantLen: 500},
+		{name: "last", data: data, chunk: 2, wantHead: []byte{1, 0, 7, 0x04, 0x4c, 2}, wantLen: 100},
+		{name: "missing", data: nil, chunk: 0, wantHead: []byte{1, 0, 7, 0, 0, 0}, wantLen: 0},
+		{name: "out of range", data: data, chunk: 3, wantErr: true},
+		{name: "too large", data: make([]byte, MaxFileSize+1), chunk: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := packet.NewPacket(nil)
+			err := PChunk(p, r, tt.data, tt.chunk)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PChunk() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if !bytes.Equal(p.Buf[:HeaderSize], tt.wantHead) {
+				t.Errorf("header = %v, want %v", p.Buf[:HeaderSize], tt.wantHead)
+			}
+			if got := len(p.Buf) - HeaderSize; got != tt.wantLen {
+				t.Errorf("chunk length = %d, want %d", got, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestChunkCount(t *testing.T) {
+	tests := []struct {
+		size int
+		want int
+	}{
+		{0, 1}, {1, 1}, {500, 1}, {501, 2}, {1000, 2}, {MaxFileSize, 132},
+	}
+	for _, tt := range tests {
+		if got := ChunkCount(tt.size); got != tt.want {
+			t.Errorf("ChunkCount(%d) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	q := newQueue(3)
+
+	prefetch := Request{Archive: cache.OnDemandMaps, File: 1, Priority: PriorityPrefetch}
+	loading := Request{Archive: cache.OnDemandModels, File: 2, Priority: PriorityLoading}
+	urgent := Request{Archive: cache.OnDemandModels, File: 3, Priority: PriorityUrgent}
+
+	q.push(prefetch)
+	first := q.next()
+	if first.req != prefetch {
+		t.Fatalf("next() = %+v, want %+v", first.req, prefetch)
+	}
+	// a chunk of the prefetch has gone out when the urgent request arrives
+	first.chunk++
+
+	q.push(loading)
+	q.push(urgent)
+	if got := q.next(); got.req != urgent {
+		t.Errorf("next() = %+v, want urgent %+v", got.req, urgent)
+	}
+	q.done(q.next())
+	if got := q.next(); got.req != loading {
+		t.Errorf("next() = %+v, want loading %+v", got.req, loading)
+	}
+
+	// asking for a queued file again raises its priority without queueing it twice
+	q.push(Request{Archive: cache.OnDemandMaps, File: 1, Priority: PriorityUrgent})
+	if got := q.next(); got != first || got.chunk != 1 {
+		t.Errorf("next() = %+v, want promoted prefetch resumed at chunk 1", got)
+	}
+	if got := q.len(); got != 2 {
+		t.Errorf("len() = %d, want 2", got)
+	}
+
+	q.push(urgent)
+	if err := q.push(Request{Archive: cache.OnDemandMidi, File: 4}); !errors.Is(err, errQueueFull) {
+		t.Errorf("push() error = %v, want %v", err, errQueueFull)
+	}
+
+	q.close()
+	if got := q.next(); got != nil {
+		t.Errorf("next() after close = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,120 @@
+package ondemand
+
+import (
+	"errors"
+	"slices"
+	"sync"
+)
+
+var errQueueFull = errors.New("ondemand: too many pending requests")
+
+// A transfer is a requested file being sent chunk by chunk.
+type transfer struct {
+	req      Request
+	priority Priority // guarded by queue.mu, may be raised while queued
+
+	// only used by the goroutine sending files
+	data   []byte
+	loaded bool
+	chunk  int
+}
+
+type fileKey struct {
+	archive uint8
+	file    uint16
+}
+
+// A queue orders the pending transfers of one connection. Transfers are
+// sent a chunk at a time from the head of the highest priority queue, so
+// an urgent request overtakes prefetches already being sent.
+type queue struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	pending [priorityCount][]*transfer
+	byFile  map[fileKey]*transfer
+	max     int
+	closed  bool
+}
+
+func newQueue(max int) *queue {
+	q := &queue{byFile: make(map[fileKey]*transfer), max: max}
+	q.cond.L = &q.mu
+	return q
+}
+
+// push queues r. A file already queued is not queued twice, but its
+// priority is raised to r's if that is higher.
+func (q *queue) push(r Request) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	key := fileKey{r.Archive, r.File}
+	if t, ok := q.byFile[key]; ok {
+		if r.Priority > t.priority {
+			q.remove(t)
+			t.priority = r.Priority
+			q.pending[t.priority] = append(q.pending[t.priority], t)
+		}
+		return nil
+	}
+
+	if len(q.byFile) >= q.max {
+		return errQueueFull
+	}
+
+	t := &transfer{req: r, priority: r.Priority}
+	q.byFile[key] = t
+	q.pending[t.priority] = append(q.pending[t.priority], t)
+	q.cond.Signal()
+	return nil
+}
+
+// next returns the transfer to send a chunk of, waiting until there is
+// one. It returns nil once the queue is closed.
+func (q *queue) next() *transfer {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed {
+		for p := priorityCount - 1; p >= 0; p-- {
+			if len(q.pending[p]) > 0 {
+				return q.pending[p][0]
+			}
+		}
+		q.cond.Wait()
+	}
+	return nil
+}
+
+// done removes a transfer that has been sent.
+func (q *queue) done(t *transfer) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remove(t)
+	delete(q.byFile, fileKey{t.req.Archive, t.req.File})
+}
+
+// remove takes t out of its priority queue. q.mu must be held.
+func (q *queue) remove(t *transfer) {
+	q.pending[t.priority] = slices.DeleteFunc(q.pending[t.priority], func(other *transfer) bool {
+		return other == t
+	})
+}
+
+// close wakes any goroutine waiting in next.
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// len returns the number of queued transfers.
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.byFile)
+}
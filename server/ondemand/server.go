@@ -0,0 +1,170 @@
+package ondemand
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"time"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/internal/listener"
+)
+
+// DefaultTimeout is how long a connection may stay silent when Timeout
+// is not set. Clients send a keep-alive request every 10 seconds.
+const DefaultTimeout = 30 * time.Second
+
+// DefaultMaxPending is the number of requests a connection may have
+// queued when MaxPending is not set.
+const DefaultMaxPending = 1000
+
+// ErrServerClosed is returned by [Server.Serve] after [Server.Close].
+var ErrServerClosed = errors.New("ondemand: server closed")
+
+// A Server answers on-demand requests with files from a [Store].
+type Server struct {
+	Store Store
+	// Timeout is how long a connection may go without sending a
+	// request, or take to accept a chunk.
+	Timeout time.Duration
+	// MaxPending is the number of requests a connection may have
+	// queued. A connection exceeding it is closed.
+	MaxPending int
+	// ErrorLog logs failed connections. If nil, the log package's
+	// standard logger is used.
+	ErrorLog *log.Logger
+
+	listener.Server
+}
+
+// NewServer returns a server for the files in store.
+func NewServer(store Store) *Server {
+	return &Server{Store: store}
+}
+
+// ListenAndServe listens on the TCP address addr and calls [Server.Serve].
+func (s *Server) ListenAndServe(addr string) error {
+	return s.Server.ListenAndServe(addr, ErrServerClosed, s.handle)
+}
+
+// Serve accepts connections on l and serves each on its own goroutine.
+// It always returns a non-nil error and closes l. [Server.Close] stops
+// every listener passed to Serve.
+func (s *Server) Serve(l net.Listener) error {
+	return s.Server.Serve(l, ErrServerClosed, s.handle)
+}
+
+func (s *Server) handle(conn net.Conn) {
+	if err := s.ServeConn(conn); err != nil {
+		listener.Logf(s.ErrorLog, "ondemand: %s: %v", conn.RemoteAddr(), err)
+	}
+}
+
+// ServeConn serves an on-demand connection until the client closes it.
+// The connection must start with [ConnectionType]. conn is closed on
+// return.
+func (s *Server) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(s.timeout())); err != nil {
+		return err
+	}
+
+	var typ [1]byte
+	if _, err := io.ReadFull(conn, typ[:]); err != nil {
+		return err
+	}
+	if typ[0] != ConnectionType {
+		return fmt.Errorf("unexpected connection type %d", typ[0])
+	}
+	if _, err := conn.Write(make([]byte, 8)); err != nil {
+		return err
+	}
+
+	maxPending := s.MaxPending
+	if maxPending == 0 {
+		maxPending = DefaultMaxPending
+	}
+	q := newQueue(maxPending)
+
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- s.readRequests(conn, q)
+		q.close()
+	}()
+
+	err := s.writeFiles(conn, q)
+	// unblock the reader if the writer failed
+	conn.Close()
+
+	if rerr := <-readErr; err == nil && !errors.Is(rerr, io.EOF) && !errors.Is(rerr, net.ErrClosed) {
+		err = rerr
+	}
+	return err
+}
+
+// readRequests queues each request read from conn until conn fails or
+// the client closes it.
+func (s *Server) readRequests(conn net.Conn, q *queue) error {
+	buf := make([]byte, RequestSize)
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(s.timeout())); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return err
+		}
+
+		r, err := DecodeRequest(packet.NewPacket(buf))
+		if err != nil {
+			return err
+		}
+		if r.Priority == PriorityKeepAlive {
+			continue
+		}
+		if err := q.push(r); err != nil {
+			return err
+		}
+	}
+}
+
+// writeFiles sends the queued files a chunk at a time until q is closed.
+func (s *Server) writeFiles(conn net.Conn, q *queue) error {
+	p := packet.NewPacket(make([]byte, 0, HeaderSize+ChunkSize))
+
+	for t := q.next(); t != nil; t = q.next() {
+		if !t.loaded {
+			data, err := s.Store.Read(t.req.Archive, t.req.File)
+			if err != nil && !isNotExist(err) {
+				return err
+			}
+			t.data, t.loaded = data, true
+		}
+
+		p.Reset()
+		if err := PChunk(p, t.req, t.data, t.chunk); err != nil {
+			return err
+		}
+		if err := conn.SetWriteDeadline(time.Now().Add(s.timeout())); err != nil {
+			return err
+		}
+		if _, err := conn.Write(p.Buf); err != nil {
+			return err
+		}
+
+		t.chunk++
+		if t.chunk >= ChunkCount(len(t.data)) {
+			q.done(t)
+		}
+	}
+	return nil
+}
+
+func (s *Server) timeout() time.Duration {
+	if s.Timeout == 0 {
+		return DefaultTimeout
+	}
+	return s.Timeout
+}
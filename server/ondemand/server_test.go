@@ -0,0 +1,176 @@
+package ondemand
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/cache"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// client is a stand-in for the client end of an on-demand connection.
+type client struct {
+	conn net.Conn
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte{ConnectionType}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 8)); err != nil {
+		t.Fatalf("reading handshake: %v", err)
+	}
+	return &client{conn: conn}
+}
+
+func (c *client) request(reqs ...Request) error {
+	p := packet.NewPacket(nil)
+	for _, r := range reqs {
+		r.Encode(p)
+	}
+	_, err := c.conn.Write(p.Buf)
+	return err
+}
+
+// readFile reads chunks until a whole file has arrived and returns it.
+// Chunks of other files are collected into partial.
+func (c *client) readFile(partial map[fileKey][]byte) (fileKey, []byte, error) {
+	header := make([]byte, HeaderSize)
+	for {
+		if _, err := io.ReadFull(c.conn, header); err != nil {
+			return fileKey{}, nil, err
+		}
+		p := packet.NewPacket(header)
+		key := fileKey{p.G1(), p.G2()}
+		size := int(p.G2())
+		chunk := int(p.G1())
+
+		n := min(ChunkSize, size-chunk*ChunkSize)
+		data := make([]byte, max(n, 0))
+		if _, err := io.ReadFull(c.conn, data); err != nil {
+			return fileKey{}, nil, err
+		}
+
+		partial[key] = append(partial[key], data...)
+		if len(partial[key]) == size {
+			file := partial[key]
+			delete(partial, key)
+			return key, file, nil
+		}
+	}
+}
+
+func TestServer_ServeConn(t *testing.T) {
+	store := DirStore(t.TempDir())
+	big := bytes.Repeat([]byte("model"), 400)
+	small := []byte("map data")
+	if err := store.Write(cache.OnDemandModels, 1, big); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Write(cache.OnDemandMaps, 2, small); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(store)
+	s.ErrorLog = log.New(io.Discard, "", 0)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(l) }()
+
+	c := dial(t, l.Addr().String())
+	defer c.conn.Close()
+
+	err = c.request(
+		Request{Archive: cache.OnDemandModels, File: 1, Priority: PriorityPrefetch},
+		Request{Priority: PriorityKeepAlive},
+		Request{Archive: cache.OnDemandMaps, File: 2, Priority: PriorityUrgent},
+		Request{Archive: cache.OnDemandMidi, File: 9, Priority: PriorityUrgent},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[fileKey][]byte{
+		{cache.OnDemandModels, 1}: big,
+		{cache.OnDemandMaps, 2}:   small,
+		{cache.OnDemandMidi, 9}:   {},
+	}
+	partial := make(map[fileKey][]byte)
+	for range len(want) {
+		key, data, err := c.readFile(partial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, want[key]) {
+			t.Errorf("file %+v = %d bytes, want %d", key, len(data), len(want[key]))
+		}
+		delete(want, key)
+	}
+	if len(want) != 0 {
+		t.Errorf("files not sent: %v", want)
+	}
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-served; !errors.Is(err, ErrServerClosed) {
+		t.Errorf("Serve() = %v, want %v", err, ErrServerClosed)
+	}
+}
+
+func TestServer_ServeConn_BadConnectionType(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+
+	go client.Write([]byte{14})
+
+	s := NewServer(DirStore(t.TempDir()))
+	if err := s.ServeConn(server); err == nil {
+		t.Error("ServeConn() accepted connection type 14")
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	store := DirStore(t.TempDir())
+	if err := store.Write(cache.OnDemandAnims, 5, []byte{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		archive     uint8
+		file        uint16
+		want        []byte
+		wantMissing bool
+		wantErr     bool
+	}{
+		{name: "exists", archive: cache.OnDemandAnims, file: 5, want: []byte{1, 2, 3}},
+		{name: "missing", archive: cache.OnDemandAnims, file: 6, wantMissing: true, wantErr: true},
+		{name: "unknown archive", archive: 9, file: 5, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Read(tt.archive, tt.file)
+			if (err != nil) != tt.wantErr || isNotExist(err) != tt.wantMissing {
+				t.Fatalf("Read() error = %v, wantErr %v, wantMissing %v", err, tt.wantErr, tt.wantMissing)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Read() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package ondemand
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zsrv/rs-server-225/cache"
+)
+
+// A Store holds the gzipped files served on demand.
+type Store interface {
+	// Read returns the gzipped file. It returns an error wrapping
+	// fs.ErrNotExist if the file does not exist.
+	Read(archive uint8, file uint16) ([]byte, error)
+}
+
+// A DirStore reads files from a directory holding one directory per
+// archive, named as in [cache.OnDemandArchives], with each file named by its id:
+//
+//	models/0
+//	maps/73
+//
+// Files are served as stored, so they must already be gzipped.
+type DirStore string
+
+func (d DirStore) Read(archive uint8, file uint16) ([]byte, error) {
+	if int(archive) >= len(cache.OnDemandArchives) {
+		return nil, errUnknownArchive
+	}
+	return os.ReadFile(filepath.Join(string(d), cache.OnDemandArchives[archive], strconv.Itoa(int(file))))
+}
+
+// Write stores data as the given file, creating the archive
+// directory if needed.
+func (d DirStore) Write(archive uint8, file uint16, data []byte) error {
+	if int(archive) >= len(cache.OnDemandArchives) {
+		return errUnknownArchive
+	}
+	dir := filepath.Join(string(d), cache.OnDemandArchives[archive])
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, strconv.Itoa(int(file))), data, 0644)
+}
+
+// isNotExist reports whether err means a file is missing from a store.
+func isNotExist(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
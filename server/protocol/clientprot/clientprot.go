@@ -0,0 +1,219 @@
+// Package clientprot defines the messages the 225 client sends to the
+// server.
+package clientprot
+
+import (
+	"github.com/zsrv/rs-server-225/server/protocol"
+)
+
+// Opcodes of the messages sent by the client.
+const (
+	// maps and timers
+	OpcodeRebuildGetMaps = 150
+	OpcodeNoTimeout      = 108
+	OpcodeIdleTimer      = 70
+	OpcodeEventTracking  = 81
+
+	// anticheat, sent at random to detect modified clients
+	OpcodeAnticheatOpLogic1    = 7
+	OpcodeAnticheatOpLogic2    = 88
+	OpcodeAnticheatOpLogic3    = 30
+	OpcodeAnticheatOpLogic4    = 176
+	OpcodeAnticheatOpLogic5    = 127
+	OpcodeAnticheatOpLogic7    = 162
+	OpcodeAnticheatOpLogic8    = 147
+	OpcodeAnticheatOpLogic9    = 22
+	OpcodeAnticheatCycleLogic1 = 146
+	OpcodeAnticheatCycleLogic2 = 215
+	OpcodeAnticheatCycleLogic3 = 236
+	OpcodeAnticheatCycleLogic4 = 85
+	OpcodeAnticheatCycleLogic5 = 219
+
+	// interactions
+	OpcodeOpObj1 = 140
+	OpcodeOpObj2 = 40
+	OpcodeOpObj3 = 200
+	OpcodeOpObj4 = 178
+	OpcodeOpObj5 = 247
+	OpcodeOpObjT = 138
+	OpcodeOpObjU = 239
+
+	OpcodeOpNpc1 = 194
+	OpcodeOpNpc2 = 8
+	OpcodeOpNpc3 = 27
+	OpcodeOpNpc4 = 113
+	OpcodeOpNpc5 = 100
+	OpcodeOpNpcT = 134
+	OpcodeOpNpcU = 202
+
+	OpcodeOpLoc1 = 245
+	OpcodeOpLoc2 = 172
+	OpcodeOpLoc3 = 96
+	OpcodeOpLoc4 = 97
+	OpcodeOpLoc5 = 116
+	OpcodeOpLocT = 9
+	OpcodeOpLocU = 75
+
+	OpcodeOpPlayer1 = 164
+	OpcodeOpPlayer2 = 53
+	OpcodeOpPlayer3 = 185
+	OpcodeOpPlayer4 = 206
+	OpcodeOpPlayerT = 177
+	OpcodeOpPlayerU = 248
+
+	OpcodeOpHeld1 = 195
+	OpcodeOpHeld2 = 71
+	OpcodeOpHeld3 = 133
+	OpcodeOpHeld4 = 157
+	OpcodeOpHeld5 = 211
+	OpcodeOpHeldT = 48
+	OpcodeOpHeldU = 130
+
+	OpcodeInvButton1 = 31
+	OpcodeInvButton2 = 59
+	OpcodeInvButton3 = 212
+	OpcodeInvButton4 = 38
+	OpcodeInvButton5 = 6
+	OpcodeInvButtonD = 159
+
+	// interfaces
+	OpcodeIfButton           = 155
+	OpcodeResumePauseButton  = 235
+	OpcodeCloseModal         = 231
+	OpcodeResumePCountDialog = 237
+	OpcodeTutorialClickSide  = 175
+
+	// movement
+	OpcodeMoveOpClick      = 93
+	OpcodeMoveMinimapClick = 165
+	OpcodeMoveGameClick    = 181
+
+	// social
+	OpcodeReportAbuse    = 190
+	OpcodeIgnoreListDel  = 171
+	OpcodeIgnoreListAdd  = 79
+	OpcodeChatSetMode    = 244
+	OpcodeMessagePrivate = 148
+	OpcodeFriendListDel  = 11
+	OpcodeFriendListAdd  = 118
+	OpcodeMessagePublic  = 158
+
+	// misc
+	OpcodeIdkSaveDesign = 52
+	OpcodeClientCheat   = 4
+)
+
+// Registry holds every message the client sends.
+var Registry = protocol.NewRegistry(
+	protocol.Prot{Opcode: OpcodeRebuildGetMaps, Name: "REBUILD_GETMAPS", Size: protocol.VarByte, New: protocol.New[RebuildGetMaps]},
+	protocol.Prot{Opcode: OpcodeNoTimeout, Name: "NO_TIMEOUT", Size: 0, New: protocol.New[NoTimeout]},
+	protocol.Prot{Opcode: OpcodeIdleTimer, Name: "IDLE_TIMER", Size: 0, New: protocol.New[IdleTimer]},
+	protocol.Prot{Opcode: OpcodeEventTracking, Name: "EVENT_TRACKING", Size: protocol.VarShort, New: protocol.New[EventTracking]},
+
+	anticheat(OpcodeAnticheatOpLogic1, "ANTICHEAT_OPLOGIC1", 4),
+	anticheat(OpcodeAnticheatOpLogic2, "ANTICHEAT_OPLOGIC2", 4),
+	anticheat(OpcodeAnticheatOpLogic3, "ANTICHEAT_OPLOGIC3", 3),
+	anticheat(OpcodeAnticheatOpLogic4, "ANTICHEAT_OPLOGIC4", 2),
+	anticheat(OpcodeAnticheatOpLogic5, "ANTICHEAT_OPLOGIC5", 0),
+	anticheat(OpcodeAnticheatOpLogic7, "ANTICHEAT_OPLOGIC7", 3),
+	anticheat(OpcodeAnticheatOpLogic8, "ANTICHEAT_OPLOGIC8", 2),
+	anticheat(OpcodeAnticheatOpLogic9, "ANTICHEAT_OPLOGIC9", 0),
+	anticheat(OpcodeAnticheatCycleLogic1, "ANTICHEAT_CYCLELOGIC1", 1),
+	anticheat(OpcodeAnticheatCycleLogic2, "ANTICHEAT_CYCLELOGIC2", 0),
+	anticheat(OpcodeAnticheatCycleLogic3, "ANTICHEAT_CYCLELOGIC3", 3),
+	anticheat(OpcodeAnticheatCycleLogic4, "ANTICHEAT_CYCLELOGIC4", 4),
+	anticheat(OpcodeAnticheatCycleLogic5, "ANTICHEAT_CYCLELOGIC5", 0),
+
+	protocol.Prot{Opcode: OpcodeOpObj1, Name: "OPOBJ1", Size: 6, New: newOp[OpObj](1)},
+	protocol.Prot{Opcode: OpcodeOpObj2, Name: "OPOBJ2", Size: 6, New: newOp[OpObj](2)},
+	protocol.Prot{Opcode: OpcodeOpObj3, Name: "OPOBJ3", Size: 6, New: newOp[OpObj](3)},
+	protocol.Prot{Opcode: OpcodeOpObj4, Name: "OPOBJ4", Size: 6, New: newOp[OpObj](4)},
+	protocol.Prot{Opcode: OpcodeOpObj5, Name: "OPOBJ5", Size: 6, New: newOp[OpObj](5)},
+	protocol.Prot{Opcode: OpcodeOpObjT, Name: "OPOBJT", Size: 8, New: protocol.New[OpObjT]},
+	protocol.Prot{Opcode: OpcodeOpObjU, Name: "OPOBJU", Size: 12, New: protocol.New[OpObjU]},
+
+	protocol.Prot{Opcode: OpcodeOpNpc1, Name: "OPNPC1", Size: 2, New: newOp[OpNpc](1)},
+	protocol.Prot{Opcode: OpcodeOpNpc2, Name: "OPNPC2", Size: 2, New: newOp[OpNpc](2)},
+	protocol.Prot{Opcode: OpcodeOpNpc3, Name: "OPNPC3", Size: 2, New: newOp[OpNpc](3)},
+	protocol.Prot{Opcode: OpcodeOpNpc4, Name: "OPNPC4", Size: 2, New: newOp[OpNpc](4)},
+	protocol.Prot{Opcode: OpcodeOpNpc5, Name: "OPNPC5", Size: 2, New: newOp[OpNpc](5)},
+	protocol.Prot{Opcode: OpcodeOpNpcT, Name: "OPNPCT", Size: 4, New: protocol.New[OpNpcT]},
+	protocol.Prot{Opcode: OpcodeOpNpcU, Name: "OPNPCU", Size: 8, New: protocol.New[OpNpcU]},
+
+	protocol.Prot{Opcode: OpcodeOpLoc1, Name: "OPLOC1", Size: 6, New: newOp[OpLoc](1)},
+	protocol.Prot{Opcode: OpcodeOpLoc2, Name: "OPLOC2", Size: 6, New: newOp[OpLoc](2)},
+	protocol.Prot{Opcode: OpcodeOpLoc3, Name: "OPLOC3", Size: 6, New: newOp[OpLoc](3)},
+	protocol.Prot{Opcode: OpcodeOpLoc4, Name: "OPLOC4", Size: 6, New: newOp[OpLoc](4)},
+	protocol.Prot{Opcode: OpcodeOpLoc5, Name: "OPLOC5", Size: 6, New: newOp[OpLoc](5)},
+	protocol.Prot{Opcode: OpcodeOpLocT, Name: "OPLOCT", Size: 8, New: protocol.New[OpLocT]},
+	protocol.Prot{Opcode: OpcodeOpLocU, Name: "OPLOCU", Size: 12, New: protocol.New[OpLocU]},
+
+	protocol.Prot{Opcode: OpcodeOpPlayer1, Name: "OPPLAYER1", Size: 2, New: newOp[OpPlayer](1)},
+	protocol.Prot{Opcode: OpcodeOpPlayer2, Name: "OPPLAYER2", Size: 2, New: newOp[OpPlayer](2)},
+	protocol.Prot{Opcode: OpcodeOpPlayer3, Name: "OPPLAYER3", Size: 2, New: newOp[OpPlayer](3)},
+	protocol.Prot{Opcode: OpcodeOpPlayer4, Name: "OPPLAYER4", Size: 2, New: newOp[OpPlayer](4)},
+	protocol.Prot{Opcode: OpcodeOpPlayerT, Name: "OPPLAYERT", Size: 4, New: protocol.New[OpPlayerT]},
+	protocol.Prot{Opcode: OpcodeOpPlayerU, Name: "OPPLAYERU", Size: 8, New: protocol.New[OpPlayerU]},
+
+	protocol.Prot{Opcode: OpcodeOpHeld1, Name: "OPHELD1", Size: 6, New: newOp[OpHeld](1)},
+	protocol.Prot{Opcode: OpcodeOpHeld2, Name: "OPHELD2", Size: 6, New: newOp[OpHeld](2)},
+	protocol.Prot{Opcode: OpcodeOpHeld3, Name: "OPHELD3", Size: 6, New: newOp[OpHeld](3)},
+	protocol.Prot{Opcode: OpcodeOpHeld4, Name: "OPHELD4", Size: 6, New: newOp[OpHeld](4)},
+	protocol.Prot{Opcode: OpcodeOpHeld5, Name: "OPHELD5", Size: 6, New: newOp[OpHeld](5)},
+	protocol.Prot{Opcode: OpcodeOpHeldT, Name: "OPHELDT", Size: 8, New: protocol.New[OpHeldT]},
+	protocol.Prot{Opcode: OpcodeOpHeldU, Name: "OPHELDU", Size: 12, New: protocol.New[OpHeldU]},
+
+	protocol.Prot{Opcode: OpcodeInvButton1, Name: "INV_BUTTON1", Size: 6, New: newOp[InvButton](1)},
+	protocol.Prot{Opcode: OpcodeInvButton2, Name: "INV_BUTTON2", Size: 6, New: newOp[InvButton](2)},
+	protocol.Prot{Opcode: OpcodeInvButton3, Name: "INV_BUTTON3", Size: 6, New: newOp[InvButton](3)},
+	protocol.Prot{Opcode: OpcodeInvButton4, Name: "INV_BUTTON4", Size: 6, New: newOp[InvButton](4)},
+	protocol.Prot{Opcode: OpcodeInvButton5, Name: "INV_BUTTON5", Size: 6, New: newOp[InvButton](5)},
+	protocol.Prot{Opcode: OpcodeInvButtonD, Name: "INV_BUTTOND", Size: 6, New: protocol.New[InvButtonD]},
+
+	protocol.Prot{Opcode: OpcodeIfButton, Name: "IF_BUTTON", Size: 2, New: protocol.New[IfButton]},
+	protocol.Prot{Opcode: OpcodeResumePauseButton, Name: "RESUME_PAUSEBUTTON", Size: 2, New: protocol.New[ResumePauseButton]},
+	protocol.Prot{Opcode: OpcodeCloseModal, Name: "CLOSE_MODAL", Size: 0, New: protocol.New[CloseModal]},
+	protocol.Prot{Opcode: OpcodeResumePCountDialog, Name: "RESUME_P_COUNTDIALOG", Size: 4, New: protocol.New[ResumePCountDialog]},
+	protocol.Prot{Opcode: OpcodeTutorialClickSide, Name: "TUTORIAL_CLICKSIDE", Size: 1, New: protocol.New[TutorialClickSide]},
+
+	protocol.Prot{Opcode: OpcodeMoveOpClick, Name: "MOVE_OPCLICK", Size: protocol.VarByte, New: newOp[Move](OpcodeMoveOpClick)},
+	protocol.Prot{Opcode: OpcodeMoveMinimapClick, Name: "MOVE_MINIMAPCLICK", Size: protocol.VarByte, New: newOp[Move](OpcodeMoveMinimapClick)},
+	protocol.Prot{Opcode: OpcodeMoveGameClick, Name: "MOVE_GAMECLICK", Size: protocol.VarByte, New: newOp[Move](OpcodeMoveGameClick)},
+
+	protocol.Prot{Opcode: OpcodeReportAbuse, Name: "REPORT_ABUSE", Size: 10, New: protocol.New[ReportAbuse]},
+	protocol.Prot{Opcode: OpcodeIgnoreListDel, Name: "IGNORELIST_DEL", Size: 8, New: protocol.New[IgnoreListDel]},
+	protocol.Prot{Opcode: OpcodeIgnoreListAdd, Name: "IGNORELIST_ADD", Size: 8, New: protocol.New[IgnoreListAdd]},
+	protocol.Prot{Opcode: OpcodeChatSetMode, Name: "CHAT_SETMODE", Size: 3, New: protocol.New[ChatSetMode]},
+	protocol.Prot{Opcode: OpcodeMessagePrivate, Name: "MESSAGE_PRIVATE", Size: protocol.VarByte, New: protocol.New[MessagePrivate]},
+	protocol.Prot{Opcode: OpcodeFriendListDel, Name: "FRIENDLIST_DEL", Size: 8, New: protocol.New[FriendListDel]},
+	protocol.Prot{Opcode: OpcodeFriendListAdd, Name: "FRIENDLIST_ADD", Size: 8, New: protocol.New[FriendListAdd]},
+	protocol.Prot{Opcode: OpcodeMessagePublic, Name: "MESSAGE_PUBLIC", Size: protocol.VarByte, New: protocol.New[MessagePublic]},
+
+	protocol.Prot{Opcode: OpcodeIdkSaveDesign, Name: "IDK_SAVEDESIGN", Size: 13, New: protocol.New[IdkSaveDesign]},
+	protocol.Prot{Opcode: OpcodeClientCheat, Name: "CLIENT_CHEAT", Size: protocol.VarByte, New: protocol.New[ClientCheat]},
+)
+
+// anticheat returns the prot of an anticheat message.
+func anticheat(opcode uint8, name string, size protocol.Size) protocol.Prot {
+	return protocol.Prot{Opcode: opcode, Name: name, Size: size, New: func() protocol.Message {
+		return &Anticheat{Op: opcode}
+	}}
+}
+
+// An opMessage is a message type shared by several opcodes, told apart
+// by its Op field.
+type opMessage[T any] interface {
+	*T
+	protocol.Message
+	setOp(op int)
+}
+
+// newOp returns a [protocol.Prot] New func for one op of a message type
+// shared by several opcodes.
+func newOp[T any, PT opMessage[T]](op int) func() protocol.Message {
+	return func() protocol.Message {
+		m := PT(new(T))
+		m.setOp(op)
+		return m
+	}
+}
@@ -0,0 +1,161 @@
+package clientprot
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/server/protocol"
+)
+
+var messageTests = []protocol.Message{
+	&RebuildGetMaps{Requests: []MapRequest{{Type: MapLand, X: 50, Z: 50}, {Type: MapLoc, X: 50, Z: 51}}},
+	&NoTimeout{},
+	&IdleTimer{},
+	&EventTracking{Data: []byte{1, 2, 3, 4, 5}},
+
+	&Anticheat{Op: OpcodeAnticheatOpLogic1, Data: []byte{1, 2, 3, 4}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic2, Data: []byte{1, 2, 3, 4}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic3, Data: []byte{1, 2, 3}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic4, Data: []byte{1, 2}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic5, Data: []byte{}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic7, Data: []byte{1, 2, 3}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic8, Data: []byte{1, 2}},
+	&Anticheat{Op: OpcodeAnticheatOpLogic9, Data: []byte{}},
+	&Anticheat{Op: OpcodeAnticheatCycleLogic1, Data: []byte{1}},
+	&Anticheat{Op: OpcodeAnticheatCycleLogic2, Data: []byte{}},
+	&Anticheat{Op: OpcodeAnticheatCycleLogic3, Data: []byte{1, 2, 3}},
+	&Anticheat{Op: OpcodeAnticheatCycleLogic4, Data: []byte{1, 2, 3, 4}},
+	&Anticheat{Op: OpcodeAnticheatCycleLogic5, Data: []byte{}},
+
+	&OpObj{Op: 1, X: 3222, Z: 3218, Obj: 995},
+	&OpObj{Op: 2, X: 3222, Z: 3218, Obj: 995},
+	&OpObj{Op: 3, X: 3222, Z: 3218, Obj: 995},
+	&OpObj{Op: 4, X: 3222, Z: 3218, Obj: 995},
+	&OpObj{Op: 5, X: 3222, Z: 3218, Obj: 995},
+	&OpObjT{X: 3222, Z: 3218, Obj: 995, Component: 1175},
+	&OpObjU{X: 3222, Z: 3218, Obj: 1511, Use: UseObj{Obj: 590, Slot: 3, Component: 3214}},
+
+	&OpNpc{Op: 1, Nid: 17},
+	&OpNpc{Op: 2, Nid: 17},
+	&OpNpc{Op: 3, Nid: 17},
+	&OpNpc{Op: 4, Nid: 17},
+	&OpNpc{Op: 5, Nid: 17},
+	&OpNpcT{Nid: 17, Component: 1152},
+	&OpNpcU{Nid: 17, Use: UseObj{Obj: 1925, Slot: 0, Component: 3214}},
+
+	&OpLoc{Op: 1, X: 3208, Z: 3220, Loc: 1276},
+	&OpLoc{Op: 2, X: 3208, Z: 3220, Loc: 1276},
+	&OpLoc{Op: 3, X: 3208, Z: 3220, Loc: 1276},
+	&OpLoc{Op: 4, X: 3208, Z: 3220, Loc: 1276},
+	&OpLoc{Op: 5, X: 3208, Z: 3220, Loc: 1276},
+	&OpLocT{X: 3208, Z: 3220, Loc: 1276, Component: 1175},
+	&OpLocU{X: 3208, Z: 3220, Loc: 2644, Use: UseObj{Obj: 1737, Slot: 5, Component: 3214}},
+
+	&OpPlayer{Op: 1, Pid: 2},
+	&OpPlayer{Op: 2, Pid: 2},
+	&OpPlayer{Op: 3, Pid: 2},
+	&OpPlayer{Op: 4, Pid: 2},
+	&OpPlayerT{Pid: 2, Component: 1152},
+	&OpPlayerU{Pid: 2, Use: UseObj{Obj: 995, Slot: 0, Component: 3214}},
+
+	&OpHeld{Op: 1, Obj: 1351, Slot: 2, Component: 3214},
+	&OpHeld{Op: 2, Obj: 1351, Slot: 2, Component: 3214},
+	&OpHeld{Op: 3, Obj: 1351, Slot: 2, Component: 3214},
+	&OpHeld{Op: 4, Obj: 1351, Slot: 2, Component: 3214},
+	&OpHeld{Op: 5, Obj: 1351, Slot: 2, Component: 3214},
+	&OpHeldT{Obj: 1351, Slot: 2, Component: 3214, Spell: 1162},
+	&OpHeldU{Obj: 590, Slot: 1, Component: 3214, Use: UseObj{Obj: 1511, Slot: 2, Component: 3214}},
+
+	&InvButton{Op: 1, Obj: 995, Slot: 0, Component: 3900},
+	&InvButton{Op: 2, Obj: 995, Slot: 0, Component: 3900},
+	&InvButton{Op: 3, Obj: 995, Slot: 0, Component: 3900},
+	&InvButton{Op: 4, Obj: 995, Slot: 0, Component: 3900},
+	&InvButton{Op: 5, Obj: 995, Slot: 0, Component: 3900},
+	&InvButtonD{Component: 3214, Slot: 0, TargetSlot: 27},
+
+	&IfButton{Component: 2458},
+	&ResumePauseButton{Component: 4885},
+	&CloseModal{},
+	&ResumePCountDialog{Count: 1000},
+	&TutorialClickSide{Tab: 3},
+
+	&Move{Op: OpcodeMoveOpClick, StartX: 3222, StartZ: 3218, Steps: []MoveStep{{DX: 1, DZ: 1}}},
+	&Move{Op: OpcodeMoveMinimapClick, StartX: 3222, StartZ: 3218, Run: true, Steps: []MoveStep{{DX: -5, DZ: 3}, {DX: -9, DZ: 3}}, Trailer: [MinimapTrailerSize]byte{1, 2, 3, 4, 57, 6, 7, 89, 9, 10, 11, 12, 13, 63}},
+	&Move{Op: OpcodeMoveGameClick, StartX: 3222, StartZ: 3218, Steps: []MoveStep{}},
+
+	&ReportAbuse{Name: 0x12345678, Rule: 6, Mute: true},
+	&IgnoreListDel{Name: 0x1234},
+	&IgnoreListAdd{Name: 0x1234},
+	&ChatSetMode{Public: 1, Private: 2, Trade: 0},
+	&MessagePrivate{To: 0x12345678, Message: "Hi there"},
+	&FriendListDel{Name: 0x5678},
+	&FriendListAdd{Name: 0x5678},
+	&MessagePublic{Colour: 9, Effect: 1, Message: "Hi there"},
+
+	&IdkSaveDesign{Gender: 1, Kits: [7]uint8{45, 255, 56, 61, 67, 70, 79}, Colours: [5]uint8{1, 2, 3, 4, 0}},
+	&ClientCheat{Command: "tele 0,50,50,22,22"},
+}
+
+func TestMessages(t *testing.T) {
+	seed := [4]uint32{11, 22, 33, 44}
+	client := io.NewClientIsaacPair(seed)
+	server := io.NewServerIsaacPair(seed)
+
+	var conn bytes.Buffer
+	w := protocol.NewWriter(&conn, client.Encoder, Registry)
+	r := protocol.NewReader(&conn, server.Decoder, Registry)
+
+	for _, want := range messageTests {
+		prot, _ := Registry.Lookup(want.Opcode())
+		t.Run(prot.Name, func(t *testing.T) {
+			if err := w.WriteMessage(want); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadMessage() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tested := make(map[uint8]bool)
+	for _, m := range messageTests {
+		tested[m.Opcode()] = true
+	}
+	for _, prot := range Registry.Prots() {
+		if !tested[prot.Opcode] {
+			t.Errorf("%s (%d) has no test message", prot.Name, prot.Opcode)
+		}
+		if got := prot.New().Opcode(); got != prot.Opcode {
+			t.Errorf("%s New().Opcode() = %d, want %d", prot.Name, got, prot.Opcode)
+		}
+	}
+}
+
+func TestMove_Decode(t *testing.T) {
+	tests := []struct {
+		name   string
+		opcode uint8
+		body   []byte
+	}{
+		{name: "odd steps", opcode: OpcodeMoveGameClick, body: []byte{0x0c, 0x96, 0x0c, 0x92, 0, 1}},
+		{name: "missing trailer", opcode: OpcodeMoveMinimapClick, body: []byte{0x0c, 0x96, 0x0c, 0x92, 0, 1, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Registry.Decode(tt.opcode, tt.body); err == nil {
+				t.Error("Decode() error = nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package clientprot
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// Map file types requested by REBUILD_GETMAPS.
+const (
+	MapLand = 0
+	MapLoc  = 1
+)
+
+// A MapRequest names a map square file the client does not have.
+type MapRequest struct {
+	Type uint8 // MapLand or MapLoc
+	X    uint8
+	Z    uint8
+}
+
+// RebuildGetMaps requests the map square files the client is missing
+// after REBUILD_NORMAL.
+type RebuildGetMaps struct {
+	Requests []MapRequest
+}
+
+func (m *RebuildGetMaps) Opcode() uint8 { return OpcodeRebuildGetMaps }
+
+func (m *RebuildGetMaps) Encode(p *packet.Packet) {
+	for _, req := range m.Requests {
+		p.P1(req.Type)
+		p.P1(req.X)
+		p.P1(req.Z)
+	}
+}
+
+func (m *RebuildGetMaps) Decode(p *packet.Packet) error {
+	if p.Len()%3 != 0 {
+		return fmt.Errorf("clientprot: map request list is %d bytes", p.Len())
+	}
+	m.Requests = make([]MapRequest, p.Len()/3)
+	for i := range m.Requests {
+		m.Requests[i] = MapRequest{Type: p.G1(), X: p.G1(), Z: p.G1()}
+	}
+	return nil
+}
+
+// NoTimeout is sent when the client has nothing else to send, to keep
+// the connection open.
+type NoTimeout struct{}
+
+func (m *NoTimeout) Opcode() uint8                 { return OpcodeNoTimeout }
+func (m *NoTimeout) Encode(p *packet.Packet)       {}
+func (m *NoTimeout) Decode(p *packet.Packet) error { return nil }
+
+// IdleTimer is sent when the player has been idle for some time.
+type IdleTimer struct{}
+
+func (m *IdleTimer) Opcode() uint8                 { return OpcodeIdleTimer }
+func (m *IdleTimer) Encode(p *packet.Packet)       {}
+func (m *IdleTimer) Decode(p *packet.Packet) error { return nil }
+
+// EventTracking holds mouse and keyboard input tracked since
+// ENABLE_TRACKING.
+type EventTracking struct {
+	Data []byte
+}
+
+func (m *EventTracking) Opcode() uint8 { return OpcodeEventTracking }
+
+func (m *EventTracking) Encode(p *packet.Packet) {
+	p.PData(m.Data, len(m.Data))
+}
+
+func (m *EventTracking) Decode(p *packet.Packet) error {
+	m.Data = slices.Clone(p.Bytes())
+	p.Pos = len(p.Buf)
+	return nil
+}
+
+// Anticheat is one of the messages the client sends at random with
+// meaningless contents. A client that sends them wrongly is modified.
+type Anticheat struct {
+	Op   uint8
+	Data []byte
+}
+
+func (m *Anticheat) Opcode() uint8 { return m.Op }
+
+func (m *Anticheat) Encode(p *packet.Packet) {
+	p.PData(m.Data, len(m.Data))
+}
+
+func (m *Anticheat) Decode(p *packet.Packet) error {
+	m.Data = slices.Clone(p.Bytes())
+	p.Pos = len(p.Buf)
+	return nil
+}
+
+// IfButton clicks a button component.
+type IfButton struct {
+	Component uint16
+}
+
+func (m *IfButton) Opcode() uint8 { return OpcodeIfButton }
+
+func (m *IfButton) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *IfButton) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// ResumePauseButton clicks "Click here to continue" in a dialog.
+type ResumePauseButton struct {
+	Component uint16
+}
+
+func (m *ResumePauseButton) Opcode() uint8 { return OpcodeResumePauseButton }
+
+func (m *ResumePauseButton) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *ResumePauseButton) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// CloseModal is sent when the client closes its modal interfaces.
+type CloseModal struct{}
+
+func (m *CloseModal) Opcode() uint8                 { return OpcodeCloseModal }
+func (m *CloseModal) Encode(p *packet.Packet)       {}
+func (m *CloseModal) Decode(p *packet.Packet) error { return nil }
+
+// ResumePCountDialog answers P_COUNTDIALOG.
+type ResumePCountDialog struct {
+	Count int32
+}
+
+func (m *ResumePCountDialog) Opcode() uint8 { return OpcodeResumePCountDialog }
+
+func (m *ResumePCountDialog) Encode(p *packet.Packet) {
+	p.P4(uint32(m.Count))
+}
+
+func (m *ResumePCountDialog) Decode(p *packet.Packet) error {
+	m.Count = int32(p.G4())
+	return nil
+}
+
+// TutorialClickSide is sent when a flashing side panel tab is clicked.
+type TutorialClickSide struct {
+	Tab uint8
+}
+
+func (m *TutorialClickSide) Opcode() uint8 { return OpcodeTutorialClickSide }
+
+func (m *TutorialClickSide) Encode(p *packet.Packet) {
+	p.P1(m.Tab)
+}
+
+func (m *TutorialClickSide) Decode(p *packet.Packet) error {
+	m.Tab = p.G1()
+	return nil
+}
+
+// IdkSaveDesign saves the player's appearance.
+type IdkSaveDesign struct {
+	Gender  uint8 // 0 male, 1 female
+	Kits    [7]uint8
+	Colours [5]uint8
+}
+
+func (m *IdkSaveDesign) Opcode() uint8 { return OpcodeIdkSaveDesign }
+
+func (m *IdkSaveDesign) Encode(p *packet.Packet) {
+	p.P1(m.Gender)
+	p.PData(m.Kits[:], len(m.Kits))
+	p.PData(m.Colours[:], len(m.Colours))
+}
+
+func (m *IdkSaveDesign) Decode(p *packet.Packet) error {
+	m.Gender = p.G1()
+	p.GData(m.Kits[:], len(m.Kits))
+	p.GData(m.Colours[:], len(m.Colours))
+	return nil
+}
+
+// ClientCheat runs a :: command.
+type ClientCheat struct {
+	Command string
+}
+
+func (m *ClientCheat) Opcode() uint8 { return OpcodeClientCheat }
+
+func (m *ClientCheat) Encode(p *packet.Packet) {
+	p.PJStrLF(m.Command)
+}
+
+func (m *ClientCheat) Decode(p *packet.Packet) error {
+	var err error
+	m.Command, err = p.TryGJStrLF()
+	return err
+}
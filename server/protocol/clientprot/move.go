@@ -0,0 +1,68 @@
+package clientprot
+
+import (
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// MinimapTrailerSize is the size of the extra data sent after the steps
+// of MOVE_MINIMAPCLICK.
+const MinimapTrailerSize = 14
+
+// A MoveStep is a waypoint of a path, relative to its start.
+type MoveStep struct {
+	DX int8
+	DZ int8
+}
+
+// Move asks to walk a path the client found (MOVE_GAMECLICK,
+// MOVE_MINIMAPCLICK and MOVE_OPCLICK).
+type Move struct {
+	Op     uint8 // OpcodeMoveGameClick, OpcodeMoveMinimapClick or OpcodeMoveOpClick
+	StartX uint16
+	StartZ uint16
+	Run    bool // ctrl was held
+	Steps  []MoveStep
+	// Trailer holds the camera and minimap state the client sends after
+	// a minimap click; it is zero for the other opcodes.
+	Trailer [MinimapTrailerSize]byte
+}
+
+func (m *Move) setOp(op int)  { m.Op = uint8(op) }
+func (m *Move) Opcode() uint8 { return m.Op }
+
+func (m *Move) Encode(p *packet.Packet) {
+	p.P2(m.StartX)
+	p.P2(m.StartZ)
+	p.PBool(m.Run)
+	for _, step := range m.Steps {
+		p.P1(uint8(step.DX))
+		p.P1(uint8(step.DZ))
+	}
+	if m.Op == OpcodeMoveMinimapClick {
+		p.PData(m.Trailer[:], MinimapTrailerSize)
+	}
+}
+
+func (m *Move) Decode(p *packet.Packet) error {
+	m.StartX = p.G2()
+	m.StartZ = p.G2()
+	m.Run = p.GBool()
+
+	n := p.Len()
+	if m.Op == OpcodeMoveMinimapClick {
+		n -= MinimapTrailerSize
+	}
+	if n < 0 || n%2 != 0 {
+		return fmt.Errorf("clientprot: move has %d bytes of steps", n)
+	}
+	m.Steps = make([]MoveStep, n/2)
+	for i := range m.Steps {
+		m.Steps[i] = MoveStep{DX: p.G1B(), DZ: p.G1B()}
+	}
+	if m.Op == OpcodeMoveMinimapClick {
+		p.GData(m.Trailer[:], MinimapTrailerSize)
+	}
+	return nil
+}
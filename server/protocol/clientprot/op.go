@@ -0,0 +1,415 @@
+package clientprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A UseObj is the held obj used on a target, as in OPOBJU.
+type UseObj struct {
+	Obj       uint16
+	Slot      uint16
+	Component uint16
+}
+
+func pUseObj(p *packet.Packet, u UseObj) {
+	p.P2(u.Obj)
+	p.P2(u.Slot)
+	p.P2(u.Component)
+}
+
+func gUseObj(p *packet.Packet) UseObj {
+	return UseObj{Obj: p.G2(), Slot: p.G2(), Component: p.G2()}
+}
+
+var opObjOpcodes = [...]uint8{OpcodeOpObj1, OpcodeOpObj2, OpcodeOpObj3, OpcodeOpObj4, OpcodeOpObj5}
+
+// OpObj selects an option of an obj stack on the ground (OPOBJ1-5).
+type OpObj struct {
+	Op  int // 1-5
+	X   uint16
+	Z   uint16
+	Obj uint16
+}
+
+func (m *OpObj) setOp(op int)  { m.Op = op }
+func (m *OpObj) Opcode() uint8 { return opObjOpcodes[m.Op-1] }
+
+func (m *OpObj) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Obj)
+}
+
+func (m *OpObj) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Obj = p.G2()
+	return nil
+}
+
+// OpObjT casts a spell on an obj stack on the ground.
+type OpObjT struct {
+	X         uint16
+	Z         uint16
+	Obj       uint16
+	Component uint16 // spell
+}
+
+func (m *OpObjT) Opcode() uint8 { return OpcodeOpObjT }
+
+func (m *OpObjT) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Obj)
+	p.P2(m.Component)
+}
+
+func (m *OpObjT) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Obj = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// OpObjU uses a held obj on an obj stack on the ground.
+type OpObjU struct {
+	X   uint16
+	Z   uint16
+	Obj uint16
+	Use UseObj
+}
+
+func (m *OpObjU) Opcode() uint8 { return OpcodeOpObjU }
+
+func (m *OpObjU) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Obj)
+	pUseObj(p, m.Use)
+}
+
+func (m *OpObjU) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Obj = p.G2()
+	m.Use = gUseObj(p)
+	return nil
+}
+
+var opNpcOpcodes = [...]uint8{OpcodeOpNpc1, OpcodeOpNpc2, OpcodeOpNpc3, OpcodeOpNpc4, OpcodeOpNpc5}
+
+// OpNpc selects an option of an npc (OPNPC1-5).
+type OpNpc struct {
+	Op  int // 1-5
+	Nid uint16
+}
+
+func (m *OpNpc) setOp(op int)  { m.Op = op }
+func (m *OpNpc) Opcode() uint8 { return opNpcOpcodes[m.Op-1] }
+
+func (m *OpNpc) Encode(p *packet.Packet) {
+	p.P2(m.Nid)
+}
+
+func (m *OpNpc) Decode(p *packet.Packet) error {
+	m.Nid = p.G2()
+	return nil
+}
+
+// OpNpcT casts a spell on an npc.
+type OpNpcT struct {
+	Nid       uint16
+	Component uint16 // spell
+}
+
+func (m *OpNpcT) Opcode() uint8 { return OpcodeOpNpcT }
+
+func (m *OpNpcT) Encode(p *packet.Packet) {
+	p.P2(m.Nid)
+	p.P2(m.Component)
+}
+
+func (m *OpNpcT) Decode(p *packet.Packet) error {
+	m.Nid = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// OpNpcU uses a held obj on an npc.
+type OpNpcU struct {
+	Nid uint16
+	Use UseObj
+}
+
+func (m *OpNpcU) Opcode() uint8 { return OpcodeOpNpcU }
+
+func (m *OpNpcU) Encode(p *packet.Packet) {
+	p.P2(m.Nid)
+	pUseObj(p, m.Use)
+}
+
+func (m *OpNpcU) Decode(p *packet.Packet) error {
+	m.Nid = p.G2()
+	m.Use = gUseObj(p)
+	return nil
+}
+
+var opLocOpcodes = [...]uint8{OpcodeOpLoc1, OpcodeOpLoc2, OpcodeOpLoc3, OpcodeOpLoc4, OpcodeOpLoc5}
+
+// OpLoc selects an option of a loc (OPLOC1-5).
+type OpLoc struct {
+	Op  int // 1-5
+	X   uint16
+	Z   uint16
+	Loc uint16
+}
+
+func (m *OpLoc) setOp(op int)  { m.Op = op }
+func (m *OpLoc) Opcode() uint8 { return opLocOpcodes[m.Op-1] }
+
+func (m *OpLoc) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Loc)
+}
+
+func (m *OpLoc) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Loc = p.G2()
+	return nil
+}
+
+// OpLocT casts a spell on a loc.
+type OpLocT struct {
+	X         uint16
+	Z         uint16
+	Loc       uint16
+	Component uint16 // spell
+}
+
+func (m *OpLocT) Opcode() uint8 { return OpcodeOpLocT }
+
+func (m *OpLocT) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Loc)
+	p.P2(m.Component)
+}
+
+func (m *OpLocT) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Loc = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// OpLocU uses a held obj on a loc.
+type OpLocU struct {
+	X   uint16
+	Z   uint16
+	Loc uint16
+	Use UseObj
+}
+
+func (m *OpLocU) Opcode() uint8 { return OpcodeOpLocU }
+
+func (m *OpLocU) Encode(p *packet.Packet) {
+	p.P2(m.X)
+	p.P2(m.Z)
+	p.P2(m.Loc)
+	pUseObj(p, m.Use)
+}
+
+func (m *OpLocU) Decode(p *packet.Packet) error {
+	m.X = p.G2()
+	m.Z = p.G2()
+	m.Loc = p.G2()
+	m.Use = gUseObj(p)
+	return nil
+}
+
+var opPlayerOpcodes = [...]uint8{OpcodeOpPlayer1, OpcodeOpPlayer2, OpcodeOpPlayer3, OpcodeOpPlayer4}
+
+// OpPlayer selects an option of another player (OPPLAYER1-4).
+type OpPlayer struct {
+	Op  int // 1-4
+	Pid uint16
+}
+
+func (m *OpPlayer) setOp(op int)  { m.Op = op }
+func (m *OpPlayer) Opcode() uint8 { return opPlayerOpcodes[m.Op-1] }
+
+func (m *OpPlayer) Encode(p *packet.Packet) {
+	p.P2(m.Pid)
+}
+
+func (m *OpPlayer) Decode(p *packet.Packet) error {
+	m.Pid = p.G2()
+	return nil
+}
+
+// OpPlayerT casts a spell on another player.
+type OpPlayerT struct {
+	Pid       uint16
+	Component uint16 // spell
+}
+
+func (m *OpPlayerT) Opcode() uint8 { return OpcodeOpPlayerT }
+
+func (m *OpPlayerT) Encode(p *packet.Packet) {
+	p.P2(m.Pid)
+	p.P2(m.Component)
+}
+
+func (m *OpPlayerT) Decode(p *packet.Packet) error {
+	m.Pid = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// OpPlayerU uses a held obj on another player.
+type OpPlayerU struct {
+	Pid uint16
+	Use UseObj
+}
+
+func (m *OpPlayerU) Opcode() uint8 { return OpcodeOpPlayerU }
+
+func (m *OpPlayerU) Encode(p *packet.Packet) {
+	p.P2(m.Pid)
+	pUseObj(p, m.Use)
+}
+
+func (m *OpPlayerU) Decode(p *packet.Packet) error {
+	m.Pid = p.G2()
+	m.Use = gUseObj(p)
+	return nil
+}
+
+var opHeldOpcodes = [...]uint8{OpcodeOpHeld1, OpcodeOpHeld2, OpcodeOpHeld3, OpcodeOpHeld4, OpcodeOpHeld5}
+
+// OpHeld selects an option of an obj in an inventory (OPHELD1-5).
+type OpHeld struct {
+	Op        int // 1-5
+	Obj       uint16
+	Slot      uint16
+	Component uint16
+}
+
+func (m *OpHeld) setOp(op int)  { m.Op = op }
+func (m *OpHeld) Opcode() uint8 { return opHeldOpcodes[m.Op-1] }
+
+func (m *OpHeld) Encode(p *packet.Packet) {
+	p.P2(m.Obj)
+	p.P2(m.Slot)
+	p.P2(m.Component)
+}
+
+func (m *OpHeld) Decode(p *packet.Packet) error {
+	m.Obj = p.G2()
+	m.Slot = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// OpHeldT casts a spell on an obj in an inventory.
+type OpHeldT struct {
+	Obj       uint16
+	Slot      uint16
+	Component uint16
+	Spell     uint16
+}
+
+func (m *OpHeldT) Opcode() uint8 { return OpcodeOpHeldT }
+
+func (m *OpHeldT) Encode(p *packet.Packet) {
+	p.P2(m.Obj)
+	p.P2(m.Slot)
+	p.P2(m.Component)
+	p.P2(m.Spell)
+}
+
+func (m *OpHeldT) Decode(p *packet.Packet) error {
+	m.Obj = p.G2()
+	m.Slot = p.G2()
+	m.Component = p.G2()
+	m.Spell = p.G2()
+	return nil
+}
+
+// OpHeldU uses a held obj on another.
+type OpHeldU struct {
+	Obj       uint16
+	Slot      uint16
+	Component uint16
+	Use       UseObj
+}
+
+func (m *OpHeldU) Opcode() uint8 { return OpcodeOpHeldU }
+
+func (m *OpHeldU) Encode(p *packet.Packet) {
+	p.P2(m.Obj)
+	p.P2(m.Slot)
+	p.P2(m.Component)
+	pUseObj(p, m.Use)
+}
+
+func (m *OpHeldU) Decode(p *packet.Packet) error {
+	m.Obj = p.G2()
+	m.Slot = p.G2()
+	m.Component = p.G2()
+	m.Use = gUseObj(p)
+	return nil
+}
+
+var invButtonOpcodes = [...]uint8{OpcodeInvButton1, OpcodeInvButton2, OpcodeInvButton3, OpcodeInvButton4, OpcodeInvButton5}
+
+// InvButton selects an option of an obj in an interface inventory, such
+// as a shop or bank (INV_BUTTON1-5).
+type InvButton struct {
+	Op        int // 1-5
+	Obj       uint16
+	Slot      uint16
+	Component uint16
+}
+
+func (m *InvButton) setOp(op int)  { m.Op = op }
+func (m *InvButton) Opcode() uint8 { return invButtonOpcodes[m.Op-1] }
+
+func (m *InvButton) Encode(p *packet.Packet) {
+	p.P2(m.Obj)
+	p.P2(m.Slot)
+	p.P2(m.Component)
+}
+
+func (m *InvButton) Decode(p *packet.Packet) error {
+	m.Obj = p.G2()
+	m.Slot = p.G2()
+	m.Component = p.G2()
+	return nil
+}
+
+// InvButtonD drags an obj to another slot of an inventory.
+type InvButtonD struct {
+	Component  uint16
+	Slot       uint16
+	TargetSlot uint16
+}
+
+func (m *InvButtonD) Opcode() uint8 { return OpcodeInvButtonD }
+
+func (m *InvButtonD) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Slot)
+	p.P2(m.TargetSlot)
+}
+
+func (m *InvButtonD) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Slot = p.G2()
+	m.TargetSlot = p.G2()
+	return nil
+}
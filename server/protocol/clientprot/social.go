@@ -0,0 +1,157 @@
+package clientprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/jagex2/wordpack"
+)
+
+// ReportAbuse reports a player for breaking a rule.
+type ReportAbuse struct {
+	Name uint64 // base37 encoded
+	Rule uint8
+	Mute bool // staff only: mute the player for 48 hours
+}
+
+func (m *ReportAbuse) Opcode() uint8 { return OpcodeReportAbuse }
+
+func (m *ReportAbuse) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+	p.P1(m.Rule)
+	p.PBool(m.Mute)
+}
+
+func (m *ReportAbuse) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	m.Rule = p.G1()
+	m.Mute = p.GBool()
+	return nil
+}
+
+// IgnoreListDel removes a name from the ignore list.
+type IgnoreListDel struct {
+	Name uint64 // base37 encoded
+}
+
+func (m *IgnoreListDel) Opcode() uint8 { return OpcodeIgnoreListDel }
+
+func (m *IgnoreListDel) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+}
+
+func (m *IgnoreListDel) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	return nil
+}
+
+// IgnoreListAdd adds a name to the ignore list.
+type IgnoreListAdd struct {
+	Name uint64 // base37 encoded
+}
+
+func (m *IgnoreListAdd) Opcode() uint8 { return OpcodeIgnoreListAdd }
+
+func (m *IgnoreListAdd) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+}
+
+func (m *IgnoreListAdd) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	return nil
+}
+
+// ChatSetMode sets the chat modes shown below the chat box.
+type ChatSetMode struct {
+	Public  uint8
+	Private uint8
+	Trade   uint8
+}
+
+func (m *ChatSetMode) Opcode() uint8 { return OpcodeChatSetMode }
+
+func (m *ChatSetMode) Encode(p *packet.Packet) {
+	p.P1(m.Public)
+	p.P1(m.Private)
+	p.P1(m.Trade)
+}
+
+func (m *ChatSetMode) Decode(p *packet.Packet) error {
+	m.Public = p.G1()
+	m.Private = p.G1()
+	m.Trade = p.G1()
+	return nil
+}
+
+// MessagePrivate sends a private message.
+type MessagePrivate struct {
+	To      uint64 // base37 encoded
+	Message string
+}
+
+func (m *MessagePrivate) Opcode() uint8 { return OpcodeMessagePrivate }
+
+func (m *MessagePrivate) Encode(p *packet.Packet) {
+	p.P8(m.To)
+	wordpack.Pack(p, m.Message)
+}
+
+func (m *MessagePrivate) Decode(p *packet.Packet) error {
+	m.To = p.G8()
+	var err error
+	m.Message, err = wordpack.Unpack(p, p.Len())
+	return err
+}
+
+// FriendListDel removes a name from the friend list.
+type FriendListDel struct {
+	Name uint64 // base37 encoded
+}
+
+func (m *FriendListDel) Opcode() uint8 { return OpcodeFriendListDel }
+
+func (m *FriendListDel) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+}
+
+func (m *FriendListDel) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	return nil
+}
+
+// FriendListAdd adds a name to the friend list.
+type FriendListAdd struct {
+	Name uint64 // base37 encoded
+}
+
+func (m *FriendListAdd) Opcode() uint8 { return OpcodeFriendListAdd }
+
+func (m *FriendListAdd) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+}
+
+func (m *FriendListAdd) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	return nil
+}
+
+// MessagePublic says a message in public chat.
+type MessagePublic struct {
+	Colour  uint8
+	Effect  uint8
+	Message string
+}
+
+func (m *MessagePublic) Opcode() uint8 { return OpcodeMessagePublic }
+
+func (m *MessagePublic) Encode(p *packet.Packet) {
+	p.P1(m.Colour)
+	p.P1(m.Effect)
+	wordpack.Pack(p, m.Message)
+}
+
+func (m *MessagePublic) Decode(p *packet.Packet) error {
+	m.Colour = p.G1()
+	m.Effect = p.G1()
+	var err error
+	m.Message, err = wordpack.Unpack(p, p.Len())
+	return err
+}
@@ -0,0 +1,153 @@
+package protocol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	stdio "io"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A Reader reads messages from a connection.
+type Reader struct {
+	r        *bufio.Reader
+	cipher   *io.Isaac
+	registry *Registry
+}
+
+// NewReader returns a reader of the messages in registry. Opcodes are
+// decrypted with cipher; if it is nil, they are read as-is.
+func NewReader(r stdio.Reader, cipher *io.Isaac, registry *Registry) *Reader {
+	return &Reader{r: bufio.NewReader(r), cipher: cipher, registry: registry}
+}
+
+// ReadFrame reads the next opcode and message body.
+func (r *Reader) ReadFrame() (uint8, []byte, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	opcode := b
+	if r.cipher != nil {
+		opcode = r.cipher.DecryptOpcode(b)
+	}
+
+	prot, ok := r.registry.Lookup(opcode)
+	if !ok {
+		return opcode, nil, fmt.Errorf("%w %d", ErrUnknownOpcode, opcode)
+	}
+
+	size := int(prot.Size)
+	switch prot.Size {
+	case VarByte:
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return opcode, nil, unexpectedEOF(err)
+		}
+		size = int(b)
+	case VarShort:
+		var header [2]byte
+		if _, err := stdio.ReadFull(r.r, header[:]); err != nil {
+			return opcode, nil, unexpectedEOF(err)
+		}
+		size = int(header[0])<<8 | int(header[1])
+	}
+
+	body := make([]byte, size)
+	if _, err := stdio.ReadFull(r.r, body); err != nil {
+		return opcode, nil, unexpectedEOF(err)
+	}
+	return opcode, body, nil
+}
+
+// ReadMessage reads and decodes the next message.
+func (r *Reader) ReadMessage() (Message, error) {
+	opcode, body, err := r.ReadFrame()
+	if err != nil {
+		return nil, err
+	}
+	return r.registry.Decode(opcode, body)
+}
+
+// unexpectedEOF reports a connection closed part way through a message.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, stdio.EOF) {
+		return stdio.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// A Writer buffers messages for a connection until Flush is called.
+type Writer struct {
+	w        stdio.Writer
+	cipher   *io.Isaac
+	registry *Registry
+	buf      *packet.Packet
+}
+
+// NewWriter returns a writer of the messages in registry. Opcodes are
+// encrypted with cipher; if it is nil, they are written as-is.
+func NewWriter(w stdio.Writer, cipher *io.Isaac, registry *Registry) *Writer {
+	return &Writer{w: w, cipher: cipher, registry: registry, buf: packet.NewPacket(nil)}
+}
+
+// WriteMessage encodes m and adds it to the buffer. It returns an error,
+// leaving the buffer unchanged, if m's opcode is not registered or its
+// body does not fit the opcode's size.
+func (w *Writer) WriteMessage(m Encoder) error {
+	prot, ok := w.registry.Lookup(m.Opcode())
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownOpcode, m.Opcode())
+	}
+
+	start := len(w.buf.Buf)
+	w.buf.P1(prot.Opcode)
+	switch prot.Size {
+	case VarByte:
+		w.buf.P1(0)
+	case VarShort:
+		w.buf.P2(0)
+	}
+
+	bodyStart := len(w.buf.Buf)
+	m.Encode(w.buf)
+	size := len(w.buf.Buf) - bodyStart
+
+	switch {
+	case prot.Size >= 0 && size != int(prot.Size):
+		w.buf.Truncate(start)
+		return fmt.Errorf("protocol: %s body is %d bytes, want %d", prot.Name, size, prot.Size)
+	case size > prot.Size.Max():
+		w.buf.Truncate(start)
+		return fmt.Errorf("protocol: %s body is %d bytes, more than %s allows", prot.Name, size, prot.Size)
+	}
+
+	switch prot.Size {
+	case VarByte:
+		w.buf.PSize1(size)
+	case VarShort:
+		w.buf.PSize2(size)
+	}
+
+	if w.cipher != nil {
+		w.buf.Buf[start] = w.cipher.EncryptOpcode(prot.Opcode)
+	}
+	return nil
+}
+
+// Buffered returns the number of bytes waiting to be flushed.
+func (w *Writer) Buffered() int {
+	return len(w.buf.Buf)
+}
+
+// Flush writes the buffered messages to the connection.
+func (w *Writer) Flush() error {
+	if len(w.buf.Buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(w.buf.Buf)
+	w.buf.Reset()
+	return err
+}
@@ -0,0 +1,193 @@
+// Package protocol frames the messages of a game connection.
+//
+// Each message is sent as an opcode, encrypted with the sender's isaac
+// cipher, followed by the message body. Most messages have a fixed size
+// known to both ends; the rest are preceded by their size in one byte
+// ([VarByte]) or two ([VarShort]). The opcodes, sizes and message types
+// of each direction are held in a [Registry]; see the clientprot and
+// serverprot packages.
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A Size is the size of a message body in bytes, or one of [VarByte]
+// and [VarShort] for messages preceded by their size.
+type Size int
+
+const (
+	VarByte  Size = -1 // body preceded by a 1 byte size
+	VarShort Size = -2 // body preceded by a 2 byte size
+)
+
+// Max returns the largest body a message of this size can have.
+func (s Size) Max() int {
+	switch s {
+	case VarByte:
+		return 0xFF
+	case VarShort:
+		return 0xFFFF
+	default:
+		return int(s)
+	}
+}
+
+func (s Size) String() string {
+	switch s {
+	case VarByte:
+		return "var byte"
+	case VarShort:
+		return "var short"
+	default:
+		return fmt.Sprintf("%d", int(s))
+	}
+}
+
+// An Encoder is a message that can be sent.
+type Encoder interface {
+	// Opcode returns the opcode the message is sent with.
+	Opcode() uint8
+	// Encode puts the message body into p.
+	Encode(p *packet.Packet)
+}
+
+// A Decoder is a message that can be received.
+type Decoder interface {
+	// Decode gets the message body from p. It may panic with a
+	// *packet.ReadError if p is too short; [Registry.Decode]
+	// recovers that and returns it as an error.
+	Decode(p *packet.Packet) error
+}
+
+// A Message can be both sent and received.
+type Message interface {
+	Encoder
+	Decoder
+}
+
+// A Raw is a message without a registered type. Its body is kept as-is.
+type Raw struct {
+	Op   uint8
+	Data []byte
+}
+
+func (m *Raw) Opcode() uint8 { return m.Op }
+
+func (m *Raw) Encode(p *packet.Packet) {
+	p.PData(m.Data, len(m.Data))
+}
+
+func (m *Raw) Decode(p *packet.Packet) error {
+	m.Data = slices.Clone(p.Bytes())
+	p.Pos = len(p.Buf)
+	return nil
+}
+
+// A Prot describes one opcode.
+type Prot struct {
+	Opcode uint8
+	Name   string
+	Size   Size
+	// New returns an empty message of the opcode's type, or nil if the
+	// opcode has no message type and is decoded as a [Raw].
+	New func() Message
+}
+
+// A Registry holds the opcodes of one direction of a connection.
+type Registry struct {
+	prots [256]*Prot
+}
+
+// NewRegistry returns a registry holding prots.
+// It panics if two of them share an opcode.
+func NewRegistry(prots ...Prot) *Registry {
+	r := &Registry{}
+	for _, prot := range prots {
+		r.Register(prot)
+	}
+	return r
+}
+
+// Register adds prot to the registry.
+// It panics if its opcode is already registered.
+func (r *Registry) Register(prot Prot) {
+	if existing := r.prots[prot.Opcode]; existing != nil {
+		panic(fmt.Sprintf("protocol: opcode %d registered as both %s and %s", prot.Opcode, existing.Name, prot.Name))
+	}
+	r.prots[prot.Opcode] = &prot
+}
+
+// Lookup returns the registered opcode.
+func (r *Registry) Lookup(opcode uint8) (*Prot, bool) {
+	prot := r.prots[opcode]
+	return prot, prot != nil
+}
+
+// Prots returns every registered opcode, in opcode order.
+func (r *Registry) Prots() []*Prot {
+	var prots []*Prot
+	for _, prot := range r.prots {
+		if prot != nil {
+			prots = append(prots, prot)
+		}
+	}
+	return prots
+}
+
+// ErrUnknownOpcode is returned for an opcode missing from a [Registry].
+// The size of its body cannot be known, so the connection cannot be read
+// any further.
+var ErrUnknownOpcode = errors.New("protocol: unknown opcode")
+
+// ErrTrailingData is returned when a message body is longer than its
+// decoder reads.
+var ErrTrailingData = errors.New("protocol: message has trailing data")
+
+// Decode decodes body as a message of the given opcode. Opcodes without
+// a message type are returned as a [*Raw].
+func (r *Registry) Decode(opcode uint8, body []byte) (Message, error) {
+	prot, ok := r.Lookup(opcode)
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnknownOpcode, opcode)
+	}
+
+	var m Message
+	if prot.New != nil {
+		m = prot.New()
+	} else {
+		m = &Raw{Op: opcode}
+	}
+
+	if err := Decode(m, packet.NewPacket(body)); err != nil {
+		return nil, fmt.Errorf("protocol: decoding %s: %w", prot.Name, err)
+	}
+	return m, nil
+}
+
+// Decode decodes all of p into m, returning an error if p is too short
+// or too long for the message.
+func Decode(m Decoder, p *packet.Packet) (err error) {
+	defer packet.Recover(&err)
+
+	if err := m.Decode(p); err != nil {
+		return err
+	}
+	if p.Len() != 0 {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// New returns a new, empty *T. It is meant for [Prot.New], for example
+// New: protocol.New[MessageGame].
+func New[T any, PT interface {
+	*T
+	Message
+}]() Message {
+	return PT(new(T))
+}
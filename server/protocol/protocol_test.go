@@ -0,0 +1,194 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	stdio "io"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+type testMessage struct {
+	A uint16
+	B uint8
+}
+
+func (m *testMessage) Opcode() uint8 { return 1 }
+
+func (m *testMessage) Encode(p *packet.Packet) {
+	p.P2(m.A)
+	p.P1(m.B)
+}
+
+func (m *testMessage) Decode(p *packet.Packet) error {
+	m.A = p.G2()
+	m.B = p.G1()
+	return nil
+}
+
+func TestNewRegistry_duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("NewRegistry() did not panic on a duplicate opcode")
+		}
+	}()
+	NewRegistry(Prot{Opcode: 1, Name: "A"}, Prot{Opcode: 1, Name: "B"})
+}
+
+func TestRegistry_Decode(t *testing.T) {
+	r := NewRegistry(
+		Prot{Opcode: 1, Name: "TEST", Size: 3, New: New[testMessage]},
+		Prot{Opcode: 2, Name: "VARBYTE", Size: VarByte},
+		Prot{Opcode: 4, Name: "EMPTY", Size: 0},
+	)
+	tests := []struct {
+		name    string
+		opcode  uint8
+		body    []byte
+		want    Message
+		wantErr error
+	}{
+		{name: "typed", opcode: 1, body: []byte{0x12, 0x34, 5}, want: &testMessage{A: 0x1234, B: 5}},
+		{name: "raw", opcode: 2, body: []byte{1, 2}, want: &Raw{Op: 2, Data: []byte{1, 2}}},
+		{name: "raw empty", opcode: 4, body: nil, want: &Raw{Op: 4, Data: []byte{}}},
+		{name: "unknown", opcode: 9, wantErr: ErrUnknownOpcode},
+		{name: "truncated", opcode: 1, body: []byte{0x12, 0x34}, wantErr: stdio.ErrUnexpectedEOF},
+		{name: "trailing", opcode: 1, body: []byte{0x12, 0x34, 5, 6}, wantErr: ErrTrailingData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Decode(tt.opcode, tt.body)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if raw, ok := got.(*Raw); ok && raw.Data == nil {
+				raw.Data = []byte{}
+			}
+			if !equalMessage(got, tt.want) {
+				t.Errorf("Decode() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalMessage(a, b Message) bool {
+	pa, pb := packet.NewPacket(nil), packet.NewPacket(nil)
+	a.Encode(pa)
+	b.Encode(pb)
+	return a.Opcode() == b.Opcode() && bytes.Equal(pa.Buf, pb.Buf)
+}
+
+func TestFraming(t *testing.T) {
+	seed := [4]uint32{1, 2, 3, 4}
+	server := io.NewServerIsaacPair(seed)
+	client := io.NewClientIsaacPair(seed)
+	r := NewRegistry(
+		Prot{Opcode: 1, Name: "TEST", Size: 3, New: New[testMessage]},
+		Prot{Opcode: 2, Name: "VARBYTE", Size: VarByte},
+		Prot{Opcode: 3, Name: "VARSHORT", Size: VarShort},
+		Prot{Opcode: 4, Name: "EMPTY", Size: 0},
+	)
+
+	msgs := []Message{
+		&testMessage{A: 7, B: 8},
+		&Raw{Op: 2, Data: bytes.Repeat([]byte{0xaa}, 255)},
+		&Raw{Op: 3, Data: bytes.Repeat([]byte{0xbb}, 300)},
+		&Raw{Op: 4, Data: []byte{}},
+		&testMessage{A: 0xffff, B: 0},
+	}
+
+	var conn bytes.Buffer
+	w := NewWriter(&conn, server.Encoder, r)
+	for _, m := range msgs {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("WriteMessage(%#v) error = %v", m, err)
+		}
+	}
+	if want := 4 + 2 + 255 + 3 + 300 + 1 + 4; w.Buffered() != want {
+		t.Errorf("Buffered() = %d, want %d", w.Buffered(), want)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Buffered() != 0 {
+		t.Errorf("Buffered() after Flush = %d", w.Buffered())
+	}
+
+	rd := NewReader(&conn, client.Decoder, r)
+	for _, want := range msgs {
+		got, err := rd.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage() error = %v", err)
+		}
+		if !equalMessage(got, want) {
+			t.Errorf("ReadMessage() = %#v, want %#v", got, want)
+		}
+	}
+	if _, err := rd.ReadMessage(); err != stdio.EOF {
+		t.Errorf("ReadMessage() at end error = %v, want EOF", err)
+	}
+}
+
+func TestWriter_WriteMessage_invalid(t *testing.T) {
+	r := NewRegistry(
+		Prot{Opcode: 1, Name: "TEST", Size: 3, New: New[testMessage]},
+		Prot{Opcode: 2, Name: "VARBYTE", Size: VarByte},
+		Prot{Opcode: 3, Name: "VARSHORT", Size: VarShort},
+	)
+	tests := []struct {
+		name string
+		m    Message
+	}{
+		{name: "unknown opcode", m: &Raw{Op: 9}},
+		{name: "wrong fixed size", m: &Raw{Op: 1, Data: []byte{1, 2}}},
+		{name: "var byte too long", m: &Raw{Op: 2, Data: make([]byte, 256)}},
+		{name: "var short too long", m: &Raw{Op: 3, Data: make([]byte, 0x10000)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conn bytes.Buffer
+			w := NewWriter(&conn, nil, r)
+			if err := w.WriteMessage(&testMessage{}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteMessage(tt.m); err == nil {
+				t.Error("WriteMessage() error = nil")
+			}
+			if w.Buffered() != 4 {
+				t.Errorf("Buffered() = %d, want the earlier message only", w.Buffered())
+			}
+		})
+	}
+}
+
+func TestReader_ReadFrame_truncated(t *testing.T) {
+	prots := NewRegistry(
+		Prot{Opcode: 1, Name: "TEST", Size: 3, New: New[testMessage]},
+		Prot{Opcode: 2, Name: "VARBYTE", Size: VarByte},
+		Prot{Opcode: 3, Name: "VARSHORT", Size: VarShort},
+	)
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr error
+	}{
+		{name: "unknown opcode", input: []byte{9}, wantErr: ErrUnknownOpcode},
+		{name: "fixed body", input: []byte{1, 0}, wantErr: stdio.ErrUnexpectedEOF},
+		{name: "var byte size", input: []byte{2}, wantErr: stdio.ErrUnexpectedEOF},
+		{name: "var short size", input: []byte{3, 0}, wantErr: stdio.ErrUnexpectedEOF},
+		{name: "var short body", input: []byte{3, 0, 2, 1}, wantErr: stdio.ErrUnexpectedEOF},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(tt.input), nil, prots)
+			if _, _, err := r.ReadFrame(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ReadFrame() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
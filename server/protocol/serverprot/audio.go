@@ -0,0 +1,78 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// SynthSound plays a sound effect.
+type SynthSound struct {
+	Sound uint16
+	Loops uint8
+	Delay uint16
+}
+
+func (m *SynthSound) Opcode() uint8 { return OpcodeSynthSound }
+
+func (m *SynthSound) Encode(p *packet.Packet) {
+	p.P2(m.Sound)
+	p.P1(m.Loops)
+	p.P2(m.Delay)
+}
+
+func (m *SynthSound) Decode(p *packet.Packet) error {
+	m.Sound = p.G2()
+	m.Loops = p.G1()
+	m.Delay = p.G2()
+	return nil
+}
+
+// MidiSong plays a song from the midi archive. The client fetches it if
+// its cached copy does not match Crc and Length.
+type MidiSong struct {
+	Name   string
+	Crc    uint32
+	Length uint32
+}
+
+func (m *MidiSong) Opcode() uint8 { return OpcodeMidiSong }
+
+func (m *MidiSong) Encode(p *packet.Packet) {
+	p.PJStrLF(m.Name)
+	p.P4(m.Crc)
+	p.P4(m.Length)
+}
+
+func (m *MidiSong) Decode(p *packet.Packet) error {
+	var err error
+	if m.Name, err = p.TryGJStrLF(); err != nil {
+		return err
+	}
+	m.Crc = p.G4()
+	m.Length = p.G4()
+	return nil
+}
+
+// MidiJingle plays a short song, then resumes the current one.
+type MidiJingle struct {
+	Name   string
+	Crc    uint32
+	Length uint32
+}
+
+func (m *MidiJingle) Opcode() uint8 { return OpcodeMidiJingle }
+
+func (m *MidiJingle) Encode(p *packet.Packet) {
+	p.PJStrLF(m.Name)
+	p.P4(m.Crc)
+	p.P4(m.Length)
+}
+
+func (m *MidiJingle) Decode(p *packet.Packet) error {
+	var err error
+	if m.Name, err = p.TryGJStrLF(); err != nil {
+		return err
+	}
+	m.Crc = p.G4()
+	m.Length = p.G4()
+	return nil
+}
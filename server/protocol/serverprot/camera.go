@@ -0,0 +1,93 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// CamMoveTo moves the camera to a tile in the loaded map.
+type CamMoveTo struct {
+	X          uint8 // local tile
+	Z          uint8 // local tile
+	Height     uint16
+	Speed      uint8
+	Multiplier uint8 // 0 moves the camera at a constant speed
+}
+
+func (m *CamMoveTo) Opcode() uint8 { return OpcodeCamMoveTo }
+
+func (m *CamMoveTo) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+	p.P2(m.Height)
+	p.P1(m.Speed)
+	p.P1(m.Multiplier)
+}
+
+func (m *CamMoveTo) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	m.Height = p.G2()
+	m.Speed = p.G1()
+	m.Multiplier = p.G1()
+	return nil
+}
+
+// CamShake shakes the camera along one axis.
+type CamShake struct {
+	Type      uint8 // 0-4: x, y, z, yaw, pitch
+	Jitter    uint8
+	Amplitude uint8
+	Frequency uint8
+}
+
+func (m *CamShake) Opcode() uint8 { return OpcodeCamShake }
+
+func (m *CamShake) Encode(p *packet.Packet) {
+	p.P1(m.Type)
+	p.P1(m.Jitter)
+	p.P1(m.Amplitude)
+	p.P1(m.Frequency)
+}
+
+func (m *CamShake) Decode(p *packet.Packet) error {
+	m.Type = p.G1()
+	m.Jitter = p.G1()
+	m.Amplitude = p.G1()
+	m.Frequency = p.G1()
+	return nil
+}
+
+// CamLookAt turns the camera to face a tile in the loaded map.
+type CamLookAt struct {
+	X          uint8 // local tile
+	Z          uint8 // local tile
+	Height     uint16
+	Speed      uint8
+	Multiplier uint8
+}
+
+func (m *CamLookAt) Opcode() uint8 { return OpcodeCamLookAt }
+
+func (m *CamLookAt) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+	p.P2(m.Height)
+	p.P1(m.Speed)
+	p.P1(m.Multiplier)
+}
+
+func (m *CamLookAt) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	m.Height = p.G2()
+	m.Speed = p.G1()
+	m.Multiplier = p.G1()
+	return nil
+}
+
+// CamReset returns the camera to following the local player.
+type CamReset struct{}
+
+func (m *CamReset) Opcode() uint8                 { return OpcodeCamReset }
+func (m *CamReset) Encode(p *packet.Packet)       {}
+func (m *CamReset) Decode(p *packet.Packet) error { return nil }
@@ -0,0 +1,322 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// IfOpenChatModal opens an interface in the chat box.
+type IfOpenChatModal struct {
+	Component uint16
+}
+
+func (m *IfOpenChatModal) Opcode() uint8 { return OpcodeIfOpenChatModal }
+
+func (m *IfOpenChatModal) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *IfOpenChatModal) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// IfOpenMainSideModal opens an interface in the main viewport and
+// another in the side panel.
+type IfOpenMainSideModal struct {
+	Main uint16
+	Side uint16
+}
+
+func (m *IfOpenMainSideModal) Opcode() uint8 { return OpcodeIfOpenMainSideModal }
+
+func (m *IfOpenMainSideModal) Encode(p *packet.Packet) {
+	p.P2(m.Main)
+	p.P2(m.Side)
+}
+
+func (m *IfOpenMainSideModal) Decode(p *packet.Packet) error {
+	m.Main = p.G2()
+	m.Side = p.G2()
+	return nil
+}
+
+// IfClose closes any open modal interfaces.
+type IfClose struct{}
+
+func (m *IfClose) Opcode() uint8                 { return OpcodeIfClose }
+func (m *IfClose) Encode(p *packet.Packet)       {}
+func (m *IfClose) Decode(p *packet.Packet) error { return nil }
+
+// IfOpenSideOverlay sets the interface shown in a side panel tab.
+type IfOpenSideOverlay struct {
+	Component uint16 // 0xFFFF clears the tab
+	Tab       uint8
+}
+
+func (m *IfOpenSideOverlay) Opcode() uint8 { return OpcodeIfOpenSideOverlay }
+
+func (m *IfOpenSideOverlay) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P1(m.Tab)
+}
+
+func (m *IfOpenSideOverlay) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Tab = p.G1()
+	return nil
+}
+
+// IfOpenMainModal opens an interface in the main viewport.
+type IfOpenMainModal struct {
+	Component uint16
+}
+
+func (m *IfOpenMainModal) Opcode() uint8 { return OpcodeIfOpenMainModal }
+
+func (m *IfOpenMainModal) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *IfOpenMainModal) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// IfOpenSideModal opens an interface over the side panel.
+type IfOpenSideModal struct {
+	Component uint16
+}
+
+func (m *IfOpenSideModal) Opcode() uint8 { return OpcodeIfOpenSideModal }
+
+func (m *IfOpenSideModal) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *IfOpenSideModal) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// IfSetColour sets the colour of a component, as 15-bit RGB.
+type IfSetColour struct {
+	Component uint16
+	Colour    uint16
+}
+
+func (m *IfSetColour) Opcode() uint8 { return OpcodeIfSetColour }
+
+func (m *IfSetColour) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Colour)
+}
+
+func (m *IfSetColour) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Colour = p.G2()
+	return nil
+}
+
+// IfSetHide shows or hides a component.
+type IfSetHide struct {
+	Component uint16
+	Hidden    bool
+}
+
+func (m *IfSetHide) Opcode() uint8 { return OpcodeIfSetHide }
+
+func (m *IfSetHide) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.PBool(m.Hidden)
+}
+
+func (m *IfSetHide) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Hidden = p.GBool()
+	return nil
+}
+
+// IfSetObject shows an obj model in a component.
+type IfSetObject struct {
+	Component uint16
+	Obj       uint16
+	Zoom      uint16
+}
+
+func (m *IfSetObject) Opcode() uint8 { return OpcodeIfSetObject }
+
+func (m *IfSetObject) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Obj)
+	p.P2(m.Zoom)
+}
+
+func (m *IfSetObject) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Obj = p.G2()
+	m.Zoom = p.G2()
+	return nil
+}
+
+// IfShowSide selects a side panel tab.
+type IfShowSide struct {
+	Tab uint8
+}
+
+func (m *IfShowSide) Opcode() uint8 { return OpcodeIfShowSide }
+
+func (m *IfShowSide) Encode(p *packet.Packet) {
+	p.P1(m.Tab)
+}
+
+func (m *IfShowSide) Decode(p *packet.Packet) error {
+	m.Tab = p.G1()
+	return nil
+}
+
+// IfSetModel shows a model in a component.
+type IfSetModel struct {
+	Component uint16
+	Model     uint16
+}
+
+func (m *IfSetModel) Opcode() uint8 { return OpcodeIfSetModel }
+
+func (m *IfSetModel) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Model)
+}
+
+func (m *IfSetModel) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Model = p.G2()
+	return nil
+}
+
+// IfSetAnim plays an animation on the model of a component.
+type IfSetAnim struct {
+	Component uint16
+	Seq       uint16
+}
+
+func (m *IfSetAnim) Opcode() uint8 { return OpcodeIfSetAnim }
+
+func (m *IfSetAnim) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Seq)
+}
+
+func (m *IfSetAnim) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Seq = p.G2()
+	return nil
+}
+
+// IfSetPlayerHead shows the local player's head in a component.
+type IfSetPlayerHead struct {
+	Component uint16
+}
+
+func (m *IfSetPlayerHead) Opcode() uint8 { return OpcodeIfSetPlayerHead }
+
+func (m *IfSetPlayerHead) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *IfSetPlayerHead) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// IfSetText sets the text of a component.
+type IfSetText struct {
+	Component uint16
+	Text      string
+}
+
+func (m *IfSetText) Opcode() uint8 { return OpcodeIfSetText }
+
+func (m *IfSetText) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.PJStrLF(m.Text)
+}
+
+func (m *IfSetText) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	var err error
+	m.Text, err = p.TryGJStrLF()
+	return err
+}
+
+// IfSetNpcHead shows an npc's head in a component.
+type IfSetNpcHead struct {
+	Component uint16
+	Npc       uint16
+}
+
+func (m *IfSetNpcHead) Opcode() uint8 { return OpcodeIfSetNpcHead }
+
+func (m *IfSetNpcHead) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(m.Npc)
+}
+
+func (m *IfSetNpcHead) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Npc = p.G2()
+	return nil
+}
+
+// IfSetPosition moves a component relative to its layer.
+type IfSetPosition struct {
+	Component uint16
+	X         int16
+	Y         int16
+}
+
+func (m *IfSetPosition) Opcode() uint8 { return OpcodeIfSetPosition }
+
+func (m *IfSetPosition) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P2(uint16(m.X))
+	p.P2(uint16(m.Y))
+}
+
+func (m *IfSetPosition) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.X = p.G2S()
+	m.Y = p.G2S()
+	return nil
+}
+
+// TutFlash flashes a side panel tab.
+type TutFlash struct {
+	Tab uint8
+}
+
+func (m *TutFlash) Opcode() uint8 { return OpcodeTutFlash }
+
+func (m *TutFlash) Encode(p *packet.Packet) {
+	p.P1(m.Tab)
+}
+
+func (m *TutFlash) Decode(p *packet.Packet) error {
+	m.Tab = p.G1()
+	return nil
+}
+
+// TutOpen opens a tutorial interface in the chat box.
+type TutOpen struct {
+	Component uint16
+}
+
+func (m *TutOpen) Opcode() uint8 { return OpcodeTutOpen }
+
+func (m *TutOpen) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *TutOpen) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
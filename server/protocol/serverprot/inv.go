@@ -0,0 +1,103 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// An InvObj is the contents of an inventory slot.
+type InvObj struct {
+	Obj   int // -1 for an empty slot
+	Count uint32
+}
+
+func pInvObj(p *packet.Packet, o InvObj) {
+	p.P2(uint16(o.Obj + 1))
+	if o.Count >= 255 {
+		p.P1(255)
+		p.P4(o.Count)
+	} else {
+		p.P1(uint8(o.Count))
+	}
+}
+
+func gInvObj(p *packet.Packet) InvObj {
+	o := InvObj{Obj: int(p.G2()) - 1}
+	o.Count = uint32(p.G1())
+	if o.Count == 255 {
+		o.Count = p.G4()
+	}
+	return o
+}
+
+// UpdateInvStopTransmit clears an inventory component.
+type UpdateInvStopTransmit struct {
+	Component uint16
+}
+
+func (m *UpdateInvStopTransmit) Opcode() uint8 { return OpcodeUpdateInvStopTransmit }
+
+func (m *UpdateInvStopTransmit) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+}
+
+func (m *UpdateInvStopTransmit) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	return nil
+}
+
+// UpdateInvFull sets every slot of an inventory component.
+type UpdateInvFull struct {
+	Component uint16
+	Objs      []InvObj // at most 255
+}
+
+func (m *UpdateInvFull) Opcode() uint8 { return OpcodeUpdateInvFull }
+
+func (m *UpdateInvFull) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	p.P1(uint8(len(m.Objs)))
+	for _, o := range m.Objs {
+		pInvObj(p, o)
+	}
+}
+
+func (m *UpdateInvFull) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Objs = make([]InvObj, p.G1())
+	for i := range m.Objs {
+		m.Objs[i] = gInvObj(p)
+	}
+	return nil
+}
+
+// An InvSlot is a changed inventory slot.
+type InvSlot struct {
+	Slot uint8
+	InvObj
+}
+
+// UpdateInvPartial sets some slots of an inventory component.
+type UpdateInvPartial struct {
+	Component uint16
+	Slots     []InvSlot
+}
+
+func (m *UpdateInvPartial) Opcode() uint8 { return OpcodeUpdateInvPartial }
+
+func (m *UpdateInvPartial) Encode(p *packet.Packet) {
+	p.P2(m.Component)
+	for _, s := range m.Slots {
+		p.P1(s.Slot)
+		pInvObj(p, s.InvObj)
+	}
+}
+
+func (m *UpdateInvPartial) Decode(p *packet.Packet) error {
+	m.Component = p.G2()
+	m.Slots = nil
+	for p.Len() > 0 {
+		slot := p.G1()
+		m.Slots = append(m.Slots, InvSlot{Slot: slot, InvObj: gInvObj(p)})
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package serverprot
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// A RebuildMapSquare is a map square the client needs, with the crcs of
+// its cached files.
+type RebuildMapSquare struct {
+	X       uint8
+	Z       uint8
+	LandCrc uint32
+	LocCrc  uint32
+}
+
+// RebuildNormal loads the 13x13 zones around a zone. The client requests
+// any map square files it does not have with a matching crc with
+// REBUILD_GETMAPS, which are sent with DATA_LAND and DATA_LOC.
+type RebuildNormal struct {
+	ZoneX      uint16
+	ZoneZ      uint16
+	MapSquares []RebuildMapSquare
+}
+
+func (m *RebuildNormal) Opcode() uint8 { return OpcodeRebuildNormal }
+
+func (m *RebuildNormal) Encode(p *packet.Packet) {
+	p.P2(m.ZoneX)
+	p.P2(m.ZoneZ)
+	for _, sq := range m.MapSquares {
+		p.P1(sq.X)
+		p.P1(sq.Z)
+		p.P4(sq.LandCrc)
+		p.P4(sq.LocCrc)
+	}
+}
+
+func (m *RebuildNormal) Decode(p *packet.Packet) error {
+	m.ZoneX = p.G2()
+	m.ZoneZ = p.G2()
+	m.MapSquares = nil
+	for p.Len() > 0 {
+		var sq RebuildMapSquare
+		sq.X = p.G1()
+		sq.Z = p.G1()
+		sq.LandCrc = p.G4()
+		sq.LocCrc = p.G4()
+		m.MapSquares = append(m.MapSquares, sq)
+	}
+	return nil
+}
+
+// MapChunkSize is the most map file data sent in one DATA_LAND or
+// DATA_LOC.
+const MapChunkSize = 1000
+
+// A MapChunk is part of a map square file the client asked for with
+// REBUILD_GETMAPS. The file is sent as it is stored in the client's
+// maps directory, in chunks the client copies into a buffer of Length
+// bytes at Offset.
+type MapChunk struct {
+	X      uint8
+	Z      uint8
+	Offset uint16
+	Length uint16 // length of the whole file
+	Data   []byte
+}
+
+func (c *MapChunk) encode(p *packet.Packet) {
+	p.P1(c.X)
+	p.P1(c.Z)
+	p.P2(c.Offset)
+	p.P2(c.Length)
+	p.PData(c.Data, len(c.Data))
+}
+
+func (c *MapChunk) decode(p *packet.Packet) error {
+	c.X = p.G1()
+	c.Z = p.G1()
+	c.Offset = p.G2()
+	c.Length = p.G2()
+	if int(c.Offset)+p.Len() > int(c.Length) {
+		return fmt.Errorf("serverprot: %d bytes at %d overrun a %d byte map file", p.Len(), c.Offset, c.Length)
+	}
+	c.Data = slices.Clone(p.Bytes())
+	p.Pos = len(p.Buf)
+	return nil
+}
+
+// MapChunks splits the map file data of the map square at x, z into
+// chunks of at most MapChunkSize bytes.
+func MapChunks(x uint8, z uint8, data []byte) ([]MapChunk, error) {
+	if len(data) > 0xFFFF {
+		return nil, fmt.Errorf("serverprot: map file %d_%d is too large (%d bytes)", x, z, len(data))
+	}
+	var chunks []MapChunk
+	for off := 0; off < len(data); off += MapChunkSize {
+		chunks = append(chunks, MapChunk{
+			X:      x,
+			Z:      z,
+			Offset: uint16(off),
+			Length: uint16(len(data)),
+			Data:   data[off:min(off+MapChunkSize, len(data))],
+		})
+	}
+	return chunks, nil
+}
+
+// DataLand sends part of a map square's landscape file.
+type DataLand struct {
+	MapChunk
+}
+
+func (m *DataLand) Opcode() uint8                 { return OpcodeDataLand }
+func (m *DataLand) Encode(p *packet.Packet)       { m.encode(p) }
+func (m *DataLand) Decode(p *packet.Packet) error { return m.decode(p) }
+
+// DataLandDone tells the client a landscape file has been sent in full.
+type DataLandDone struct {
+	X uint8
+	Z uint8
+}
+
+func (m *DataLandDone) Opcode() uint8 { return OpcodeDataLandDone }
+
+func (m *DataLandDone) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+}
+
+func (m *DataLandDone) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	return nil
+}
+
+// DataLoc sends part of a map square's loc file.
+type DataLoc struct {
+	MapChunk
+}
+
+func (m *DataLoc) Opcode() uint8                 { return OpcodeDataLoc }
+func (m *DataLoc) Encode(p *packet.Packet)       { m.encode(p) }
+func (m *DataLoc) Decode(p *packet.Packet) error { return m.decode(p) }
+
+// DataLocDone tells the client a loc file has been sent in full.
+type DataLocDone struct {
+	X uint8
+	Z uint8
+}
+
+func (m *DataLocDone) Opcode() uint8 { return OpcodeDataLocDone }
+
+func (m *DataLocDone) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+}
+
+func (m *DataLocDone) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	return nil
+}
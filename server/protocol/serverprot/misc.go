@@ -0,0 +1,227 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// FinishTracking asks the client to send the input it has tracked.
+type FinishTracking struct{}
+
+func (m *FinishTracking) Opcode() uint8                 { return OpcodeFinishTracking }
+func (m *FinishTracking) Encode(p *packet.Packet)       {}
+func (m *FinishTracking) Decode(p *packet.Packet) error { return nil }
+
+// EnableTracking starts tracking the client's mouse and keyboard input.
+type EnableTracking struct{}
+
+func (m *EnableTracking) Opcode() uint8                 { return OpcodeEnableTracking }
+func (m *EnableTracking) Encode(p *packet.Packet)       {}
+func (m *EnableTracking) Decode(p *packet.Packet) error { return nil }
+
+// UnsetMapFlag removes the minimap destination flag.
+type UnsetMapFlag struct{}
+
+func (m *UnsetMapFlag) Opcode() uint8                 { return OpcodeUnsetMapFlag }
+func (m *UnsetMapFlag) Encode(p *packet.Packet)       {}
+func (m *UnsetMapFlag) Decode(p *packet.Packet) error { return nil }
+
+// UpdateRunWeight sets the weight shown in the equipment tab.
+type UpdateRunWeight struct {
+	Weight int16 // kg
+}
+
+func (m *UpdateRunWeight) Opcode() uint8 { return OpcodeUpdateRunWeight }
+
+func (m *UpdateRunWeight) Encode(p *packet.Packet) {
+	p.P2(uint16(m.Weight))
+}
+
+func (m *UpdateRunWeight) Decode(p *packet.Packet) error {
+	m.Weight = p.G2S()
+	return nil
+}
+
+// Hint arrow types.
+const (
+	HintArrowNpc    = 1
+	HintArrowTile   = 2 // 2-6 point at the centre or an edge of a tile
+	HintArrowPlayer = 10
+)
+
+// HintArrow points an arrow at an npc, player or tile.
+type HintArrow struct {
+	Type   uint8
+	Entity uint16 // npc or player index, for HintArrowNpc and HintArrowPlayer
+	X      uint16 // absolute tile, for tile arrows
+	Z      uint16 // absolute tile, for tile arrows
+	Height uint8  // for tile arrows
+}
+
+func (m *HintArrow) Opcode() uint8 { return OpcodeHintArrow }
+
+func (m *HintArrow) isTile() bool {
+	return m.Type >= HintArrowTile && m.Type <= 6
+}
+
+func (m *HintArrow) Encode(p *packet.Packet) {
+	p.P1(m.Type)
+	switch {
+	case m.isTile():
+		p.P2(m.X)
+		p.P2(m.Z)
+		p.P1(m.Height)
+	case m.Type == HintArrowNpc || m.Type == HintArrowPlayer:
+		p.P2(m.Entity)
+		p.PData(make([]byte, 3), 3)
+	default:
+		p.PData(make([]byte, 5), 5)
+	}
+}
+
+func (m *HintArrow) Decode(p *packet.Packet) error {
+	m.Type = p.G1()
+	switch {
+	case m.isTile():
+		m.X = p.G2()
+		m.Z = p.G2()
+		m.Height = p.G1()
+	case m.Type == HintArrowNpc || m.Type == HintArrowPlayer:
+		m.Entity = p.G2()
+		return p.TryGData(make([]byte, 3), 3)
+	default:
+		return p.TryGData(make([]byte, 5), 5)
+	}
+	return nil
+}
+
+// UpdateRebootTimer starts the system update countdown.
+type UpdateRebootTimer struct {
+	Ticks uint16 // game ticks until the reboot
+}
+
+func (m *UpdateRebootTimer) Opcode() uint8 { return OpcodeUpdateRebootTimer }
+
+func (m *UpdateRebootTimer) Encode(p *packet.Packet) {
+	p.P2(m.Ticks)
+}
+
+func (m *UpdateRebootTimer) Decode(p *packet.Packet) error {
+	m.Ticks = p.G2()
+	return nil
+}
+
+// UpdateStat sets the experience and current level of a stat.
+type UpdateStat struct {
+	Stat  uint8
+	Exp   uint32
+	Level uint8
+}
+
+func (m *UpdateStat) Opcode() uint8 { return OpcodeUpdateStat }
+
+func (m *UpdateStat) Encode(p *packet.Packet) {
+	p.P1(m.Stat)
+	p.P4(m.Exp)
+	p.P1(m.Level)
+}
+
+func (m *UpdateStat) Decode(p *packet.Packet) error {
+	m.Stat = p.G1()
+	m.Exp = p.G4()
+	m.Level = p.G1()
+	return nil
+}
+
+// UpdateRunEnergy sets the run energy percentage.
+type UpdateRunEnergy struct {
+	Energy uint8
+}
+
+func (m *UpdateRunEnergy) Opcode() uint8 { return OpcodeUpdateRunEnergy }
+
+func (m *UpdateRunEnergy) Encode(p *packet.Packet) {
+	p.P1(m.Energy)
+}
+
+func (m *UpdateRunEnergy) Decode(p *packet.Packet) error {
+	m.Energy = p.G1()
+	return nil
+}
+
+// ResetAnims stops every animation in the loaded map.
+type ResetAnims struct{}
+
+func (m *ResetAnims) Opcode() uint8                 { return OpcodeResetAnims }
+func (m *ResetAnims) Encode(p *packet.Packet)       {}
+func (m *ResetAnims) Decode(p *packet.Packet) error { return nil }
+
+// UpdateUID192 sets the local player's index.
+type UpdateUID192 struct {
+	Pid uint16
+}
+
+func (m *UpdateUID192) Opcode() uint8 { return OpcodeUpdateUID192 }
+
+func (m *UpdateUID192) Encode(p *packet.Packet) {
+	p.P2(m.Pid)
+}
+
+func (m *UpdateUID192) Decode(p *packet.Packet) error {
+	m.Pid = p.G2()
+	return nil
+}
+
+// LastLoginInfo opens the welcome screen.
+type LastLoginInfo struct {
+	IP                      uint32
+	DaysSinceLogin          uint16
+	DaysSinceRecoveryChange uint8
+	UnreadMessages          uint16
+}
+
+func (m *LastLoginInfo) Opcode() uint8 { return OpcodeLastLoginInfo }
+
+func (m *LastLoginInfo) Encode(p *packet.Packet) {
+	p.P4(m.IP)
+	p.P2(m.DaysSinceLogin)
+	p.P1(m.DaysSinceRecoveryChange)
+	p.P2(m.UnreadMessages)
+}
+
+func (m *LastLoginInfo) Decode(p *packet.Packet) error {
+	m.IP = p.G4()
+	m.DaysSinceLogin = p.G2()
+	m.DaysSinceRecoveryChange = p.G1()
+	m.UnreadMessages = p.G2()
+	return nil
+}
+
+// Logout returns the client to the title screen.
+type Logout struct{}
+
+func (m *Logout) Opcode() uint8                 { return OpcodeLogout }
+func (m *Logout) Encode(p *packet.Packet)       {}
+func (m *Logout) Decode(p *packet.Packet) error { return nil }
+
+// PCountDialog asks the player to enter an amount.
+type PCountDialog struct{}
+
+func (m *PCountDialog) Opcode() uint8                 { return OpcodePCountDialog }
+func (m *PCountDialog) Encode(p *packet.Packet)       {}
+func (m *PCountDialog) Decode(p *packet.Packet) error { return nil }
+
+// SetMultiway shows or hides the multiway combat icon.
+type SetMultiway struct {
+	Multiway bool
+}
+
+func (m *SetMultiway) Opcode() uint8 { return OpcodeSetMultiway }
+
+func (m *SetMultiway) Encode(p *packet.Packet) {
+	p.PBool(m.Multiway)
+}
+
+func (m *SetMultiway) Decode(p *packet.Packet) error {
+	m.Multiway = p.GBool()
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package serverprot defines the messages the server sends to the 225
+// client.
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/server/protocol"
+)
+
+// Opcodes of the messages sent to the client.
+const (
+	// interfaces
+	OpcodeIfOpenChatModal     = 14
+	OpcodeIfOpenMainSideModal = 28
+	OpcodeIfClose             = 129
+	OpcodeIfOpenSideOverlay   = 167
+	OpcodeIfOpenMainModal     = 168
+	OpcodeIfOpenSideModal     = 195
+
+	// updating interfaces
+	OpcodeIfSetColour     = 2
+	OpcodeIfSetHide       = 26
+	OpcodeIfSetObject     = 46
+	OpcodeIfShowSide      = 84
+	OpcodeIfSetModel      = 87
+	OpcodeIfSetAnim       = 103
+	OpcodeIfSetPlayerHead = 197
+	OpcodeIfSetText       = 201
+	OpcodeIfSetNpcHead    = 204
+	OpcodeIfSetPosition   = 209
+
+	// tutorial island
+	OpcodeTutFlash = 126
+	OpcodeTutOpen  = 185
+
+	// inventories
+	OpcodeUpdateInvStopTransmit = 15
+	OpcodeUpdateInvFull         = 98
+	OpcodeUpdateInvPartial      = 213
+
+	// camera
+	OpcodeCamMoveTo = 3
+	OpcodeCamShake  = 13
+	OpcodeCamLookAt = 74
+	OpcodeCamReset  = 239
+
+	// entity updates, built by package info
+	OpcodeNpcInfo    = 1
+	OpcodePlayerInfo = 184
+
+	// input tracking
+	OpcodeFinishTracking = 133
+	OpcodeEnableTracking = 226
+
+	// social
+	OpcodeMessageGame        = 4
+	OpcodeUpdateIgnoreList   = 21
+	OpcodeChatFilterSettings = 32
+	OpcodeMessagePrivate     = 41
+	OpcodeUpdateFriendList   = 152
+
+	// misc
+	OpcodeUnsetMapFlag      = 19
+	OpcodeUpdateRunWeight   = 22
+	OpcodeHintArrow         = 25
+	OpcodeUpdateRebootTimer = 43
+	OpcodeUpdateStat        = 44
+	OpcodeUpdateRunEnergy   = 68
+	OpcodeResetAnims        = 136
+	OpcodeUpdateUID192      = 139
+	OpcodeLastLoginInfo     = 140
+	OpcodeLogout            = 142
+	OpcodePCountDialog      = 243
+	OpcodeSetMultiway       = 254
+
+	// maps
+	OpcodeDataLocDone   = 20
+	OpcodeDataLandDone  = 80
+	OpcodeDataLand      = 132
+	OpcodeDataLoc       = 220
+	OpcodeRebuildNormal = 237
+
+	// vars
+	OpcodeVarpSmall           = 150
+	OpcodeVarpLarge           = 175
+	OpcodeResetClientVarCache = 193
+
+	// audio
+	OpcodeSynthSound = 12
+	OpcodeMidiSong   = 54
+	OpcodeMidiJingle = 212
+
+	// zones
+	OpcodeUpdateZonePartialFollows  = 7
+	OpcodeUpdateZoneFullFollows     = 135
+	OpcodeUpdateZonePartialEnclosed = 162
+
+	// zone protocol, sent alone or inside UPDATE_ZONE_PARTIAL_ENCLOSED
+	OpcodeLocMerge     = 23
+	OpcodeLocAnim      = 42
+	OpcodeObjDel       = 49
+	OpcodeObjReveal    = 50
+	OpcodeLocAddChange = 59
+	OpcodeMapProjAnim  = 69
+	OpcodeLocDel       = 76
+	OpcodeObjCount     = 151
+	OpcodeMapAnim      = 191
+	OpcodeObjAdd       = 223
+)
+
+// Registry holds every message the server sends.
+var Registry = protocol.NewRegistry(
+	protocol.Prot{Opcode: OpcodeIfOpenChatModal, Name: "IF_OPENCHATMODAL", Size: 2, New: protocol.New[IfOpenChatModal]},
+	protocol.Prot{Opcode: OpcodeIfOpenMainSideModal, Name: "IF_OPENMAINSIDEMODAL", Size: 4, New: protocol.New[IfOpenMainSideModal]},
+	protocol.Prot{Opcode: OpcodeIfClose, Name: "IF_CLOSE", Size: 0, New: protocol.New[IfClose]},
+	protocol.Prot{Opcode: OpcodeIfOpenSideOverlay, Name: "IF_OPENSIDEOVERLAY", Size: 3, New: protocol.New[IfOpenSideOverlay]},
+	protocol.Prot{Opcode: OpcodeIfOpenMainModal, Name: "IF_OPENMAINMODAL", Size: 2, New: protocol.New[IfOpenMainModal]},
+	protocol.Prot{Opcode: OpcodeIfOpenSideModal, Name: "IF_OPENSIDEMODAL", Size: 2, New: protocol.New[IfOpenSideModal]},
+
+	protocol.Prot{Opcode: OpcodeIfSetColour, Name: "IF_SETCOLOUR", Size: 4, New: protocol.New[IfSetColour]},
+	protocol.Prot{Opcode: OpcodeIfSetHide, Name: "IF_SETHIDE", Size: 3, New: protocol.New[IfSetHide]},
+	protocol.Prot{Opcode: OpcodeIfSetObject, Name: "IF_SETOBJECT", Size: 6, New: protocol.New[IfSetObject]},
+	protocol.Prot{Opcode: OpcodeIfShowSide, Name: "IF_SHOWSIDE", Size: 1, New: protocol.New[IfShowSide]},
+	protocol.Prot{Opcode: OpcodeIfSetModel, Name: "IF_SETMODEL", Size: 4, New: protocol.New[IfSetModel]},
+	protocol.Prot{Opcode: OpcodeIfSetAnim, Name: "IF_SETANIM", Size: 4, New: protocol.New[IfSetAnim]},
+	protocol.Prot{Opcode: OpcodeIfSetPlayerHead, Name: "IF_SETPLAYERHEAD", Size: 2, New: protocol.New[IfSetPlayerHead]},
+	protocol.Prot{Opcode: OpcodeIfSetText, Name: "IF_SETTEXT", Size: protocol.VarShort, New: protocol.New[IfSetText]},
+	protocol.Prot{Opcode: OpcodeIfSetNpcHead, Name: "IF_SETNPCHEAD", Size: 4, New: protocol.New[IfSetNpcHead]},
+	protocol.Prot{Opcode: OpcodeIfSetPosition, Name: "IF_SETPOSITION", Size: 6, New: protocol.New[IfSetPosition]},
+
+	protocol.Prot{Opcode: OpcodeTutFlash, Name: "TUT_FLASH", Size: 1, New: protocol.New[TutFlash]},
+	protocol.Prot{Opcode: OpcodeTutOpen, Name: "TUT_OPEN", Size: 2, New: protocol.New[TutOpen]},
+
+	protocol.Prot{Opcode: OpcodeUpdateInvStopTransmit, Name: "UPDATE_INV_STOP_TRANSMIT", Size: 2, New: protocol.New[UpdateInvStopTransmit]},
+	protocol.Prot{Opcode: OpcodeUpdateInvFull, Name: "UPDATE_INV_FULL", Size: protocol.VarShort, New: protocol.New[UpdateInvFull]},
+	protocol.Prot{Opcode: OpcodeUpdateInvPartial, Name: "UPDATE_INV_PARTIAL", Size: protocol.VarShort, New: protocol.New[UpdateInvPartial]},
+
+	protocol.Prot{Opcode: OpcodeCamMoveTo, Name: "CAM_MOVETO", Size: 6, New: protocol.New[CamMoveTo]},
+	protocol.Prot{Opcode: OpcodeCamShake, Name: "CAM_SHAKE", Size: 4, New: protocol.New[CamShake]},
+	protocol.Prot{Opcode: OpcodeCamLookAt, Name: "CAM_LOOKAT", Size: 6, New: protocol.New[CamLookAt]},
+	protocol.Prot{Opcode: OpcodeCamReset, Name: "CAM_RESET", Size: 0, New: protocol.New[CamReset]},
+
+	protocol.Prot{Opcode: OpcodeNpcInfo, Name: "NPC_INFO", Size: protocol.VarShort},
+	protocol.Prot{Opcode: OpcodePlayerInfo, Name: "PLAYER_INFO", Size: protocol.VarShort},
+
+	protocol.Prot{Opcode: OpcodeFinishTracking, Name: "FINISH_TRACKING", Size: 0, New: protocol.New[FinishTracking]},
+	protocol.Prot{Opcode: OpcodeEnableTracking, Name: "ENABLE_TRACKING", Size: 0, New: protocol.New[EnableTracking]},
+
+	protocol.Prot{Opcode: OpcodeMessageGame, Name: "MESSAGE_GAME", Size: protocol.VarByte, New: protocol.New[MessageGame]},
+	protocol.Prot{Opcode: OpcodeUpdateIgnoreList, Name: "UPDATE_IGNORELIST", Size: protocol.VarShort, New: protocol.New[UpdateIgnoreList]},
+	protocol.Prot{Opcode: OpcodeChatFilterSettings, Name: "CHAT_FILTER_SETTINGS", Size: 3, New: protocol.New[ChatFilterSettings]},
+	protocol.Prot{Opcode: OpcodeMessagePrivate, Name: "MESSAGE_PRIVATE", Size: protocol.VarByte, New: protocol.New[MessagePrivate]},
+	protocol.Prot{Opcode: OpcodeUpdateFriendList, Name: "UPDATE_FRIENDLIST", Size: 9, New: protocol.New[UpdateFriendList]},
+
+	protocol.Prot{Opcode: OpcodeUnsetMapFlag, Name: "UNSET_MAP_FLAG", Size: 0, New: protocol.New[UnsetMapFlag]},
+	protocol.Prot{Opcode: OpcodeUpdateRunWeight, Name: "UPDATE_RUNWEIGHT", Size: 2, New: protocol.New[UpdateRunWeight]},
+	protocol.Prot{Opcode: OpcodeHintArrow, Name: "HINT_ARROW", Size: 6, New: protocol.New[HintArrow]},
+	protocol.Prot{Opcode: OpcodeUpdateRebootTimer, Name: "UPDATE_REBOOT_TIMER", Size: 2, New: protocol.New[UpdateRebootTimer]},
+	protocol.Prot{Opcode: OpcodeUpdateStat, Name: "UPDATE_STAT", Size: 6, New: protocol.New[UpdateStat]},
+	protocol.Prot{Opcode: OpcodeUpdateRunEnergy, Name: "UPDATE_RUNENERGY", Size: 1, New: protocol.New[UpdateRunEnergy]},
+	protocol.Prot{Opcode: OpcodeResetAnims, Name: "RESET_ANIMS", Size: 0, New: protocol.New[ResetAnims]},
+	protocol.Prot{Opcode: OpcodeUpdateUID192, Name: "UPDATE_UID192", Size: 2, New: protocol.New[UpdateUID192]},
+	protocol.Prot{Opcode: OpcodeLastLoginInfo, Name: "LAST_LOGIN_INFO", Size: 9, New: protocol.New[LastLoginInfo]},
+	protocol.Prot{Opcode: OpcodeLogout, Name: "LOGOUT", Size: 0, New: protocol.New[Logout]},
+	protocol.Prot{Opcode: OpcodePCountDialog, Name: "P_COUNTDIALOG", Size: 0, New: protocol.New[PCountDialog]},
+	protocol.Prot{Opcode: OpcodeSetMultiway, Name: "SET_MULTIWAY", Size: 1, New: protocol.New[SetMultiway]},
+
+	protocol.Prot{Opcode: OpcodeDataLocDone, Name: "DATA_LOC_DONE", Size: 2, New: protocol.New[DataLocDone]},
+	protocol.Prot{Opcode: OpcodeDataLandDone, Name: "DATA_LAND_DONE", Size: 2, New: protocol.New[DataLandDone]},
+	protocol.Prot{Opcode: OpcodeDataLand, Name: "DATA_LAND", Size: protocol.VarShort, New: protocol.New[DataLand]},
+	protocol.Prot{Opcode: OpcodeDataLoc, Name: "DATA_LOC", Size: protocol.VarShort, New: protocol.New[DataLoc]},
+	protocol.Prot{Opcode: OpcodeRebuildNormal, Name: "REBUILD_NORMAL", Size: protocol.VarShort, New: protocol.New[RebuildNormal]},
+
+	protocol.Prot{Opcode: OpcodeVarpSmall, Name: "VARP_SMALL", Size: 3, New: protocol.New[VarpSmall]},
+	protocol.Prot{Opcode: OpcodeVarpLarge, Name: "VARP_LARGE", Size: 6, New: protocol.New[VarpLarge]},
+	protocol.Prot{Opcode: OpcodeResetClientVarCache, Name: "RESET_CLIENT_VARCACHE", Size: 0, New: protocol.New[ResetClientVarCache]},
+
+	protocol.Prot{Opcode: OpcodeSynthSound, Name: "SYNTH_SOUND", Size: 5, New: protocol.New[SynthSound]},
+	protocol.Prot{Opcode: OpcodeMidiSong, Name: "MIDI_SONG", Size: protocol.VarByte, New: protocol.New[MidiSong]},
+	protocol.Prot{Opcode: OpcodeMidiJingle, Name: "MIDI_JINGLE", Size: protocol.VarByte, New: protocol.New[MidiJingle]},
+
+	protocol.Prot{Opcode: OpcodeUpdateZonePartialFollows, Name: "UPDATE_ZONE_PARTIAL_FOLLOWS", Size: 2, New: protocol.New[UpdateZonePartialFollows]},
+	protocol.Prot{Opcode: OpcodeUpdateZoneFullFollows, Name: "UPDATE_ZONE_FULL_FOLLOWS", Size: 2, New: protocol.New[UpdateZoneFullFollows]},
+	protocol.Prot{Opcode: OpcodeUpdateZonePartialEnclosed, Name: "UPDATE_ZONE_PARTIAL_ENCLOSED", Size: protocol.VarShort, New: protocol.New[UpdateZonePartialEnclosed]},
+
+	protocol.Prot{Opcode: OpcodeLocMerge, Name: "LOC_MERGE", Size: 14, New: protocol.New[LocMerge]},
+	protocol.Prot{Opcode: OpcodeLocAnim, Name: "LOC_ANIM", Size: 4, New: protocol.New[LocAnim]},
+	protocol.Prot{Opcode: OpcodeObjDel, Name: "OBJ_DEL", Size: 3, New: protocol.New[ObjDel]},
+	protocol.Prot{Opcode: OpcodeObjReveal, Name: "OBJ_REVEAL", Size: 7, New: protocol.New[ObjReveal]},
+	protocol.Prot{Opcode: OpcodeLocAddChange, Name: "LOC_ADD_CHANGE", Size: 4, New: protocol.New[LocAddChange]},
+	protocol.Prot{Opcode: OpcodeMapProjAnim, Name: "MAP_PROJANIM", Size: 15, New: protocol.New[MapProjAnim]},
+	protocol.Prot{Opcode: OpcodeLocDel, Name: "LOC_DEL", Size: 2, New: protocol.New[LocDel]},
+	protocol.Prot{Opcode: OpcodeObjCount, Name: "OBJ_COUNT", Size: 7, New: protocol.New[ObjCount]},
+	protocol.Prot{Opcode: OpcodeMapAnim, Name: "MAP_ANIM", Size: 6, New: protocol.New[MapAnim]},
+	protocol.Prot{Opcode: OpcodeObjAdd, Name: "OBJ_ADD", Size: 5, New: protocol.New[ObjAdd]},
+)
@@ -0,0 +1,217 @@
+package serverprot
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/zsrv/rs-server-225/jagex2/io"
+	"github.com/zsrv/rs-server-225/server/protocol"
+)
+
+var messageTests = []protocol.Message{
+	&IfOpenChatModal{Component: 4882},
+	&IfOpenMainSideModal{Main: 3824, Side: 3822},
+	&IfClose{},
+	&IfOpenSideOverlay{Component: 3917, Tab: 1},
+	&IfOpenMainModal{Component: 5292},
+	&IfOpenSideModal{Component: 3213},
+	&IfSetColour{Component: 5451, Colour: 0x7c00},
+	&IfSetHide{Component: 2426, Hidden: true},
+	&IfSetObject{Component: 1688, Obj: 995, Zoom: 200},
+	&IfShowSide{Tab: 3},
+	&IfSetModel{Component: 6179, Model: 1234},
+	&IfSetAnim{Component: 4883, Seq: 588},
+	&IfSetPlayerHead{Component: 969},
+	&IfSetText{Component: 357, Text: "Welcome to RuneScape"},
+	&IfSetNpcHead{Component: 4883, Npc: 0},
+	&IfSetPosition{Component: 3650, X: -12, Y: 300},
+	&TutFlash{Tab: 4},
+	&TutOpen{Component: 6179},
+	&UpdateInvStopTransmit{Component: 3214},
+	&UpdateInvFull{Component: 3214, Objs: []InvObj{{Obj: 995, Count: 1000}, {Obj: -1}, {Obj: 1351, Count: 1}, {Obj: 0, Count: 254}}},
+	&UpdateInvPartial{Component: 1688, Slots: []InvSlot{{Slot: 3, InvObj: InvObj{Obj: 1163, Count: 1}}, {Slot: 27, InvObj: InvObj{Obj: -1}}}},
+	&CamMoveTo{X: 52, Z: 40, Height: 400, Speed: 2, Multiplier: 10},
+	&CamShake{Type: 3, Jitter: 20, Amplitude: 10, Frequency: 4},
+	&CamLookAt{X: 55, Z: 47, Height: 100, Speed: 1, Multiplier: 0},
+	&CamReset{},
+	&FinishTracking{},
+	&EnableTracking{},
+	&MessageGame{Text: "Welcome to RuneScape."},
+	&UpdateIgnoreList{Names: []uint64{0x1234, 0x5678}},
+	&ChatFilterSettings{Public: 0, Private: 1, Trade: 2},
+	&MessagePrivate{From: 0x12345678, MessageID: 99, StaffModLevel: 2, Message: "Hi there"},
+	&UpdateFriendList{Name: 0x12345678, World: 1},
+	&UnsetMapFlag{},
+	&UpdateRunWeight{Weight: -5},
+	&HintArrow{Type: HintArrowNpc, Entity: 12},
+	&UpdateRebootTimer{Ticks: 500},
+	&UpdateStat{Stat: 3, Exp: 1154, Level: 10},
+	&UpdateRunEnergy{Energy: 100},
+	&ResetAnims{},
+	&UpdateUID192{Pid: 1},
+	&LastLoginInfo{IP: 0x7f000001, DaysSinceLogin: 3, DaysSinceRecoveryChange: 201, UnreadMessages: 1},
+	&Logout{},
+	&PCountDialog{},
+	&SetMultiway{Multiway: true},
+	&DataLocDone{X: 50, Z: 50},
+	&DataLandDone{X: 50, Z: 51},
+	&DataLand{MapChunk{X: 50, Z: 50, Offset: 1000, Length: 1003, Data: []byte{1, 2, 3}}},
+	&DataLoc{MapChunk{X: 50, Z: 51, Offset: 0, Length: 2, Data: []byte{0, 0}}},
+	&RebuildNormal{ZoneX: 402, ZoneZ: 402, MapSquares: []RebuildMapSquare{{X: 50, Z: 50, LandCrc: 0xdeadbeef, LocCrc: 0xcafebabe}, {X: 50, Z: 51}}},
+	&VarpSmall{Varp: 43, Value: -1},
+	&VarpLarge{Varp: 281, Value: 1000000},
+	&ResetClientVarCache{},
+	&SynthSound{Sound: 2739, Loops: 1, Delay: 0},
+	&MidiSong{Name: "harmony", Crc: 0x12345678, Length: 2048},
+	&MidiJingle{Name: "level_up", Crc: 0x87654321, Length: 512},
+	&UpdateZonePartialFollows{X: 48, Z: 56},
+	&UpdateZoneFullFollows{X: 48, Z: 56},
+	&UpdateZonePartialEnclosed{X: 48, Z: 56, Messages: []protocol.Message{
+		&ObjAdd{Coord: NewZoneCoord(3, 4), Obj: 995, Count: 10},
+		&LocDel{Coord: NewZoneCoord(7, 0), Shape: NewLocShape(10, 2)},
+	}},
+	&LocMerge{Coord: NewZoneCoord(1, 2), Shape: NewLocShape(10, 1), Loc: 2282, Start: 30, End: 90, Pid: 5, East: 1, South: -1, West: -2, North: 2},
+	&LocAnim{Coord: NewZoneCoord(1, 2), Shape: NewLocShape(0, 3), Seq: 1},
+	&ObjDel{Coord: NewZoneCoord(5, 5), Obj: 526},
+	&ObjReveal{Coord: NewZoneCoord(5, 5), Obj: 526, Count: 1, Receiver: 7},
+	&LocAddChange{Coord: NewZoneCoord(6, 1), Shape: NewLocShape(22, 0), Loc: 1276},
+	&MapProjAnim{Coord: NewZoneCoord(2, 2), DX: 3, DZ: -4, Target: -2, SpotAnim: 91, SrcHeight: 43, DstHeight: 31, StartDelay: 51, EndDelay: 70, Peak: 16, Arc: 64},
+	&LocDel{Coord: NewZoneCoord(0, 7), Shape: NewLocShape(0, 0)},
+	&ObjCount{Coord: NewZoneCoord(4, 4), Obj: 995, OldCount: 10, NewCount: 20},
+	&MapAnim{Coord: NewZoneCoord(3, 3), SpotAnim: 86, Height: 100, Delay: 0},
+	&ObjAdd{Coord: NewZoneCoord(3, 4), Obj: 995, Count: 10},
+}
+
+func TestMessages(t *testing.T) {
+	seed := [4]uint32{11, 22, 33, 44}
+	server := io.NewServerIsaacPair(seed)
+	client := io.NewClientIsaacPair(seed)
+
+	var conn bytes.Buffer
+	w := protocol.NewWriter(&conn, server.Encoder, Registry)
+	r := protocol.NewReader(&conn, client.Decoder, Registry)
+
+	for _, want := range messageTests {
+		prot, _ := Registry.Lookup(want.Opcode())
+		t.Run(prot.Name, func(t *testing.T) {
+			if err := w.WriteMessage(want); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("ReadMessage() = %#v, want %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	tested := make(map[uint8]bool)
+	for _, m := range messageTests {
+		tested[m.Opcode()] = true
+	}
+	for _, prot := range Registry.Prots() {
+		if prot.New == nil {
+			continue
+		}
+		if !tested[prot.Opcode] {
+			t.Errorf("%s (%d) has no test message", prot.Name, prot.Opcode)
+		}
+		if got := prot.New().Opcode(); got != prot.Opcode {
+			t.Errorf("%s New().Opcode() = %d, want %d", prot.Name, got, prot.Opcode)
+		}
+	}
+}
+
+func TestHintArrow(t *testing.T) {
+	tests := []struct {
+		name string
+		m    HintArrow
+	}{
+		{name: "npc", m: HintArrow{Type: HintArrowNpc, Entity: 3}},
+		{name: "player", m: HintArrow{Type: HintArrowPlayer, Entity: 2000}},
+		{name: "tile", m: HintArrow{Type: HintArrowTile, X: 3222, Z: 3218, Height: 120}},
+		{name: "tile edge", m: HintArrow{Type: 6, X: 3222, Z: 3218, Height: 10}},
+		{name: "none", m: HintArrow{Type: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var conn bytes.Buffer
+			w := protocol.NewWriter(&conn, nil, Registry)
+			if err := w.WriteMessage(&tt.m); err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+			w.Flush()
+			got, err := protocol.NewReader(&conn, nil, Registry).ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, &tt.m) {
+				t.Errorf("ReadMessage() = %#v, want %#v", got, &tt.m)
+			}
+		})
+	}
+}
+
+func TestUpdateZonePartialEnclosed_Decode(t *testing.T) {
+	// IF_CLOSE is registered, but is not a zone message.
+	if _, err := Registry.Decode(OpcodeUpdateZonePartialEnclosed, []byte{0, 0, OpcodeIfClose}); err == nil {
+		t.Error("Decode() accepted a non-zone message")
+	}
+	if _, err := Registry.Decode(OpcodeUpdateZonePartialEnclosed, []byte{0, 0, OpcodeMapAnim, 1, 2}); err == nil {
+		t.Error("Decode() accepted a truncated message")
+	}
+}
+
+func TestZoneCoord(t *testing.T) {
+	c := NewZoneCoord(3221, 3218)
+	if c.X() != 5 || c.Z() != 2 {
+		t.Errorf("NewZoneCoord() = (%d, %d), want (5, 2)", c.X(), c.Z())
+	}
+	s := NewLocShape(22, 3)
+	if s.Shape() != 22 || s.Angle() != 3 {
+		t.Errorf("NewLocShape() = (%d, %d), want (22, 3)", s.Shape(), s.Angle())
+	}
+}
+
+func TestMapChunks(t *testing.T) {
+	data := make([]byte, 2*MapChunkSize+1)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	chunks, err := MapChunks(50, 51, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("MapChunks() = %d chunks, want 3", len(chunks))
+	}
+	var got []byte
+	for i, c := range chunks {
+		if c.X != 50 || c.Z != 51 || int(c.Offset) != len(got) || int(c.Length) != len(data) {
+			t.Errorf("chunk %d = %d_%d at %d of %d", i, c.X, c.Z, c.Offset, c.Length)
+		}
+		got = append(got, c.Data...)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("chunks do not join back into the file")
+	}
+
+	if _, err := MapChunks(50, 51, make([]byte, 0x10000)); err == nil {
+		t.Error("MapChunks() accepted a file too large to send")
+	}
+}
+
+func TestDataLand_Decode(t *testing.T) {
+	// 3 bytes at offset 1 of a 3 byte file
+	if _, err := Registry.Decode(OpcodeDataLand, []byte{50, 50, 0, 1, 0, 3, 1, 2, 3}); err == nil {
+		t.Error("Decode() accepted a chunk past the end of the file")
+	}
+}
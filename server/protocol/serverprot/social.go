@@ -0,0 +1,111 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/jagex2/wordpack"
+)
+
+// MessageGame adds a line to the chat box.
+type MessageGame struct {
+	Text string
+}
+
+func (m *MessageGame) Opcode() uint8 { return OpcodeMessageGame }
+
+func (m *MessageGame) Encode(p *packet.Packet) {
+	p.PJStrLF(m.Text)
+}
+
+func (m *MessageGame) Decode(p *packet.Packet) error {
+	var err error
+	m.Text, err = p.TryGJStrLF()
+	return err
+}
+
+// UpdateIgnoreList sets the ignore list. Names are base37 encoded.
+type UpdateIgnoreList struct {
+	Names []uint64
+}
+
+func (m *UpdateIgnoreList) Opcode() uint8 { return OpcodeUpdateIgnoreList }
+
+func (m *UpdateIgnoreList) Encode(p *packet.Packet) {
+	for _, name := range m.Names {
+		p.P8(name)
+	}
+}
+
+func (m *UpdateIgnoreList) Decode(p *packet.Packet) error {
+	m.Names = make([]uint64, p.Len()/8)
+	for i := range m.Names {
+		m.Names[i] = p.G8()
+	}
+	return nil
+}
+
+// ChatFilterSettings sets the chat modes shown below the chat box.
+type ChatFilterSettings struct {
+	Public  uint8
+	Private uint8
+	Trade   uint8
+}
+
+func (m *ChatFilterSettings) Opcode() uint8 { return OpcodeChatFilterSettings }
+
+func (m *ChatFilterSettings) Encode(p *packet.Packet) {
+	p.P1(m.Public)
+	p.P1(m.Private)
+	p.P1(m.Trade)
+}
+
+func (m *ChatFilterSettings) Decode(p *packet.Packet) error {
+	m.Public = p.G1()
+	m.Private = p.G1()
+	m.Trade = p.G1()
+	return nil
+}
+
+// MessagePrivate delivers a private message.
+type MessagePrivate struct {
+	From          uint64 // base37 encoded name
+	MessageID     uint32 // unique per message, used to drop duplicates
+	StaffModLevel uint8
+	Message       string
+}
+
+func (m *MessagePrivate) Opcode() uint8 { return OpcodeMessagePrivate }
+
+func (m *MessagePrivate) Encode(p *packet.Packet) {
+	p.P8(m.From)
+	p.P4(m.MessageID)
+	p.P1(m.StaffModLevel)
+	wordpack.Pack(p, m.Message)
+}
+
+func (m *MessagePrivate) Decode(p *packet.Packet) error {
+	m.From = p.G8()
+	m.MessageID = p.G4()
+	m.StaffModLevel = p.G1()
+	var err error
+	m.Message, err = wordpack.Unpack(p, p.Len())
+	return err
+}
+
+// UpdateFriendList sets the world a friend is on, 0 if offline.
+type UpdateFriendList struct {
+	Name  uint64 // base37 encoded
+	World uint8
+}
+
+func (m *UpdateFriendList) Opcode() uint8 { return OpcodeUpdateFriendList }
+
+func (m *UpdateFriendList) Encode(p *packet.Packet) {
+	p.P8(m.Name)
+	p.P1(m.World)
+}
+
+func (m *UpdateFriendList) Decode(p *packet.Packet) error {
+	m.Name = p.G8()
+	m.World = p.G1()
+	return nil
+}
@@ -0,0 +1,50 @@
+package serverprot
+
+import (
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+)
+
+// VarpSmall sets a varp to a value that fits in a byte.
+type VarpSmall struct {
+	Varp  uint16
+	Value int8
+}
+
+func (m *VarpSmall) Opcode() uint8 { return OpcodeVarpSmall }
+
+func (m *VarpSmall) Encode(p *packet.Packet) {
+	p.P2(m.Varp)
+	p.P1(uint8(m.Value))
+}
+
+func (m *VarpSmall) Decode(p *packet.Packet) error {
+	m.Varp = p.G2()
+	m.Value = p.G1B()
+	return nil
+}
+
+// VarpLarge sets a varp.
+type VarpLarge struct {
+	Varp  uint16
+	Value int32
+}
+
+func (m *VarpLarge) Opcode() uint8 { return OpcodeVarpLarge }
+
+func (m *VarpLarge) Encode(p *packet.Packet) {
+	p.P2(m.Varp)
+	p.P4(uint32(m.Value))
+}
+
+func (m *VarpLarge) Decode(p *packet.Packet) error {
+	m.Varp = p.G2()
+	m.Value = int32(p.G4())
+	return nil
+}
+
+// ResetClientVarCache sets every varp back to its cached value.
+type ResetClientVarCache struct{}
+
+func (m *ResetClientVarCache) Opcode() uint8                 { return OpcodeResetClientVarCache }
+func (m *ResetClientVarCache) Encode(p *packet.Packet)       {}
+func (m *ResetClientVarCache) Decode(p *packet.Packet) error { return nil }
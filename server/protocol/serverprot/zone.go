@@ -0,0 +1,399 @@
+package serverprot
+
+import (
+	"fmt"
+
+	"github.com/zsrv/rs-server-225/jagex2/packet"
+	"github.com/zsrv/rs-server-225/server/protocol"
+)
+
+// UpdateZonePartialFollows sets the zone the following zone messages
+// apply to.
+type UpdateZonePartialFollows struct {
+	X uint8 // local tile of the zone's south west corner
+	Z uint8
+}
+
+func (m *UpdateZonePartialFollows) Opcode() uint8 { return OpcodeUpdateZonePartialFollows }
+
+func (m *UpdateZonePartialFollows) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+}
+
+func (m *UpdateZonePartialFollows) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	return nil
+}
+
+// UpdateZoneFullFollows clears the objs and locs the server added to a
+// zone, ahead of the messages that rebuild it.
+type UpdateZoneFullFollows struct {
+	X uint8 // local tile of the zone's south west corner
+	Z uint8
+}
+
+func (m *UpdateZoneFullFollows) Opcode() uint8 { return OpcodeUpdateZoneFullFollows }
+
+func (m *UpdateZoneFullFollows) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+}
+
+func (m *UpdateZoneFullFollows) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	return nil
+}
+
+// UpdateZonePartialEnclosed sends several zone messages for one zone.
+// Each is written as its opcode, unencrypted, and its body.
+type UpdateZonePartialEnclosed struct {
+	X        uint8 // local tile of the zone's south west corner
+	Z        uint8
+	Messages []protocol.Message
+}
+
+func (m *UpdateZonePartialEnclosed) Opcode() uint8 { return OpcodeUpdateZonePartialEnclosed }
+
+func (m *UpdateZonePartialEnclosed) Encode(p *packet.Packet) {
+	p.P1(m.X)
+	p.P1(m.Z)
+	for _, msg := range m.Messages {
+		p.P1(msg.Opcode())
+		msg.Encode(p)
+	}
+}
+
+func (m *UpdateZonePartialEnclosed) Decode(p *packet.Packet) error {
+	m.X = p.G1()
+	m.Z = p.G1()
+	m.Messages = nil
+	for p.Len() > 0 {
+		opcode := p.G1()
+		prot, ok := Registry.Lookup(opcode)
+		if !ok || !isZoneProt(opcode) {
+			return fmt.Errorf("serverprot: opcode %d is not a zone message", opcode)
+		}
+		size := int(prot.Size)
+		body := make([]byte, size)
+		if err := p.TryGData(body, size); err != nil {
+			return err
+		}
+		msg, err := Registry.Decode(opcode, body)
+		if err != nil {
+			return err
+		}
+		m.Messages = append(m.Messages, msg)
+	}
+	return nil
+}
+
+// isZoneProt reports whether opcode is a zone message, which may be
+// enclosed in UPDATE_ZONE_PARTIAL_ENCLOSED.
+func isZoneProt(opcode uint8) bool {
+	switch opcode {
+	case OpcodeLocMerge, OpcodeLocAnim, OpcodeObjDel, OpcodeObjReveal, OpcodeLocAddChange,
+		OpcodeMapProjAnim, OpcodeLocDel, OpcodeObjCount, OpcodeMapAnim, OpcodeObjAdd:
+		return true
+	}
+	return false
+}
+
+// A ZoneCoord is a tile within a zone, packed into one byte.
+type ZoneCoord uint8
+
+// NewZoneCoord returns the coord of a tile, taking x and z modulo 8.
+func NewZoneCoord(x, z int) ZoneCoord {
+	return ZoneCoord((x&7)<<4 | z&7)
+}
+
+// X returns the tile's x offset in the zone.
+func (c ZoneCoord) X() int { return int(c>>4) & 7 }
+
+// Z returns the tile's z offset in the zone.
+func (c ZoneCoord) Z() int { return int(c) & 7 }
+
+// A LocShape packs the shape and angle of a loc into one byte.
+type LocShape uint8
+
+// NewLocShape returns the packed shape and angle.
+func NewLocShape(shape, angle int) LocShape {
+	return LocShape(shape<<2 | angle&3)
+}
+
+// Shape returns the loc's shape.
+func (s LocShape) Shape() int { return int(s >> 2) }
+
+// Angle returns the loc's angle.
+func (s LocShape) Angle() int { return int(s) & 3 }
+
+// LocMerge merges a loc's model into a player's for a time, as when
+// climbing an agility obstacle.
+type LocMerge struct {
+	Coord ZoneCoord
+	Shape LocShape
+	Loc   uint16
+	Start uint16 // client cycles from now
+	End   uint16
+	Pid   uint16
+	East  int8 // bounds of the loc, relative to the player
+	South int8
+	West  int8
+	North int8
+}
+
+func (m *LocMerge) Opcode() uint8 { return OpcodeLocMerge }
+
+func (m *LocMerge) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P1(uint8(m.Shape))
+	p.P2(m.Loc)
+	p.P2(m.Start)
+	p.P2(m.End)
+	p.P2(m.Pid)
+	p.P1(uint8(m.East))
+	p.P1(uint8(m.South))
+	p.P1(uint8(m.West))
+	p.P1(uint8(m.North))
+}
+
+func (m *LocMerge) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Shape = LocShape(p.G1())
+	m.Loc = p.G2()
+	m.Start = p.G2()
+	m.End = p.G2()
+	m.Pid = p.G2()
+	m.East = p.G1B()
+	m.South = p.G1B()
+	m.West = p.G1B()
+	m.North = p.G1B()
+	return nil
+}
+
+// LocAnim plays an animation on a loc.
+type LocAnim struct {
+	Coord ZoneCoord
+	Shape LocShape
+	Seq   uint16
+}
+
+func (m *LocAnim) Opcode() uint8 { return OpcodeLocAnim }
+
+func (m *LocAnim) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P1(uint8(m.Shape))
+	p.P2(m.Seq)
+}
+
+func (m *LocAnim) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Shape = LocShape(p.G1())
+	m.Seq = p.G2()
+	return nil
+}
+
+// ObjDel removes an obj stack from a tile.
+type ObjDel struct {
+	Coord ZoneCoord
+	Obj   uint16
+}
+
+func (m *ObjDel) Opcode() uint8 { return OpcodeObjDel }
+
+func (m *ObjDel) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P2(m.Obj)
+}
+
+func (m *ObjDel) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Obj = p.G2()
+	return nil
+}
+
+// ObjReveal shows an obj stack to everyone but the player who dropped it.
+type ObjReveal struct {
+	Coord    ZoneCoord
+	Obj      uint16
+	Count    uint16
+	Receiver uint16 // pid of the player who already sees the obj
+}
+
+func (m *ObjReveal) Opcode() uint8 { return OpcodeObjReveal }
+
+func (m *ObjReveal) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P2(m.Obj)
+	p.P2(m.Count)
+	p.P2(m.Receiver)
+}
+
+func (m *ObjReveal) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Obj = p.G2()
+	m.Count = p.G2()
+	m.Receiver = p.G2()
+	return nil
+}
+
+// LocAddChange adds a loc, replacing any loc in the same layer.
+type LocAddChange struct {
+	Coord ZoneCoord
+	Shape LocShape
+	Loc   uint16
+}
+
+func (m *LocAddChange) Opcode() uint8 { return OpcodeLocAddChange }
+
+func (m *LocAddChange) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P1(uint8(m.Shape))
+	p.P2(m.Loc)
+}
+
+func (m *LocAddChange) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Shape = LocShape(p.G1())
+	m.Loc = p.G2()
+	return nil
+}
+
+// MapProjAnim fires a projectile from a tile.
+type MapProjAnim struct {
+	Coord      ZoneCoord
+	DX         int8 // destination, relative to the source tile
+	DZ         int8
+	Target     int16 // npc index + 1, or -(player index + 1); 0 for none
+	SpotAnim   uint16
+	SrcHeight  uint8
+	DstHeight  uint8
+	StartDelay uint16 // client cycles
+	EndDelay   uint16
+	Peak       uint8
+	Arc        uint8
+}
+
+func (m *MapProjAnim) Opcode() uint8 { return OpcodeMapProjAnim }
+
+func (m *MapProjAnim) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P1(uint8(m.DX))
+	p.P1(uint8(m.DZ))
+	p.P2(uint16(m.Target))
+	p.P2(m.SpotAnim)
+	p.P1(m.SrcHeight)
+	p.P1(m.DstHeight)
+	p.P2(m.StartDelay)
+	p.P2(m.EndDelay)
+	p.P1(m.Peak)
+	p.P1(m.Arc)
+}
+
+func (m *MapProjAnim) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.DX = p.G1B()
+	m.DZ = p.G1B()
+	m.Target = p.G2S()
+	m.SpotAnim = p.G2()
+	m.SrcHeight = p.G1()
+	m.DstHeight = p.G1()
+	m.StartDelay = p.G2()
+	m.EndDelay = p.G2()
+	m.Peak = p.G1()
+	m.Arc = p.G1()
+	return nil
+}
+
+// LocDel removes a loc.
+type LocDel struct {
+	Coord ZoneCoord
+	Shape LocShape
+}
+
+func (m *LocDel) Opcode() uint8 { return OpcodeLocDel }
+
+func (m *LocDel) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P1(uint8(m.Shape))
+}
+
+func (m *LocDel) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Shape = LocShape(p.G1())
+	return nil
+}
+
+// ObjCount changes the size of an obj stack.
+type ObjCount struct {
+	Coord    ZoneCoord
+	Obj      uint16
+	OldCount uint16
+	NewCount uint16
+}
+
+func (m *ObjCount) Opcode() uint8 { return OpcodeObjCount }
+
+func (m *ObjCount) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P2(m.Obj)
+	p.P2(m.OldCount)
+	p.P2(m.NewCount)
+}
+
+func (m *ObjCount) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Obj = p.G2()
+	m.OldCount = p.G2()
+	m.NewCount = p.G2()
+	return nil
+}
+
+// MapAnim plays a spotanim on a tile.
+type MapAnim struct {
+	Coord    ZoneCoord
+	SpotAnim uint16
+	Height   uint8
+	Delay    uint16 // client cycles
+}
+
+func (m *MapAnim) Opcode() uint8 { return OpcodeMapAnim }
+
+func (m *MapAnim) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P2(m.SpotAnim)
+	p.P1(m.Height)
+	p.P2(m.Delay)
+}
+
+func (m *MapAnim) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.SpotAnim = p.G2()
+	m.Height = p.G1()
+	m.Delay = p.G2()
+	return nil
+}
+
+// ObjAdd adds an obj stack to a tile.
+type ObjAdd struct {
+	Coord ZoneCoord
+	Obj   uint16
+	Count uint16
+}
+
+func (m *ObjAdd) Opcode() uint8 { return OpcodeObjAdd }
+
+func (m *ObjAdd) Encode(p *packet.Packet) {
+	p.P1(uint8(m.Coord))
+	p.P2(m.Obj)
+	p.P2(m.Count)
+}
+
+func (m *ObjAdd) Decode(p *packet.Packet) error {
+	m.Coord = ZoneCoord(p.G1())
+	m.Obj = p.G2()
+	m.Count = p.G2()
+	return nil
+}